@@ -16,13 +16,19 @@ package main
 import (
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"smtp-relay/internal/api"
+	"smtp-relay/internal/archive"
 	"smtp-relay/internal/auth"
 	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/mailauth"
 	"smtp-relay/internal/services"
 )
 
@@ -35,6 +41,8 @@ func main() {
 	// 从环境变量读取配置
 	mongoURI := getEnv("MONGODB_URI", "mongodb://localhost:27017/smtp_relay")
 	mongoDatabase := getEnv("MONGODB_DATABASE", "smtp_relay")
+	redisURL := getEnv("REDIS_URL", "redis://localhost:6379")
+	redisPassword := getEnv("REDIS_PASSWORD", "")
 	apiPort := getEnv("API_PORT", "8080")
 	secretKey := getEnv("API_SECRET_KEY", "your-secret-key-change-in-production")
 
@@ -59,22 +67,114 @@ func main() {
 		logger.WithError(err).Fatal("创建数据库索引失败")
 	}
 
+	// 连接Redis（读取SMTP服务的速率限制令牌桶）
+	redisClient, err := database.NewRedis(redisURL, redisPassword, logger)
+	if err != nil {
+		logger.WithError(err).Fatal("连接Redis失败")
+	}
+	defer redisClient.Close()
+
 	// 创建认证服务
 	authService := auth.NewService(db, logger, secretKey)
 
-	// 创建SMTP凭据服务
+	// 创建SMTP凭据服务并启动过期检查（禁用过期凭据、清理宽限期结束的旧密码）
 	credentialService := services.NewSMTPCredentialService(db, logger)
+	expiryInterval, err := time.ParseDuration(getEnv("CREDENTIAL_EXPIRY_CHECK_INTERVAL", "10m"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的凭据过期检查间隔")
+	}
+	credentialService.StartExpiryChecker(expiryInterval)
+	defer credentialService.Stop()
 
 	// 创建MailLog服务
 	mailLogService := services.NewMailLogService(db, logger)
 
+	// 初始化加密器
+	encryptor, err := encryption.NewFromKeyFile(getEnv("ENCRYPTION_KEY_FILE", ""))
+	if err != nil {
+		logger.WithError(err).Fatal("加载加密主密钥失败")
+	}
+
+	// 创建原始邮件归档服务
+	archiveStore, err := archive.NewStore(loadArchiveConfig(), db)
+	if err != nil {
+		logger.WithError(err).Fatal("初始化归档存储失败")
+	}
+	retentionDays, _ := strconv.Atoi(getEnv("ARCHIVE_RETENTION_DAYS", "30"))
+	archiveService := services.NewArchiveService(db, archiveStore, encryptor, retentionDays, logger)
+
+	// 启动过期归档清理
+	purgeInterval, err := time.ParseDuration(getEnv("ARCHIVE_PURGE_INTERVAL", "1h"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的归档清理间隔")
+	}
+	archiveService.StartPurger(purgeInterval)
+	defer archiveService.Stop()
+
+	// 启动主密钥轮换后的数据重新包装
+	rewrapInterval, err := time.ParseDuration(getEnv("ENCRYPTION_REWRAP_INTERVAL", "24h"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的数据重新包装间隔")
+	}
+	rewrapService := services.NewKeyRewrapService(db, encryptor, archiveStore, logger)
+	rewrapService.Start(rewrapInterval)
+	defer rewrapService.Stop()
+
+	// DNS解析器（可指向本地测试DNS服务器）
+	resolver := mailauth.NewResolver(getEnv("DNS_RESOLVER", ""))
+
+	// 创建发件域名服务并启动定期重新验证
+	domainService := services.NewDomainService(db, resolver, logger)
+	reverifyInterval, err := time.ParseDuration(getEnv("DOMAIN_REVERIFY_INTERVAL", "24h"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的域名重新验证间隔")
+	}
+	domainService.StartVerifier(reverifyInterval)
+	defer domainService.Stop()
+
+	// 创建通知服务
+	notificationService := services.NewNotificationService(db, encryptor, logger)
+
+	// 启动DKIM密钥轮换调度（DNS重新验证、新密钥切换、旧密钥过期、按策略自动轮换）
+	rotationInterval, err := time.ParseDuration(getEnv("DKIM_ROTATION_INTERVAL", "1h"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的DKIM轮换调度间隔")
+	}
+	dkimRotationService := services.NewDKIMRotationService(db, services.NewDKIMService(db, encryptor, resolver, logger), notificationService, logger)
+	dkimRotationService.Start(rotationInterval)
+	defer dkimRotationService.Stop()
+
+	// 启动凭据异常检测（学习发信行为基线，行为严重偏离时告警或自动停用凭据）
+	anomalyInterval, err := time.ParseDuration(getEnv("CREDENTIAL_ANOMALY_CHECK_INTERVAL", "10m"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的凭据异常检测间隔")
+	}
+	anomalyService := services.NewCredentialAnomalyService(db, notificationService, logger)
+	anomalyService.Start(anomalyInterval)
+	defer anomalyService.Stop()
+
+	// 启动用量计量（一天结束并经过结算期后生成不可修改的每日用量记录）
+	meteringInterval, err := time.ParseDuration(getEnv("USAGE_METERING_INTERVAL", "1h"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的用量计量间隔")
+	}
+	settlePeriod, err := time.ParseDuration(getEnv("USAGE_SETTLE_PERIOD", "24h"))
+	if err != nil {
+		logger.WithError(err).Fatal("无效的用量结算期")
+	}
+	usageService := services.NewUsageService(db, settlePeriod, logger)
+	usageService.Start(meteringInterval)
+	defer usageService.Stop()
+
 	// 创建API服务器
 	apiConfig := &api.Config{
-		Port:      apiPort,
-		SecretKey: secretKey,
+		Port:        apiPort,
+		SecretKey:   secretKey,
+		RelayIPs:    splitList(getEnv("RELAY_IPS", getEnv("RELAY_IP", ""))),
+		RelayDomain: getEnv("RELAY_DOMAIN", "mail.ict.run"),
 	}
 
-	apiServer := api.NewServer(apiConfig, db, logger, authService, credentialService, mailLogService)
+	apiServer := api.NewServer(apiConfig, db, logger, authService, credentialService, mailLogService, archiveService, domainService, notificationService, dkimRotationService, anomalyService, services.NewRateLimitService(redisClient, logger), usageService, encryptor, resolver)
 
 	// 启动API服务器
 	go func() {
@@ -101,6 +201,32 @@ func main() {
 	logger.Info("SMTP中继API服务已停止")
 }
 
+// loadArchiveConfig 从环境变量读取归档存储配置
+func loadArchiveConfig() *archive.Config {
+	return &archive.Config{
+		Backend:      getEnv("ARCHIVE_BACKEND", "gridfs"),
+		GridFSBucket: getEnv("ARCHIVE_GRIDFS_BUCKET", "message_archive"),
+		Path:         getEnv("ARCHIVE_PATH", "./data/archive"),
+		S3Endpoint:   getEnv("ARCHIVE_S3_ENDPOINT", ""),
+		S3Region:     getEnv("ARCHIVE_S3_REGION", ""),
+		S3Bucket:     getEnv("ARCHIVE_S3_BUCKET", ""),
+		S3AccessKey:  getEnv("ARCHIVE_S3_ACCESS_KEY", ""),
+		S3SecretKey:  getEnv("ARCHIVE_S3_SECRET_KEY", ""),
+		S3UseSSL:     getEnv("ARCHIVE_S3_USE_SSL", "true") == "true",
+	}
+}
+
+// splitList 拆分逗号分隔的配置项
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // getEnv 获取环境变量，如果不存在则返回默认值
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -10,7 +10,10 @@ import (
 	"github.com/spf13/viper"
 
 	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/mailauth"
 	"smtp-relay/internal/queue"
+	"smtp-relay/internal/services"
 	"smtp-relay/internal/worker"
 )
 
@@ -62,8 +65,37 @@ func main() {
 	}
 	defer queueService.Close()
 
+	// 初始化加密器（用于解密上游SMTP密码）
+	encryptor, err := encryption.NewFromKeyFile(viper.GetString("ENCRYPTION_KEY_FILE"))
+	if err != nil {
+		logger.WithError(err).Fatal("加载加密主密钥失败")
+	}
+
+	// DNS解析器
+	resolver := mailauth.NewResolver(viper.GetString("DNS_RESOLVER"))
+
+	// 创建发件域名服务（用于获取退信域名）
+	domainService := services.NewDomainService(db, resolver, logger)
+
+	// 初始化DKIM签名服务
+	dkimService := services.NewDKIMService(db, encryptor, resolver, logger)
+	var signingService *services.DKIMService
+	if viper.GetBool("DKIM_ENABLED") {
+		signingService = dkimService
+	}
+
+	// 初始化ARC封装服务
+	var arcService *services.ARCService
+	if viper.GetBool("ARC_ENABLED") {
+		authServID := viper.GetString("ARC_AUTHSERV_ID")
+		if authServID == "" {
+			authServID, _ = os.Hostname()
+		}
+		arcService = services.NewARCService(dkimService, resolver, authServID, logger)
+	}
+
 	// 创建邮件处理器
-	processor := worker.NewProcessor(db, logger, queueService)
+	processor := worker.NewProcessor(db, logger, queueService, encryptor, domainService, signingService, arcService)
 
 	// 启动处理器
 	processorConfig := &worker.Config{
@@ -110,6 +142,8 @@ func loadConfig() error {
 	viper.SetDefault("WORKER_COUNT", 5)
 	viper.SetDefault("PROCESS_TIMEOUT", "30s")
 	viper.SetDefault("RETRY_INTERVAL", "1m")
+	viper.SetDefault("DKIM_ENABLED", true)
+	viper.SetDefault("ARC_ENABLED", true)
 
 	// 从环境变量读取
 	viper.AutomaticEnv()
@@ -45,100 +45,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/login": {
-            "post": {
-                "description": "用户登录获取JWT令牌",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "用户登录",
-                "parameters": [
-                    {
-                        "description": "登录信息",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.LoginRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "登录成功",
-                        "schema": {
-                            "$ref": "#/definitions/api.LoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "请求参数错误",
-                        "schema": {
-                            "$ref": "#/definitions/api.APIResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "认证失败",
-                        "schema": {
-                            "$ref": "#/definitions/api.APIResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/v1/auth/register": {
-            "post": {
-                "description": "注册新用户账户",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "auth"
-                ],
-                "summary": "用户注册",
-                "parameters": [
-                    {
-                        "description": "注册信息",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/api.RegisterRequest"
-                        }
-                    }
-                ],
-                "responses": {
-                    "201": {
-                        "description": "注册成功",
-                        "schema": {
-                            "$ref": "#/definitions/api.RegisterResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "请求参数错误",
-                        "schema": {
-                            "$ref": "#/definitions/api.APIResponse"
-                        }
-                    }
-                }
-            }
-        },
-        "/api/v1/credentials": {
+        "/api/v1/admin/plans": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取当前用户的所有SMTP凭据",
+                "description": "获取所有套餐（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -146,14 +60,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "SMTP Credentials"
+                    "Admin"
                 ],
-                "summary": "获取SMTP凭据列表",
+                "summary": "获取套餐列表",
                 "responses": {
                     "200": {
                         "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.CredentialListResponse"
+                            "$ref": "#/definitions/api.PlanListResponse"
                         }
                     },
                     "401": {
@@ -161,6 +75,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
                     }
                 }
             },
@@ -170,7 +90,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "为当前用户创建新的SMTP凭据",
+                "description": "创建套餐，限制用户所有凭据合计的每日/每月收件人数，以及凭据数、域名数和邮件大小（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -178,17 +98,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "SMTP Credentials"
+                    "Admin"
                 ],
-                "summary": "创建SMTP凭据",
+                "summary": "创建套餐",
                 "parameters": [
                     {
-                        "description": "凭据信息",
+                        "description": "套餐定义",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.CreateCredentialRequest"
+                            "$ref": "#/definitions/api.PlanRequest"
                         }
                     }
                 ],
@@ -196,7 +116,7 @@ const docTemplate = `{
                     "201": {
                         "description": "创建成功",
                         "schema": {
-                            "$ref": "#/definitions/api.CreateCredentialResponse"
+                            "$ref": "#/definitions/api.PlanResponse"
                         }
                     },
                     "400": {
@@ -210,18 +130,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "套餐名称已存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/credentials/{id}": {
+        "/api/v1/admin/plans/{id}": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取指定ID的SMTP凭据详情",
+                "description": "获取指定套餐（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -229,13 +161,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "SMTP Credentials"
+                    "Admin"
                 ],
-                "summary": "获取单个SMTP凭据",
+                "summary": "获取套餐详情",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "凭据ID",
+                        "description": "套餐ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -245,7 +177,7 @@ const docTemplate = `{
                     "200": {
                         "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.CredentialResponse"
+                            "$ref": "#/definitions/api.PlanResponse"
                         }
                     },
                     "401": {
@@ -254,8 +186,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "404": {
-                        "description": "凭据不存在",
+                        "description": "套餐不存在",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -268,7 +206,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "更新指定ID的SMTP凭据信息",
+                "description": "更新套餐定义，立即对使用该套餐的所有用户生效（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -276,24 +214,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "SMTP Credentials"
+                    "Admin"
                 ],
-                "summary": "更新SMTP凭据",
+                "summary": "更新套餐",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "凭据ID",
+                        "description": "套餐ID",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "更新信息",
+                        "description": "套餐定义",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.UpdateCredentialRequest"
+                            "$ref": "#/definitions/api.PlanRequest"
                         }
                     }
                 ],
@@ -301,7 +239,7 @@ const docTemplate = `{
                     "200": {
                         "description": "更新成功",
                         "schema": {
-                            "$ref": "#/definitions/api.APIResponse"
+                            "$ref": "#/definitions/api.PlanResponse"
                         }
                     },
                     "400": {
@@ -316,8 +254,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "404": {
-                        "description": "凭据不存在",
+                        "description": "套餐不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "套餐名称已存在",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -330,7 +280,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "删除指定ID的SMTP凭据",
+                "description": "删除套餐，仍有用户使用的套餐不能删除（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -338,13 +288,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "SMTP Credentials"
+                    "Admin"
                 ],
-                "summary": "删除SMTP凭据",
+                "summary": "删除套餐",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "凭据ID",
+                        "description": "套餐ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -363,57 +313,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
-                    "404": {
-                        "description": "凭据不存在",
+                    "403": {
+                        "description": "需要管理员权限",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/v1/credentials/{id}/reset-password": {
-            "post": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "重置指定ID的SMTP凭据密码",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "SMTP Credentials"
-                ],
-                "summary": "重置SMTP凭据密码",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "凭据ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "重置成功",
-                        "schema": {
-                            "$ref": "#/definitions/api.ResetPasswordResponse"
-                        }
                     },
-                    "401": {
-                        "description": "未授权",
+                    "404": {
+                        "description": "套餐不存在",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
-                    "404": {
-                        "description": "凭据不存在",
+                    "409": {
+                        "description": "套餐正在使用中",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -421,14 +334,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/dkim/keys": {
+        "/api/v1/admin/smtp-configs": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取当前用户的所有DKIM密钥对",
+                "description": "获取Worker投递使用的所有上游SMTP服务器配置（不包含密码，需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -436,14 +349,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "DKIM"
+                    "Admin"
                 ],
-                "summary": "获取DKIM密钥对列表",
+                "summary": "获取上游SMTP配置列表",
                 "responses": {
                     "200": {
                         "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.DKIMKeyPairListResponse"
+                            "$ref": "#/definitions/api.SMTPConfigListResponse"
                         }
                     },
                     "401": {
@@ -451,6 +364,12 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
                     }
                 }
             },
@@ -460,7 +379,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "为指定域名创建新的DKIM密钥对",
+                "description": "创建上游SMTP服务器配置，密码加密存储，Worker定期重新加载启用的配置（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -468,17 +387,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "DKIM"
+                    "Admin"
                 ],
-                "summary": "创建DKIM密钥对",
+                "summary": "创建上游SMTP配置",
                 "parameters": [
                     {
-                        "description": "密钥对信息",
+                        "description": "SMTP配置",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.CreateDKIMKeyRequest"
+                            "$ref": "#/definitions/api.SMTPConfigRequest"
                         }
                     }
                 ],
@@ -486,7 +405,7 @@ const docTemplate = `{
                     "201": {
                         "description": "创建成功",
                         "schema": {
-                            "$ref": "#/definitions/api.DKIMKeyPairResponse"
+                            "$ref": "#/definitions/api.SMTPConfigResponse"
                         }
                     },
                     "400": {
@@ -500,18 +419,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "该主机和端口的SMTP配置已存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/dkim/keys/{id}": {
-            "get": {
+        "/api/v1/admin/smtp-configs/{id}": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取指定ID的DKIM密钥对详情",
+                "description": "更新上游SMTP服务器配置，不传password时保留原密码（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -519,23 +450,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "DKIM"
+                    "Admin"
                 ],
-                "summary": "获取单个DKIM密钥对",
+                "summary": "更新上游SMTP配置",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "密钥对ID",
+                        "description": "SMTP配置ID",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "SMTP配置",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SMTPConfigRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "更新成功",
                         "schema": {
-                            "$ref": "#/definitions/api.DKIMKeyPairResponse"
+                            "$ref": "#/definitions/api.SMTPConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
                         }
                     },
                     "401": {
@@ -544,8 +490,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "404": {
-                        "description": "密钥对不存在",
+                        "description": "SMTP配置不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "该主机和端口的SMTP配置已存在",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -558,7 +516,7 @@ const docTemplate = `{
                         "BearerAuth": []
                     }
                 ],
-                "description": "删除指定ID的DKIM密钥对",
+                "description": "删除上游SMTP服务器配置（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -566,13 +524,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "DKIM"
+                    "Admin"
                 ],
-                "summary": "删除DKIM密钥对",
+                "summary": "删除上游SMTP配置",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "密钥对ID",
+                        "description": "SMTP配置ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -591,8 +549,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "404": {
-                        "description": "密钥对不存在",
+                        "description": "SMTP配置不存在",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -600,14 +564,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/dkim/keys/{id}/dns": {
+        "/api/v1/admin/users": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取指定密钥对的DNS TXT记录信息",
+                "description": "分页获取所有用户，支持按用户名或邮箱搜索、按状态和角色过滤（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -615,23 +579,58 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "DKIM"
+                    "Admin"
                 ],
-                "summary": "获取DKIM DNS记录",
+                "summary": "获取用户列表",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "密钥对ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "用户名或邮箱",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "active",
+                            "suspended",
+                            "deleted"
+                        ],
+                        "type": "string",
+                        "description": "用户状态",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "user",
+                            "admin",
+                            "read_only"
+                        ],
+                        "type": "string",
+                        "description": "用户角色",
+                        "name": "role",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.DNSRecordResponse"
+                            "$ref": "#/definitions/api.AdminUserListResponse"
                         }
                     },
                     "401": {
@@ -640,8 +639,8 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
-                    "404": {
-                        "description": "密钥对不存在",
+                    "403": {
+                        "description": "需要管理员权限",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -649,14 +648,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/dkim/keys/{id}/rotate": {
-            "post": {
+        "/api/v1/admin/users/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "为指定密钥对生成新的密钥，旧密钥标记为即将过期",
+                "description": "获取任意状态用户的信息和生效的套餐（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -664,13 +663,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "DKIM"
+                    "Admin"
                 ],
-                "summary": "轮换DKIM密钥对",
+                "summary": "获取用户详情",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "密钥对ID",
+                        "description": "用户ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -678,9 +677,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "轮换成功",
+                        "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.DKIMKeyPairResponse"
+                            "$ref": "#/definitions/api.AdminUserResponse"
                         }
                     },
                     "401": {
@@ -689,8 +688,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "404": {
-                        "description": "密钥对不存在",
+                        "description": "用户不存在",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -698,14 +703,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/dkim/keys/{id}/verify": {
-            "post": {
+        "/api/v1/admin/users/{id}/credentials": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "验证指定密钥对的DNS记录是否正确配置",
+                "description": "获取任意用户的SMTP凭据列表（不包含密码，需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -713,13 +718,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "DKIM"
+                    "Admin"
                 ],
-                "summary": "验证DKIM DNS记录",
+                "summary": "获取用户的SMTP凭据",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "密钥对ID",
+                        "description": "用户ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -727,9 +732,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "验证完成",
+                        "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.DKIMValidationResponse"
+                            "$ref": "#/definitions/api.AdminCredentialListResponse"
                         }
                     },
                     "401": {
@@ -738,8 +743,14 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "404": {
-                        "description": "密钥对不存在",
+                        "description": "用户不存在",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -747,14 +758,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/logs": {
+        "/api/v1/admin/users/{id}/logs": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取当前用户的邮件发送日志，支持分页和筛选",
+                "description": "获取任意用户的邮件发送日志，筛选参数与 /api/v1/logs 相同（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -762,10 +773,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "MailLog"
+                    "Admin"
                 ],
-                "summary": "获取MailLog",
+                "summary": "获取用户的MailLog",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "integer",
                         "default": 1,
@@ -817,6 +835,28 @@ const docTemplate = `{
                         "description": "结束日期",
                         "name": "date_to",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签筛选（X-Relay-Tag）",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "活动ID筛选（X-Relay-Campaign）",
+                        "name": "campaign",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "元数据筛选，格式key:value，可重复（须全部匹配）",
+                        "name": "metadata",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -826,23 +866,41 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.MailLogListResponse"
                         }
                     },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "401": {
                         "description": "未授权",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/logs/recent": {
-            "get": {
+        "/api/v1/admin/users/{id}/plan": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取用户近期发信历史，支持天数筛选和状态筛选，包含统计信息",
+                "description": "为用户分配套餐，plan_id为空时取消分配，使用默认套餐（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -850,53 +908,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "MailLog"
+                    "Admin"
                 ],
-                "summary": "获取近期MailLog",
+                "summary": "为用户分配套餐",
                 "parameters": [
                     {
-                        "maximum": 365,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 30,
-                        "description": "最近N天",
-                        "name": "days",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "queued",
-                            "sending",
-                            "sent",
-                            "failed"
-                        ],
                         "type": "string",
-                        "description": "邮件状态",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "页码",
-                        "name": "page",
-                        "in": "query"
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "maximum": 100,
-                        "minimum": 1,
-                        "type": "integer",
-                        "default": 50,
-                        "description": "每页数量",
-                        "name": "page_size",
-                        "in": "query"
+                        "description": "套餐",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.AssignPlanRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "分配成功",
                         "schema": {
-                            "$ref": "#/definitions/api.RecentMailLogsResponse"
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
                         }
                     },
                     "401": {
@@ -904,18 +947,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户或套餐不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/logs/{id}": {
-            "get": {
+        "/api/v1/admin/users/{id}/reactivate": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取指定ID的MailLog详情",
+                "description": "重新启用被停用的用户（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -923,13 +978,13 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "MailLog"
+                    "Admin"
                 ],
-                "summary": "获取单个MailLog",
+                "summary": "重新启用用户",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "MailLogID",
+                        "description": "用户ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -937,9 +992,9 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "启用成功",
                         "schema": {
-                            "$ref": "#/definitions/api.MailLogResponse"
+                            "$ref": "#/definitions/api.APIResponse"
                         }
                     },
                     "401": {
@@ -948,8 +1003,20 @@ const docTemplate = `{
                             "$ref": "#/definitions/api.APIResponse"
                         }
                     },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
                     "404": {
-                        "description": "MailLog不存在",
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "用户已删除",
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
@@ -957,14 +1024,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/stats": {
-            "get": {
+        "/api/v1/admin/users/{id}/role": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取用户邮件统计信息和凭据统计信息",
+                "description": "设置用户角色：user（普通用户）、admin（管理员）、read_only（只读，只能查看自己的数据）；不能修改自己的角色（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -972,14 +1039,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "status"
+                    "Admin"
+                ],
+                "summary": "设置用户角色",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "角色",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SetUserRoleRequest"
+                        }
+                    }
                 ],
-                "summary": "获取统计信息",
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "设置成功",
                         "schema": {
-                            "$ref": "#/definitions/api.StatsResponse"
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
                         }
                     },
                     "401": {
@@ -987,18 +1078,30 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
                     }
                 }
             }
         },
-        "/api/v1/stats/quota": {
-            "get": {
+        "/api/v1/admin/users/{id}/settings": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取用户邮件配额使用情况统计",
+                "description": "更新任意用户的配额、速率限制、时区等设置，只修改请求中提供的字段（需要管理员权限）",
                 "consumes": [
                     "application/json"
                 ],
@@ -1006,14 +1109,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "status"
+                    "Admin"
+                ],
+                "summary": "更新用户设置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "用户设置",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.UserSettingsUpdate"
+                        }
+                    }
                 ],
-                "summary": "获取配额统计",
                 "responses": {
                     "200": {
-                        "description": "获取成功",
+                        "description": "更新成功",
                         "schema": {
-                            "$ref": "#/definitions/api.QuotaStatsResponse"
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
                         }
                     },
                     "401": {
@@ -1021,18 +1148,191 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/api.APIResponse"
                         }
-                    }
-                }
-            }
-        },
-        "/api/v1/user": {
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/users/{id}/suspend": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "停用用户：用户不能再登录API，已签发的令牌立即失效，其SMTP凭据也无法认证（需要管理员权限）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "停用用户",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "用户ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "停用原因",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.SuspendUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "停用成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "需要管理员权限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "用户不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "用户已删除",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/login": {
+            "post": {
+                "description": "用户登录获取JWT令牌",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "用户登录",
+                "parameters": [
+                    {
+                        "description": "登录信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.LoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "登录成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "认证失败",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/register": {
+            "post": {
+                "description": "注册新用户账户",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "用户注册",
+                "parameters": [
+                    {
+                        "description": "注册信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.RegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "注册成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.RegisterResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "获取当前用户的详细信息",
+                "description": "获取当前用户的所有SMTP凭据",
                 "consumes": [
                     "application/json"
                 ],
@@ -1040,14 +1340,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "usermgmt"
+                    "SMTP Credentials"
                 ],
-                "summary": "获取用户信息",
+                "summary": "获取SMTP凭据列表",
                 "responses": {
                     "200": {
                         "description": "获取成功",
                         "schema": {
-                            "$ref": "#/definitions/api.UserInfoResponse"
+                            "$ref": "#/definitions/api.CredentialListResponse"
                         }
                     },
                     "401": {
@@ -1058,13 +1358,13 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "更新当前用户的信息",
+                "description": "为当前用户创建新的SMTP凭据",
                 "consumes": [
                     "application/json"
                 ],
@@ -1072,25 +1372,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "usermgmt"
+                    "SMTP Credentials"
                 ],
-                "summary": "更新用户信息",
+                "summary": "创建SMTP凭据",
                 "parameters": [
                     {
-                        "description": "更新信息",
+                        "description": "凭据信息",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/api.UpdateUserInfoRequest"
+                            "$ref": "#/definitions/api.CreateCredentialRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "更新成功",
+                    "201": {
+                        "description": "创建成功",
                         "schema": {
-                            "$ref": "#/definitions/api.APIResponse"
+                            "$ref": "#/definitions/api.CreateCredentialResponse"
                         }
                     },
                     "400": {
@@ -1108,9 +1408,14 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
+        "/api/v1/credentials/{id}": {
             "get": {
-                "description": "检查服务健康状态",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定ID的SMTP凭据详情",
                 "consumes": [
                     "application/json"
                 ],
@@ -1118,780 +1423,7007 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "system"
+                    "SMTP Credentials"
+                ],
+                "summary": "获取单个SMTP凭据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "健康检查",
                 "responses": {
                     "200": {
-                        "description": "服务正常",
+                        "description": "获取成功",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/api.CredentialResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "api.APIResponse": {
-            "type": "object",
-            "properties": {
-                "data": {},
-                "error": {
-                    "type": "string",
-                    "example": "错误信息"
-                },
-                "message": {
-                    "type": "string",
-                    "example": "操作成功"
-                },
-                "success": {
-                    "type": "boolean",
-                    "example": true
-                }
-            }
-        },
-        "api.CreateCredentialRequest": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "更新指定ID的SMTP凭据信息。settings可限制凭据的发件地址（allowed_senders，支持*@example.com等本地部分通配符）、单封邮件大小（max_message_size）、是否要求TLS（require_tls）、允许的监听端口（allowed_ports）和发送时段（sending_hours）；存在速率上限时messages_per_second、recipients_per_minute必须在1到上限之间",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "更新SMTP凭据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "更新信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateCredentialRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除指定ID的SMTP凭据",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "删除SMTP凭据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/anomalies": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取凭据发信行为偏离基线时记录的告警，包括偏离的指标、当时的行为和处理结果（alerted或suspended）",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "获取SMTP凭据异常告警",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.CredentialAnomalyListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/baseline": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取异常检测为凭据学习的发信行为基线（每小时邮件数、客户端IP数、收件域名数、投递失败率的均值和标准差）以及最近60分钟的实际行为。\n基线只学习有发信的小时，至少学习24小时后才开始检测",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "获取SMTP凭据行为基线",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.CredentialBaselineResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/clients": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取使用该凭据认证过的客户端，按来源IP、EHLO名称、TLS版本和认证机制区分，包含首次/最近使用时间和认证次数，最近使用的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "获取SMTP凭据客户端历史",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "只返回该IP的记录",
+                        "name": "ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.CredentialClientListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/enable": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "重新启用因发信行为异常（疑似泄露）被自动停用的凭据。停用后必须先重置密码才能启用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "重新启用SMTP凭据",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "启用成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.CredentialResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "需要先重置密码或凭据不能手动启用",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/expiration": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "设置或清除凭据的过期时间，过期后凭据被自动禁用；为因过期被禁用的凭据设置新的过期时间后凭据恢复可用",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "设置SMTP凭据过期时间",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "过期时间",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SetCredentialExpirationRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "设置成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.CredentialResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/previous-password": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "提前结束密码轮换的宽限期，旧密码立即失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "撤销SMTP凭据旧密码",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "撤销成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在或没有旧密码",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/reset-password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "重置指定ID的SMTP凭据密码，旧密码立即失效；需要平滑切换时请使用轮换接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "重置SMTP凭据密码",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "重置成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.ResetPasswordResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/rotate-password": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "生成新密码，旧密码在宽限期内仍可用于认证，以便逐台更新使用该凭据的应用服务器；宽限期结束后旧密码自动失效",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "轮换SMTP凭据密码",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "轮换参数",
+                        "name": "body",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/api.RotateCredentialPasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "轮换成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.RotatePasswordResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "旧密码仍在宽限期内或凭据已禁用",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/sandbox/messages": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定SMTP凭据在沙箱模式下捕获的邮件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sandbox"
+                ],
+                "summary": "获取沙箱邮件列表",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.SandboxMessageListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除指定SMTP凭据捕获的全部沙箱邮件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sandbox"
+                ],
+                "summary": "清空沙箱邮件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "清空成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/sandbox/messages/{messageId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取沙箱邮件的头部和MIME部件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sandbox"
+                ],
+                "summary": "获取沙箱邮件详情",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "沙箱邮件ID",
+                        "name": "messageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.SandboxMessageDetailResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "沙箱邮件不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除指定的沙箱邮件",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Sandbox"
+                ],
+                "summary": "删除沙箱邮件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "沙箱邮件ID",
+                        "name": "messageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "沙箱邮件不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/sandbox/messages/{messageId}/raw": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以message/rfc822格式返回沙箱邮件的原始内容",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Sandbox"
+                ],
+                "summary": "下载沙箱邮件原文",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "沙箱邮件ID",
+                        "name": "messageId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "邮件原文",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "沙箱邮件不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/credentials/{id}/security-events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取凭据相关的安全事件（如首次从新IP认证），最新的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMTP Credentials"
+                ],
+                "summary": "获取SMTP凭据安全事件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.SecurityEventListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "凭据不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/configs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户所有域名的DKIM签名设置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "获取域名DKIM配置列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMConfigListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "为已验证的发件域名设置DKIM签名参数：规范化算法、签名头部、From过度签名、签名域名和默认密钥，配置同样作用于其子域名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "创建域名DKIM配置",
+                "parameters": [
+                    {
+                        "description": "DKIM配置",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateDKIMConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "域名未验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "配置已存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/configs/{domain}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定域名的DKIM签名设置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "获取域名DKIM配置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMConfigResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "配置不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "替换指定域名的DKIM签名设置，未提供的字段使用默认值",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "更新域名DKIM配置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "DKIM配置",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMConfigRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMConfigResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "配置不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除指定域名的DKIM签名设置，删除后恢复默认签名设置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "删除域名DKIM配置",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "配置不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/keys": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户的所有DKIM密钥对",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "获取DKIM密钥对列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMKeyPairListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "为指定域名创建新的DKIM密钥对，支持RSA（1024/2048/4096位）和Ed25519（RFC 8463）；同一域名同时存在两种密钥时投递将双重签名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "创建DKIM密钥对",
+                "parameters": [
+                    {
+                        "description": "密钥对信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateDKIMKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "创建成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMKeyPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "域名未验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/keys/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "导入已在其他中继使用的PEM私钥（PKCS#1/PKCS#8，RSA或Ed25519），私钥必须与 selector._domainkey.domain 当前发布的公钥一致",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "导入DKIM私钥",
+                "parameters": [
+                    {
+                        "description": "私钥信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ImportDKIMKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "导入成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMKeyPairResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "私钥无效或与DNS记录不匹配",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "域名未验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/keys/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定ID的DKIM密钥对详情",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "获取单个DKIM密钥对",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥对ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMKeyPairResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "密钥对不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除指定ID的DKIM密钥对",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "删除DKIM密钥对",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥对ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "密钥对不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/keys/{id}/dns": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定密钥对的DNS TXT记录信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "获取DKIM DNS记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥对ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DNSRecordResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "密钥对不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/keys/{id}/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "导出指定密钥对的PEM格式公钥及DNS记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "导出DKIM公钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥对ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "导出成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMKeyExportResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "密钥对不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "导出指定密钥对的PEM格式私钥（PKCS#8或PKCS#1），需提供当前登录密码重新认证",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "导出DKIM私钥",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥对ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "重新认证信息及导出格式",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.ExportDKIMPrivateKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "导出成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMKeyExportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权或密码错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "密钥对不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/keys/{id}/rotate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "为指定密钥对生成新选择器的密钥（pending状态），新选择器的DNS记录生效后自动切换签名，旧密钥进入宽限期后过期",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "轮换DKIM密钥对",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥对ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "轮换成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMKeyPairResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "密钥对不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "密钥对状态不允许轮换",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/keys/{id}/verify": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "验证指定密钥对的DNS记录是否正确配置",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "验证DKIM DNS记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "密钥对ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证完成",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMValidationResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "密钥对不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/policies": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户所有域名的DKIM自动轮换策略",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "获取DKIM轮换策略列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMRotationPolicyListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/dkim/policies/{domain}": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "为域名设置DKIM自动轮换策略：密钥达到轮换周期后自动生成新选择器并通知发布DNS记录，记录生效后切换签名，旧密钥在宽限期后过期",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "设置DKIM轮换策略",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "轮换策略",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.SetDKIMRotationPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "设置成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DKIMRotationPolicyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除域名的DKIM自动轮换策略，已开始的轮换不受影响",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "DKIM"
+                ],
+                "summary": "删除DKIM轮换策略",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "轮换策略不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domains": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户声明的所有发件域名及其验证状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "获取发件域名列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DomainListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "声明一个发件域名并生成验证令牌，需在DNS中发布返回的TXT记录后调用验证接口",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "声明发件域名",
+                "parameters": [
+                    {
+                        "description": "域名信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.CreateDomainRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "声明成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DomainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "域名数量已达到套餐上限",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "域名已添加或已被其他用户验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domains/{domain}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定发件域名的验证状态和需要发布的TXT记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "获取发件域名",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DomainResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "域名不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "设置已验证域名的退信域名，投递时信封发件人将改写为 bounce+\u003c邮件ID\u003e@\u003c退信域名\u003e",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "更新发件域名",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "更新信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateDomainRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DomainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "域名不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除发件域名，删除后该域名的发件地址将无法通过中继发信",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "删除发件域名",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "域名不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domains/{domain}/health": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "检查域名的SPF（是否授权中继IP、DNS查询次数）、所有有效DKIM选择器、DMARC策略与对齐、MX记录以及中继IP的正反向DNS，并给出修复建议",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "域名认证健康检查",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "检查完成",
+                        "schema": {
+                            "$ref": "#/definitions/api.DomainHealthResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "无效的域名",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/domains/{domain}/verify": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "查询 _smtp-relay.\u003c域名\u003e 的TXT记录，包含验证令牌时将域名标记为已验证",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Domains"
+                ],
+                "summary": "验证发件域名",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "域名",
+                        "name": "domain",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.DomainResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "未找到验证TXT记录",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "域名不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "域名已被其他用户验证",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/logs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户的邮件发送日志，支持分页和筛选",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MailLog"
+                ],
+                "summary": "获取MailLog",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "queued",
+                            "sending",
+                            "sent",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "邮件状态",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "发件人筛选",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "收件人筛选",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "开始日期",
+                        "name": "date_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date",
+                        "description": "结束日期",
+                        "name": "date_to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签筛选（X-Relay-Tag）",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "活动ID筛选（X-Relay-Campaign）",
+                        "name": "campaign",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "元数据筛选，格式key:value，可重复（须全部匹配）",
+                        "name": "metadata",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.MailLogListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/logs/recent": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取用户近期发信历史，支持天数筛选和状态筛选，包含统计信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MailLog"
+                ],
+                "summary": "获取近期MailLog",
+                "parameters": [
+                    {
+                        "maximum": 365,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 30,
+                        "description": "最近N天",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "queued",
+                            "sending",
+                            "sent",
+                            "failed"
+                        ],
+                        "type": "string",
+                        "description": "邮件状态",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 50,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.RecentMailLogsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/logs/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定ID的MailLog详情",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "MailLog"
+                ],
+                "summary": "获取单个MailLog",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MailLogID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.MailLogResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "MailLog不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/logs/{id}/raw": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "以.eml格式下载指定MailLog对应的归档原始邮件",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "MailLog"
+                ],
+                "summary": "下载归档的原始邮件",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "MailLogID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "邮件原文",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "归档邮件不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notifications": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "分页获取当前用户的通知（如需要发布的DKIM DNS记录），最新的在前",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "获取通知列表",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "页码",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 20,
+                        "description": "每页数量",
+                        "name": "page_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "仅未读",
+                        "name": "unread_only",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotificationListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notifications/preferences": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取配额提醒阈值和通知渠道（邮件、Webhook）设置；未设置时在配额用量达到80%、95%和100%时发送站内通知",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "获取通知偏好",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotificationPreferencesResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "设置配额提醒阈值（凭据和用户的小时/日/月配额用量达到阈值时，每个周期每个阈值只通知一次）以及通知渠道。站内通知始终保存；启用邮件时通过中继发送到指定邮箱；设置Webhook时以JSON POST事件，设置了签名密钥时附带X-Relay-Signature: sha256=\u003cHMAC-SHA256\u003e头部",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "更新通知偏好",
+                "parameters": [
+                    {
+                        "description": "通知偏好",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.NotificationPreferencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.NotificationPreferencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notifications/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "将当前用户的全部未读通知标记为已读",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "全部标记已读",
+                "responses": {
+                    "200": {
+                        "description": "标记成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/notifications/{id}/read": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "将指定通知标记为已读",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Notifications"
+                ],
+                "summary": "标记通知已读",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "通知ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "标记成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "通知不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/smime/certificates": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户的所有S/MIME证书，即将过期或已过期的证书会在warnings中提示",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMIME"
+                ],
+                "summary": "获取S/MIME证书列表",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.SMIMECertificateListResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "为发件地址或域名上传S/MIME证书和私钥，匹配的发件人邮件将以multipart/signed格式签名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMIME"
+                ],
+                "summary": "上传S/MIME证书",
+                "parameters": [
+                    {
+                        "description": "证书信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UploadSMIMECertificateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "上传成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.SMIMECertificateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/smime/certificates/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取指定ID的S/MIME证书详情及有效期状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMIME"
+                ],
+                "summary": "获取单个S/MIME证书",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "证书ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.SMIMECertificateResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "证书不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "删除指定的S/MIME证书，之后该发件人的邮件不再签名",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SMIME"
+                ],
+                "summary": "删除S/MIME证书",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "证书ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "删除成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "证书不存在",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stats": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取用户邮件统计信息和凭据统计信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "获取统计信息",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.StatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stats/groups": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按客户端通过X-Relay-Tag、X-Relay-Campaign、X-Relay-Metadata头部附加的标签、活动ID或元数据键分组统计最近N天的邮件数量和状态，按数量降序最多返回100组。\n分组值为空字符串表示邮件未设置该项；一封邮件有多个标签时分别计入每个标签",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "分组邮件统计",
+                "parameters": [
+                    {
+                        "enum": [
+                            "tag",
+                            "campaign",
+                            "metadata"
+                        ],
+                        "type": "string",
+                        "description": "分组维度",
+                        "name": "group_by",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "元数据键（group_by=metadata时必填）",
+                        "name": "metadata_key",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 365,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 30,
+                        "description": "最近N天",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "标签筛选",
+                        "name": "tag",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "活动ID筛选",
+                        "name": "campaign",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "元数据筛选，格式key:value，可重复",
+                        "name": "metadata",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.GroupedStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/stats/quota": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取用户和各SMTP凭据的配额使用情况（按收件人数计量，周期为用户时区（未设置时使用套餐时区，都未设置时为UTC）的整点小时、自然日和自然月）、用户生效的套餐，以及各凭据速率限制令牌桶的当前可用令牌数",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "获取配额统计",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.QuotaStatsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/tools/verify-message": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "上传原始邮件（.eml），验证其中所有DKIM签名和ARC链，按客户端IP评估SPF，并给出DMARC对齐结果。\n邮件可以multipart表单的file字段上传，也可直接作为请求体（Content-Type: message/rfc822）提交",
+                "consumes": [
+                    "multipart/form-data",
+                    "message/rfc822"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Tools"
+                ],
+                "summary": "验证邮件认证结果",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "原始邮件（.eml）",
+                        "name": "file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "发送方IP，为空时跳过SPF检查",
+                        "name": "client_ip",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "SMTP MAIL FROM地址，为空时使用Return-Path或From头部",
+                        "name": "mail_from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HELO/EHLO域名",
+                        "name": "helo",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "验证完成",
+                        "schema": {
+                            "$ref": "#/definitions/api.MessageVerificationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "邮件过大",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/usage": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取日期范围内按凭据汇总的每日用量记录（接受的邮件数、收件人数、字节数、已投递数、投递失败数）和合计，日期按用户时区，范围不超过366天。记录在一天结束并经过结算期后生成，此后不再修改。format=csv时以CSV文件导出",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "Usage"
+                ],
+                "summary": "获取每日用量记录",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "开始日期 YYYY-MM-DD",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "结束日期 YYYY-MM-DD",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "凭据ID",
+                        "name": "credential_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "default": "json",
+                        "description": "导出格式",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.UsageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/usage/invoice": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "按凭据汇总用户某月（用户时区的自然月）的用量记录，用于内部计费；complete为false表示当月还有日期尚未生成用量记录",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Usage"
+                ],
+                "summary": "获取月度用量汇总",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "月份 YYYY-MM",
+                        "name": "month",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.UsageInvoiceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/user": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "获取当前用户的详细信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usermgmt"
+                ],
+                "summary": "获取用户信息",
+                "responses": {
+                    "200": {
+                        "description": "获取成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.UserInfoResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "更新当前用户的信息",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "usermgmt"
+                ],
+                "summary": "更新用户信息",
+                "parameters": [
+                    {
+                        "description": "更新信息",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/api.UpdateUserInfoRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "更新成功",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "请求参数错误",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "未授权",
+                        "schema": {
+                            "$ref": "#/definitions/api.APIResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "检查服务健康状态",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "健康检查",
+                "responses": {
+                    "200": {
+                        "description": "服务正常",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "api.APIResponse": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string",
+                    "example": "错误信息"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "操作成功"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.AdminCredentialListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SMTPCredential"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.AdminUserListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 20
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 42
+                        },
+                        "users": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.User"
+                            }
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.AdminUserResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "plan": {
+                            "description": "用户生效的套餐，没有套餐时为null",
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.Plan"
+                                }
+                            ]
+                        },
+                        "user": {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.AssignPlanRequest": {
+            "type": "object",
+            "properties": {
+                "plan_id": {
+                    "description": "为空表示取消分配，使用默认套餐",
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                }
+            }
+        },
+        "api.CreateCredentialRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 200,
+                    "example": "用于发送营销邮件的SMTP凭据"
+                },
+                "expires_at": {
+                    "description": "过期时间，为空表示永不过期",
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "sandbox"
+                    ],
+                    "example": "normal"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1,
+                    "example": "My SMTP Credential"
+                }
+            }
+        },
+        "api.CreateCredentialResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "credential": {
+                            "$ref": "#/definitions/models.SMTPCredential"
+                        },
+                        "password": {
+                            "type": "string",
+                            "example": "generated_password"
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.CreateDKIMConfigRequest": {
+            "type": "object",
+            "required": [
+                "domain"
+            ],
+            "properties": {
+                "active": {
+                    "description": "是否启用该配置，默认true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "body_canon": {
+                    "description": "默认relaxed",
+                    "type": "string",
+                    "enum": [
+                        "relaxed",
+                        "simple"
+                    ],
+                    "example": "relaxed"
+                },
+                "default_key_id": {
+                    "description": "默认密钥（替换同算法的自动选择）",
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "domain": {
+                    "type": "string",
+                    "example": "example.com"
+                },
+                "enabled": {
+                    "description": "是否对该域名签名，默认true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "header_canon": {
+                    "description": "默认relaxed",
+                    "type": "string",
+                    "enum": [
+                        "relaxed",
+                        "simple"
+                    ],
+                    "example": "relaxed"
+                },
+                "oversign_from": {
+                    "description": "是否过度签名From，默认true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "sign_headers": {
+                    "description": "须包含From",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "From",
+                        "To",
+                        "Subject",
+                        "Date",
+                        "Message-ID"
+                    ]
+                },
+                "signing_domains": {
+                    "description": "签名域名（d=），须为该域名或其上级域名",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "example.com"
+                    ]
+                }
+            }
+        },
+        "api.CreateDKIMKeyRequest": {
+            "type": "object",
+            "required": [
+                "domain",
+                "selector"
+            ],
+            "properties": {
+                "algorithm": {
+                    "description": "默认rsa-sha256",
+                    "type": "string",
+                    "enum": [
+                        "rsa-sha256",
+                        "ed25519-sha256"
+                    ],
+                    "example": "rsa-sha256"
+                },
+                "domain": {
+                    "type": "string",
+                    "example": "example.com"
+                },
+                "key_size": {
+                    "description": "仅RSA有效",
+                    "type": "integer",
+                    "enum": [
+                        1024,
+                        2048,
+                        4096
+                    ],
+                    "example": 2048
+                },
+                "selector": {
+                    "type": "string",
+                    "example": "default"
+                }
+            }
+        },
+        "api.CreateDomainRequest": {
+            "type": "object",
+            "required": [
+                "domain"
+            ],
+            "properties": {
+                "domain": {
+                    "type": "string",
+                    "example": "example.com"
+                }
+            }
+        },
+        "api.CredentialAnomalyListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "anomalies": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CredentialAnomaly"
+                            }
+                        },
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 20
+                        },
+                        "pages": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 3
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.CredentialBaselineResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "baseline": {
+                            "description": "尚未学习时为null",
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.CredentialBaseline"
+                                }
+                            ]
+                        },
+                        "current": {
+                            "description": "最近60分钟的发信行为",
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.CredentialActivity"
+                                }
+                            ]
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.CredentialClientListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "clients": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CredentialClient"
+                            }
+                        },
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 20
+                        },
+                        "pages": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 5
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.CredentialListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SMTPCredential"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.CredentialResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SMTPCredential"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMConfigListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DKIMConfig"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMConfigRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "是否启用该配置，默认true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "body_canon": {
+                    "description": "默认relaxed",
+                    "type": "string",
+                    "enum": [
+                        "relaxed",
+                        "simple"
+                    ],
+                    "example": "relaxed"
+                },
+                "default_key_id": {
+                    "description": "默认密钥（替换同算法的自动选择）",
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "enabled": {
+                    "description": "是否对该域名签名，默认true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "header_canon": {
+                    "description": "默认relaxed",
+                    "type": "string",
+                    "enum": [
+                        "relaxed",
+                        "simple"
+                    ],
+                    "example": "relaxed"
+                },
+                "oversign_from": {
+                    "description": "是否过度签名From，默认true",
+                    "type": "boolean",
+                    "example": true
+                },
+                "sign_headers": {
+                    "description": "须包含From",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "From",
+                        "To",
+                        "Subject",
+                        "Date",
+                        "Message-ID"
+                    ]
+                },
+                "signing_domains": {
+                    "description": "签名域名（d=），须为该域名或其上级域名",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "example.com"
+                    ]
+                }
+            }
+        },
+        "api.DKIMConfigResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DKIMConfig"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMKeyExportResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DKIMKeyExport"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMKeyPairListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DKIMKeyPair"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMKeyPairResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DKIMKeyPair"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMRotationPolicyListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DKIMRotationPolicy"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMRotationPolicyResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DKIMRotationPolicy"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DKIMValidationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DKIMValidationResult"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DNSRecordResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DNSRecord"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DomainData": {
+            "type": "object",
+            "properties": {
+                "bounce_domain": {
+                    "description": "退信域名（信封发件人域名）",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "failed_checks": {
+                    "description": "连续重新验证失败次数",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_checked_at": {
+                    "description": "最后检查时间",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, verified, suspended",
+                    "type": "string"
+                },
+                "suspended_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "verification_record": {
+                    "$ref": "#/definitions/models.DNSRecord"
+                },
+                "verification_token": {
+                    "description": "TXT验证令牌",
+                    "type": "string"
+                },
+                "verified_at": {
+                    "description": "首次验证通过时间",
+                    "type": "string"
+                }
+            }
+        },
+        "api.DomainHealthResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.DomainHealthReport"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DomainListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.DomainData"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.DomainResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/api.DomainData"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.ExportDKIMPrivateKeyRequest": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "format": {
+                    "description": "默认pkcs8，pkcs1仅支持RSA",
+                    "type": "string",
+                    "enum": [
+                        "pkcs8",
+                        "pkcs1"
+                    ],
+                    "example": "pkcs8"
+                },
+                "password": {
+                    "description": "当前登录密码（重新认证）",
+                    "type": "string",
+                    "example": "password123"
+                }
+            }
+        },
+        "api.GroupedStatsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "days": {
+                            "type": "integer",
+                            "example": 30
+                        },
+                        "group_by": {
+                            "type": "string",
+                            "example": "tag"
+                        },
+                        "groups": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/services.MailStatsGroup"
+                            }
+                        },
+                        "metadata_key": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.ImportDKIMKeyRequest": {
+            "type": "object",
+            "required": [
+                "domain",
+                "private_key",
+                "selector"
+            ],
+            "properties": {
+                "domain": {
+                    "type": "string",
+                    "example": "example.com"
+                },
+                "private_key": {
+                    "description": "PEM格式私钥（PKCS#1或PKCS#8，RSA或Ed25519）",
+                    "type": "string"
+                },
+                "selector": {
+                    "type": "string",
+                    "example": "s2024"
+                }
+            }
+        },
+        "api.LoginRequest": {
+            "type": "object",
+            "required": [
+                "password",
+                "username"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string",
+                    "example": "password123"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "user@example.com"
+                }
+            }
+        },
+        "api.LoginResponse": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/api.UserInfo"
+                }
+            }
+        },
+        "api.MailLogListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "mail_logs": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MailLog"
+                            }
+                        },
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 20
+                        },
+                        "pages": {
+                            "type": "integer",
+                            "example": 5
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 100
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.MailLogResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.MailLog"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.MessageVerificationResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.MessageVerification"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.NotificationListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "notifications": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Notification"
+                            }
+                        },
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 20
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 5
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.NotificationPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "接收通知的邮箱，为空时使用账户邮箱",
+                    "type": "string",
+                    "example": "ops@example.com"
+                },
+                "email_enabled": {
+                    "description": "是否通过中继发送邮件通知",
+                    "type": "boolean",
+                    "example": true
+                },
+                "quota_thresholds": {
+                    "description": "配额用量提醒阈值（百分比，1-100），为空表示不发送配额提醒",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        80,
+                        95,
+                        100
+                    ]
+                },
+                "webhook_secret": {
+                    "description": "Webhook签名密钥，不传时保留原密钥，传空字符串时清除",
+                    "type": "string",
+                    "example": "change-me"
+                },
+                "webhook_url": {
+                    "description": "接收通知的Webhook地址（须为公网地址），为空表示不调用",
+                    "type": "string",
+                    "example": "https://example.com/hooks/relay"
+                }
+            }
+        },
+        "api.NotificationPreferencesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.NotificationPreferences"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.PlanListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Plan"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.PlanRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "daily_limit": {
+                    "description": "每日收件人数（所有凭据合计）",
+                    "type": "integer",
+                    "example": 10000
+                },
+                "description": {
+                    "type": "string",
+                    "example": "基础套餐"
+                },
+                "is_default": {
+                    "description": "设为默认套餐（未分配套餐的用户使用）",
+                    "type": "boolean",
+                    "example": false
+                },
+                "max_credentials": {
+                    "description": "最多SMTP凭据数",
+                    "type": "integer",
+                    "example": 10
+                },
+                "max_domains": {
+                    "description": "最多发件域名数",
+                    "type": "integer",
+                    "example": 5
+                },
+                "max_message_size": {
+                    "description": "单封邮件最大字节数",
+                    "type": "integer",
+                    "example": 10485760
+                },
+                "monthly_limit": {
+                    "description": "每月收件人数（所有凭据合计）",
+                    "type": "integer",
+                    "example": 200000
+                },
+                "name": {
+                    "type": "string",
+                    "example": "basic"
+                },
+                "timezone": {
+                    "description": "配额周期和统计使用的IANA时区，用户未设置时区时生效，为空表示UTC",
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                }
+            }
+        },
+        "api.PlanResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.Plan"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.QuotaStatsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "credential_quotas": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        },
+                        "plan": {
+                            "description": "用户生效的套餐，没有套餐时为null",
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/models.Plan"
+                                }
+                            ]
+                        },
+                        "user_settings": {
+                            "type": "object",
+                            "properties": {
+                                "daily_quota": {
+                                    "type": "integer",
+                                    "example": 1000
+                                },
+                                "hourly_quota": {
+                                    "type": "integer",
+                                    "example": 100
+                                },
+                                "messages_per_second": {
+                                    "type": "integer",
+                                    "example": 10
+                                },
+                                "recipients_per_minute": {
+                                    "type": "integer",
+                                    "example": 600
+                                }
+                            }
+                        },
+                        "user_usage": {
+                            "$ref": "#/definitions/services.QuotaUsage"
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.RecentMailLogsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "days": {
+                            "type": "integer",
+                            "example": 30
+                        },
+                        "mail_logs": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.MailLog"
+                            }
+                        },
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 50
+                        },
+                        "pages": {
+                            "type": "integer",
+                            "example": 2
+                        },
+                        "statistics": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "integer"
+                            }
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 100
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.RegisterRequest": {
+            "type": "object",
+            "required": [
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8,
+                    "example": "password123"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3,
+                    "example": "testuser"
+                }
+            }
+        },
+        "api.RegisterResponse": {
+            "type": "object",
+            "properties": {
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "token": {
+                    "type": "string",
+                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                },
+                "user": {
+                    "$ref": "#/definitions/api.UserInfo"
+                }
+            }
+        },
+        "api.RelayInfoData": {
+            "description": "SMTP中继域名和IP",
+            "type": "object",
+            "properties": {
+                "relayDomain": {
+                    "type": "string",
+                    "example": "mail.ict.run"
+                },
+                "relayIP": {
+                    "type": "string",
+                    "example": "110.151.22.51"
+                }
+            }
+        },
+        "api.RelayInfoResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/api.RelayInfoData"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.ResetPasswordResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "密码重置成功"
+                },
+                "password": {
+                    "type": "string",
+                    "example": "new_generated_password"
+                },
+                "password_id": {
+                    "type": "string",
+                    "example": "9f86d081"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.RotateCredentialPasswordRequest": {
+            "type": "object",
+            "properties": {
+                "grace_period_hours": {
+                    "description": "旧密码继续有效的小时数，默认24",
+                    "type": "integer",
+                    "maximum": 720,
+                    "minimum": 1,
+                    "example": 24
+                }
+            }
+        },
+        "api.RotatePasswordResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "credential": {
+                            "$ref": "#/definitions/models.SMTPCredential"
+                        },
+                        "password": {
+                            "type": "string",
+                            "example": "new_generated_password"
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SMIMECertificateListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/api.SMIMECertificateView"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "warnings": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "api.SMIMECertificateResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/api.SMIMECertificateView"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SMIMECertificateView": {
+            "type": "object",
+            "properties": {
+                "certificate": {
+                    "description": "PEM格式证书链",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "days_until_expiry": {
+                    "type": "integer",
+                    "example": 180
+                },
+                "email_addresses": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "expiry_status": {
+                    "description": "valid, expiring, expired",
+                    "type": "string",
+                    "example": "valid"
+                },
+                "fingerprint": {
+                    "description": "SHA-256指纹",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "issuer": {
+                    "type": "string"
+                },
+                "not_after": {
+                    "type": "string"
+                },
+                "not_before": {
+                    "type": "string"
+                },
+                "sender": {
+                    "description": "发件地址（user@example.com）或域名（example.com）",
+                    "type": "string"
+                },
+                "serial_number": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "active, disabled",
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "api.SMTPConfigListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SMTPConfig"
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SMTPConfigRequest": {
+            "type": "object",
+            "required": [
+                "host",
+                "name",
+                "port"
+            ],
+            "properties": {
+                "active": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "host": {
+                    "type": "string",
+                    "example": "smtp.example.com"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "primary"
+                },
+                "password": {
+                    "description": "更新时不传表示保留原密码",
+                    "type": "string",
+                    "example": "secret"
+                },
+                "port": {
+                    "type": "integer",
+                    "example": 587
+                },
+                "priority": {
+                    "description": "数值越小越优先",
+                    "type": "integer",
+                    "example": 1
+                },
+                "tls": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "username": {
+                    "type": "string",
+                    "example": "relay@example.com"
+                }
+            }
+        },
+        "api.SMTPConfigResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SMTPConfig"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SandboxMessageDetailResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.SandboxMessageDetail"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SandboxMessageListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "messages": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SandboxMessage"
+                            }
+                        },
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 20
+                        },
+                        "pages": {
+                            "type": "integer",
+                            "example": 5
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 100
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SecurityEventListResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "events": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SecurityEvent"
+                            }
+                        },
+                        "page": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "page_size": {
+                            "type": "integer",
+                            "example": 20
+                        },
+                        "pages": {
+                            "type": "integer",
+                            "example": 1
+                        },
+                        "total": {
+                            "type": "integer",
+                            "example": 2
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SetCredentialExpirationRequest": {
+            "type": "object",
+            "properties": {
+                "expires_at": {
+                    "description": "为空表示永不过期",
+                    "type": "string",
+                    "example": "2027-01-01T00:00:00Z"
+                }
+            }
+        },
+        "api.SetDKIMRotationPolicyRequest": {
+            "type": "object",
+            "required": [
+                "interval_days"
+            ],
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "grace_period_days": {
+                    "description": "旧密钥宽限期（1-90天，默认30天且不超过轮换周期的一半）",
+                    "type": "integer",
+                    "example": 30
+                },
+                "interval_days": {
+                    "description": "轮换周期（7-730天）",
+                    "type": "integer",
+                    "example": 90
+                }
+            }
+        },
+        "api.SetUserRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "description": "user, admin, read_only",
+                    "type": "string",
+                    "example": "read_only"
+                }
+            }
+        },
+        "api.StatsResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "credential_count": {
+                            "type": "integer",
+                            "example": 3
+                        },
+                        "credential_stats": {
+                            "type": "array",
+                            "items": {
+                                "type": "object",
+                                "additionalProperties": true
+                            }
+                        },
+                        "mail_stats": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.SuspendUserRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "example": "账单逾期"
+                }
+            }
+        },
+        "api.UpdateCredentialRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
                 "description": {
                     "type": "string",
-                    "maxLength": 200,
-                    "example": "用于发送营销邮件的SMTP凭据"
+                    "maxLength": 200,
+                    "example": "更新后的SMTP凭据描述"
+                },
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "sandbox"
+                    ],
+                    "example": "sandbox"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 1,
+                    "example": "Updated SMTP Credential"
+                },
+                "settings": {
+                    "$ref": "#/definitions/models.SMTPCredentialSettings"
+                }
+            }
+        },
+        "api.UpdateDomainRequest": {
+            "type": "object",
+            "properties": {
+                "bounce_domain": {
+                    "description": "为空时清除退信域名",
+                    "type": "string",
+                    "example": "bounces.example.com"
+                }
+            }
+        },
+        "api.UpdateUserInfoRequest": {
+            "type": "object",
+            "properties": {
+                "settings": {
+                    "$ref": "#/definitions/api.UserPreferencesRequest"
+                },
+                "username": {
+                    "type": "string",
+                    "maxLength": 50,
+                    "minLength": 3,
+                    "example": "newusername"
+                }
+            }
+        },
+        "api.UploadSMIMECertificateRequest": {
+            "type": "object",
+            "required": [
+                "certificate",
+                "private_key",
+                "sender"
+            ],
+            "properties": {
+                "certificate": {
+                    "description": "PEM格式证书（可包含中间证书）",
+                    "type": "string"
+                },
+                "private_key": {
+                    "description": "PEM格式私钥",
+                    "type": "string"
+                },
+                "sender": {
+                    "description": "发件地址或域名",
+                    "type": "string",
+                    "example": "billing@example.com"
+                }
+            }
+        },
+        "api.UsageInvoiceResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "$ref": "#/definitions/models.UsageInvoice"
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.UsageResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "object",
+                    "properties": {
+                        "from": {
+                            "type": "string",
+                            "example": "2026-09-01"
+                        },
+                        "records": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.UsageRecord"
+                            }
+                        },
+                        "to": {
+                            "type": "string",
+                            "example": "2026-09-30"
+                        },
+                        "totals": {
+                            "$ref": "#/definitions/models.UsageCounts"
+                        }
+                    }
+                },
+                "success": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "api.UserInfo": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "testuser"
+                }
+            }
+        },
+        "api.UserInfoResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "example": "2023-01-01T00:00:00Z"
+                },
+                "email": {
+                    "type": "string",
+                    "example": "user@example.com"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439011"
+                },
+                "plan_id": {
+                    "type": "string",
+                    "example": "507f1f77bcf86cd799439012"
+                },
+                "role": {
+                    "type": "string",
+                    "example": "admin"
+                },
+                "settings": {
+                    "$ref": "#/definitions/models.UserSettings"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "active"
+                },
+                "username": {
+                    "type": "string",
+                    "example": "testuser"
+                }
+            }
+        },
+        "api.UserPreferencesRequest": {
+            "type": "object",
+            "properties": {
+                "allowed_domains": {
+                    "description": "新建凭据默认允许的发件域名",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "timezone": {
+                    "description": "IANA时区，空字符串表示使用套餐时区",
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                }
+            }
+        },
+        "models.ARCVerification": {
+            "type": "object",
+            "properties": {
+                "auth_results": {
+                    "description": "各实例的ARC-Authentication-Results（按实例升序）",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "instances": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "result": {
+                    "description": "none, pass, fail",
+                    "type": "string"
+                }
+            }
+        },
+        "models.AnomalyDeviation": {
+            "type": "object",
+            "properties": {
+                "baseline": {
+                    "description": "基线均值",
+                    "type": "number"
+                },
+                "metric": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "description": "触发阈值",
+                    "type": "number"
+                },
+                "value": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.BaselineMetric": {
+            "type": "object",
+            "properties": {
+                "mean": {
+                    "type": "number"
+                },
+                "std_dev": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CredentialActivity": {
+            "type": "object",
+            "properties": {
+                "failed": {
+                    "type": "integer"
+                },
+                "failure_rate": {
+                    "description": "failed / (sent + failed)",
+                    "type": "number"
+                },
+                "recipient_domains": {
+                    "type": "integer"
+                },
+                "sent": {
+                    "type": "integer"
+                },
+                "source_ips": {
+                    "type": "integer"
+                },
+                "volume": {
+                    "type": "integer"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CredentialAnomaly": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "alerted, suspended",
+                    "type": "string"
+                },
+                "activity": {
+                    "$ref": "#/definitions/models.CredentialActivity"
+                },
+                "credential_id": {
+                    "type": "string"
+                },
+                "credential_name": {
+                    "type": "string"
+                },
+                "detected_at": {
+                    "type": "string"
+                },
+                "deviations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AnomalyDeviation"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CredentialBaseline": {
+            "type": "object",
+            "properties": {
+                "credential_id": {
+                    "type": "string"
+                },
+                "failure_rate": {
+                    "$ref": "#/definitions/models.BaselineMetric"
+                },
+                "hourly_volume": {
+                    "$ref": "#/definitions/models.BaselineMetric"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_anomaly_at": {
+                    "description": "最近一次检测到异常的时间",
+                    "type": "string"
+                },
+                "last_hour": {
+                    "description": "最近一次学习的整点小时",
+                    "type": "string"
+                },
+                "recipient_domains": {
+                    "$ref": "#/definitions/models.BaselineMetric"
+                },
+                "sample_hours": {
+                    "description": "已学习的小时数",
+                    "type": "integer"
+                },
+                "source_ips": {
+                    "$ref": "#/definitions/models.BaselineMetric"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CredentialClient": {
+            "type": "object",
+            "properties": {
+                "auth_mechanism": {
+                    "type": "string",
+                    "example": "PLAIN"
+                },
+                "count": {
+                    "description": "认证成功次数",
+                    "type": "integer"
+                },
+                "credential_id": {
+                    "type": "string"
+                },
+                "ehlo": {
+                    "description": "客户端EHLO/HELO名称",
+                    "type": "string"
+                },
+                "first_seen": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_seen": {
+                    "type": "string"
+                },
+                "tls_version": {
+                    "description": "未使用TLS时为none",
+                    "type": "string",
+                    "example": "TLS 1.3"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DKIMConfig": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "是否激活（未激活时使用默认签名设置）",
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "domain": {
+                    "description": "配置的域名（发件地址域名）",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key_pair_id": {
+                    "description": "关联的密钥对ID（与Settings.DefaultKeyID一致）",
+                    "type": "string"
+                },
+                "settings": {
+                    "description": "DKIM设置",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.DKIMSettings"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DKIMKeyExport": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string"
                 },
-                "name": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 1,
-                    "example": "My SMTP Credential"
+                "dns_record": {
+                    "$ref": "#/definitions/models.DNSRecord"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "format": {
+                    "description": "私钥格式：pkcs8, pkcs1",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key_size": {
+                    "type": "integer"
+                },
+                "private_key": {
+                    "description": "PEM格式私钥，仅完整导出时返回",
+                    "type": "string"
+                },
+                "public_key": {
+                    "description": "PEM格式公钥（SubjectPublicKeyInfo）",
+                    "type": "string"
+                },
+                "selector": {
+                    "type": "string"
                 }
             }
         },
-        "api.CreateCredentialResponse": {
+        "models.DKIMKeyPair": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "object",
-                    "properties": {
-                        "credential": {
-                            "$ref": "#/definitions/models.SMTPCredential"
-                        },
-                        "password": {
-                            "type": "string",
-                            "example": "generated_password"
-                        }
+                "algorithm": {
+                    "description": "签名算法（rsa-sha256, ed25519-sha256）",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "dns_record": {
+                    "description": "生成的DNS TXT记录",
+                    "type": "string"
+                },
+                "dns_verified": {
+                    "description": "DNS记录是否已验证",
+                    "type": "boolean"
+                },
+                "domain": {
+                    "description": "签名域名",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "密钥过期时间",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key_size": {
+                    "description": "密钥长度（RSA为1024/2048/4096，Ed25519为256）",
+                    "type": "integer"
+                },
+                "last_verified": {
+                    "description": "最后验证时间",
+                    "type": "string"
+                },
+                "public_key": {
+                    "description": "公钥",
+                    "type": "string"
+                },
+                "rotated_from_id": {
+                    "description": "轮换前的密钥ID（新密钥DNS生效后替换该密钥）",
+                    "type": "string"
+                },
+                "selector": {
+                    "description": "DKIM选择器",
+                    "type": "string"
+                },
+                "source": {
+                    "description": "generated, imported",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, active, expiring, expired",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DKIMRotationPolicy": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "grace_period_days": {
+                    "description": "旧密钥在新密钥生效后保留的天数",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "interval_days": {
+                    "description": "轮换周期（天）",
+                    "type": "integer"
+                },
+                "last_rotated_at": {
+                    "description": "最近一次自动轮换时间",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DKIMSelectorHealth": {
+            "type": "object",
+            "properties": {
+                "key_pair_id": {
+                    "type": "string"
+                },
+                "message": {
+                    "description": "检查结论",
+                    "type": "string"
+                },
+                "record": {
+                    "description": "DNS中发布的记录",
+                    "type": "string"
+                },
+                "selector": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pass, warn, fail",
+                    "type": "string"
+                },
+                "suggestions": {
+                    "description": "修复建议",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.DKIMSettings": {
+            "type": "object",
+            "properties": {
+                "body_canon": {
+                    "description": "正文规范化（relaxed/simple）",
+                    "type": "string"
+                },
+                "default_key_id": {
+                    "description": "默认密钥ID",
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "是否启用DKIM",
+                    "type": "boolean"
+                },
+                "header_canon": {
+                    "description": "头部规范化（relaxed/simple）",
+                    "type": "string"
+                },
+                "oversign_from": {
+                    "description": "是否对From头部过度签名（防止追加第二个From）",
+                    "type": "boolean"
+                },
+                "sign_headers": {
+                    "description": "要签名的头部列表",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "signing_domains": {
+                    "description": "允许签名的域名列表",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.DKIMSignatureVerification": {
+            "type": "object",
+            "properties": {
+                "algorithm": {
+                    "type": "string"
+                },
+                "aligned": {
+                    "description": "与From域名按DMARC adkim模式对齐",
+                    "type": "boolean"
+                },
+                "body_length": {
+                    "description": "l=，未设置时省略",
+                    "type": "integer"
+                },
+                "canonicalization": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "expiration": {
+                    "type": "string"
+                },
+                "identity": {
+                    "type": "string"
+                },
+                "key_bits": {
+                    "type": "integer"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "result": {
+                    "description": "pass, fail, temperror, permerror",
+                    "type": "string"
+                },
+                "selector": {
+                    "type": "string"
+                },
+                "signed_headers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "timestamp": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DKIMValidationResult": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "dns_found": {
+                    "type": "boolean"
+                },
+                "dns_record": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "expected_dns": {
+                    "type": "string"
+                },
+                "selector": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.DMARCHealth": {
+            "type": "object",
+            "properties": {
+                "adkim": {
+                    "type": "string"
+                },
+                "aspf": {
+                    "type": "string"
+                },
+                "dkim_aligned": {
+                    "type": "boolean"
+                },
+                "message": {
+                    "description": "检查结论",
+                    "type": "string"
+                },
+                "pct": {
+                    "type": "integer"
+                },
+                "policy": {
+                    "type": "string"
+                },
+                "record": {
+                    "type": "string"
+                },
+                "record_domain": {
+                    "description": "记录所在域名（可能是组织域名）",
+                    "type": "string"
+                },
+                "rua": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "spf_aligned": {
+                    "type": "boolean"
+                },
+                "status": {
+                    "description": "pass, warn, fail",
+                    "type": "string"
+                },
+                "subdomain_policy": {
+                    "type": "string"
+                },
+                "suggestions": {
+                    "description": "修复建议",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "api.CreateDKIMKeyRequest": {
+        "models.DMARCVerification": {
             "type": "object",
-            "required": [
-                "domain",
-                "selector"
-            ],
             "properties": {
-                "domain": {
-                    "type": "string",
-                    "example": "example.com"
+                "adkim": {
+                    "type": "string"
                 },
-                "key_size": {
-                    "type": "integer",
-                    "example": 2048
+                "aspf": {
+                    "type": "string"
                 },
-                "selector": {
-                    "type": "string",
-                    "example": "default"
+                "dkim_aligned": {
+                    "type": "boolean"
+                },
+                "from_domain": {
+                    "type": "string"
+                },
+                "pct": {
+                    "type": "integer"
+                },
+                "policy": {
+                    "description": "适用的策略（子域名使用sp=）",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "record": {
+                    "type": "string"
+                },
+                "record_domain": {
+                    "description": "记录所在域名（可能是组织域名）",
+                    "type": "string"
+                },
+                "result": {
+                    "description": "pass, fail, none, temperror, permerror",
+                    "type": "string"
+                },
+                "spf_aligned": {
+                    "type": "boolean"
                 }
             }
         },
-        "api.CredentialListResponse": {
+        "models.DNSRecord": {
             "type": "object",
             "properties": {
-                "data": {
+                "name": {
+                    "description": "记录名称，如 selector._domainkey.example.com",
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "优先级（对TXT记录通常为0）",
+                    "type": "integer"
+                },
+                "strings": {
+                    "description": "按255字节拆分后的TXT字符串（超长记录需分段发布）",
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.SMTPCredential"
+                        "type": "string"
                     }
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
-                }
-            }
-        },
-        "api.CredentialResponse": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "$ref": "#/definitions/models.SMTPCredential"
+                "ttl": {
+                    "description": "TTL值",
+                    "type": "integer"
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "type": {
+                    "description": "TXT",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "记录值",
+                    "type": "string"
                 }
             }
         },
-        "api.DKIMKeyPairListResponse": {
+        "models.DomainHealthReport": {
             "type": "object",
             "properties": {
-                "data": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "dkim": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.DKIMKeyPair"
+                        "$ref": "#/definitions/models.DKIMSelectorHealth"
                     }
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "dmarc": {
+                    "$ref": "#/definitions/models.DMARCHealth"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "mx": {
+                    "$ref": "#/definitions/models.MXHealth"
+                },
+                "relay_ips": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "reverse_dns": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ReverseDNSHealth"
+                    }
+                },
+                "spf": {
+                    "$ref": "#/definitions/models.SPFHealth"
+                },
+                "status": {
+                    "description": "所有检查项中最差的状态",
+                    "type": "string"
                 }
             }
         },
-        "api.DKIMKeyPairResponse": {
+        "models.MXHealth": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/models.DKIMKeyPair"
+                "hosts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "message": {
+                    "description": "检查结论",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pass, warn, fail",
+                    "type": "string"
+                },
+                "suggestions": {
+                    "description": "修复建议",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "api.DKIMValidationResponse": {
+        "models.MailLog": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/models.DKIMValidationResult"
+                "archive": {
+                    "description": "原始邮件归档信息",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MessageArchive"
+                        }
+                    ]
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "attempts": {
+                    "type": "integer"
+                },
+                "campaign_id": {
+                    "description": "X-Relay-Campaign活动ID",
+                    "type": "string"
+                },
+                "client_ip": {
+                    "description": "提交邮件的客户端IP",
+                    "type": "string"
+                },
+                "client_message_id": {
+                    "description": "客户端提供的Message-ID",
+                    "type": "string"
+                },
+                "completed_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "credential_id": {
+                    "type": "string"
+                },
+                "duplicate_count": {
+                    "description": "幂等窗口内的重复提交次数",
+                    "type": "integer"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "X-Relay-Expires过期时间，过期未投递的邮件不再发送",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_attempt": {
+                    "type": "string"
+                },
+                "last_duplicate_at": {
+                    "description": "最近一次重复提交时间",
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "X-Relay-Metadata自定义元数据",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "password_id": {
+                    "description": "提交时认证使用的凭据密码标识",
+                    "type": "string"
+                },
+                "priority": {
+                    "description": "X-Relay-Priority优先级提示: high, normal, low",
+                    "type": "string"
+                },
+                "relay_ip": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "queued, sending, sent, failed, captured, expired",
+                    "type": "string"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "X-Relay-Tag标签",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "api.DNSRecordResponse": {
+        "models.MessageArchive": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/models.DNSRecord"
+                "archived_at": {
+                    "description": "归档时间",
+                    "type": "string"
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "backend": {
+                    "description": "gridfs, filesystem, s3",
+                    "type": "string"
+                },
+                "compressed_size": {
+                    "description": "压缩后大小",
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "description": "过期时间（为空表示永久保留）",
+                    "type": "string"
+                },
+                "size": {
+                    "description": "原始大小",
+                    "type": "integer"
                 }
             }
         },
-        "api.LoginRequest": {
+        "models.MessageVerification": {
             "type": "object",
-            "required": [
-                "password",
-                "username"
-            ],
             "properties": {
-                "password": {
-                    "type": "string",
-                    "example": "password123"
+                "arc": {
+                    "$ref": "#/definitions/models.ARCVerification"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "user@example.com"
+                "dkim": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DKIMSignatureVerification"
+                    }
+                },
+                "dmarc": {
+                    "$ref": "#/definitions/models.DMARCVerification"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "message_id": {
+                    "type": "string"
+                },
+                "spf": {
+                    "description": "未提供客户端IP时省略",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SPFVerification"
+                        }
+                    ]
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "verified_at": {
+                    "type": "string"
                 }
             }
         },
-        "api.LoginResponse": {
+        "models.Notification": {
             "type": "object",
             "properties": {
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "created_at": {
+                    "type": "string"
                 },
-                "token": {
-                    "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                "data": {
+                    "description": "附加数据（如需要发布的DNS记录）",
+                    "type": "object",
+                    "additionalProperties": true
                 },
-                "user": {
-                    "$ref": "#/definitions/api.UserInfo"
+                "id": {
+                    "type": "string"
+                },
+                "level": {
+                    "description": "info, warning, critical",
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "read": {
+                    "description": "是否已读",
+                    "type": "boolean"
+                },
+                "read_at": {
+                    "description": "已读时间",
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "type": {
+                    "description": "通知类型，如 dkim_dns_pending",
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "api.MailLogListResponse": {
+        "models.NotificationPreferences": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "object",
-                    "properties": {
-                        "mail_logs": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.MailLog"
-                            }
-                        },
-                        "page": {
-                            "type": "integer",
-                            "example": 1
-                        },
-                        "page_size": {
-                            "type": "integer",
-                            "example": 20
-                        },
-                        "pages": {
-                            "type": "integer",
-                            "example": 5
-                        },
-                        "total": {
-                            "type": "integer",
-                            "example": 100
-                        }
-                    }
+                "email": {
+                    "description": "接收通知的邮箱，为空时使用账户邮箱",
+                    "type": "string",
+                    "example": "ops@example.com"
                 },
-                "success": {
+                "email_enabled": {
+                    "description": "是否通过中继发送邮件通知",
                     "type": "boolean",
                     "example": true
+                },
+                "has_webhook_secret": {
+                    "description": "是否已设置Webhook签名密钥",
+                    "type": "boolean"
+                },
+                "quota_thresholds": {
+                    "description": "配额用量提醒阈值（百分比，1-100），为空表示不发送配额提醒",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        80,
+                        95,
+                        100
+                    ]
+                },
+                "webhook_url": {
+                    "description": "接收通知的Webhook地址",
+                    "type": "string",
+                    "example": "https://example.com/hooks/relay"
                 }
             }
         },
-        "api.MailLogResponse": {
+        "models.Plan": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/models.MailLog"
+                "created_at": {
+                    "type": "string"
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "daily_limit": {
+                    "description": "每日收件人数（所有凭据合计）",
+                    "type": "integer",
+                    "example": 10000
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "is_default": {
+                    "description": "未分配套餐的用户使用默认套餐",
+                    "type": "boolean"
+                },
+                "max_credentials": {
+                    "description": "最多SMTP凭据数",
+                    "type": "integer",
+                    "example": 10
+                },
+                "max_domains": {
+                    "description": "最多发件域名数",
+                    "type": "integer",
+                    "example": 5
+                },
+                "max_message_size": {
+                    "description": "单封邮件最大字节数",
+                    "type": "integer",
+                    "example": 0
+                },
+                "messages_per_second": {
+                    "description": "每秒邮件数上限（所有凭据合计，0表示不限制），用户和凭据只能设置更低的值",
+                    "type": "integer",
+                    "example": 50
+                },
+                "monthly_limit": {
+                    "description": "每月收件人数（所有凭据合计）",
+                    "type": "integer",
+                    "example": 200000
+                },
+                "name": {
+                    "type": "string",
+                    "example": "basic"
+                },
+                "recipients_per_minute": {
+                    "description": "每分钟收件人数上限（所有凭据合计，0表示不限制），用户和凭据只能设置更低的值",
+                    "type": "integer",
+                    "example": 3000
+                },
+                "timezone": {
+                    "description": "用户未设置时区时使用的IANA时区，默认UTC",
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                },
+                "updated_at": {
+                    "type": "string"
                 }
             }
         },
-        "api.QuotaStatsResponse": {
+        "models.ReverseDNSHealth": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "object",
-                    "properties": {
-                        "credential_quotas": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
-                        },
-                        "user_settings": {
-                            "type": "object",
-                            "properties": {
-                                "daily_quota": {
-                                    "type": "integer",
-                                    "example": 1000
-                                },
-                                "hourly_quota": {
-                                    "type": "integer",
-                                    "example": 100
-                                }
-                            }
-                        }
+                "confirmed": {
+                    "description": "PTR主机名能正向解析回该IP",
+                    "type": "boolean"
+                },
+                "hostnames": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "ip": {
+                    "type": "string"
+                },
+                "message": {
+                    "description": "检查结论",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pass, warn, fail",
+                    "type": "string"
+                },
+                "suggestions": {
+                    "description": "修复建议",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "api.RecentMailLogsResponse": {
+        "models.SMTPConfig": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "object",
-                    "properties": {
-                        "days": {
-                            "type": "integer",
-                            "example": 30
-                        },
-                        "mail_logs": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.MailLog"
-                            }
-                        },
-                        "page": {
-                            "type": "integer",
-                            "example": 1
-                        },
-                        "page_size": {
-                            "type": "integer",
-                            "example": 50
-                        },
-                        "pages": {
-                            "type": "integer",
-                            "example": 2
-                        },
-                        "statistics": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "integer"
-                            }
-                        },
-                        "total": {
-                            "type": "integer",
-                            "example": 100
-                        }
-                    }
+                "active": {
+                    "type": "boolean"
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "host": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "tls": {
+                    "type": "boolean"
+                },
+                "username": {
+                    "type": "string"
                 }
             }
         },
-        "api.RegisterRequest": {
+        "models.SMTPCredential": {
             "type": "object",
-            "required": [
-                "email",
-                "password",
-                "username"
-            ],
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "user@example.com"
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "description": "描述信息",
+                    "type": "string"
+                },
+                "disabled_at": {
+                    "type": "string"
+                },
+                "disabled_reason": {
+                    "description": "expired",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "凭据过期时间，过期后自动禁用",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used": {
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "normal, sandbox（沙箱模式只捕获不投递）",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "凭据名称，如\"mailcow-server1\"",
+                    "type": "string"
+                },
+                "password_created_at": {
+                    "description": "当前密码的生成时间",
+                    "type": "string"
+                },
+                "password_id": {
+                    "description": "当前密码的标识（不含密码本身）",
+                    "type": "string"
+                },
+                "password_last_used": {
+                    "description": "当前密码最近一次认证时间",
+                    "type": "string"
+                },
+                "previous_password": {
+                    "description": "轮换后在宽限期内仍然有效的旧密码",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SMTPCredentialPassword"
+                        }
+                    ]
+                },
+                "settings": {
+                    "$ref": "#/definitions/models.SMTPCredentialSettings"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 8,
-                    "example": "password123"
+                "status": {
+                    "description": "active, disabled",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "usage_count": {
+                    "description": "使用次数",
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
                 },
                 "username": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 3,
-                    "example": "testuser"
+                    "description": "SMTP用户名",
+                    "type": "string"
                 }
             }
         },
-        "api.RegisterResponse": {
+        "models.SMTPCredentialPassword": {
             "type": "object",
             "properties": {
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "created_at": {
+                    "type": "string"
                 },
-                "token": {
-                    "type": "string",
-                    "example": "eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."
+                "expires_at": {
+                    "description": "宽限期结束时间",
+                    "type": "string"
                 },
-                "user": {
-                    "$ref": "#/definitions/api.UserInfo"
-                }
-            }
-        },
-        "api.RelayInfoData": {
-            "description": "SMTP中继域名和IP",
-            "type": "object",
-            "properties": {
-                "relayDomain": {
-                    "type": "string",
-                    "example": "mail.ict.run"
+                "id": {
+                    "description": "密码标识",
+                    "type": "string"
                 },
-                "relayIP": {
-                    "type": "string",
-                    "example": "110.151.22.51"
+                "last_used": {
+                    "type": "string"
                 }
             }
         },
-        "api.RelayInfoResponse": {
+        "models.SMTPCredentialSettings": {
             "type": "object",
             "properties": {
-                "data": {
-                    "$ref": "#/definitions/api.RelayInfoData"
+                "allowed_domains": {
+                    "description": "允许发送的域名",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
+                "allowed_ports": {
+                    "description": "允许连接的监听端口，为空表示不限制",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "allowed_senders": {
+                    "description": "允许的发件地址，支持本地部分通配符，如 noreply@example.com、*@example.com、alert-*@example.com",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "anomaly_action": {
+                    "description": "发信行为异常时的处理方式: suspend（默认）, alert, off",
+                    "type": "string"
+                },
+                "daily_quota": {
+                    "description": "该凭据的日配额",
+                    "type": "integer"
+                },
+                "dedup_enabled": {
+                    "description": "是否启用重复提交检测",
+                    "type": "boolean"
+                },
+                "dedup_window_minutes": {
+                    "description": "去重时间窗口（分钟，默认10）",
+                    "type": "integer"
+                },
+                "hourly_quota": {
+                    "description": "该凭据的小时配额",
+                    "type": "integer"
+                },
+                "max_message_size": {
+                    "description": "单封邮件最大字节数（0表示使用服务器限制）",
+                    "type": "integer"
+                },
+                "max_recipients": {
+                    "description": "单封邮件最大收件人数",
+                    "type": "integer"
+                },
+                "messages_per_second": {
+                    "description": "每秒最多发送的邮件数（0表示不限制）",
+                    "type": "integer"
+                },
+                "recipients_per_minute": {
+                    "description": "每分钟最多发送的收件人数（0表示不限制）",
+                    "type": "integer"
+                },
+                "require_tls": {
+                    "description": "是否要求TLS连接",
+                    "type": "boolean"
+                },
+                "sandbox_retention_hours": {
+                    "description": "沙箱邮件保留小时数（0表示不过期）",
+                    "type": "integer"
+                },
+                "sending_hours": {
+                    "description": "允许发送的时段，为空表示不限制",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SendingWindow"
+                        }
+                    ]
                 }
             }
         },
-        "api.ResetPasswordResponse": {
+        "models.SPFHealth": {
             "type": "object",
             "properties": {
+                "lookups": {
+                    "description": "最坏情况下的DNS查询次数",
+                    "type": "integer"
+                },
                 "message": {
-                    "type": "string",
-                    "example": "密码重置成功"
+                    "description": "检查结论",
+                    "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "example": "new_generated_password"
+                "record": {
+                    "type": "string"
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
-                }
-            }
-        },
-        "api.StatsResponse": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "type": "object",
-                    "properties": {
-                        "credential_count": {
-                            "type": "integer",
-                            "example": 3
-                        },
-                        "credential_stats": {
-                            "type": "array",
-                            "items": {
-                                "type": "object",
-                                "additionalProperties": true
-                            }
-                        },
-                        "mail_stats": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+                "relay_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SPFRelayResult"
                     }
                 },
-                "success": {
-                    "type": "boolean",
-                    "example": true
-                }
-            }
-        },
-        "api.UpdateCredentialRequest": {
-            "type": "object",
-            "required": [
-                "name"
-            ],
-            "properties": {
-                "description": {
-                    "type": "string",
-                    "maxLength": 200,
-                    "example": "更新后的SMTP凭据描述"
-                },
-                "name": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 1,
-                    "example": "Updated SMTP Credential"
+                "status": {
+                    "description": "pass, warn, fail",
+                    "type": "string"
                 },
-                "settings": {
-                    "$ref": "#/definitions/models.SMTPCredentialSettings"
+                "suggestions": {
+                    "description": "修复建议",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "api.UpdateUserInfoRequest": {
+        "models.SPFRelayResult": {
             "type": "object",
             "properties": {
-                "settings": {
-                    "$ref": "#/definitions/models.UserSettings"
+                "ip": {
+                    "type": "string"
                 },
-                "username": {
-                    "type": "string",
-                    "maxLength": 50,
-                    "minLength": 3,
-                    "example": "newusername"
+                "result": {
+                    "description": "pass, fail, softfail, neutral, none, temperror, permerror",
+                    "type": "string"
                 }
             }
         },
-        "api.UserInfo": {
+        "models.SPFVerification": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string",
-                    "example": "user@example.com"
+                "aligned": {
+                    "description": "与From域名按DMARC aspf模式对齐",
+                    "type": "boolean"
                 },
-                "id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
+                "client_ip": {
+                    "type": "string"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "testuser"
+                "domain": {
+                    "type": "string"
+                },
+                "helo": {
+                    "type": "string"
+                },
+                "lookups": {
+                    "type": "integer"
+                },
+                "mechanism": {
+                    "description": "命中的机制",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "record": {
+                    "type": "string"
+                },
+                "result": {
+                    "description": "pass, fail, softfail, neutral, none, temperror, permerror",
+                    "type": "string"
+                },
+                "sender": {
+                    "description": "检查使用的MAIL FROM地址",
+                    "type": "string"
                 }
             }
         },
-        "api.UserInfoResponse": {
+        "models.SandboxMessage": {
             "type": "object",
             "properties": {
                 "created_at": {
-                    "type": "string",
-                    "example": "2023-01-01T00:00:00Z"
+                    "type": "string"
                 },
-                "email": {
-                    "type": "string",
-                    "example": "user@example.com"
+                "credential_id": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "自动过期时间（TTL索引）",
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
                 },
                 "id": {
-                    "type": "string",
-                    "example": "507f1f77bcf86cd799439011"
+                    "type": "string"
                 },
-                "settings": {
-                    "$ref": "#/definitions/models.UserSettings"
+                "mail_log_id": {
+                    "type": "string"
                 },
-                "status": {
-                    "type": "string",
-                    "example": "active"
+                "message_id": {
+                    "type": "string"
                 },
-                "username": {
-                    "type": "string",
-                    "example": "testuser"
+                "size": {
+                    "type": "integer"
+                },
+                "subject": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "models.DKIMKeyPair": {
+        "models.SandboxMessageDetail": {
             "type": "object",
             "properties": {
-                "algorithm": {
-                    "description": "签名算法（rsa-sha256）",
+                "created_at": {
                     "type": "string"
                 },
-                "created_at": {
+                "credential_id": {
                     "type": "string"
                 },
-                "dns_record": {
-                    "description": "生成的DNS TXT记录",
+                "expires_at": {
+                    "description": "自动过期时间（TTL索引）",
                     "type": "string"
                 },
-                "dns_verified": {
-                    "description": "DNS记录是否已验证",
-                    "type": "boolean"
+                "from": {
+                    "type": "string"
                 },
-                "domain": {
-                    "description": "签名域名",
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "id": {
                     "type": "string"
                 },
-                "expires_at": {
-                    "description": "密钥过期时间",
+                "mail_log_id": {
                     "type": "string"
                 },
-                "id": {
+                "message_id": {
                     "type": "string"
                 },
-                "key_size": {
-                    "description": "密钥长度（1024, 2048）",
+                "parts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SandboxMessagePart"
+                    }
+                },
+                "size": {
                     "type": "integer"
                 },
-                "last_verified": {
-                    "description": "最后验证时间",
+                "subject": {
                     "type": "string"
                 },
-                "public_key": {
-                    "description": "公钥",
+                "to": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SandboxMessagePart": {
+            "type": "object",
+            "properties": {
+                "charset": {
                     "type": "string"
                 },
-                "selector": {
-                    "description": "DKIM选择器",
+                "content": {
+                    "description": "仅文本部件返回解码后的内容",
                     "type": "string"
                 },
-                "status": {
-                    "description": "active, inactive, expired",
+                "content_type": {
                     "type": "string"
                 },
-                "updated_at": {
+                "disposition": {
                     "type": "string"
                 },
-                "user_id": {
+                "filename": {
                     "type": "string"
+                },
+                "index": {
+                    "type": "integer"
+                },
+                "size": {
+                    "type": "integer"
                 }
             }
         },
-        "models.DKIMValidationResult": {
+        "models.SecurityEvent": {
             "type": "object",
             "properties": {
-                "checked_at": {
+                "created_at": {
                     "type": "string"
                 },
-                "dns_found": {
-                    "type": "boolean"
+                "credential_id": {
+                    "type": "string"
                 },
-                "dns_record": {
+                "details": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "id": {
                     "type": "string"
                 },
-                "domain": {
+                "ip": {
                     "type": "string"
                 },
-                "error_message": {
+                "message": {
                     "type": "string"
                 },
-                "expected_dns": {
+                "type": {
                     "type": "string"
                 },
-                "selector": {
+                "user_id": {
                     "type": "string"
+                }
+            }
+        },
+        "models.SendingWindow": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "description": "结束时间 HH:MM，早于开始时间表示跨越午夜",
+                    "type": "string",
+                    "example": "18:00"
                 },
-                "valid": {
-                    "type": "boolean"
+                "start": {
+                    "description": "开始时间 HH:MM",
+                    "type": "string",
+                    "example": "09:00"
+                },
+                "timezone": {
+                    "description": "IANA时区，默认UTC",
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                },
+                "weekdays": {
+                    "description": "允许的星期（0为周日），为空表示每天",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        1,
+                        2,
+                        3,
+                        4,
+                        5
+                    ]
                 }
             }
         },
-        "models.DNSRecord": {
+        "models.UsageCounts": {
             "type": "object",
             "properties": {
-                "name": {
-                    "description": "记录名称，如 selector._domainkey.example.com",
-                    "type": "string"
+                "bounced": {
+                    "description": "投递失败的邮件数（failed）",
+                    "type": "integer",
+                    "example": 12
                 },
-                "priority": {
-                    "description": "优先级（对TXT记录通常为0）",
-                    "type": "integer"
+                "bytes": {
+                    "description": "邮件字节数",
+                    "type": "integer",
+                    "example": 52428800
                 },
-                "ttl": {
-                    "description": "TTL值",
-                    "type": "integer"
+                "delivered": {
+                    "description": "已投递的邮件数（sent）",
+                    "type": "integer",
+                    "example": 1180
                 },
-                "type": {
-                    "description": "TXT",
-                    "type": "string"
+                "messages": {
+                    "description": "接受的邮件数",
+                    "type": "integer",
+                    "example": 1200
                 },
-                "value": {
-                    "description": "记录值",
-                    "type": "string"
+                "recipients": {
+                    "description": "收件人数",
+                    "type": "integer",
+                    "example": 3400
                 }
             }
         },
-        "models.MailLog": {
+        "models.UsageInvoice": {
             "type": "object",
             "properties": {
-                "attempts": {
-                    "type": "integer"
+                "complete": {
+                    "description": "当月所有日期都已生成用量记录",
+                    "type": "boolean",
+                    "example": true
                 },
-                "completed_at": {
+                "email": {
                     "type": "string"
                 },
-                "created_at": {
+                "generated_at": {
                     "type": "string"
                 },
-                "credential_id": {
+                "lines": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UsageInvoiceLine"
+                    }
+                },
+                "month": {
+                    "type": "string",
+                    "example": "2026-09"
+                },
+                "period_end": {
                     "type": "string"
                 },
-                "error_message": {
+                "period_start": {
                     "type": "string"
                 },
-                "from": {
+                "plan": {
+                    "description": "生成汇总时用户生效的套餐",
+                    "type": "string",
+                    "example": "basic"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Shanghai"
+                },
+                "totals": {
+                    "$ref": "#/definitions/models.UsageCounts"
+                },
+                "user_id": {
                     "type": "string"
                 },
-                "id": {
+                "username": {
                     "type": "string"
+                }
+            }
+        },
+        "models.UsageInvoiceLine": {
+            "type": "object",
+            "properties": {
+                "bounced": {
+                    "description": "投递失败的邮件数（failed）",
+                    "type": "integer",
+                    "example": 12
                 },
-                "last_attempt": {
+                "bytes": {
+                    "description": "邮件字节数",
+                    "type": "integer",
+                    "example": 52428800
+                },
+                "credential_id": {
                     "type": "string"
                 },
-                "message_id": {
+                "credential_name": {
                     "type": "string"
                 },
-                "relay_ip": {
+                "delivered": {
+                    "description": "已投递的邮件数（sent）",
+                    "type": "integer",
+                    "example": 1180
+                },
+                "messages": {
+                    "description": "接受的邮件数",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "recipients": {
+                    "description": "收件人数",
+                    "type": "integer",
+                    "example": 3400
+                }
+            }
+        },
+        "models.UsageRecord": {
+            "type": "object",
+            "properties": {
+                "bounced": {
+                    "description": "投递失败的邮件数（failed）",
+                    "type": "integer",
+                    "example": 12
+                },
+                "bytes": {
+                    "description": "邮件字节数",
+                    "type": "integer",
+                    "example": 52428800
+                },
+                "created_at": {
                     "type": "string"
                 },
-                "size": {
-                    "type": "integer"
+                "credential_id": {
+                    "description": "为空表示未通过SMTP凭据提交的邮件",
+                    "type": "string"
                 },
-                "status": {
-                    "description": "queued, sending, sent, failed",
+                "credential_name": {
+                    "description": "生成记录时的凭据名称",
                     "type": "string"
                 },
-                "subject": {
+                "date": {
+                    "description": "用户时区的日期",
+                    "type": "string",
+                    "example": "2026-10-01"
+                },
+                "delivered": {
+                    "description": "已投递的邮件数（sent）",
+                    "type": "integer",
+                    "example": 1180
+                },
+                "id": {
                     "type": "string"
                 },
-                "to": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "messages": {
+                    "description": "接受的邮件数",
+                    "type": "integer",
+                    "example": 1200
+                },
+                "recipients": {
+                    "description": "收件人数",
+                    "type": "integer",
+                    "example": 3400
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Asia/Shanghai"
                 },
                 "user_id": {
                     "type": "string"
+                },
+                "window_end": {
+                    "type": "string"
+                },
+                "window_start": {
+                    "type": "string"
                 }
             }
         },
-        "models.SMTPCredential": {
+        "models.User": {
             "type": "object",
             "properties": {
                 "created_at": {
                     "type": "string"
                 },
-                "description": {
-                    "description": "描述信息",
+                "email": {
                     "type": "string"
                 },
                 "id": {
                     "type": "string"
                 },
-                "last_used": {
+                "plan_id": {
+                    "description": "分配的套餐，为空时使用默认套餐",
                     "type": "string"
                 },
-                "name": {
-                    "description": "凭据名称，如\"mailcow-server1\"",
+                "role": {
+                    "description": "user（默认）, admin, read_only",
                     "type": "string"
                 },
                 "settings": {
-                    "$ref": "#/definitions/models.SMTPCredentialSettings"
+                    "$ref": "#/definitions/models.UserSettings"
                 },
                 "status": {
-                    "description": "active, disabled",
+                    "description": "active, suspended, deleted",
                     "type": "string"
                 },
-                "updated_at": {
+                "suspended_at": {
+                    "description": "被管理员停用的时间",
                     "type": "string"
                 },
-                "usage_count": {
-                    "description": "使用次数",
-                    "type": "integer"
+                "suspended_reason": {
+                    "description": "停用原因",
+                    "type": "string"
                 },
-                "user_id": {
+                "updated_at": {
                     "type": "string"
                 },
                 "username": {
-                    "description": "SMTP用户名",
                     "type": "string"
                 }
             }
         },
-        "models.SMTPCredentialSettings": {
+        "models.UserSettings": {
             "type": "object",
             "properties": {
                 "allowed_domains": {
-                    "description": "允许发送的域名",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "archive_retention_days": {
+                    "description": "原始邮件归档保留天数（0表示使用系统默认值）",
+                    "type": "integer"
+                },
                 "daily_quota": {
-                    "description": "该凭据的日配额",
                     "type": "integer"
                 },
                 "hourly_quota": {
-                    "description": "该凭据的小时配额",
                     "type": "integer"
                 },
-                "max_recipients": {
-                    "description": "单封邮件最大收件人数",
+                "messages_per_second": {
+                    "description": "所有凭据合计每秒最多发送的邮件数（0表示不限制）",
                     "type": "integer"
+                },
+                "recipients_per_minute": {
+                    "description": "所有凭据合计每分钟最多发送的收件人数（0表示不限制）",
+                    "type": "integer"
+                },
+                "timezone": {
+                    "description": "配额周期、统计和报表使用的IANA时区，为空时使用套餐时区，默认UTC",
+                    "type": "string",
+                    "example": "Asia/Shanghai"
                 }
             }
         },
-        "models.UserSettings": {
+        "services.MailStatsGroup": {
+            "type": "object",
+            "properties": {
+                "status_counts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "success_rate": {
+                    "type": "number"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "value": {
+                    "description": "分组值，空字符串表示未设置",
+                    "type": "string"
+                }
+            }
+        },
+        "services.QuotaUsage": {
+            "type": "object",
+            "properties": {
+                "daily_used": {
+                    "type": "integer",
+                    "example": 320
+                },
+                "hourly_used": {
+                    "type": "integer",
+                    "example": 25
+                },
+                "monthly_used": {
+                    "type": "integer",
+                    "example": 8200
+                }
+            }
+        },
+        "services.UserSettingsUpdate": {
             "type": "object",
             "properties": {
                 "allowed_domains": {
+                    "description": "新建凭据默认允许的发件域名",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "archive_retention_days": {
+                    "description": "原始邮件归档保留天数（0表示使用系统默认值）",
+                    "type": "integer",
+                    "example": 30
+                },
                 "daily_quota": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 1000
                 },
                 "hourly_quota": {
-                    "type": "integer"
+                    "type": "integer",
+                    "example": 100
+                },
+                "messages_per_second": {
+                    "description": "所有凭据合计每秒最多发送的邮件数（0表示不限制）",
+                    "type": "integer",
+                    "example": 10
+                },
+                "recipients_per_minute": {
+                    "description": "所有凭据合计每分钟最多发送的收件人数（0表示不限制）",
+                    "type": "integer",
+                    "example": 600
+                },
+                "timezone": {
+                    "description": "IANA时区，空字符串表示使用套餐时区",
+                    "type": "string",
+                    "example": "Asia/Shanghai"
                 }
             }
         }
@@ -0,0 +1,936 @@
+package api
+
+import (
+	"strings"
+
+	"smtp-relay/internal/models"
+	"smtp-relay/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 管理员相关请求结构体
+
+// PlanRequest 创建或更新套餐请求，各项限制为0表示不限制
+type PlanRequest struct {
+	Name           string `json:"name" binding:"required" example:"basic"`
+	Description    string `json:"description" example:"基础套餐"`
+	DailyLimit     int64  `json:"daily_limit" example:"10000"`         // 每日收件人数（所有凭据合计）
+	MonthlyLimit   int64  `json:"monthly_limit" example:"200000"`      // 每月收件人数（所有凭据合计）
+	MaxCredentials int    `json:"max_credentials" example:"10"`        // 最多SMTP凭据数
+	MaxDomains     int    `json:"max_domains" example:"5"`             // 最多发件域名数
+	MaxMessageSize int64  `json:"max_message_size" example:"10485760"` // 单封邮件最大字节数
+	IsDefault      bool   `json:"is_default" example:"false"`          // 设为默认套餐（未分配套餐的用户使用）
+	Timezone       string `json:"timezone" example:"Asia/Shanghai"`    // 配额周期和统计使用的IANA时区，用户未设置时区时生效，为空表示UTC
+}
+
+// AssignPlanRequest 为用户分配套餐请求
+type AssignPlanRequest struct {
+	PlanID string `json:"plan_id" example:"507f1f77bcf86cd799439012"` // 为空表示取消分配，使用默认套餐
+}
+
+// AdminListUsersRequest 用户列表请求参数
+type AdminListUsersRequest struct {
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+	Search   string `form:"search"` // 按用户名或邮箱搜索
+	Status   string `form:"status"` // active, suspended, deleted
+	Role     string `form:"role"`   // user, admin, read_only
+}
+
+// SuspendUserRequest 停用用户请求
+type SuspendUserRequest struct {
+	Reason string `json:"reason" example:"账单逾期"`
+}
+
+// SetUserRoleRequest 设置用户角色请求
+type SetUserRoleRequest struct {
+	Role string `json:"role" binding:"required" example:"read_only"` // user, admin, read_only
+}
+
+// SMTPConfigRequest 创建或更新上游SMTP配置请求
+type SMTPConfigRequest struct {
+	Name     string  `json:"name" binding:"required" example:"primary"`
+	Host     string  `json:"host" binding:"required" example:"smtp.example.com"`
+	Port     int     `json:"port" binding:"required" example:"587"`
+	Username string  `json:"username" example:"relay@example.com"`
+	Password *string `json:"password,omitempty" example:"secret"` // 更新时不传表示保留原密码
+	TLS      bool    `json:"tls" example:"true"`
+	Active   bool    `json:"active" example:"true"`
+	Priority int     `json:"priority" example:"1"` // 数值越小越优先
+}
+
+// 管理员相关响应结构体
+
+// PlanResponse 套餐响应
+type PlanResponse struct {
+	Success bool         `json:"success" example:"true"`
+	Data    *models.Plan `json:"data"`
+}
+
+// PlanListResponse 套餐列表响应
+type PlanListResponse struct {
+	Success bool           `json:"success" example:"true"`
+	Data    []*models.Plan `json:"data"`
+}
+
+// AdminUserListResponse 用户列表响应
+type AdminUserListResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Users    []*models.User `json:"users"`
+		Total    int64          `json:"total" example:"42"`
+		Page     int            `json:"page" example:"1"`
+		PageSize int            `json:"page_size" example:"20"`
+	} `json:"data"`
+}
+
+// AdminUserResponse 用户详情响应
+type AdminUserResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		User *models.User `json:"user"`
+		Plan *models.Plan `json:"plan"` // 用户生效的套餐，没有套餐时为null
+	} `json:"data"`
+}
+
+// AdminCredentialListResponse 用户SMTP凭据列表响应
+type AdminCredentialListResponse struct {
+	Success bool                     `json:"success" example:"true"`
+	Data    []*models.SMTPCredential `json:"data"`
+}
+
+// SMTPConfigResponse 上游SMTP配置响应
+type SMTPConfigResponse struct {
+	Success bool               `json:"success" example:"true"`
+	Data    *models.SMTPConfig `json:"data"`
+}
+
+// SMTPConfigListResponse 上游SMTP配置列表响应
+type SMTPConfigListResponse struct {
+	Success bool                 `json:"success" example:"true"`
+	Data    []*models.SMTPConfig `json:"data"`
+}
+
+// setupAdminRoutes 设置管理员路由
+func (s *Server) setupAdminRoutes(authenticated *gin.RouterGroup) {
+	admin := authenticated.Group("/admin")
+	admin.Use(s.adminMiddleware())
+	{
+		admin.GET("/plans", s.listPlans)
+		admin.POST("/plans", s.createPlan)
+		admin.GET("/plans/:id", s.getPlan)
+		admin.PUT("/plans/:id", s.updatePlan)
+		admin.DELETE("/plans/:id", s.deletePlan)
+		admin.GET("/users", s.listUsers)
+		admin.GET("/users/:id", s.getUserDetail)
+		admin.POST("/users/:id/suspend", s.suspendUser)
+		admin.POST("/users/:id/reactivate", s.reactivateUser)
+		admin.PUT("/users/:id/settings", s.updateUserSettings)
+		admin.PUT("/users/:id/role", s.setUserRole)
+		admin.PUT("/users/:id/plan", s.assignUserPlan)
+		admin.GET("/users/:id/logs", s.getUserMailLogs)
+		admin.GET("/users/:id/credentials", s.getUserCredentials)
+		admin.GET("/smtp-configs", s.listSMTPConfigs)
+		admin.POST("/smtp-configs", s.createSMTPConfig)
+		admin.PUT("/smtp-configs/:id", s.updateSMTPConfig)
+		admin.DELETE("/smtp-configs/:id", s.deleteSMTPConfig)
+	}
+}
+
+// adminMiddleware 管理员权限中间件，需在authMiddleware和roleMiddleware之后使用
+func (s *Server) adminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("user_role") != models.UserRoleAdmin {
+			c.JSON(403, gin.H{"error": "需要管理员权限"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// listPlans 获取套餐列表
+// @Summary 获取套餐列表
+// @Description 获取所有套餐（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} PlanListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Router /api/v1/admin/plans [get]
+func (s *Server) listPlans(c *gin.Context) {
+	plans, err := s.planService.ListPlans()
+	if err != nil {
+		s.logger.WithError(err).Error("获取套餐列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    plans,
+	})
+}
+
+// createPlan 创建套餐
+// @Summary 创建套餐
+// @Description 创建套餐，限制用户所有凭据合计的每日/每月收件人数，以及凭据数、域名数和邮件大小（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body PlanRequest true "套餐定义"
+// @Success 201 {object} PlanResponse "创建成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 409 {object} APIResponse "套餐名称已存在"
+// @Router /api/v1/admin/plans [post]
+func (s *Server) createPlan(c *gin.Context) {
+	var req PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	plan, err := s.planService.CreatePlan(req.toPlan())
+	if err != nil {
+		s.handlePlanError(c, err, "创建套餐失败")
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// getPlan 获取套餐详情
+// @Summary 获取套餐详情
+// @Description 获取指定套餐（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "套餐ID"
+// @Success 200 {object} PlanResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "套餐不存在"
+// @Router /api/v1/admin/plans/{id} [get]
+func (s *Server) getPlan(c *gin.Context) {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的套餐ID"})
+		return
+	}
+
+	plan, err := s.planService.GetPlan(planID)
+	if err != nil {
+		s.handlePlanError(c, err, "获取套餐失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// updatePlan 更新套餐
+// @Summary 更新套餐
+// @Description 更新套餐定义，立即对使用该套餐的所有用户生效（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "套餐ID"
+// @Param body body PlanRequest true "套餐定义"
+// @Success 200 {object} PlanResponse "更新成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "套餐不存在"
+// @Failure 409 {object} APIResponse "套餐名称已存在"
+// @Router /api/v1/admin/plans/{id} [put]
+func (s *Server) updatePlan(c *gin.Context) {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的套餐ID"})
+		return
+	}
+
+	var req PlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	plan, err := s.planService.UpdatePlan(planID, req.toPlan())
+	if err != nil {
+		s.handlePlanError(c, err, "更新套餐失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    plan,
+	})
+}
+
+// deletePlan 删除套餐
+// @Summary 删除套餐
+// @Description 删除套餐，仍有用户使用的套餐不能删除（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "套餐ID"
+// @Success 200 {object} APIResponse "删除成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "套餐不存在"
+// @Failure 409 {object} APIResponse "套餐正在使用中"
+// @Router /api/v1/admin/plans/{id} [delete]
+func (s *Server) deletePlan(c *gin.Context) {
+	planID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的套餐ID"})
+		return
+	}
+
+	if err := s.planService.DeletePlan(planID); err != nil {
+		s.handlePlanError(c, err, "删除套餐失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "套餐已删除",
+	})
+}
+
+// assignUserPlan 为用户分配套餐
+// @Summary 为用户分配套餐
+// @Description 为用户分配套餐，plan_id为空时取消分配，使用默认套餐（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Param body body AssignPlanRequest true "套餐"
+// @Success 200 {object} APIResponse "分配成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户或套餐不存在"
+// @Router /api/v1/admin/users/{id}/plan [put]
+func (s *Server) assignUserPlan(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var req AssignPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	var planID *primitive.ObjectID
+	if req.PlanID != "" {
+		id, err := primitive.ObjectIDFromHex(req.PlanID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "无效的套餐ID"})
+			return
+		}
+		planID = &id
+	}
+
+	if err := s.planService.AssignPlan(userID, planID); err != nil {
+		s.handlePlanError(c, err, "分配套餐失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "套餐已分配",
+	})
+}
+
+// listUsers 获取用户列表
+// @Summary 获取用户列表
+// @Description 分页获取所有用户，支持按用户名或邮箱搜索、按状态和角色过滤（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param search query string false "用户名或邮箱"
+// @Param status query string false "用户状态" Enums(active, suspended, deleted)
+// @Param role query string false "用户角色" Enums(user, admin, read_only)
+// @Success 200 {object} AdminUserListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Router /api/v1/admin/users [get]
+func (s *Server) listUsers(c *gin.Context) {
+	var req AdminListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	users, total, err := s.userAdminService.ListUsers(services.UserListFilter{
+		Search: req.Search,
+		Status: req.Status,
+		Role:   req.Role,
+	}, req.Page, req.PageSize)
+	if err != nil {
+		s.logger.WithError(err).Error("获取用户列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"users":     users,
+			"total":     total,
+			"page":      req.Page,
+			"page_size": req.PageSize,
+		},
+	})
+}
+
+// getUserDetail 获取用户详情
+// @Summary 获取用户详情
+// @Description 获取任意状态用户的信息和生效的套餐（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} AdminUserResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户不存在"
+// @Router /api/v1/admin/users/{id} [get]
+func (s *Server) getUserDetail(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	user, err := s.userAdminService.GetUser(userID)
+	if err != nil {
+		s.handleUserAdminError(c, err, "获取用户失败")
+		return
+	}
+	plan, err := s.planService.GetUserPlan(user)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取用户套餐失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"user": user,
+			"plan": plan,
+		},
+	})
+}
+
+// suspendUser 停用用户
+// @Summary 停用用户
+// @Description 停用用户：用户不能再登录API，已签发的令牌立即失效，其SMTP凭据也无法认证（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Param body body SuspendUserRequest false "停用原因"
+// @Success 200 {object} APIResponse "停用成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户不存在"
+// @Failure 409 {object} APIResponse "用户已删除"
+// @Router /api/v1/admin/users/{id}/suspend [post]
+func (s *Server) suspendUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+	if userID.Hex() == c.GetString("user_id") {
+		c.JSON(400, gin.H{"error": "不能停用自己的账户"})
+		return
+	}
+
+	var req SuspendUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "请求参数错误"})
+			return
+		}
+	}
+
+	user, err := s.userAdminService.SuspendUser(userID, req.Reason)
+	if err != nil {
+		s.handleUserAdminError(c, err, "停用用户失败")
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id": c.GetString("user_id"),
+		"user_id":  userID.Hex(),
+		"reason":   req.Reason,
+	}).Warn("管理员停用用户")
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "用户已停用",
+		"data":    user,
+	})
+}
+
+// reactivateUser 重新启用用户
+// @Summary 重新启用用户
+// @Description 重新启用被停用的用户（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} APIResponse "启用成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户不存在"
+// @Failure 409 {object} APIResponse "用户已删除"
+// @Router /api/v1/admin/users/{id}/reactivate [post]
+func (s *Server) reactivateUser(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	user, err := s.userAdminService.ReactivateUser(userID)
+	if err != nil {
+		s.handleUserAdminError(c, err, "启用用户失败")
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id": c.GetString("user_id"),
+		"user_id":  userID.Hex(),
+	}).Info("管理员重新启用用户")
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "用户已启用",
+		"data":    user,
+	})
+}
+
+// updateUserSettings 更新用户设置
+// @Summary 更新用户设置
+// @Description 更新任意用户的配额、速率限制、时区等设置，只修改请求中提供的字段（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Param body body services.UserSettingsUpdate true "用户设置"
+// @Success 200 {object} APIResponse "更新成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户不存在"
+// @Router /api/v1/admin/users/{id}/settings [put]
+func (s *Server) updateUserSettings(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var update services.UserSettingsUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	user, err := s.userAdminService.UpdateSettings(userID, &update)
+	if err != nil {
+		s.handleUserAdminError(c, err, "更新用户设置失败")
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id": c.GetString("user_id"),
+		"user_id":  userID.Hex(),
+	}).Info("管理员更新用户设置")
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "用户设置已更新",
+		"data":    user,
+	})
+}
+
+// setUserRole 设置用户角色
+// @Summary 设置用户角色
+// @Description 设置用户角色：user（普通用户）、admin（管理员）、read_only（只读，只能查看自己的数据）；不能修改自己的角色（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Param body body SetUserRoleRequest true "角色"
+// @Success 200 {object} APIResponse "设置成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户不存在"
+// @Router /api/v1/admin/users/{id}/role [put]
+func (s *Server) setUserRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+	if userID.Hex() == c.GetString("user_id") {
+		c.JSON(400, gin.H{"error": "不能修改自己的角色"})
+		return
+	}
+
+	var req SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	user, err := s.userAdminService.SetRole(userID, req.Role)
+	if err != nil {
+		s.handleUserAdminError(c, err, "设置用户角色失败")
+		return
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"admin_id": c.GetString("user_id"),
+		"user_id":  userID.Hex(),
+		"role":     req.Role,
+	}).Warn("管理员修改用户角色")
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "用户角色已更新",
+		"data":    user,
+	})
+}
+
+// getUserMailLogs 获取用户的MailLog
+// @Summary 获取用户的MailLog
+// @Description 获取任意用户的邮件发送日志，筛选参数与 /api/v1/logs 相同（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param status query string false "邮件状态" Enums(queued,sending,sent,failed)
+// @Param from query string false "发件人筛选"
+// @Param to query string false "收件人筛选"
+// @Param date_from query string false "开始日期" format(date)
+// @Param date_to query string false "结束日期" format(date)
+// @Param tag query string false "标签筛选（X-Relay-Tag）"
+// @Param campaign query string false "活动ID筛选（X-Relay-Campaign）"
+// @Param metadata query []string false "元数据筛选，格式key:value，可重复（须全部匹配）" collectionFormat(multi)
+// @Success 200 {object} MailLogListResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户不存在"
+// @Router /api/v1/admin/users/{id}/logs [get]
+func (s *Server) getUserMailLogs(c *gin.Context) {
+	var req GetMailLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+	if _, err := s.userAdminService.GetUser(userID); err != nil {
+		s.handleUserAdminError(c, err, "获取用户失败")
+		return
+	}
+
+	s.listMailLogs(c, userID, &req)
+}
+
+// getUserCredentials 获取用户的SMTP凭据
+// @Summary 获取用户的SMTP凭据
+// @Description 获取任意用户的SMTP凭据列表（不包含密码，需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "用户ID"
+// @Success 200 {object} AdminCredentialListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "用户不存在"
+// @Router /api/v1/admin/users/{id}/credentials [get]
+func (s *Server) getUserCredentials(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+	if _, err := s.userAdminService.GetUser(userID); err != nil {
+		s.handleUserAdminError(c, err, "获取用户失败")
+		return
+	}
+
+	credentials, err := s.credentialService.ListCredentials(userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取凭据列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    credentials,
+	})
+}
+
+// listSMTPConfigs 获取上游SMTP配置列表
+// @Summary 获取上游SMTP配置列表
+// @Description 获取Worker投递使用的所有上游SMTP服务器配置（不包含密码，需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SMTPConfigListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Router /api/v1/admin/smtp-configs [get]
+func (s *Server) listSMTPConfigs(c *gin.Context) {
+	configs, err := s.smtpConfigService.ListConfigs()
+	if err != nil {
+		s.logger.WithError(err).Error("获取SMTP配置列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    configs,
+	})
+}
+
+// createSMTPConfig 创建上游SMTP配置
+// @Summary 创建上游SMTP配置
+// @Description 创建上游SMTP服务器配置，密码加密存储，Worker定期重新加载启用的配置（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body SMTPConfigRequest true "SMTP配置"
+// @Success 201 {object} SMTPConfigResponse "创建成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 409 {object} APIResponse "该主机和端口的SMTP配置已存在"
+// @Router /api/v1/admin/smtp-configs [post]
+func (s *Server) createSMTPConfig(c *gin.Context) {
+	var req SMTPConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	password := ""
+	if req.Password != nil {
+		password = *req.Password
+	}
+	config, err := s.smtpConfigService.CreateConfig(req.toSMTPConfig(), password)
+	if err != nil {
+		s.handleSMTPConfigError(c, err, "创建SMTP配置失败")
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"data":    config,
+	})
+}
+
+// updateSMTPConfig 更新上游SMTP配置
+// @Summary 更新上游SMTP配置
+// @Description 更新上游SMTP服务器配置，不传password时保留原密码（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "SMTP配置ID"
+// @Param body body SMTPConfigRequest true "SMTP配置"
+// @Success 200 {object} SMTPConfigResponse "更新成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "SMTP配置不存在"
+// @Failure 409 {object} APIResponse "该主机和端口的SMTP配置已存在"
+// @Router /api/v1/admin/smtp-configs/{id} [put]
+func (s *Server) updateSMTPConfig(c *gin.Context) {
+	configID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的SMTP配置ID"})
+		return
+	}
+
+	var req SMTPConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	config, err := s.smtpConfigService.UpdateConfig(configID, req.toSMTPConfig(), req.Password)
+	if err != nil {
+		s.handleSMTPConfigError(c, err, "更新SMTP配置失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    config,
+	})
+}
+
+// deleteSMTPConfig 删除上游SMTP配置
+// @Summary 删除上游SMTP配置
+// @Description 删除上游SMTP服务器配置（需要管理员权限）
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "SMTP配置ID"
+// @Success 200 {object} APIResponse "删除成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "需要管理员权限"
+// @Failure 404 {object} APIResponse "SMTP配置不存在"
+// @Router /api/v1/admin/smtp-configs/{id} [delete]
+func (s *Server) deleteSMTPConfig(c *gin.Context) {
+	configID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的SMTP配置ID"})
+		return
+	}
+
+	if err := s.smtpConfigService.DeleteConfig(configID); err != nil {
+		s.handleSMTPConfigError(c, err, "删除SMTP配置失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "SMTP配置已删除",
+	})
+}
+
+// toPlan 转换为套餐模型
+func (r *PlanRequest) toPlan() *models.Plan {
+	return &models.Plan{
+		Name:           r.Name,
+		Description:    r.Description,
+		DailyLimit:     r.DailyLimit,
+		MonthlyLimit:   r.MonthlyLimit,
+		MaxCredentials: r.MaxCredentials,
+		MaxDomains:     r.MaxDomains,
+		MaxMessageSize: r.MaxMessageSize,
+		IsDefault:      r.IsDefault,
+		Timezone:       r.Timezone,
+	}
+}
+
+// handlePlanError 将套餐服务错误转换为HTTP响应
+func (s *Server) handlePlanError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "套餐不存在" || err.Error() == "用户不存在":
+		c.JSON(404, gin.H{"error": err.Error()})
+	case err.Error() == "套餐名称已存在" || strings.HasPrefix(err.Error(), "套餐正在被"):
+		c.JSON(409, gin.H{"error": err.Error()})
+	case strings.HasPrefix(err.Error(), "套餐名称") || err.Error() == "套餐限制不能为负数" ||
+		strings.HasPrefix(err.Error(), "无效的时区"):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		s.logger.WithError(err).Error(message)
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+	}
+}
+
+// toSMTPConfig 转换为上游SMTP配置模型（不包含密码）
+func (r *SMTPConfigRequest) toSMTPConfig() *models.SMTPConfig {
+	return &models.SMTPConfig{
+		Name:     r.Name,
+		Host:     r.Host,
+		Port:     r.Port,
+		Username: r.Username,
+		TLS:      r.TLS,
+		Active:   r.Active,
+		Priority: r.Priority,
+	}
+}
+
+// handleUserAdminError 将用户管理服务错误转换为HTTP响应
+func (s *Server) handleUserAdminError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "用户不存在":
+		c.JSON(404, gin.H{"error": err.Error()})
+	case err.Error() == "用户已删除":
+		c.JSON(409, gin.H{"error": err.Error()})
+	case err.Error() == "无效的用户角色" || strings.HasPrefix(err.Error(), "无效的时区") || strings.HasSuffix(err.Error(), "之间"):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		s.logger.WithError(err).Error(message)
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+	}
+}
+
+// handleSMTPConfigError 将上游SMTP配置服务错误转换为HTTP响应
+func (s *Server) handleSMTPConfigError(c *gin.Context, err error, message string) {
+	switch {
+	case err.Error() == "SMTP配置不存在":
+		c.JSON(404, gin.H{"error": err.Error()})
+	case err.Error() == "该主机和端口的SMTP配置已存在":
+		c.JSON(409, gin.H{"error": err.Error()})
+	case strings.HasSuffix(err.Error(), "不能为空") || strings.HasSuffix(err.Error(), "之间"):
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		s.logger.WithError(err).Error(message)
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+	}
+}
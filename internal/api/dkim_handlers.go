@@ -1,7 +1,12 @@
 package api
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+
 	"smtp-relay/internal/models"
+	"smtp-relay/internal/services"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -12,9 +17,48 @@ import (
 
 // CreateDKIMKeyRequest 创建DKIM密钥对请求
 type CreateDKIMKeyRequest struct {
-	Domain   string `json:"domain" binding:"required" example:"example.com"`
-	Selector string `json:"selector" binding:"required" example:"default"`
-	KeySize  int    `json:"key_size" binding:"omitempty" example:"2048"`
+	Domain    string `json:"domain" binding:"required" example:"example.com"`
+	Selector  string `json:"selector" binding:"required" example:"default"`
+	Algorithm string `json:"algorithm" binding:"omitempty,oneof=rsa-sha256 ed25519-sha256" example:"rsa-sha256"` // 默认rsa-sha256
+	KeySize   int    `json:"key_size" binding:"omitempty,oneof=1024 2048 4096" example:"2048"`                   // 仅RSA有效
+}
+
+// ImportDKIMKeyRequest 导入DKIM私钥请求
+type ImportDKIMKeyRequest struct {
+	Domain     string `json:"domain" binding:"required" example:"example.com"`
+	Selector   string `json:"selector" binding:"required" example:"s2024"`
+	PrivateKey string `json:"private_key" binding:"required"` // PEM格式私钥（PKCS#1或PKCS#8，RSA或Ed25519）
+}
+
+// ExportDKIMPrivateKeyRequest 导出DKIM私钥请求
+type ExportDKIMPrivateKeyRequest struct {
+	Password string `json:"password" binding:"required" example:"password123"`            // 当前登录密码（重新认证）
+	Format   string `json:"format" binding:"omitempty,oneof=pkcs8 pkcs1" example:"pkcs8"` // 默认pkcs8，pkcs1仅支持RSA
+}
+
+// SetDKIMRotationPolicyRequest 设置DKIM轮换策略请求
+type SetDKIMRotationPolicyRequest struct {
+	Enabled         bool `json:"enabled" example:"true"`
+	IntervalDays    int  `json:"interval_days" binding:"required" example:"90"`      // 轮换周期（7-730天）
+	GracePeriodDays int  `json:"grace_period_days" binding:"omitempty" example:"30"` // 旧密钥宽限期（1-90天，默认30天且不超过轮换周期的一半）
+}
+
+// DKIMConfigRequest 域名DKIM签名设置
+type DKIMConfigRequest struct {
+	Active         *bool    `json:"active" example:"true"`                                                   // 是否启用该配置，默认true
+	Enabled        *bool    `json:"enabled" example:"true"`                                                  // 是否对该域名签名，默认true
+	DefaultKeyID   string   `json:"default_key_id" example:"507f1f77bcf86cd799439011"`                       // 默认密钥（替换同算法的自动选择）
+	SigningDomains []string `json:"signing_domains" example:"example.com"`                                   // 签名域名（d=），须为该域名或其上级域名
+	HeaderCanon    string   `json:"header_canon" binding:"omitempty,oneof=relaxed simple" example:"relaxed"` // 默认relaxed
+	BodyCanon      string   `json:"body_canon" binding:"omitempty,oneof=relaxed simple" example:"relaxed"`   // 默认relaxed
+	SignHeaders    []string `json:"sign_headers" example:"From,To,Subject,Date,Message-ID"`                  // 须包含From
+	OversignFrom   *bool    `json:"oversign_from" example:"true"`                                            // 是否过度签名From，默认true
+}
+
+// CreateDKIMConfigRequest 创建域名DKIM配置请求
+type CreateDKIMConfigRequest struct {
+	Domain string `json:"domain" binding:"required" example:"example.com"`
+	DKIMConfigRequest
 }
 
 // DKIM相关响应结构体
@@ -31,6 +75,36 @@ type DKIMKeyPairListResponse struct {
 	Data    []*models.DKIMKeyPair `json:"data"`
 }
 
+// DKIMKeyExportResponse DKIM密钥导出响应
+type DKIMKeyExportResponse struct {
+	Success bool                  `json:"success" example:"true"`
+	Data    *models.DKIMKeyExport `json:"data"`
+}
+
+// DKIMRotationPolicyResponse DKIM轮换策略响应
+type DKIMRotationPolicyResponse struct {
+	Success bool                       `json:"success" example:"true"`
+	Data    *models.DKIMRotationPolicy `json:"data"`
+}
+
+// DKIMRotationPolicyListResponse DKIM轮换策略列表响应
+type DKIMRotationPolicyListResponse struct {
+	Success bool                         `json:"success" example:"true"`
+	Data    []*models.DKIMRotationPolicy `json:"data"`
+}
+
+// DKIMConfigResponse 域名DKIM配置响应
+type DKIMConfigResponse struct {
+	Success bool               `json:"success" example:"true"`
+	Data    *models.DKIMConfig `json:"data"`
+}
+
+// DKIMConfigListResponse 域名DKIM配置列表响应
+type DKIMConfigListResponse struct {
+	Success bool                 `json:"success" example:"true"`
+	Data    []*models.DKIMConfig `json:"data"`
+}
+
 // DNSRecordResponse DNS记录响应
 type DNSRecordResponse struct {
 	Success bool              `json:"success" example:"true"`
@@ -49,11 +123,22 @@ func (s *Server) setupDKIMRoutes(authenticated *gin.RouterGroup) {
 	{
 		dkim.GET("/keys", s.listDKIMKeys)
 		dkim.POST("/keys", s.createDKIMKey)
+		dkim.POST("/keys/import", s.importDKIMKey)
 		dkim.GET("/keys/:id", s.getDKIMKey)
 		dkim.DELETE("/keys/:id", s.deleteDKIMKey)
 		dkim.POST("/keys/:id/rotate", s.rotateDKIMKey)
 		dkim.GET("/keys/:id/dns", s.getDKIMDNSRecord)
 		dkim.POST("/keys/:id/verify", s.verifyDKIMDNS)
+		dkim.GET("/keys/:id/export", s.exportDKIMPublicKey)
+		dkim.POST("/keys/:id/export", s.exportDKIMPrivateKey)
+		dkim.GET("/configs", s.listDKIMConfigs)
+		dkim.POST("/configs", s.createDKIMConfig)
+		dkim.GET("/configs/:domain", s.getDKIMConfig)
+		dkim.PUT("/configs/:domain", s.updateDKIMConfig)
+		dkim.DELETE("/configs/:domain", s.deleteDKIMConfig)
+		dkim.GET("/policies", s.listDKIMRotationPolicies)
+		dkim.PUT("/policies/:domain", s.setDKIMRotationPolicy)
+		dkim.DELETE("/policies/:domain", s.deleteDKIMRotationPolicy)
 	}
 }
 
@@ -91,7 +176,7 @@ func (s *Server) listDKIMKeys(c *gin.Context) {
 
 // createDKIMKey 创建DKIM密钥对
 // @Summary 创建DKIM密钥对
-// @Description 为指定域名创建新的DKIM密钥对
+// @Description 为指定域名创建新的DKIM密钥对，支持RSA（1024/2048/4096位）和Ed25519（RFC 8463）；同一域名同时存在两种密钥时投递将双重签名
 // @Tags DKIM
 // @Accept json
 // @Produce json
@@ -100,6 +185,7 @@ func (s *Server) listDKIMKeys(c *gin.Context) {
 // @Success 201 {object} DKIMKeyPairResponse "创建成功"
 // @Failure 400 {object} APIResponse "请求参数错误"
 // @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "域名未验证"
 // @Router /api/v1/dkim/keys [post]
 func (s *Server) createDKIMKey(c *gin.Context) {
 	var req CreateDKIMKeyRequest
@@ -115,13 +201,24 @@ func (s *Server) createDKIMKey(c *gin.Context) {
 		return
 	}
 
+	// 只能为已验证的域名创建密钥
+	if err := s.domainService.RequireVerifiedDomain(userID, req.Domain); err != nil {
+		if err.Error() == "域名未验证" {
+			c.JSON(403, gin.H{"error": "域名未验证"})
+		} else {
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("检查域名验证状态失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
 	// 设置默认密钥长度
 	if req.KeySize == 0 {
 		req.KeySize = 2048
 	}
 
 	// 调用服务层创建密钥对
-	keyPair, err := s.dkimService.GenerateKeyPair(userID, req.Domain, req.Selector, req.KeySize)
+	keyPair, err := s.dkimService.GenerateKeyPair(userID, req.Domain, req.Selector, req.Algorithm, req.KeySize)
 	if err != nil {
 		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("创建DKIM密钥对失败")
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -232,7 +329,7 @@ func (s *Server) deleteDKIMKey(c *gin.Context) {
 
 // rotateDKIMKey 轮换DKIM密钥对
 // @Summary 轮换DKIM密钥对
-// @Description 为指定密钥对生成新的密钥，旧密钥标记为即将过期
+// @Description 为指定密钥对生成新选择器的密钥（pending状态），新选择器的DNS记录生效后自动切换签名，旧密钥进入宽限期后过期
 // @Tags DKIM
 // @Accept json
 // @Produce json
@@ -241,6 +338,7 @@ func (s *Server) deleteDKIMKey(c *gin.Context) {
 // @Success 200 {object} DKIMKeyPairResponse "轮换成功"
 // @Failure 401 {object} APIResponse "未授权"
 // @Failure 404 {object} APIResponse "密钥对不存在"
+// @Failure 409 {object} APIResponse "密钥对状态不允许轮换"
 // @Router /api/v1/dkim/keys/{id}/rotate [post]
 func (s *Server) rotateDKIMKey(c *gin.Context) {
 	// 获取用户ID
@@ -262,6 +360,8 @@ func (s *Server) rotateDKIMKey(c *gin.Context) {
 	if err != nil {
 		if err.Error() == "DKIM密钥对不存在" {
 			c.JSON(404, gin.H{"error": "DKIM密钥对不存在"})
+		} else if errors.Is(err, services.ErrRotationInactiveKey) || errors.Is(err, services.ErrRotationPending) {
+			c.JSON(409, gin.H{"error": err.Error()})
 		} else {
 			s.logger.WithError(err).WithFields(logrus.Fields{
 				"user_id":     userID.Hex(),
@@ -274,7 +374,7 @@ func (s *Server) rotateDKIMKey(c *gin.Context) {
 
 	c.JSON(200, gin.H{
 		"success": true,
-		"message": "DKIM密钥对轮换成功",
+		"message": "新密钥已生成，请发布DNS记录，记录生效后将自动切换签名",
 		"data":    newKeyPair,
 	})
 }
@@ -377,6 +477,529 @@ func (s *Server) verifyDKIMDNS(c *gin.Context) {
 
 // 辅助函数
 
+// importDKIMKey 导入DKIM私钥
+// @Summary 导入DKIM私钥
+// @Description 导入已在其他中继使用的PEM私钥（PKCS#1/PKCS#8，RSA或Ed25519），私钥必须与 selector._domainkey.domain 当前发布的公钥一致
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body ImportDKIMKeyRequest true "私钥信息"
+// @Success 201 {object} DKIMKeyPairResponse "导入成功"
+// @Failure 400 {object} APIResponse "私钥无效或与DNS记录不匹配"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "域名未验证"
+// @Router /api/v1/dkim/keys/import [post]
+func (s *Server) importDKIMKey(c *gin.Context) {
+	var req ImportDKIMKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 只能为已验证的域名导入密钥
+	if err := s.domainService.RequireVerifiedDomain(userID, req.Domain); err != nil {
+		if err.Error() == "域名未验证" {
+			c.JSON(403, gin.H{"error": "域名未验证"})
+		} else {
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("检查域名验证状态失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	// 调用服务层导入密钥对
+	keyPair, err := s.dkimService.ImportKeyPair(userID, req.Domain, req.Selector, req.PrivateKey)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Warn("导入DKIM密钥对失败")
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"data":    keyPair,
+	})
+}
+
+// exportDKIMPublicKey 导出DKIM公钥
+// @Summary 导出DKIM公钥
+// @Description 导出指定密钥对的PEM格式公钥及DNS记录
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "密钥对ID"
+// @Success 200 {object} DKIMKeyExportResponse "导出成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "密钥对不存在"
+// @Router /api/v1/dkim/keys/{id}/export [get]
+func (s *Server) exportDKIMPublicKey(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取密钥对ID
+	keyPairID, err := s.getDKIMKeyID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的密钥对ID"})
+		return
+	}
+
+	export, err := s.dkimService.ExportPublicKey(userID, keyPairID)
+	if err != nil {
+		if err.Error() == "DKIM密钥对不存在" {
+			c.JSON(404, gin.H{"error": "DKIM密钥对不存在"})
+		} else {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":     userID.Hex(),
+				"key_pair_id": keyPairID.Hex(),
+			}).Error("导出DKIM公钥失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    export,
+	})
+}
+
+// exportDKIMPrivateKey 导出DKIM完整密钥材料
+// @Summary 导出DKIM私钥
+// @Description 导出指定密钥对的PEM格式私钥（PKCS#8或PKCS#1），需提供当前登录密码重新认证
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "密钥对ID"
+// @Param body body ExportDKIMPrivateKeyRequest true "重新认证信息及导出格式"
+// @Success 200 {object} DKIMKeyExportResponse "导出成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权或密码错误"
+// @Failure 404 {object} APIResponse "密钥对不存在"
+// @Router /api/v1/dkim/keys/{id}/export [post]
+func (s *Server) exportDKIMPrivateKey(c *gin.Context) {
+	var req ExportDKIMPrivateKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取密钥对ID
+	keyPairID, err := s.getDKIMKeyID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的密钥对ID"})
+		return
+	}
+
+	// 重新认证
+	if err := s.authService.VerifyPassword(userID, req.Password); err != nil {
+		if err.Error() == "密码错误" || err.Error() == "用户不存在" {
+			c.JSON(401, gin.H{"error": "密码错误"})
+		} else {
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("重新认证失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	export, err := s.dkimService.ExportPrivateKey(userID, keyPairID, req.Format)
+	if err != nil {
+		switch err.Error() {
+		case "DKIM密钥对不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		case "PKCS#1格式仅支持RSA密钥":
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":     userID.Hex(),
+				"key_pair_id": keyPairID.Hex(),
+			}).Error("导出DKIM私钥失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    export,
+	})
+}
+
+// listDKIMRotationPolicies 获取DKIM轮换策略列表
+// @Summary 获取DKIM轮换策略列表
+// @Description 获取当前用户所有域名的DKIM自动轮换策略
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} DKIMRotationPolicyListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/dkim/policies [get]
+func (s *Server) listDKIMRotationPolicies(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	policies, err := s.dkimRotationService.ListPolicies(userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取DKIM轮换策略失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    policies,
+	})
+}
+
+// setDKIMRotationPolicy 设置域名DKIM轮换策略
+// @Summary 设置DKIM轮换策略
+// @Description 为域名设置DKIM自动轮换策略：密钥达到轮换周期后自动生成新选择器并通知发布DNS记录，记录生效后切换签名，旧密钥在宽限期后过期
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Param body body SetDKIMRotationPolicyRequest true "轮换策略"
+// @Success 200 {object} DKIMRotationPolicyResponse "设置成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/dkim/policies/{domain} [put]
+func (s *Server) setDKIMRotationPolicy(c *gin.Context) {
+	var req SetDKIMRotationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	policy, err := s.dkimRotationService.SetPolicy(userID, c.Param("domain"), req.Enabled, req.IntervalDays, req.GracePeriodDays)
+	if err != nil {
+		switch err.Error() {
+		case "无效的域名", "轮换周期必须在7-730天之间", "宽限期必须在1-90天之间", "宽限期必须小于轮换周期":
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("设置DKIM轮换策略失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// deleteDKIMRotationPolicy 删除域名DKIM轮换策略
+// @Summary 删除DKIM轮换策略
+// @Description 删除域名的DKIM自动轮换策略，已开始的轮换不受影响
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Success 200 {object} APIResponse "删除成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "轮换策略不存在"
+// @Router /api/v1/dkim/policies/{domain} [delete]
+func (s *Server) deleteDKIMRotationPolicy(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := s.dkimRotationService.DeletePolicy(userID, c.Param("domain")); err != nil {
+		switch err.Error() {
+		case "轮换策略不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		case "无效的域名":
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("删除DKIM轮换策略失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "DKIM轮换策略删除成功",
+	})
+}
+
+// listDKIMConfigs 获取域名DKIM配置列表
+// @Summary 获取域名DKIM配置列表
+// @Description 获取当前用户所有域名的DKIM签名设置
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} DKIMConfigListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/dkim/configs [get]
+func (s *Server) listDKIMConfigs(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	configs, err := s.dkimService.ListConfigs(userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取DKIM配置列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    configs,
+	})
+}
+
+// createDKIMConfig 创建域名DKIM配置
+// @Summary 创建域名DKIM配置
+// @Description 为已验证的发件域名设置DKIM签名参数：规范化算法、签名头部、From过度签名、签名域名和默认密钥，配置同样作用于其子域名
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body CreateDKIMConfigRequest true "DKIM配置"
+// @Success 201 {object} DKIMConfigResponse "创建成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "域名未验证"
+// @Failure 409 {object} APIResponse "配置已存在"
+// @Router /api/v1/dkim/configs [post]
+func (s *Server) createDKIMConfig(c *gin.Context) {
+	var req CreateDKIMConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	settings, active, ok := s.buildDKIMSettings(c, userID, req.Domain, &req.DKIMConfigRequest)
+	if !ok {
+		return
+	}
+
+	config, err := s.dkimService.CreateConfig(userID, req.Domain, settings, active)
+	if err != nil {
+		s.handleDKIMConfigError(c, err, userID, "创建DKIM配置失败")
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"data":    config,
+	})
+}
+
+// getDKIMConfig 获取域名DKIM配置
+// @Summary 获取域名DKIM配置
+// @Description 获取指定域名的DKIM签名设置
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Success 200 {object} DKIMConfigResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "配置不存在"
+// @Router /api/v1/dkim/configs/{domain} [get]
+func (s *Server) getDKIMConfig(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	config, err := s.dkimService.GetConfig(userID, c.Param("domain"))
+	if err != nil {
+		s.handleDKIMConfigError(c, err, userID, "获取DKIM配置失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    config,
+	})
+}
+
+// updateDKIMConfig 更新域名DKIM配置
+// @Summary 更新域名DKIM配置
+// @Description 替换指定域名的DKIM签名设置，未提供的字段使用默认值
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Param body body DKIMConfigRequest true "DKIM配置"
+// @Success 200 {object} DKIMConfigResponse "更新成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "配置不存在"
+// @Router /api/v1/dkim/configs/{domain} [put]
+func (s *Server) updateDKIMConfig(c *gin.Context) {
+	var req DKIMConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	settings, active, ok := s.buildDKIMSettings(c, userID, c.Param("domain"), &req)
+	if !ok {
+		return
+	}
+
+	config, err := s.dkimService.UpdateConfig(userID, c.Param("domain"), settings, active)
+	if err != nil {
+		s.handleDKIMConfigError(c, err, userID, "更新DKIM配置失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    config,
+	})
+}
+
+// deleteDKIMConfig 删除域名DKIM配置
+// @Summary 删除域名DKIM配置
+// @Description 删除指定域名的DKIM签名设置，删除后恢复默认签名设置
+// @Tags DKIM
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Success 200 {object} APIResponse "删除成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "配置不存在"
+// @Router /api/v1/dkim/configs/{domain} [delete]
+func (s *Server) deleteDKIMConfig(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := s.dkimService.DeleteConfig(userID, c.Param("domain")); err != nil {
+		s.handleDKIMConfigError(c, err, userID, "删除DKIM配置失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "DKIM配置删除成功",
+	})
+}
+
+// buildDKIMSettings 将请求转换为DKIM设置并检查域名验证状态，失败时已写入响应
+func (s *Server) buildDKIMSettings(c *gin.Context, userID primitive.ObjectID, domain string, req *DKIMConfigRequest) (models.DKIMSettings, bool, bool) {
+	settings := models.DKIMSettings{
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		SigningDomains: req.SigningDomains,
+		HeaderCanon:    req.HeaderCanon,
+		BodyCanon:      req.BodyCanon,
+		SignHeaders:    req.SignHeaders,
+		OversignFrom:   req.OversignFrom == nil || *req.OversignFrom,
+	}
+	active := req.Active == nil || *req.Active
+
+	if req.DefaultKeyID != "" {
+		keyPairID, err := primitive.ObjectIDFromHex(req.DefaultKeyID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "无效的默认密钥ID"})
+			return settings, false, false
+		}
+		settings.DefaultKeyID = &keyPairID
+	}
+
+	// 配置域名及签名域名都必须已验证
+	for _, name := range append([]string{domain}, req.SigningDomains...) {
+		if err := s.domainService.RequireVerifiedDomain(userID, name); err != nil {
+			if err.Error() == "域名未验证" {
+				c.JSON(403, gin.H{"error": fmt.Sprintf("域名未验证: %s", name)})
+			} else {
+				s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("检查域名验证状态失败")
+				c.JSON(500, gin.H{"error": "服务器内部错误"})
+			}
+			return settings, false, false
+		}
+	}
+
+	return settings, active, true
+}
+
+// handleDKIMConfigError 将DKIM配置服务错误映射为HTTP响应
+func (s *Server) handleDKIMConfigError(c *gin.Context, err error, userID primitive.ObjectID, message string) {
+	msg := err.Error()
+	switch {
+	case msg == "DKIM配置不存在" || msg == "DKIM密钥对不存在":
+		c.JSON(404, gin.H{"error": msg})
+	case msg == "该域名的DKIM配置已存在":
+		c.JSON(409, gin.H{"error": msg})
+	case msg == "无效的域名" || msg == "规范化算法必须是relaxed或simple" || msg == "签名头部必须包含From" ||
+		msg == "默认密钥必须属于签名域名" || strings.HasPrefix(msg, "From头部重复") ||
+		strings.HasPrefix(msg, "无效的头部名称") || strings.HasPrefix(msg, "不能签名") ||
+		strings.HasPrefix(msg, "签名域名必须是该域名或其上级域名"):
+		c.JSON(400, gin.H{"error": msg})
+	default:
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error(message)
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+	}
+}
+
 // getDKIMKeyID 从URL参数获取DKIM密钥对ID
 func (s *Server) getDKIMKeyID(c *gin.Context) (primitive.ObjectID, error) {
 	keyIDStr := c.Param("id")
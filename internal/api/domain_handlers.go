@@ -0,0 +1,343 @@
+package api
+
+import (
+	"smtp-relay/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 域名相关请求结构体
+
+// CreateDomainRequest 声明发件域名请求
+type CreateDomainRequest struct {
+	Domain string `json:"domain" binding:"required" example:"example.com"`
+}
+
+// UpdateDomainRequest 更新发件域名请求
+type UpdateDomainRequest struct {
+	BounceDomain string `json:"bounce_domain" example:"bounces.example.com"` // 为空时清除退信域名
+}
+
+// 域名相关响应结构体
+
+// DomainData 发件域名及其验证记录
+type DomainData struct {
+	*models.Domain
+	VerificationRecord *models.DNSRecord `json:"verification_record"`
+}
+
+// DomainResponse 发件域名响应
+type DomainResponse struct {
+	Success bool        `json:"success" example:"true"`
+	Data    *DomainData `json:"data"`
+}
+
+// DomainListResponse 发件域名列表响应
+type DomainListResponse struct {
+	Success bool          `json:"success" example:"true"`
+	Data    []*DomainData `json:"data"`
+}
+
+// DomainHealthResponse 域名健康检查响应
+type DomainHealthResponse struct {
+	Success bool                       `json:"success" example:"true"`
+	Data    *models.DomainHealthReport `json:"data"`
+}
+
+// setupDomainRoutes 设置域名相关路由
+func (s *Server) setupDomainRoutes(authenticated *gin.RouterGroup) {
+	domains := authenticated.Group("/domains")
+	{
+		domains.GET("", s.getDomains)
+		domains.POST("", s.createDomain)
+		domains.GET("/:domain", s.getDomain)
+		domains.PUT("/:domain", s.updateDomain)
+		domains.DELETE("/:domain", s.deleteDomain)
+		domains.POST("/:domain/verify", s.verifyDomain)
+		domains.GET("/:domain/health", s.getDomainHealth)
+	}
+}
+
+// newDomainData 组装域名响应数据
+func newDomainData(domain *models.Domain) *DomainData {
+	return &DomainData{
+		Domain:             domain,
+		VerificationRecord: domain.VerificationRecord(),
+	}
+}
+
+// getDomains 获取发件域名列表
+// @Summary 获取发件域名列表
+// @Description 获取当前用户声明的所有发件域名及其验证状态
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} DomainListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/domains [get]
+func (s *Server) getDomains(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	domains, err := s.domainService.ListDomains(userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取域名列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	data := make([]*DomainData, 0, len(domains))
+	for _, domain := range domains {
+		data = append(data, newDomainData(domain))
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// createDomain 声明发件域名
+// @Summary 声明发件域名
+// @Description 声明一个发件域名并生成验证令牌，需在DNS中发布返回的TXT记录后调用验证接口
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body CreateDomainRequest true "域名信息"
+// @Success 201 {object} DomainResponse "声明成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 403 {object} APIResponse "域名数量已达到套餐上限"
+// @Failure 409 {object} APIResponse "域名已添加或已被其他用户验证"
+// @Router /api/v1/domains [post]
+func (s *Server) createDomain(c *gin.Context) {
+	var req CreateDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	domain, err := s.domainService.ClaimDomain(userID, req.Domain)
+	if err != nil {
+		s.handleDomainError(c, err, userID, req.Domain, "声明域名失败")
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"data":    newDomainData(domain),
+	})
+}
+
+// getDomain 获取发件域名
+// @Summary 获取发件域名
+// @Description 获取指定发件域名的验证状态和需要发布的TXT记录
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Success 200 {object} DomainResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "域名不存在"
+// @Router /api/v1/domains/{domain} [get]
+func (s *Server) getDomain(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	domain, err := s.domainService.GetDomain(userID, c.Param("domain"))
+	if err != nil {
+		s.handleDomainError(c, err, userID, c.Param("domain"), "获取域名失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    newDomainData(domain),
+	})
+}
+
+// updateDomain 更新发件域名
+// @Summary 更新发件域名
+// @Description 设置已验证域名的退信域名，投递时信封发件人将改写为 bounce+<邮件ID>@<退信域名>
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Param body body UpdateDomainRequest true "更新信息"
+// @Success 200 {object} DomainResponse "更新成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "域名不存在"
+// @Router /api/v1/domains/{domain} [put]
+func (s *Server) updateDomain(c *gin.Context) {
+	var req UpdateDomainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	domain, err := s.domainService.UpdateBounceDomain(userID, c.Param("domain"), req.BounceDomain)
+	if err != nil {
+		s.handleDomainError(c, err, userID, c.Param("domain"), "更新域名失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    newDomainData(domain),
+	})
+}
+
+// deleteDomain 删除发件域名
+// @Summary 删除发件域名
+// @Description 删除发件域名，删除后该域名的发件地址将无法通过中继发信
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Success 200 {object} APIResponse "删除成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "域名不存在"
+// @Router /api/v1/domains/{domain} [delete]
+func (s *Server) deleteDomain(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if err := s.domainService.DeleteDomain(userID, c.Param("domain")); err != nil {
+		s.handleDomainError(c, err, userID, c.Param("domain"), "删除域名失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "域名删除成功",
+	})
+}
+
+// verifyDomain 验证发件域名
+// @Summary 验证发件域名
+// @Description 查询 _smtp-relay.<域名> 的TXT记录，包含验证令牌时将域名标记为已验证
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Success 200 {object} DomainResponse "验证成功"
+// @Failure 400 {object} APIResponse "未找到验证TXT记录"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "域名不存在"
+// @Failure 409 {object} APIResponse "域名已被其他用户验证"
+// @Router /api/v1/domains/{domain}/verify [post]
+func (s *Server) verifyDomain(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	domain, err := s.domainService.VerifyDomain(userID, c.Param("domain"))
+	if err != nil {
+		s.handleDomainError(c, err, userID, c.Param("domain"), "验证域名失败")
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    newDomainData(domain),
+	})
+}
+
+// handleDomainError 将域名服务错误映射为HTTP响应
+func (s *Server) handleDomainError(c *gin.Context, err error, userID primitive.ObjectID, domain, message string) {
+	switch err.Error() {
+	case "域名不存在":
+		c.JSON(404, gin.H{"error": err.Error()})
+	case "该域名已添加", "该域名已被其他用户验证":
+		c.JSON(409, gin.H{"error": err.Error()})
+	case "域名数量已达到套餐上限":
+		c.JSON(403, gin.H{"error": err.Error()})
+	case "无效的域名", "域名未验证", "未找到验证TXT记录", "退信域名必须是该域名或其子域名":
+		c.JSON(400, gin.H{"error": err.Error()})
+	default:
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id": userID.Hex(),
+			"domain":  domain,
+		}).Error(message)
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+	}
+}
+
+// getDomainHealth 检查域名认证配置
+// @Summary 域名认证健康检查
+// @Description 检查域名的SPF（是否授权中继IP、DNS查询次数）、所有有效DKIM选择器、DMARC策略与对齐、MX记录以及中继IP的正反向DNS，并给出修复建议
+// @Tags Domains
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param domain path string true "域名"
+// @Success 200 {object} DomainHealthResponse "检查完成"
+// @Failure 400 {object} APIResponse "无效的域名"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/domains/{domain}/health [get]
+func (s *Server) getDomainHealth(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	domain := c.Param("domain")
+	report, err := s.domainHealthService.Check(userID, domain)
+	if err != nil {
+		if err.Error() == "无效的域名" {
+			c.JSON(400, gin.H{"error": "无效的域名"})
+		} else {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id": userID.Hex(),
+				"domain":  domain,
+			}).Error("域名健康检查失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
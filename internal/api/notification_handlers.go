@@ -0,0 +1,270 @@
+package api
+
+import (
+	"strings"
+
+	"smtp-relay/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 通知相关请求结构体
+
+// GetNotificationsRequest 获取通知列表请求参数
+type GetNotificationsRequest struct {
+	Page       int  `form:"page,default=1"`
+	PageSize   int  `form:"page_size,default=20"`
+	UnreadOnly bool `form:"unread_only"`
+}
+
+// NotificationPreferencesRequest 更新通知偏好请求
+type NotificationPreferencesRequest struct {
+	QuotaThresholds []int   `json:"quota_thresholds" example:"80,95,100"`                  // 配额用量提醒阈值（百分比，1-100），为空表示不发送配额提醒
+	EmailEnabled    bool    `json:"email_enabled" example:"true"`                          // 是否通过中继发送邮件通知
+	Email           string  `json:"email" example:"ops@example.com"`                       // 接收通知的邮箱，为空时使用账户邮箱
+	WebhookURL      string  `json:"webhook_url" example:"https://example.com/hooks/relay"` // 接收通知的Webhook地址（须为公网地址），为空表示不调用
+	WebhookSecret   *string `json:"webhook_secret,omitempty" example:"change-me"`          // Webhook签名密钥，不传时保留原密钥，传空字符串时清除
+}
+
+// 通知相关响应结构体
+
+// NotificationListResponse 通知列表响应
+type NotificationListResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Notifications []*models.Notification `json:"notifications"`
+		Total         int64                  `json:"total" example:"5"`
+		Page          int                    `json:"page" example:"1"`
+		PageSize      int                    `json:"page_size" example:"20"`
+	} `json:"data"`
+}
+
+// NotificationPreferencesResponse 通知偏好响应
+type NotificationPreferencesResponse struct {
+	Success bool                            `json:"success" example:"true"`
+	Data    *models.NotificationPreferences `json:"data"`
+}
+
+// setupNotificationRoutes 设置通知相关路由
+func (s *Server) setupNotificationRoutes(authenticated *gin.RouterGroup) {
+	notifications := authenticated.Group("/notifications")
+	{
+		notifications.GET("", s.getNotifications)
+		notifications.POST("/read", s.markAllNotificationsRead)
+		notifications.GET("/preferences", s.getNotificationPreferences)
+		notifications.PUT("/preferences", s.updateNotificationPreferences)
+		notifications.POST("/:id/read", s.markNotificationRead)
+	}
+}
+
+// getNotifications 获取通知列表
+// @Summary 获取通知列表
+// @Description 分页获取当前用户的通知（如需要发布的DKIM DNS记录），最新的在前
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param unread_only query bool false "仅未读"
+// @Success 200 {object} NotificationListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/notifications [get]
+func (s *Server) getNotifications(c *gin.Context) {
+	var req GetNotificationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	notifications, total, err := s.notificationService.ListNotifications(userID, req.UnreadOnly, req.Page, req.PageSize)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取通知列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"notifications": notifications,
+			"total":         total,
+			"page":          req.Page,
+			"page_size":     req.PageSize,
+		},
+	})
+}
+
+// markNotificationRead 将通知标记为已读
+// @Summary 标记通知已读
+// @Description 将指定通知标记为已读
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "通知ID"
+// @Success 200 {object} APIResponse "标记成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "通知不存在"
+// @Router /api/v1/notifications/{id}/read [post]
+func (s *Server) markNotificationRead(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	notificationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的通知ID"})
+		return
+	}
+
+	if err := s.notificationService.MarkRead(userID, notificationID); err != nil {
+		if err.Error() == "通知不存在" {
+			c.JSON(404, gin.H{"error": "通知不存在"})
+		} else {
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("标记通知已读失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "通知已标记为已读",
+	})
+}
+
+// markAllNotificationsRead 将全部通知标记为已读
+// @Summary 全部标记已读
+// @Description 将当前用户的全部未读通知标记为已读
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} APIResponse "标记成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/notifications/read [post]
+func (s *Server) markAllNotificationsRead(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	count, err := s.notificationService.MarkAllRead(userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("标记通知已读失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "通知已全部标记为已读",
+		"data":    gin.H{"updated": count},
+	})
+}
+
+// getNotificationPreferences 获取通知偏好
+// @Summary 获取通知偏好
+// @Description 获取配额提醒阈值和通知渠道（邮件、Webhook）设置；未设置时在配额用量达到80%、95%和100%时发送站内通知
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} NotificationPreferencesResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/notifications/preferences [get]
+func (s *Server) getNotificationPreferences(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	preferences, err := s.notificationService.GetPreferences(userID)
+	if err != nil {
+		if err.Error() == "用户不存在" {
+			c.JSON(404, gin.H{"error": "用户不存在"})
+		} else {
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取通知偏好失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    preferences,
+	})
+}
+
+// updateNotificationPreferences 更新通知偏好
+// @Summary 更新通知偏好
+// @Description 设置配额提醒阈值（凭据和用户的小时/日/月配额用量达到阈值时，每个周期每个阈值只通知一次）以及通知渠道。站内通知始终保存；启用邮件时通过中继发送到指定邮箱；设置Webhook时以JSON POST事件，设置了签名密钥时附带X-Relay-Signature: sha256=<HMAC-SHA256>头部
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body NotificationPreferencesRequest true "通知偏好"
+// @Success 200 {object} NotificationPreferencesResponse "更新成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/notifications/preferences [put]
+func (s *Server) updateNotificationPreferences(c *gin.Context) {
+	var req NotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	preferences := &models.NotificationPreferences{
+		QuotaThresholds: req.QuotaThresholds,
+		EmailEnabled:    req.EmailEnabled,
+		Email:           req.Email,
+		WebhookURL:      req.WebhookURL,
+	}
+	if req.WebhookSecret != nil {
+		preferences.WebhookSecret = *req.WebhookSecret
+	}
+
+	updated, err := s.notificationService.UpdatePreferences(userID, preferences, req.WebhookSecret == nil)
+	if err != nil {
+		switch {
+		case err.Error() == "用户不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		case strings.HasPrefix(err.Error(), "配额提醒阈值") || strings.HasPrefix(err.Error(), "无效的") ||
+			strings.HasPrefix(err.Error(), "Webhook"):
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("更新通知偏好失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
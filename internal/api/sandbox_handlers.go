@@ -0,0 +1,290 @@
+package api
+
+import (
+	"fmt"
+
+	"smtp-relay/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 沙箱相关请求结构体
+
+// ListSandboxMessagesRequest 获取沙箱邮件列表请求参数
+type ListSandboxMessagesRequest struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=20"`
+}
+
+// 沙箱相关响应结构体
+
+// SandboxMessageListResponse 沙箱邮件列表响应
+type SandboxMessageListResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Messages []*models.SandboxMessage `json:"messages"`
+		Total    int64                    `json:"total" example:"100"`
+		Page     int                      `json:"page" example:"1"`
+		PageSize int                      `json:"page_size" example:"20"`
+		Pages    int64                    `json:"pages" example:"5"`
+	} `json:"data"`
+}
+
+// SandboxMessageDetailResponse 沙箱邮件详情响应
+type SandboxMessageDetailResponse struct {
+	Success bool                         `json:"success" example:"true"`
+	Data    *models.SandboxMessageDetail `json:"data"`
+}
+
+// setupSandboxRoutes 设置沙箱邮件相关路由
+func (s *Server) setupSandboxRoutes(authenticated *gin.RouterGroup) {
+	sandbox := authenticated.Group("/credentials/:id/sandbox")
+	{
+		sandbox.GET("/messages", s.listSandboxMessages)
+		sandbox.DELETE("/messages", s.clearSandboxMessages)
+		sandbox.GET("/messages/:messageId", s.getSandboxMessage)
+		sandbox.GET("/messages/:messageId/raw", s.getSandboxMessageRaw)
+		sandbox.DELETE("/messages/:messageId", s.deleteSandboxMessage)
+	}
+}
+
+// listSandboxMessages 获取沙箱邮件列表
+// @Summary 获取沙箱邮件列表
+// @Description 获取指定SMTP凭据在沙箱模式下捕获的邮件
+// @Tags Sandbox
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} SandboxMessageListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Router /api/v1/credentials/{id}/sandbox/messages [get]
+func (s *Server) listSandboxMessages(c *gin.Context) {
+	var req ListSandboxMessagesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	userID, credentialID, ok := s.getSandboxCredential(c)
+	if !ok {
+		return
+	}
+
+	// 参数验证
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	messages, total, err := s.sandboxService.ListMessages(userID, credentialID, req.Page, req.PageSize)
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":       userID.Hex(),
+			"credential_id": credentialID.Hex(),
+		}).Error("获取沙箱邮件列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"messages":  messages,
+			"total":     total,
+			"page":      req.Page,
+			"page_size": req.PageSize,
+			"pages":     (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// getSandboxMessage 获取沙箱邮件详情
+// @Summary 获取沙箱邮件详情
+// @Description 获取沙箱邮件的头部和MIME部件
+// @Tags Sandbox
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Param messageId path string true "沙箱邮件ID"
+// @Success 200 {object} SandboxMessageDetailResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "沙箱邮件不存在"
+// @Router /api/v1/credentials/{id}/sandbox/messages/{messageId} [get]
+func (s *Server) getSandboxMessage(c *gin.Context) {
+	userID, credentialID, ok := s.getSandboxCredential(c)
+	if !ok {
+		return
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(c.Param("messageId"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的沙箱邮件ID"})
+		return
+	}
+
+	detail, err := s.sandboxService.GetMessageDetail(userID, credentialID, messageID)
+	if err != nil {
+		if err.Error() == "沙箱邮件不存在" {
+			c.JSON(404, gin.H{"error": "沙箱邮件不存在"})
+		} else {
+			s.logger.WithError(err).WithField("message_id", messageID.Hex()).Error("获取沙箱邮件详情失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    detail,
+	})
+}
+
+// getSandboxMessageRaw 下载沙箱邮件原文
+// @Summary 下载沙箱邮件原文
+// @Description 以message/rfc822格式返回沙箱邮件的原始内容
+// @Tags Sandbox
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Param messageId path string true "沙箱邮件ID"
+// @Success 200 {file} file "邮件原文"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "沙箱邮件不存在"
+// @Router /api/v1/credentials/{id}/sandbox/messages/{messageId}/raw [get]
+func (s *Server) getSandboxMessageRaw(c *gin.Context) {
+	userID, credentialID, ok := s.getSandboxCredential(c)
+	if !ok {
+		return
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(c.Param("messageId"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的沙箱邮件ID"})
+		return
+	}
+
+	message, err := s.sandboxService.GetMessage(userID, credentialID, messageID)
+	if err != nil {
+		if err.Error() == "沙箱邮件不存在" {
+			c.JSON(404, gin.H{"error": "沙箱邮件不存在"})
+		} else {
+			s.logger.WithError(err).WithField("message_id", messageID.Hex()).Error("获取沙箱邮件原文失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.eml", message.ID.Hex()))
+	c.Data(200, "message/rfc822", message.Raw)
+}
+
+// deleteSandboxMessage 删除沙箱邮件
+// @Summary 删除沙箱邮件
+// @Description 删除指定的沙箱邮件
+// @Tags Sandbox
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Param messageId path string true "沙箱邮件ID"
+// @Success 200 {object} APIResponse "删除成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "沙箱邮件不存在"
+// @Router /api/v1/credentials/{id}/sandbox/messages/{messageId} [delete]
+func (s *Server) deleteSandboxMessage(c *gin.Context) {
+	userID, credentialID, ok := s.getSandboxCredential(c)
+	if !ok {
+		return
+	}
+
+	messageID, err := primitive.ObjectIDFromHex(c.Param("messageId"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的沙箱邮件ID"})
+		return
+	}
+
+	if err := s.sandboxService.DeleteMessage(userID, credentialID, messageID); err != nil {
+		if err.Error() == "沙箱邮件不存在" {
+			c.JSON(404, gin.H{"error": "沙箱邮件不存在"})
+		} else {
+			s.logger.WithError(err).WithField("message_id", messageID.Hex()).Error("删除沙箱邮件失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "沙箱邮件删除成功",
+	})
+}
+
+// clearSandboxMessages 清空沙箱邮件
+// @Summary 清空沙箱邮件
+// @Description 删除指定SMTP凭据捕获的全部沙箱邮件
+// @Tags Sandbox
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Success 200 {object} APIResponse "清空成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Router /api/v1/credentials/{id}/sandbox/messages [delete]
+func (s *Server) clearSandboxMessages(c *gin.Context) {
+	userID, credentialID, ok := s.getSandboxCredential(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := s.sandboxService.ClearMessages(userID, credentialID)
+	if err != nil {
+		s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Error("清空沙箱邮件失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "沙箱邮件已清空",
+		"data":    gin.H{"deleted": deleted},
+	})
+}
+
+// 辅助函数
+
+// getSandboxCredential 解析并校验当前用户对凭据的访问权限，失败时已写入响应
+func (s *Server) getSandboxCredential(c *gin.Context) (primitive.ObjectID, primitive.ObjectID, bool) {
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	if _, err := s.credentialService.GetCredential(userID, credentialID); err != nil {
+		if err.Error() == "SMTP凭据不存在" {
+			c.JSON(404, gin.H{"error": "SMTP凭据不存在"})
+		} else {
+			s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Error("获取SMTP凭据失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return primitive.NilObjectID, primitive.NilObjectID, false
+	}
+
+	return userID, credentialID, true
+}
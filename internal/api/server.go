@@ -20,6 +20,8 @@ import (
 	_ "smtp-relay/docs"
 	"smtp-relay/internal/auth"
 	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/mailauth"
 	"smtp-relay/internal/models"
 	"smtp-relay/internal/services"
 )
@@ -41,32 +43,83 @@ type RegisterRequest struct {
 
 // CreateCredentialRequest 创建SMTP凭据请求
 type CreateCredentialRequest struct {
-	Name        string `json:"name" binding:"required,min=1,max=50" example:"My SMTP Credential"`
-	Description string `json:"description" binding:"max=200" example:"用于发送营销邮件的SMTP凭据"`
+	Name        string     `json:"name" binding:"required,min=1,max=50" example:"My SMTP Credential"`
+	Description string     `json:"description" binding:"max=200" example:"用于发送营销邮件的SMTP凭据"`
+	Mode        string     `json:"mode" binding:"omitempty,oneof=normal sandbox" example:"normal"`
+	ExpiresAt   *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"` // 过期时间，为空表示永不过期
+}
+
+// RotateCredentialPasswordRequest 轮换SMTP凭据密码请求
+type RotateCredentialPasswordRequest struct {
+	GracePeriodHours int `json:"grace_period_hours" binding:"omitempty,min=1,max=720" example:"24"` // 旧密码继续有效的小时数，默认24
+}
+
+// SetCredentialExpirationRequest 设置SMTP凭据过期时间请求
+type SetCredentialExpirationRequest struct {
+	ExpiresAt *time.Time `json:"expires_at" example:"2027-01-01T00:00:00Z"` // 为空表示永不过期
+}
+
+// ListCredentialAnomaliesRequest 获取凭据异常告警请求参数
+type ListCredentialAnomaliesRequest struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=20"`
+}
+
+// ListCredentialClientsRequest 获取凭据客户端历史请求参数
+type ListCredentialClientsRequest struct {
+	IP       string `form:"ip"` // 只返回该IP的记录
+	Page     int    `form:"page,default=1"`
+	PageSize int    `form:"page_size,default=20"`
+}
+
+// ListSecurityEventsRequest 获取凭据安全事件请求参数
+type ListSecurityEventsRequest struct {
+	Page     int `form:"page,default=1"`
+	PageSize int `form:"page_size,default=20"`
 }
 
 // UpdateCredentialRequest 更新SMTP凭据请求
 type UpdateCredentialRequest struct {
 	Name        string                         `json:"name" binding:"required,min=1,max=50" example:"Updated SMTP Credential"`
 	Description string                         `json:"description" binding:"max=200" example:"更新后的SMTP凭据描述"`
+	Mode        string                         `json:"mode" binding:"omitempty,oneof=normal sandbox" example:"sandbox"`
 	Settings    *models.SMTPCredentialSettings `json:"settings"`
 }
 
 // UpdateUserInfoRequest 更新用户信息请求
 type UpdateUserInfoRequest struct {
-	Username string               `json:"username" binding:"omitempty,min=3,max=50" example:"newusername"`
-	Settings *models.UserSettings `json:"settings"`
+	Username string                  `json:"username" binding:"omitempty,min=3,max=50" example:"newusername"`
+	Settings *UserPreferencesRequest `json:"settings"`
+}
+
+// UserPreferencesRequest 用户可以自行修改的设置，只修改提供的字段；配额、速率限制和归档保留天数由管理员设置
+type UserPreferencesRequest struct {
+	Timezone       *string   `json:"timezone,omitempty" example:"Asia/Shanghai"` // IANA时区，空字符串表示使用套餐时区
+	AllowedDomains *[]string `json:"allowed_domains,omitempty"`                  // 新建凭据默认允许的发件域名
 }
 
 // GetMailLogsRequest 获取MailLog请求参数
 type GetMailLogsRequest struct {
-	Page     int    `form:"page,default=1"`
-	PageSize int    `form:"page_size,default=20"`
-	Status   string `form:"status"`
-	From     string `form:"from"`
-	To       string `form:"to"`
-	DateFrom string `form:"date_from"`
-	DateTo   string `form:"date_to"`
+	Page     int      `form:"page,default=1"`
+	PageSize int      `form:"page_size,default=20"`
+	Status   string   `form:"status"`
+	From     string   `form:"from"`
+	To       string   `form:"to"`
+	DateFrom string   `form:"date_from"`
+	DateTo   string   `form:"date_to"`
+	Tag      string   `form:"tag"`      // X-Relay-Tag标签
+	Campaign string   `form:"campaign"` // X-Relay-Campaign活动ID
+	Metadata []string `form:"metadata"` // 元数据过滤，格式key:value，可重复
+}
+
+// GetGroupedStatsRequest 分组统计请求参数
+type GetGroupedStatsRequest struct {
+	GroupBy     string   `form:"group_by" binding:"required"` // tag, campaign, metadata
+	MetadataKey string   `form:"metadata_key"`                // group_by=metadata时的元数据键
+	Days        int      `form:"days,default=30"`             // 最近N天，默认30天
+	Tag         string   `form:"tag"`
+	Campaign    string   `form:"campaign"`
+	Metadata    []string `form:"metadata"`
 }
 
 // GetRecentMailLogsRequest 获取近期MailLog请求参数
@@ -114,6 +167,8 @@ type UserInfoResponse struct {
 	Username  string               `json:"username" example:"testuser"`
 	Email     string               `json:"email" example:"user@example.com"`
 	Status    string               `json:"status" example:"active"`
+	Role      string               `json:"role,omitempty" example:"admin"`
+	PlanID    string               `json:"plan_id,omitempty" example:"507f1f77bcf86cd799439012"`
 	Settings  *models.UserSettings `json:"settings"`
 	CreatedAt time.Time            `json:"created_at" example:"2023-01-01T00:00:00Z"`
 }
@@ -141,9 +196,64 @@ type CreateCredentialResponse struct {
 
 // ResetPasswordResponse 重置密码响应
 type ResetPasswordResponse struct {
-	Success  bool   `json:"success" example:"true"`
-	Message  string `json:"message" example:"密码重置成功"`
-	Password string `json:"password" example:"new_generated_password"`
+	Success    bool   `json:"success" example:"true"`
+	Message    string `json:"message" example:"密码重置成功"`
+	Password   string `json:"password" example:"new_generated_password"`
+	PasswordID string `json:"password_id" example:"9f86d081"`
+}
+
+// RotatePasswordResponse 轮换密码响应
+type RotatePasswordResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Credential *models.SMTPCredential `json:"credential"`
+		Password   string                 `json:"password" example:"new_generated_password"`
+	} `json:"data"`
+}
+
+// CredentialBaselineResponse 凭据行为基线响应
+type CredentialBaselineResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Baseline *models.CredentialBaseline `json:"baseline"` // 尚未学习时为null
+		Current  *models.CredentialActivity `json:"current"`  // 最近60分钟的发信行为
+	} `json:"data"`
+}
+
+// CredentialAnomalyListResponse 凭据异常告警列表响应
+type CredentialAnomalyListResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Anomalies []*models.CredentialAnomaly `json:"anomalies"`
+		Total     int64                       `json:"total" example:"3"`
+		Page      int                         `json:"page" example:"1"`
+		PageSize  int                         `json:"page_size" example:"20"`
+		Pages     int64                       `json:"pages" example:"1"`
+	} `json:"data"`
+}
+
+// CredentialClientListResponse 凭据客户端历史响应
+type CredentialClientListResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Clients  []*models.CredentialClient `json:"clients"`
+		Total    int64                      `json:"total" example:"5"`
+		Page     int                        `json:"page" example:"1"`
+		PageSize int                        `json:"page_size" example:"20"`
+		Pages    int64                      `json:"pages" example:"1"`
+	} `json:"data"`
+}
+
+// SecurityEventListResponse 安全事件列表响应
+type SecurityEventListResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		Events   []*models.SecurityEvent `json:"events"`
+		Total    int64                   `json:"total" example:"2"`
+		Page     int                     `json:"page" example:"1"`
+		PageSize int                     `json:"page_size" example:"20"`
+		Pages    int64                   `json:"pages" example:"1"`
+	} `json:"data"`
 }
 
 // MailLogResponse MailLog响应
@@ -188,14 +298,29 @@ type StatsResponse struct {
 	} `json:"data"`
 }
 
+// GroupedStatsResponse 分组统计响应
+type GroupedStatsResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		GroupBy     string                     `json:"group_by" example:"tag"`
+		MetadataKey string                     `json:"metadata_key,omitempty"`
+		Days        int                        `json:"days" example:"30"`
+		Groups      []*services.MailStatsGroup `json:"groups"`
+	} `json:"data"`
+}
+
 // QuotaStatsResponse 配额统计响应
 type QuotaStatsResponse struct {
 	Success bool `json:"success" example:"true"`
 	Data    struct {
 		UserSettings struct {
-			DailyQuota  int `json:"daily_quota" example:"1000"`
-			HourlyQuota int `json:"hourly_quota" example:"100"`
+			DailyQuota          int `json:"daily_quota" example:"1000"`
+			HourlyQuota         int `json:"hourly_quota" example:"100"`
+			MessagesPerSecond   int `json:"messages_per_second" example:"10"`
+			RecipientsPerMinute int `json:"recipients_per_minute" example:"600"`
 		} `json:"user_settings"`
+		UserUsage        *services.QuotaUsage     `json:"user_usage"`
+		Plan             *models.Plan             `json:"plan"` // 用户生效的套餐，没有套餐时为null
 		CredentialQuotas []map[string]interface{} `json:"credential_quotas"`
 	} `json:"data"`
 }
@@ -216,33 +341,67 @@ type RelayInfoResponse struct {
 
 // Server API服务器结构
 type Server struct {
-	config            *Config
-	db                *database.MongoDB
-	logger            *logrus.Logger
-	authService       *auth.Service
-	credentialService *services.SMTPCredentialService
-	mailLogService    *services.MailLogService
-	dkimService       *services.DKIMService
-	router            *gin.Engine
-	server            *http.Server
+	config               *Config
+	db                   *database.MongoDB
+	logger               *logrus.Logger
+	authService          *auth.Service
+	credentialService    *services.SMTPCredentialService
+	mailLogService       *services.MailLogService
+	dkimService          *services.DKIMService
+	sandboxService       *services.SandboxService
+	archiveService       *services.ArchiveService
+	smimeService         *services.SMIMEService
+	domainHealthService  *services.DomainHealthService
+	domainService        *services.DomainService
+	notificationService  *services.NotificationService
+	dkimRotationService  *services.DKIMRotationService
+	anomalyService       *services.CredentialAnomalyService
+	clientService        *services.CredentialClientService
+	rateLimitService     *services.RateLimitService
+	planService          *services.PlanService
+	usageService         *services.UsageService
+	userAdminService     *services.UserAdminService
+	smtpConfigService    *services.SMTPConfigService
+	messageVerifyService *services.MessageVerifyService
+	router               *gin.Engine
+	server               *http.Server
 }
 
 // Config API服务器配置
 type Config struct {
-	Port      string
-	SecretKey string
+	Port        string
+	SecretKey   string
+	RelayIPs    []string // 中继出口IP（用于域名健康检查）
+	RelayDomain string   // 中继域名，未配置RelayIPs时解析该域名获取IP
 }
 
 // NewServer 创建API服务器
-func NewServer(config *Config, db *database.MongoDB, logger *logrus.Logger, authService *auth.Service, credentialService *services.SMTPCredentialService, mailLogService *services.MailLogService) *Server {
+func NewServer(config *Config, db *database.MongoDB, logger *logrus.Logger, authService *auth.Service, credentialService *services.SMTPCredentialService, mailLogService *services.MailLogService, archiveService *services.ArchiveService, domainService *services.DomainService, notificationService *services.NotificationService, dkimRotationService *services.DKIMRotationService, anomalyService *services.CredentialAnomalyService, rateLimitService *services.RateLimitService, usageService *services.UsageService, encryptor *encryption.Encryptor, resolver mailauth.Resolver) *Server {
+	dkimService := services.NewDKIMService(db, encryptor, resolver, logger)
+
 	return &Server{
-		config:            config,
-		db:                db,
-		logger:            logger,
-		authService:       authService,
-		credentialService: credentialService,
-		mailLogService:    mailLogService,
-		dkimService:       services.NewDKIMService(db, logger),
+		config:               config,
+		db:                   db,
+		logger:               logger,
+		authService:          authService,
+		credentialService:    credentialService,
+		mailLogService:       mailLogService,
+		dkimService:          dkimService,
+		sandboxService:       services.NewSandboxService(db, encryptor, logger),
+		archiveService:       archiveService,
+		smimeService:         services.NewSMIMEService(db, encryptor, logger),
+		domainHealthService:  services.NewDomainHealthService(dkimService, resolver, config.RelayIPs, config.RelayDomain, logger),
+		domainService:        domainService,
+		notificationService:  notificationService,
+		dkimRotationService:  dkimRotationService,
+		anomalyService:       anomalyService,
+		clientService:        services.NewCredentialClientService(db, notificationService, logger),
+		rateLimitService:     rateLimitService,
+		planService:          services.NewPlanService(db, logger),
+		usageService:         usageService,
+		userAdminService:     services.NewUserAdminService(db, logger),
+		smtpConfigService:    services.NewSMTPConfigService(db, encryptor, logger),
+		messageVerifyService: services.NewMessageVerifyService(resolver, logger),
 	}
 }
 
@@ -314,7 +473,7 @@ func (s *Server) setupRoutes() {
 
 		// 需要认证的路由
 		authenticated := v1.Group("/")
-		authenticated.Use(s.authMiddleware())
+		authenticated.Use(s.authMiddleware(), s.roleMiddleware())
 		{
 			// 用户信息
 			authenticated.GET("/user", s.getUserInfo)
@@ -329,6 +488,14 @@ func (s *Server) setupRoutes() {
 				credentials.PUT("/:id", s.updateCredential)
 				credentials.DELETE("/:id", s.deleteCredential)
 				credentials.POST("/:id/reset-password", s.resetCredentialPassword)
+				credentials.POST("/:id/rotate-password", s.rotateCredentialPassword)
+				credentials.DELETE("/:id/previous-password", s.revokePreviousCredentialPassword)
+				credentials.PUT("/:id/expiration", s.setCredentialExpiration)
+				credentials.POST("/:id/enable", s.enableCredential)
+				credentials.GET("/:id/baseline", s.getCredentialBaseline)
+				credentials.GET("/:id/anomalies", s.listCredentialAnomalies)
+				credentials.GET("/:id/clients", s.listCredentialClients)
+				credentials.GET("/:id/security-events", s.listCredentialSecurityEvents)
 			}
 
 			// MailLog
@@ -337,6 +504,7 @@ func (s *Server) setupRoutes() {
 				logs.GET("", s.getMailLogs)
 				logs.GET("/recent", s.getRecentMailLogs)
 				logs.GET("/:id", s.getMailLog)
+				logs.GET("/:id/raw", s.getMailLogRaw)
 			}
 
 			// 统计信息
@@ -344,10 +512,32 @@ func (s *Server) setupRoutes() {
 			{
 				stats.GET("", s.getStats)
 				stats.GET("/quota", s.getQuotaStats)
+				stats.GET("/groups", s.getGroupedStats)
 			}
 
 			// DKIM管理
 			s.setupDKIMRoutes(authenticated)
+
+			// 沙箱邮件
+			s.setupSandboxRoutes(authenticated)
+
+			// S/MIME证书
+			s.setupSMIMERoutes(authenticated)
+
+			// 域名健康检查
+			s.setupDomainRoutes(authenticated)
+
+			// 用户通知
+			s.setupNotificationRoutes(authenticated)
+
+			// 用量计量
+			s.setupUsageRoutes(authenticated)
+
+			// 邮件认证验证工具
+			s.setupToolRoutes(authenticated)
+
+			// 管理员接口
+			s.setupAdminRoutes(authenticated)
 		}
 	}
 
@@ -433,6 +623,32 @@ func (s *Server) authMiddleware() gin.HandlerFunc {
 	}
 }
 
+// roleMiddleware 角色中间件，需在authMiddleware之后使用：拒绝已停用的用户，记录用户角色，只读用户只能执行查询请求
+func (s *Server) roleMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := s.authService.GetUserByID(c.GetString("user_id"))
+		if err != nil {
+			if err.Error() == "用户不存在" || err.Error() == "无效的用户ID" {
+				c.JSON(401, gin.H{"error": "用户不存在或已被停用"})
+			} else {
+				s.logger.WithError(err).Error("查询用户失败")
+				c.JSON(500, gin.H{"error": "服务器内部错误"})
+			}
+			c.Abort()
+			return
+		}
+
+		role := user.EffectiveRole()
+		c.Set("user_role", role)
+		if role == models.UserRoleReadOnly && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.JSON(403, gin.H{"error": "只读用户不能修改数据"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // 处理函数
 
 // healthCheck 健康检查
@@ -565,6 +781,8 @@ func (s *Server) getUserInfo(c *gin.Context) {
 		"username":   user.Username,
 		"email":      user.Email,
 		"status":     user.Status,
+		"role":       user.Role,
+		"plan_id":    user.PlanID,
 		"settings":   user.Settings,
 		"created_at": user.CreatedAt,
 	})
@@ -629,19 +847,20 @@ func (s *Server) updateUserInfo(c *gin.Context) {
 		updateData["username"] = req.Username
 	}
 
-	// 更新设置（如果提供）
+	// 更新设置（如果提供），只修改用户可以自行修改的字段
 	if req.Settings != nil {
-		// 验证配额设置
-		if req.Settings.DailyQuota < 0 || req.Settings.DailyQuota > 10000 {
-			c.JSON(400, gin.H{"error": "日配额必须在0-10000之间"})
-			return
+		update := services.UserSettingsUpdate{
+			Timezone:       req.Settings.Timezone,
+			AllowedDomains: req.Settings.AllowedDomains,
 		}
-		if req.Settings.HourlyQuota < 0 || req.Settings.HourlyQuota > 1000 {
-			c.JSON(400, gin.H{"error": "小时配额必须在0-1000之间"})
+		fields, err := update.SetFields()
+		if err != nil {
+			c.JSON(400, gin.H{"error": err.Error()})
 			return
 		}
-
-		updateData["settings"] = req.Settings
+		for key, value := range fields {
+			updateData[key] = value
+		}
 	}
 
 	// 执行更新
@@ -742,7 +961,7 @@ func (s *Server) createCredential(c *gin.Context) {
 	}
 
 	// 调用服务层创建凭据
-	credential, password, err := s.credentialService.CreateCredential(userID, req.Name, req.Description)
+	credential, password, err := s.credentialService.CreateCredential(userID, req.Name, req.Description, req.Mode, req.ExpiresAt)
 	if err != nil {
 		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("创建SMTP凭据失败")
 		c.JSON(400, gin.H{"error": err.Error()})
@@ -808,7 +1027,7 @@ func (s *Server) getCredential(c *gin.Context) {
 
 // updateCredential 更新SMTP凭据
 // @Summary 更新SMTP凭据
-// @Description 更新指定ID的SMTP凭据信息
+// @Description 更新指定ID的SMTP凭据信息。settings可限制凭据的发件地址（allowed_senders，支持*@example.com等本地部分通配符）、单封邮件大小（max_message_size）、是否要求TLS（require_tls）、允许的监听端口（allowed_ports）和发送时段（sending_hours）；存在速率上限时messages_per_second、recipients_per_minute必须在1到上限之间
 // @Tags SMTP Credentials
 // @Accept json
 // @Produce json
@@ -841,18 +1060,63 @@ func (s *Server) updateCredential(c *gin.Context) {
 		return
 	}
 
-	// 如果没有提供settings，使用默认值
+	// 速率限制只能低于套餐和管理员设置的上限
+	user, err := s.authService.GetUserByID(userID.Hex())
+	if err != nil {
+		c.JSON(404, gin.H{"error": "用户不存在"})
+		return
+	}
+	plan, err := s.planService.GetUserPlan(user)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取用户套餐失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	// 如果没有提供settings，使用默认值（存在速率上限时使用该上限）
+	messagesPerSecond, recipientsPerMinute := services.RateLimitCeiling(user, plan)
 	settings := models.SMTPCredentialSettings{
-		DailyQuota:    1000,
-		HourlyQuota:   100,
-		MaxRecipients: 100,
+		DailyQuota:          1000,
+		HourlyQuota:         100,
+		MaxRecipients:       100,
+		MessagesPerSecond:   messagesPerSecond,
+		RecipientsPerMinute: recipientsPerMinute,
 	}
 	if req.Settings != nil {
 		settings = *req.Settings
 	}
 
+	// 验证去重时间窗口
+	if settings.DedupWindowMinutes < 0 || settings.DedupWindowMinutes > 1440 {
+		c.JSON(400, gin.H{"error": "去重时间窗口必须在0-1440分钟之间"})
+		return
+	}
+
+	// 校验发件地址规则、邮件大小、端口和发送时段
+	if err := services.ValidateCredentialSettings(&settings); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	if err := services.ValidateCredentialRateLimits(&settings, user, plan); err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 限制发件域名（包括发件地址规则中的域名）必须是已验证的域名
+	for _, domain := range append(settings.AllowedDomains, services.SenderPatternDomains(settings.AllowedSenders)...) {
+		if err := s.domainService.RequireVerifiedDomain(userID, domain); err != nil {
+			if err.Error() == "域名未验证" {
+				c.JSON(400, gin.H{"error": fmt.Sprintf("域名未验证: %s", domain)})
+			} else {
+				s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("检查域名验证状态失败")
+				c.JSON(500, gin.H{"error": "服务器内部错误"})
+			}
+			return
+		}
+	}
+
 	// 调用服务层更新凭据
-	err = s.credentialService.UpdateCredential(userID, credentialID, req.Name, req.Description, settings)
+	err = s.credentialService.UpdateCredential(userID, credentialID, req.Name, req.Description, req.Mode, settings)
 	if err != nil {
 		if err.Error() == "SMTP凭据不存在" {
 			c.JSON(404, gin.H{"error": "SMTP凭据不存在"})
@@ -922,7 +1186,7 @@ func (s *Server) deleteCredential(c *gin.Context) {
 
 // resetCredentialPassword 重置凭据密码
 // @Summary 重置SMTP凭据密码
-// @Description 重置指定ID的SMTP凭据密码
+// @Description 重置指定ID的SMTP凭据密码，旧密码立即失效；需要平滑切换时请使用轮换接口
 // @Tags SMTP Credentials
 // @Accept json
 // @Produce json
@@ -948,7 +1212,7 @@ func (s *Server) resetCredentialPassword(c *gin.Context) {
 	}
 
 	// 调用服务层重置密码
-	newPassword, err := s.credentialService.ResetPassword(userID, credentialID)
+	newPassword, passwordID, err := s.credentialService.ResetPassword(userID, credentialID)
 	if err != nil {
 		if err.Error() == "SMTP凭据不存在" {
 			c.JSON(404, gin.H{"error": "SMTP凭据不存在"})
@@ -963,34 +1227,35 @@ func (s *Server) resetCredentialPassword(c *gin.Context) {
 	}
 
 	c.JSON(200, gin.H{
-		"success":  true,
-		"message":  "密码重置成功",
-		"password": newPassword,
+		"success":     true,
+		"message":     "密码重置成功",
+		"password":    newPassword,
+		"password_id": passwordID,
 	})
 }
 
-// getMailLogs 获取MailLog
-// @Summary 获取MailLog
-// @Description 获取当前用户的邮件发送日志，支持分页和筛选
-// @Tags MailLog
+// rotateCredentialPassword 轮换凭据密码
+// @Summary 轮换SMTP凭据密码
+// @Description 生成新密码，旧密码在宽限期内仍可用于认证，以便逐台更新使用该凭据的应用服务器；宽限期结束后旧密码自动失效
+// @Tags SMTP Credentials
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param page query int false "页码" default(1)
-// @Param page_size query int false "每页数量" default(20)
-// @Param status query string false "邮件状态" Enums(queued,sending,sent,failed)
-// @Param from query string false "发件人筛选"
-// @Param to query string false "收件人筛选"
-// @Param date_from query string false "开始日期" format(date)
-// @Param date_to query string false "结束日期" format(date)
-// @Success 200 {object} MailLogListResponse "获取成功"
+// @Param id path string true "凭据ID"
+// @Param body body RotateCredentialPasswordRequest false "轮换参数"
+// @Success 200 {object} RotatePasswordResponse "轮换成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
 // @Failure 401 {object} APIResponse "未授权"
-// @Router /api/v1/logs [get]
-func (s *Server) getMailLogs(c *gin.Context) {
-	var req GetMailLogsRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(400, gin.H{"error": "请求参数错误"})
-		return
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Failure 409 {object} APIResponse "旧密码仍在宽限期内或凭据已禁用"
+// @Router /api/v1/credentials/{id}/rotate-password [post]
+func (s *Server) rotateCredentialPassword(c *gin.Context) {
+	var req RotateCredentialPasswordRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(400, gin.H{"error": "请求参数错误"})
+			return
+		}
 	}
 
 	// 获取用户ID
@@ -1000,49 +1265,55 @@ func (s *Server) getMailLogs(c *gin.Context) {
 		return
 	}
 
-	// 参数验证
-	if req.Page < 1 {
-		req.Page = 1
-	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 20
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return
 	}
 
-	// 调用服务层获取MailLog
-	mailLogs, total, err := s.mailLogService.GetMailLogsByUser(
-		userID, req.Page, req.PageSize, req.Status, req.From, req.To, req.DateFrom, req.DateTo,
-	)
+	// 调用服务层轮换密码
+	credential, newPassword, err := s.credentialService.RotatePassword(userID, credentialID, time.Duration(req.GracePeriodHours)*time.Hour)
 	if err != nil {
-		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取MailLog失败")
-		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		switch err.Error() {
+		case "SMTP凭据不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		case "上一次轮换的旧密码仍在宽限期内", "SMTP凭据已禁用", "SMTP凭据已被修改，请重试":
+			c.JSON(409, gin.H{"error": err.Error()})
+		case "宽限期不能超过30天":
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":       userID.Hex(),
+				"credential_id": credentialID.Hex(),
+			}).Error("轮换SMTP凭据密码失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"success": true,
 		"data": gin.H{
-			"mail_logs": mailLogs,
-			"total":     total,
-			"page":      req.Page,
-			"page_size": req.PageSize,
-			"pages":     (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+			"credential": credential,
+			"password":   newPassword,
 		},
 	})
 }
 
-// getMailLog 获取单个MailLog
-// @Summary 获取单个MailLog
-// @Description 获取指定ID的MailLog详情
-// @Tags MailLog
+// revokePreviousCredentialPassword 撤销宽限期内的旧密码
+// @Summary 撤销SMTP凭据旧密码
+// @Description 提前结束密码轮换的宽限期，旧密码立即失效
+// @Tags SMTP Credentials
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param id path string true "MailLogID"
-// @Success 200 {object} MailLogResponse "获取成功"
+// @Param id path string true "凭据ID"
+// @Success 200 {object} APIResponse "撤销成功"
 // @Failure 401 {object} APIResponse "未授权"
-// @Failure 404 {object} APIResponse "MailLog不存在"
-// @Router /api/v1/logs/{id} [get]
-func (s *Server) getMailLog(c *gin.Context) {
+// @Failure 404 {object} APIResponse "凭据不存在或没有旧密码"
+// @Router /api/v1/credentials/{id}/previous-password [delete]
+func (s *Server) revokePreviousCredentialPassword(c *gin.Context) {
 	// 获取用户ID
 	userID, err := s.getUserObjectID(c)
 	if err != nil {
@@ -1050,53 +1321,49 @@ func (s *Server) getMailLog(c *gin.Context) {
 		return
 	}
 
-	// 获取MailLogID
-	mailLogIDStr := c.Param("id")
-	mailLogID, err := primitive.ObjectIDFromHex(mailLogIDStr)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "无效的MailLogID"})
-		return
-	}
-
-	// 调用服务层获取MailLog
-	mailLog, err := s.mailLogService.GetMailLogByID(userID, mailLogID)
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
 	if err != nil {
-		s.logger.WithError(err).WithFields(logrus.Fields{
-			"user_id":     userID.Hex(),
-			"mail_log_id": mailLogID.Hex(),
-		}).Error("获取MailLog失败")
-		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
 		return
 	}
 
-	if mailLog == nil {
-		c.JSON(404, gin.H{"error": "MailLog不存在"})
+	if err := s.credentialService.RevokePreviousPassword(userID, credentialID); err != nil {
+		if err.Error() == "SMTP凭据不存在" || err.Error() == "没有处于宽限期的旧密码" {
+			c.JSON(404, gin.H{"error": err.Error()})
+		} else {
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":       userID.Hex(),
+				"credential_id": credentialID.Hex(),
+			}).Error("撤销SMTP凭据旧密码失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"success": true,
-		"data":    mailLog,
+		"message": "旧密码已撤销",
 	})
 }
 
-// getRecentMailLogs 获取近期MailLog
-// @Summary 获取近期MailLog
-// @Description 获取用户近期发信历史，支持天数筛选和状态筛选，包含统计信息
-// @Tags MailLog
+// setCredentialExpiration 设置凭据过期时间
+// @Summary 设置SMTP凭据过期时间
+// @Description 设置或清除凭据的过期时间，过期后凭据被自动禁用；为因过期被禁用的凭据设置新的过期时间后凭据恢复可用
+// @Tags SMTP Credentials
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param days query int false "最近N天" default(30) minimum(1) maximum(365)
-// @Param status query string false "邮件状态" Enums(queued,sending,sent,failed)
-// @Param page query int false "页码" default(1)
-// @Param page_size query int false "每页数量" default(50) minimum(1) maximum(100)
-// @Success 200 {object} RecentMailLogsResponse "获取成功"
+// @Param id path string true "凭据ID"
+// @Param body body SetCredentialExpirationRequest true "过期时间"
+// @Success 200 {object} CredentialResponse "设置成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
 // @Failure 401 {object} APIResponse "未授权"
-// @Router /api/v1/logs/recent [get]
-func (s *Server) getRecentMailLogs(c *gin.Context) {
-	var req GetRecentMailLogsRequest
-	if err := c.ShouldBindQuery(&req); err != nil {
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Router /api/v1/credentials/{id}/expiration [put]
+func (s *Server) setCredentialExpiration(c *gin.Context) {
+	var req SetCredentialExpirationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "请求参数错误"})
 		return
 	}
@@ -1108,52 +1375,51 @@ func (s *Server) getRecentMailLogs(c *gin.Context) {
 		return
 	}
 
-	// 参数验证
-	if req.Page < 1 {
-		req.Page = 1
-	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 50
-	}
-	if req.Days < 1 || req.Days > 365 {
-		req.Days = 30
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return
 	}
 
-	// 调用服务层获取近期MailLog
-	mailLogs, total, statistics, err := s.mailLogService.GetRecentMailLogsByUser(
-		userID, req.Days, req.Page, req.PageSize, req.Status,
-	)
+	credential, err := s.credentialService.SetExpiration(userID, credentialID, req.ExpiresAt)
 	if err != nil {
-		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取近期MailLog失败")
-		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		switch err.Error() {
+		case "SMTP凭据不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		case "过期时间必须晚于当前时间":
+			c.JSON(400, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":       userID.Hex(),
+				"credential_id": credentialID.Hex(),
+			}).Error("设置SMTP凭据过期时间失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"success": true,
-		"data": gin.H{
-			"mail_logs":  mailLogs,
-			"total":      total,
-			"page":       req.Page,
-			"page_size":  req.PageSize,
-			"pages":      (total + int64(req.PageSize) - 1) / int64(req.PageSize),
-			"days":       req.Days,
-			"statistics": statistics,
-		},
+		"data":    credential,
 	})
 }
 
-// getStats 获取统计信息
-// @Summary 获取统计信息
-// @Description 获取用户邮件统计信息和凭据统计信息
-// @tags status
+// enableCredential 重新启用凭据
+// @Summary 重新启用SMTP凭据
+// @Description 重新启用因发信行为异常（疑似泄露）被自动停用的凭据。停用后必须先重置密码才能启用
+// @Tags SMTP Credentials
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} StatsResponse "获取成功"
+// @Param id path string true "凭据ID"
+// @Success 200 {object} CredentialResponse "启用成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
 // @Failure 401 {object} APIResponse "未授权"
-// @Router /api/v1/stats [get]
-func (s *Server) getStats(c *gin.Context) {
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Failure 409 {object} APIResponse "需要先重置密码或凭据不能手动启用"
+// @Router /api/v1/credentials/{id}/enable [post]
+func (s *Server) enableCredential(c *gin.Context) {
 	// 获取用户ID
 	userID, err := s.getUserObjectID(c)
 	if err != nil {
@@ -1161,9 +1427,539 @@ func (s *Server) getStats(c *gin.Context) {
 		return
 	}
 
-	// 获取邮件统计信息
-	mailStats, err := s.mailLogService.GetUserMailStats(userID)
-	if err != nil {
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return
+	}
+
+	credential, err := s.credentialService.EnableCredential(userID, credentialID)
+	if err != nil {
+		switch err.Error() {
+		case "SMTP凭据不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		case "凭据疑似泄露，请先重置密码", "该凭据不能手动启用", "SMTP凭据已被修改，请重试":
+			c.JSON(409, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithFields(logrus.Fields{
+				"user_id":       userID.Hex(),
+				"credential_id": credentialID.Hex(),
+			}).Error("启用SMTP凭据失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    credential,
+	})
+}
+
+// getCredentialBaseline 获取凭据行为基线
+// @Summary 获取SMTP凭据行为基线
+// @Description 获取异常检测为凭据学习的发信行为基线（每小时邮件数、客户端IP数、收件域名数、投递失败率的均值和标准差）以及最近60分钟的实际行为。
+// @Description 基线只学习有发信的小时，至少学习24小时后才开始检测
+// @Tags SMTP Credentials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Success 200 {object} CredentialBaselineResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Router /api/v1/credentials/{id}/baseline [get]
+func (s *Server) getCredentialBaseline(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return
+	}
+
+	baseline, current, err := s.anomalyService.GetBaseline(userID, credentialID)
+	if err != nil {
+		if err.Error() == "SMTP凭据不存在" {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Error("获取凭据行为基线失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"baseline": baseline,
+			"current":  current,
+		},
+	})
+}
+
+// listCredentialAnomalies 获取凭据异常告警
+// @Summary 获取SMTP凭据异常告警
+// @Description 获取凭据发信行为偏离基线时记录的告警，包括偏离的指标、当时的行为和处理结果（alerted或suspended）
+// @Tags SMTP Credentials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} CredentialAnomalyListResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Router /api/v1/credentials/{id}/anomalies [get]
+func (s *Server) listCredentialAnomalies(c *gin.Context) {
+	var req ListCredentialAnomaliesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return
+	}
+
+	// 参数验证
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	anomalies, total, err := s.anomalyService.ListAnomalies(userID, credentialID, req.Page, req.PageSize)
+	if err != nil {
+		if err.Error() == "SMTP凭据不存在" {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Error("获取凭据异常告警失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"anomalies": anomalies,
+			"total":     total,
+			"page":      req.Page,
+			"page_size": req.PageSize,
+			"pages":     (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// listCredentialClients 获取凭据客户端历史
+// @Summary 获取SMTP凭据客户端历史
+// @Description 获取使用该凭据认证过的客户端，按来源IP、EHLO名称、TLS版本和认证机制区分，包含首次/最近使用时间和认证次数，最近使用的在前
+// @Tags SMTP Credentials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Param ip query string false "只返回该IP的记录"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} CredentialClientListResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Router /api/v1/credentials/{id}/clients [get]
+func (s *Server) listCredentialClients(c *gin.Context) {
+	var req ListCredentialClientsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return
+	}
+
+	// 参数验证
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	clients, total, err := s.clientService.ListClients(userID, credentialID, req.IP, req.Page, req.PageSize)
+	if err != nil {
+		if err.Error() == "SMTP凭据不存在" {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Error("获取凭据客户端历史失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"clients":   clients,
+			"total":     total,
+			"page":      req.Page,
+			"page_size": req.PageSize,
+			"pages":     (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// listCredentialSecurityEvents 获取凭据安全事件
+// @Summary 获取SMTP凭据安全事件
+// @Description 获取凭据相关的安全事件（如首次从新IP认证），最新的在前
+// @Tags SMTP Credentials
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "凭据ID"
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Success 200 {object} SecurityEventListResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "凭据不存在"
+// @Router /api/v1/credentials/{id}/security-events [get]
+func (s *Server) listCredentialSecurityEvents(c *gin.Context) {
+	var req ListSecurityEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取凭据ID
+	credentialID, err := s.getCredentialID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的凭据ID"})
+		return
+	}
+
+	// 参数验证
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+
+	events, total, err := s.clientService.ListSecurityEvents(userID, credentialID, req.Page, req.PageSize)
+	if err != nil {
+		if err.Error() == "SMTP凭据不存在" {
+			c.JSON(404, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Error("获取凭据安全事件失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"events":    events,
+			"total":     total,
+			"page":      req.Page,
+			"page_size": req.PageSize,
+			"pages":     (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// getMailLogs 获取MailLog
+// @Summary 获取MailLog
+// @Description 获取当前用户的邮件发送日志，支持分页和筛选
+// @Tags MailLog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(20)
+// @Param status query string false "邮件状态" Enums(queued,sending,sent,failed)
+// @Param from query string false "发件人筛选"
+// @Param to query string false "收件人筛选"
+// @Param date_from query string false "开始日期" format(date)
+// @Param date_to query string false "结束日期" format(date)
+// @Param tag query string false "标签筛选（X-Relay-Tag）"
+// @Param campaign query string false "活动ID筛选（X-Relay-Campaign）"
+// @Param metadata query []string false "元数据筛选，格式key:value，可重复（须全部匹配）" collectionFormat(multi)
+// @Success 200 {object} MailLogListResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/logs [get]
+func (s *Server) getMailLogs(c *gin.Context) {
+	var req GetMailLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	s.listMailLogs(c, userID, &req)
+}
+
+// listMailLogs 按查询条件分页输出用户的MailLog
+func (s *Server) listMailLogs(c *gin.Context, userID primitive.ObjectID, req *GetMailLogsRequest) {
+	// 参数验证
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 20
+	}
+	metadata, err := services.ParseMetadataFilters(req.Metadata)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	attribution := services.MailAttributionFilter{Tag: req.Tag, CampaignID: req.Campaign, Metadata: metadata}
+
+	// 调用服务层获取MailLog
+	mailLogs, total, err := s.mailLogService.GetMailLogsByUser(
+		userID, req.Page, req.PageSize, req.Status, req.From, req.To, req.DateFrom, req.DateTo, attribution,
+	)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取MailLog失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"mail_logs": mailLogs,
+			"total":     total,
+			"page":      req.Page,
+			"page_size": req.PageSize,
+			"pages":     (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+		},
+	})
+}
+
+// getMailLog 获取单个MailLog
+// @Summary 获取单个MailLog
+// @Description 获取指定ID的MailLog详情
+// @Tags MailLog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "MailLogID"
+// @Success 200 {object} MailLogResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "MailLog不存在"
+// @Router /api/v1/logs/{id} [get]
+func (s *Server) getMailLog(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取MailLogID
+	mailLogIDStr := c.Param("id")
+	mailLogID, err := primitive.ObjectIDFromHex(mailLogIDStr)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的MailLogID"})
+		return
+	}
+
+	// 调用服务层获取MailLog
+	mailLog, err := s.mailLogService.GetMailLogByID(userID, mailLogID)
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":     userID.Hex(),
+			"mail_log_id": mailLogID.Hex(),
+		}).Error("获取MailLog失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	if mailLog == nil {
+		c.JSON(404, gin.H{"error": "MailLog不存在"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    mailLog,
+	})
+}
+
+// getMailLogRaw 下载归档的原始邮件
+// @Summary 下载归档的原始邮件
+// @Description 以.eml格式下载指定MailLog对应的归档原始邮件
+// @Tags MailLog
+// @Produce octet-stream
+// @Security BearerAuth
+// @Param id path string true "MailLogID"
+// @Success 200 {file} file "邮件原文"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "归档邮件不存在"
+// @Router /api/v1/logs/{id}/raw [get]
+func (s *Server) getMailLogRaw(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取MailLogID
+	mailLogID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的MailLogID"})
+		return
+	}
+
+	_, raw, err := s.archiveService.GetMessage(userID, mailLogID)
+	if err != nil {
+		switch err.Error() {
+		case "MailLog不存在", "归档邮件不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithField("mail_log_id", mailLogID.Hex()).Error("获取归档邮件失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s.eml", mailLogID.Hex()))
+	c.Data(200, "message/rfc822", raw)
+}
+
+// getRecentMailLogs 获取近期MailLog
+// @Summary 获取近期MailLog
+// @Description 获取用户近期发信历史，支持天数筛选和状态筛选，包含统计信息
+// @Tags MailLog
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param days query int false "最近N天" default(30) minimum(1) maximum(365)
+// @Param status query string false "邮件状态" Enums(queued,sending,sent,failed)
+// @Param page query int false "页码" default(1)
+// @Param page_size query int false "每页数量" default(50) minimum(1) maximum(100)
+// @Success 200 {object} RecentMailLogsResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/logs/recent [get]
+func (s *Server) getRecentMailLogs(c *gin.Context) {
+	var req GetRecentMailLogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 参数验证
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 100 {
+		req.PageSize = 50
+	}
+	if req.Days < 1 || req.Days > 365 {
+		req.Days = 30
+	}
+
+	// 调用服务层获取近期MailLog
+	mailLogs, total, statistics, err := s.mailLogService.GetRecentMailLogsByUser(
+		userID, req.Days, req.Page, req.PageSize, req.Status,
+	)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取近期MailLog失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"mail_logs":  mailLogs,
+			"total":      total,
+			"page":       req.Page,
+			"page_size":  req.PageSize,
+			"pages":      (total + int64(req.PageSize) - 1) / int64(req.PageSize),
+			"days":       req.Days,
+			"statistics": statistics,
+		},
+	})
+}
+
+// getStats 获取统计信息
+// @Summary 获取统计信息
+// @Description 获取用户邮件统计信息和凭据统计信息
+// @tags status
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} StatsResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/stats [get]
+func (s *Server) getStats(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	// 获取邮件统计信息
+	mailStats, err := s.mailLogService.GetUserMailStats(userID)
+	if err != nil {
 		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取邮件统计信息失败")
 		c.JSON(500, gin.H{"error": "服务器内部错误"})
 		return
@@ -1200,9 +1996,76 @@ func (s *Server) getStats(c *gin.Context) {
 	})
 }
 
+// getGroupedStats 按标签、活动ID或元数据分组统计
+// @Summary 分组邮件统计
+// @Description 按客户端通过X-Relay-Tag、X-Relay-Campaign、X-Relay-Metadata头部附加的标签、活动ID或元数据键分组统计最近N天的邮件数量和状态，按数量降序最多返回100组。
+// @Description 分组值为空字符串表示邮件未设置该项；一封邮件有多个标签时分别计入每个标签
+// @tags status
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param group_by query string true "分组维度" Enums(tag,campaign,metadata)
+// @Param metadata_key query string false "元数据键（group_by=metadata时必填）"
+// @Param days query int false "最近N天" default(30) minimum(1) maximum(365)
+// @Param tag query string false "标签筛选"
+// @Param campaign query string false "活动ID筛选"
+// @Param metadata query []string false "元数据筛选，格式key:value，可重复" collectionFormat(multi)
+// @Success 200 {object} GroupedStatsResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/stats/groups [get]
+func (s *Server) getGroupedStats(c *gin.Context) {
+	var req GetGroupedStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	if req.Days < 1 || req.Days > 365 {
+		req.Days = 30
+	}
+	metadata, err := services.ParseMetadataFilters(req.Metadata)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	attribution := services.MailAttributionFilter{Tag: req.Tag, CampaignID: req.Campaign, Metadata: metadata}
+
+	groups, err := s.mailLogService.GetMailStatsByGroup(userID, req.GroupBy, req.MetadataKey, req.Days, attribution)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "无效的分组维度") || strings.HasPrefix(err.Error(), "无效的元数据键") {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取分组统计失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	data := gin.H{
+		"group_by": req.GroupBy,
+		"days":     req.Days,
+		"groups":   groups,
+	}
+	if req.GroupBy == services.MailStatsGroupByMetadata {
+		data["metadata_key"] = req.MetadataKey
+	}
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
 // getQuotaStats 获取配额统计
 // @Summary 获取配额统计
-// @Description 获取用户邮件配额使用情况统计
+// @Description 获取用户和各SMTP凭据的配额使用情况（按收件人数计量，周期为用户时区（未设置时使用套餐时区，都未设置时为UTC）的整点小时、自然日和自然月）、用户生效的套餐，以及各凭据速率限制令牌桶的当前可用令牌数
 // @tags status
 // @Accept json
 // @Produce json
@@ -1233,20 +2096,32 @@ func (s *Server) getQuotaStats(c *gin.Context) {
 		return
 	}
 
+	plan, err := s.planService.GetUserPlan(user)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取用户套餐失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	// 配额按收件人数计量
+	userUsage, err := s.mailLogService.GetQuotaUsage(userID, nil)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取配额用量失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
 	quotaStats := make([]map[string]interface{}, 0)
 	for _, credential := range credentials {
-		credStats, err := s.mailLogService.GetCredentialMailStats(userID, credential.ID)
+		usage, err := s.mailLogService.GetQuotaUsage(userID, &credential.ID)
 		if err != nil {
 			continue
 		}
+		hourUsed, todayUsed := usage.HourlyUsed, usage.DailyUsed
 
-		todayUsed := int64(0)
-		hourUsed := int64(0)
-		if credStats["today_total"] != nil {
-			todayUsed = credStats["today_total"].(int64)
-		}
-		if credStats["hour_total"] != nil {
-			hourUsed = credStats["hour_total"].(int64)
+		rateLimits, err := s.rateLimitService.Status(user, credential, plan)
+		if err != nil {
+			s.logger.WithError(err).WithField("credential_id", credential.ID.Hex()).Warn("获取速率限制状态失败")
 		}
 
 		quotaStats = append(quotaStats, map[string]interface{}{
@@ -1258,6 +2133,7 @@ func (s *Server) getQuotaStats(c *gin.Context) {
 			"hourly_quota":     credential.Settings.HourlyQuota,
 			"hourly_used":      hourUsed,
 			"hourly_remaining": int64(credential.Settings.HourlyQuota) - hourUsed,
+			"rate_limits":      rateLimits,
 		})
 	}
 
@@ -1265,9 +2141,14 @@ func (s *Server) getQuotaStats(c *gin.Context) {
 		"success": true,
 		"data": gin.H{
 			"user_settings": gin.H{
-				"daily_quota":  user.Settings.DailyQuota,
-				"hourly_quota": user.Settings.HourlyQuota,
+				"daily_quota":           user.Settings.DailyQuota,
+				"hourly_quota":          user.Settings.HourlyQuota,
+				"messages_per_second":   user.Settings.MessagesPerSecond,
+				"recipients_per_minute": user.Settings.RecipientsPerMinute,
 			},
+			"user_usage":        userUsage,
+			"plan":              plan,
+			"timezone":          services.QuotaLocation(user, plan).String(),
 			"credential_quotas": quotaStats,
 		},
 	})
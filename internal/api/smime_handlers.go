@@ -0,0 +1,237 @@
+package api
+
+import (
+	"fmt"
+
+	"smtp-relay/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// S/MIME相关请求结构体
+
+// UploadSMIMECertificateRequest 上传S/MIME证书请求
+type UploadSMIMECertificateRequest struct {
+	Sender      string `json:"sender" binding:"required" example:"billing@example.com"` // 发件地址或域名
+	Certificate string `json:"certificate" binding:"required"`                          // PEM格式证书（可包含中间证书）
+	PrivateKey  string `json:"private_key" binding:"required"`                          // PEM格式私钥
+}
+
+// S/MIME相关响应结构体
+
+// SMIMECertificateView S/MIME证书信息（包含有效期状态）
+type SMIMECertificateView struct {
+	*models.SMIMECertificate
+	ExpiryStatus    string `json:"expiry_status" example:"valid"` // valid, expiring, expired
+	DaysUntilExpiry int    `json:"days_until_expiry" example:"180"`
+}
+
+// SMIMECertificateResponse S/MIME证书响应
+type SMIMECertificateResponse struct {
+	Success bool                  `json:"success" example:"true"`
+	Data    *SMIMECertificateView `json:"data"`
+}
+
+// SMIMECertificateListResponse S/MIME证书列表响应
+type SMIMECertificateListResponse struct {
+	Success  bool                    `json:"success" example:"true"`
+	Data     []*SMIMECertificateView `json:"data"`
+	Warnings []string                `json:"warnings"`
+}
+
+// setupSMIMERoutes 设置S/MIME相关路由
+func (s *Server) setupSMIMERoutes(authenticated *gin.RouterGroup) {
+	smime := authenticated.Group("/smime")
+	{
+		smime.GET("/certificates", s.listSMIMECertificates)
+		smime.POST("/certificates", s.uploadSMIMECertificate)
+		smime.GET("/certificates/:id", s.getSMIMECertificate)
+		smime.DELETE("/certificates/:id", s.deleteSMIMECertificate)
+	}
+}
+
+// listSMIMECertificates 获取S/MIME证书列表
+// @Summary 获取S/MIME证书列表
+// @Description 获取当前用户的所有S/MIME证书，即将过期或已过期的证书会在warnings中提示
+// @Tags SMIME
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SMIMECertificateListResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/smime/certificates [get]
+func (s *Server) listSMIMECertificates(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	certificates, err := s.smimeService.ListCertificates(userID)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取S/MIME证书列表失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	views := make([]*SMIMECertificateView, 0, len(certificates))
+	warnings := make([]string, 0)
+	for _, certificate := range certificates {
+		view := newSMIMECertificateView(certificate)
+		views = append(views, view)
+
+		switch view.ExpiryStatus {
+		case "expired":
+			warnings = append(warnings, fmt.Sprintf("%s的S/MIME证书已于%s过期，邮件将不再签名", certificate.Sender, certificate.NotAfter.Format("2006-01-02")))
+		case "expiring":
+			warnings = append(warnings, fmt.Sprintf("%s的S/MIME证书将在%d天后过期", certificate.Sender, view.DaysUntilExpiry))
+		}
+	}
+
+	c.JSON(200, gin.H{
+		"success":  true,
+		"data":     views,
+		"warnings": warnings,
+	})
+}
+
+// uploadSMIMECertificate 上传S/MIME证书
+// @Summary 上传S/MIME证书
+// @Description 为发件地址或域名上传S/MIME证书和私钥，匹配的发件人邮件将以multipart/signed格式签名
+// @Tags SMIME
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body UploadSMIMECertificateRequest true "证书信息"
+// @Success 201 {object} SMIMECertificateResponse "上传成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/smime/certificates [post]
+func (s *Server) uploadSMIMECertificate(c *gin.Context) {
+	var req UploadSMIMECertificateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	certificate, err := s.smimeService.UploadCertificate(userID, req.Sender, req.Certificate, req.PrivateKey)
+	if err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id": userID.Hex(),
+			"sender":  req.Sender,
+		}).Warn("上传S/MIME证书失败")
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(201, gin.H{
+		"success": true,
+		"data":    newSMIMECertificateView(certificate),
+	})
+}
+
+// getSMIMECertificate 获取S/MIME证书
+// @Summary 获取单个S/MIME证书
+// @Description 获取指定ID的S/MIME证书详情及有效期状态
+// @Tags SMIME
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "证书ID"
+// @Success 200 {object} SMIMECertificateResponse "获取成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "证书不存在"
+// @Router /api/v1/smime/certificates/{id} [get]
+func (s *Server) getSMIMECertificate(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	certificateID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的证书ID"})
+		return
+	}
+
+	certificate, err := s.smimeService.GetCertificate(userID, certificateID)
+	if err != nil {
+		if err.Error() == "S/MIME证书不存在" {
+			c.JSON(404, gin.H{"error": "S/MIME证书不存在"})
+		} else {
+			s.logger.WithError(err).WithField("certificate_id", certificateID.Hex()).Error("获取S/MIME证书失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    newSMIMECertificateView(certificate),
+	})
+}
+
+// deleteSMIMECertificate 删除S/MIME证书
+// @Summary 删除S/MIME证书
+// @Description 删除指定的S/MIME证书，之后该发件人的邮件不再签名
+// @Tags SMIME
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "证书ID"
+// @Success 200 {object} APIResponse "删除成功"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 404 {object} APIResponse "证书不存在"
+// @Router /api/v1/smime/certificates/{id} [delete]
+func (s *Server) deleteSMIMECertificate(c *gin.Context) {
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	certificateID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的证书ID"})
+		return
+	}
+
+	if err := s.smimeService.DeleteCertificate(userID, certificateID); err != nil {
+		if err.Error() == "S/MIME证书不存在" {
+			c.JSON(404, gin.H{"error": "S/MIME证书不存在"})
+		} else {
+			s.logger.WithError(err).WithField("certificate_id", certificateID.Hex()).Error("删除S/MIME证书失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"message": "S/MIME证书删除成功",
+	})
+}
+
+// 辅助函数
+
+// newSMIMECertificateView 构建包含有效期状态的证书信息
+func newSMIMECertificateView(certificate *models.SMIMECertificate) *SMIMECertificateView {
+	return &SMIMECertificateView{
+		SMIMECertificate: certificate,
+		ExpiryStatus:     certificate.ExpiryStatus(),
+		DaysUntilExpiry:  certificate.DaysUntilExpiry(),
+	}
+}
@@ -0,0 +1,203 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"smtp-relay/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 用量相关请求结构体
+
+// GetUsageRequest 获取用量记录请求参数
+type GetUsageRequest struct {
+	From         string `form:"from" binding:"required"` // 开始日期 YYYY-MM-DD（用户时区，包含）
+	To           string `form:"to" binding:"required"`   // 结束日期 YYYY-MM-DD（用户时区，包含）
+	CredentialID string `form:"credential_id"`
+	Format       string `form:"format,default=json"` // json, csv
+}
+
+// GetUsageInvoiceRequest 获取月度用量汇总请求参数
+type GetUsageInvoiceRequest struct {
+	Month string `form:"month" binding:"required"` // 月份 YYYY-MM（用户时区）
+}
+
+// 用量相关响应结构体
+
+// UsageResponse 用量记录响应
+type UsageResponse struct {
+	Success bool `json:"success" example:"true"`
+	Data    struct {
+		From    string                `json:"from" example:"2026-09-01"`
+		To      string                `json:"to" example:"2026-09-30"`
+		Records []*models.UsageRecord `json:"records"`
+		Totals  models.UsageCounts    `json:"totals"`
+	} `json:"data"`
+}
+
+// UsageInvoiceResponse 月度用量汇总响应
+type UsageInvoiceResponse struct {
+	Success bool                 `json:"success" example:"true"`
+	Data    *models.UsageInvoice `json:"data"`
+}
+
+// setupUsageRoutes 设置用量相关路由
+func (s *Server) setupUsageRoutes(authenticated *gin.RouterGroup) {
+	usage := authenticated.Group("/usage")
+	{
+		usage.GET("", s.getUsage)
+		usage.GET("/invoice", s.getUsageInvoice)
+	}
+}
+
+// getUsage 获取每日用量记录
+// @Summary 获取每日用量记录
+// @Description 获取日期范围内按凭据汇总的每日用量记录（接受的邮件数、收件人数、字节数、已投递数、投递失败数）和合计，日期按用户时区，范围不超过366天。记录在一天结束并经过结算期后生成，此后不再修改。format=csv时以CSV文件导出
+// @Tags Usage
+// @Accept json
+// @Produce json,text/csv
+// @Security BearerAuth
+// @Param from query string true "开始日期 YYYY-MM-DD"
+// @Param to query string true "结束日期 YYYY-MM-DD"
+// @Param credential_id query string false "凭据ID"
+// @Param format query string false "导出格式" Enums(json, csv) default(json)
+// @Success 200 {object} UsageResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/usage [get]
+func (s *Server) getUsage(c *gin.Context) {
+	var req GetUsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+	if req.Format != "json" && req.Format != "csv" {
+		c.JSON(400, gin.H{"error": "不支持的导出格式"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	var credentialID *primitive.ObjectID
+	if req.CredentialID != "" {
+		id, err := primitive.ObjectIDFromHex(req.CredentialID)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "无效的凭据ID"})
+			return
+		}
+		credentialID = &id
+	}
+
+	records, totals, err := s.usageService.ListUsage(userID, req.From, req.To, credentialID)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "无效的") || strings.HasPrefix(err.Error(), "日期范围") {
+			c.JSON(400, gin.H{"error": err.Error()})
+		} else {
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取用量记录失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	if req.Format == "csv" {
+		s.writeUsageCSV(c, fmt.Sprintf("usage-%s-%s.csv", req.From, req.To), records)
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data": gin.H{
+			"from":    req.From,
+			"to":      req.To,
+			"records": records,
+			"totals":  totals,
+		},
+	})
+}
+
+// getUsageInvoice 获取月度用量汇总
+// @Summary 获取月度用量汇总
+// @Description 按凭据汇总用户某月（用户时区的自然月）的用量记录，用于内部计费；complete为false表示当月还有日期尚未生成用量记录
+// @Tags Usage
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param month query string true "月份 YYYY-MM"
+// @Success 200 {object} UsageInvoiceResponse "获取成功"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Router /api/v1/usage/invoice [get]
+func (s *Server) getUsageInvoice(c *gin.Context) {
+	var req GetUsageInvoiceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	// 获取用户ID
+	userID, err := s.getUserObjectID(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": "无效的用户ID"})
+		return
+	}
+
+	invoice, err := s.usageService.GetInvoice(userID, req.Month)
+	if err != nil {
+		switch err.Error() {
+		case "无效的月份":
+			c.JSON(400, gin.H{"error": err.Error()})
+		case "用户不存在":
+			c.JSON(404, gin.H{"error": err.Error()})
+		default:
+			s.logger.WithError(err).WithField("user_id", userID.Hex()).Error("获取月度用量汇总失败")
+			c.JSON(500, gin.H{"error": "服务器内部错误"})
+		}
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    invoice,
+	})
+}
+
+// writeUsageCSV 以CSV文件输出用量记录
+func (s *Server) writeUsageCSV(c *gin.Context, filename string, records []*models.UsageRecord) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Status(200)
+
+	writer := csv.NewWriter(c.Writer)
+	writer.Write([]string{"date", "timezone", "credential_id", "credential_name", "messages", "recipients", "bytes", "delivered", "bounced"})
+	for _, record := range records {
+		credentialID := ""
+		if record.CredentialID != nil {
+			credentialID = record.CredentialID.Hex()
+		}
+		writer.Write([]string{
+			record.Date,
+			record.Timezone,
+			credentialID,
+			record.CredentialName,
+			strconv.FormatInt(record.Messages, 10),
+			strconv.FormatInt(record.Recipients, 10),
+			strconv.FormatInt(record.Bytes, 10),
+			strconv.FormatInt(record.Delivered, 10),
+			strconv.FormatInt(record.Bounced, 10),
+		})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		s.logger.WithError(err).Warn("导出用量CSV失败")
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"smtp-relay/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxVerifyMessageSize 待验证邮件的最大大小
+const maxVerifyMessageSize = 25 << 20
+
+// 邮件验证相关请求结构体
+
+// VerifyMessageRequest 邮件认证验证请求参数
+type VerifyMessageRequest struct {
+	ClientIP string `form:"client_ip"` // 发送方IP，为空时跳过SPF检查
+	MailFrom string `form:"mail_from"` // SMTP MAIL FROM地址，为空时使用Return-Path或From头部
+	HELO     string `form:"helo"`      // HELO/EHLO域名
+}
+
+// 邮件验证相关响应结构体
+
+// MessageVerificationResponse 邮件认证验证响应
+type MessageVerificationResponse struct {
+	Success bool                        `json:"success" example:"true"`
+	Data    *models.MessageVerification `json:"data"`
+}
+
+// setupToolRoutes 设置工具类路由
+func (s *Server) setupToolRoutes(authenticated *gin.RouterGroup) {
+	tools := authenticated.Group("/tools")
+	{
+		tools.POST("/verify-message", s.verifyMessage)
+	}
+}
+
+// verifyMessage 验证邮件认证结果
+// @Summary 验证邮件认证结果
+// @Description 上传原始邮件（.eml），验证其中所有DKIM签名和ARC链，按客户端IP评估SPF，并给出DMARC对齐结果。
+// @Description 邮件可以multipart表单的file字段上传，也可直接作为请求体（Content-Type: message/rfc822）提交
+// @Tags Tools
+// @Accept mpfd
+// @Accept message/rfc822
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file false "原始邮件（.eml）"
+// @Param client_ip query string false "发送方IP，为空时跳过SPF检查"
+// @Param mail_from query string false "SMTP MAIL FROM地址，为空时使用Return-Path或From头部"
+// @Param helo query string false "HELO/EHLO域名"
+// @Success 200 {object} MessageVerificationResponse "验证完成"
+// @Failure 400 {object} APIResponse "请求参数错误"
+// @Failure 401 {object} APIResponse "未授权"
+// @Failure 413 {object} APIResponse "邮件过大"
+// @Router /api/v1/tools/verify-message [post]
+func (s *Server) verifyMessage(c *gin.Context) {
+	var req VerifyMessageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(400, gin.H{"error": "请求参数错误"})
+		return
+	}
+
+	message, status, errMsg := readVerifyMessage(c)
+	if errMsg != "" {
+		c.JSON(status, gin.H{"error": errMsg})
+		return
+	}
+
+	report, err := s.messageVerifyService.Verify(message, req.ClientIP, req.MailFrom, req.HELO)
+	if err != nil {
+		if err.Error() == "无效的邮件内容" || err.Error() == "无效的客户端IP" {
+			c.JSON(400, gin.H{"error": err.Error()})
+			return
+		}
+		s.logger.WithError(err).Error("邮件认证验证失败")
+		c.JSON(500, gin.H{"error": "服务器内部错误"})
+		return
+	}
+
+	c.JSON(200, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// readVerifyMessage 读取上传的原始邮件，失败时返回HTTP状态码和错误信息
+func readVerifyMessage(c *gin.Context) ([]byte, int, string) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxVerifyMessageSize)
+
+	var reader io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		file, err := c.FormFile("file")
+		if err != nil {
+			if strings.Contains(err.Error(), "too large") {
+				return nil, 413, "邮件大小超过限制"
+			}
+			return nil, 400, "缺少邮件文件"
+		}
+		opened, err := file.Open()
+		if err != nil {
+			return nil, 400, "读取邮件文件失败"
+		}
+		defer opened.Close()
+		reader = opened
+	}
+
+	message, err := io.ReadAll(io.LimitReader(reader, maxVerifyMessageSize+1))
+	if err != nil {
+		if strings.Contains(err.Error(), "too large") {
+			return nil, 413, "邮件大小超过限制"
+		}
+		return nil, 400, "读取邮件失败"
+	}
+	if len(message) > maxVerifyMessageSize {
+		return nil, 413, "邮件大小超过限制"
+	}
+	if len(message) == 0 {
+		return nil, 400, "邮件内容为空"
+	}
+	return message, 0, ""
+}
@@ -0,0 +1,99 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FilesystemStore 基于本地文件系统的归档存储
+type FilesystemStore struct {
+	root string
+}
+
+// NewFilesystemStore 创建文件系统归档存储
+func NewFilesystemStore(root string) (*FilesystemStore, error) {
+	if root == "" {
+		return nil, fmt.Errorf("文件系统归档存储路径不能为空")
+	}
+
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, fmt.Errorf("创建归档目录失败: %w", err)
+	}
+
+	return &FilesystemStore{root: root}, nil
+}
+
+// Name 返回存储后端名称
+func (s *FilesystemStore) Name() string {
+	return "filesystem"
+}
+
+// Put 写入归档对象（先写临时文件再重命名，避免读到不完整的文件）
+func (s *FilesystemStore) Put(ctx context.Context, key string, data []byte) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".archive-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Get 读取归档对象
+func (s *FilesystemStore) Get(ctx context.Context, key string) ([]byte, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete 删除归档对象
+func (s *FilesystemStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path 将归档键转换为文件路径，拒绝越出根目录的键
+func (s *FilesystemStore) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if strings.Contains(key, "..") || cleaned == "/" {
+		return "", fmt.Errorf("无效的归档键: %s", key)
+	}
+	return filepath.Join(s.root, cleaned), nil
+}
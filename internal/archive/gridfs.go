@@ -0,0 +1,83 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+)
+
+// GridFSStore 基于MongoDB GridFS的归档存储
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFSStore 创建GridFS归档存储
+func NewGridFSStore(db *database.MongoDB, bucketName string) (*GridFSStore, error) {
+	if bucketName == "" {
+		bucketName = "message_archive"
+	}
+
+	bucket, err := gridfs.NewBucket(db.Database, options.GridFSBucket().SetName(bucketName))
+	if err != nil {
+		return nil, fmt.Errorf("创建GridFS存储桶失败: %w", err)
+	}
+
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+// Name 返回存储后端名称
+func (s *GridFSStore) Name() string {
+	return "gridfs"
+}
+
+// Put 写入归档对象
+func (s *GridFSStore) Put(ctx context.Context, key string, data []byte) error {
+	// 同名文件已存在时先删除，保证重复归档幂等
+	if err := s.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	_, err := s.bucket.UploadFromStream(key, bytes.NewReader(data))
+	return err
+}
+
+// Get 读取归档对象
+func (s *GridFSStore) Get(ctx context.Context, key string) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := s.bucket.DownloadToStreamByName(key, &buf); err != nil {
+		if err == gridfs.ErrFileNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Delete 删除归档对象
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	cursor, err := s.bucket.FindContext(ctx, bson.M{"filename": key})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return err
+		}
+		if err := s.bucket.DeleteContext(ctx, file.ID); err != nil && err != gridfs.ErrFileNotFound {
+			return err
+		}
+	}
+
+	return cursor.Err()
+}
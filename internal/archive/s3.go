@@ -0,0 +1,74 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3Store 基于S3兼容对象存储的归档存储
+type S3Store struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewS3Store 创建S3兼容归档存储
+func NewS3Store(config *Config) (*S3Store, error) {
+	if config.S3Endpoint == "" || config.S3Bucket == "" {
+		return nil, fmt.Errorf("S3归档存储需要配置endpoint和bucket")
+	}
+
+	client, err := minio.New(config.S3Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(config.S3AccessKey, config.S3SecretKey, ""),
+		Secure: config.S3UseSSL,
+		Region: config.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建S3客户端失败: %w", err)
+	}
+
+	return &S3Store{
+		client: client,
+		bucket: config.S3Bucket,
+	}, nil
+}
+
+// Name 返回存储后端名称
+func (s *S3Store) Name() string {
+	return "s3"
+}
+
+// Put 写入归档对象
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: "application/gzip",
+	})
+	return err
+}
+
+// Get 读取归档对象
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer object.Close()
+
+	data, err := io.ReadAll(object)
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// Delete 删除归档对象
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
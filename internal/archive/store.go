@@ -0,0 +1,57 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"smtp-relay/internal/database"
+)
+
+// ErrNotFound 归档对象不存在
+var ErrNotFound = errors.New("归档对象不存在")
+
+// Store 原始邮件归档存储接口
+type Store interface {
+	// Name 返回存储后端名称
+	Name() string
+	// Put 写入归档对象
+	Put(ctx context.Context, key string, data []byte) error
+	// Get 读取归档对象，不存在时返回ErrNotFound
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete 删除归档对象，不存在时不返回错误
+	Delete(ctx context.Context, key string) error
+}
+
+// Config 归档存储配置
+type Config struct {
+	Backend string // gridfs, filesystem, s3
+
+	// GridFS
+	GridFSBucket string
+
+	// 文件系统
+	Path string
+
+	// S3兼容存储
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+	S3UseSSL    bool
+}
+
+// NewStore 根据配置创建归档存储
+func NewStore(config *Config, db *database.MongoDB) (Store, error) {
+	switch config.Backend {
+	case "", "gridfs":
+		return NewGridFSStore(db, config.GridFSBucket)
+	case "filesystem":
+		return NewFilesystemStore(config.Path)
+	case "s3":
+		return NewS3Store(config)
+	default:
+		return nil, fmt.Errorf("不支持的归档存储后端: %s", config.Backend)
+	}
+}
@@ -14,6 +14,8 @@ import (
 
 	"smtp-relay/internal/database"
 	"smtp-relay/internal/models"
+	"smtp-relay/internal/services"
+	"smtp-relay/internal/timeutil"
 )
 
 // Service 认证服务
@@ -109,9 +111,15 @@ func (s *Service) CheckQuota(userID primitive.ObjectID) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	// 获取用户配额信息
+	// 获取用户配额信息（周期按用户时区计算）
 	quotaCollection := s.db.GetCollection("user_quotas")
-	today := time.Now().Truncate(24 * time.Hour)
+	location, err := services.UserLocation(ctx, s.db, userID)
+	if err != nil {
+		s.logger.WithError(err).Error("查询用户时区失败")
+		return err
+	}
+	today, _ := timeutil.Window(timeutil.PeriodDay, time.Now(), location)
+	currentHour, _ := timeutil.Window(timeutil.PeriodHour, time.Now(), location)
 
 	var quota models.UserQuota
 	filter := bson.M{
@@ -119,7 +127,7 @@ func (s *Service) CheckQuota(userID primitive.ObjectID) error {
 		"date":    today,
 	}
 
-	err := quotaCollection.FindOne(ctx, filter).Decode(&quota)
+	err = quotaCollection.FindOne(ctx, filter).Decode(&quota)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			// 创建新的配额记录
@@ -130,7 +138,7 @@ func (s *Service) CheckQuota(userID primitive.ObjectID) error {
 				DailyLimit:    1000, // 默认值
 				HourlyCount:   0,
 				HourlyLimit:   100, // 默认值
-				LastResetHour: time.Now().Truncate(time.Hour),
+				LastResetHour: currentHour,
 				LastResetDay:  today,
 			}
 
@@ -145,7 +153,6 @@ func (s *Service) CheckQuota(userID primitive.ObjectID) error {
 	}
 
 	// 检查小时配额
-	currentHour := time.Now().Truncate(time.Hour)
 	if quota.LastResetHour.Before(currentHour) {
 		// 重置小时计数
 		quota.HourlyCount = 0
@@ -196,9 +203,15 @@ func (s *Service) CheckCredentialQuota(credentialID primitive.ObjectID) error {
 		return err
 	}
 
-	// 获取凭据配额信息
+	// 获取凭据配额信息（周期按用户时区计算）
 	quotaCollection := s.db.GetCollection("credential_quotas")
-	today := time.Now().Truncate(24 * time.Hour)
+	location, err := services.UserLocation(ctx, s.db, credential.UserID)
+	if err != nil {
+		s.logger.WithError(err).Error("查询用户时区失败")
+		return err
+	}
+	today, _ := timeutil.Window(timeutil.PeriodDay, time.Now(), location)
+	currentHour, _ := timeutil.Window(timeutil.PeriodHour, time.Now(), location)
 
 	var quota models.CredentialQuota
 	filter := bson.M{
@@ -217,7 +230,7 @@ func (s *Service) CheckCredentialQuota(credentialID primitive.ObjectID) error {
 				DailyLimit:    credential.Settings.DailyQuota,
 				HourlyCount:   0,
 				HourlyLimit:   credential.Settings.HourlyQuota,
-				LastResetHour: time.Now().Truncate(time.Hour),
+				LastResetHour: currentHour,
 				LastResetDay:  today,
 				CreatedAt:     time.Now(),
 				UpdatedAt:     time.Now(),
@@ -234,7 +247,6 @@ func (s *Service) CheckCredentialQuota(credentialID primitive.ObjectID) error {
 	}
 
 	// 检查小时配额
-	currentHour := time.Now().Truncate(time.Hour)
 	if quota.LastResetHour.Before(currentHour) {
 		// 重置小时计数
 		quota.HourlyCount = 0
@@ -369,6 +381,28 @@ func (s *Service) AuthenticateUserByUsername(username, password string) (*models
 	return &user, nil
 }
 
+// VerifyPassword 重新认证：验证已登录用户的当前密码（用于导出密钥等敏感操作）
+func (s *Service) VerifyPassword(userID primitive.ObjectID, password string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := s.db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID, "status": "active"}).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return errors.New("用户不存在")
+		}
+		return err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		s.logger.WithField("user_id", userID.Hex()).Warn("重新认证密码验证失败")
+		return errors.New("密码错误")
+	}
+
+	return nil
+}
+
 // CreateUser 创建新用户
 func (s *Service) CreateUser(username, email, password string) (*models.User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
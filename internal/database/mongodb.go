@@ -88,6 +88,9 @@ func (m *MongoDB) CreateIndexes() error {
 		{
 			Keys: bson.D{{"status", 1}},
 		},
+		{
+			Keys: bson.D{{Key: "plan_id", Value: 1}},
+		},
 	}
 
 	if _, err := userCollection.Indexes().CreateMany(ctx, userIndexes); err != nil {
@@ -107,6 +110,13 @@ func (m *MongoDB) CreateIndexes() error {
 		{
 			Keys: bson.D{{"active", 1}},
 		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+		{
+			Keys:    bson.D{{Key: "previous_password.expires_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
 	}
 
 	if _, err := credentialCollection.Indexes().CreateMany(ctx, credentialIndexes); err != nil {
@@ -131,6 +141,16 @@ func (m *MongoDB) CreateIndexes() error {
 		{
 			Keys: bson.D{{"created_at", -1}},
 		},
+		{
+			Keys:    bson.D{{Key: "archive.expires_at", Value: 1}},
+			Options: options.Index().SetSparse(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "tags", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "campaign_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
 	}
 
 	if _, err := mailLogCollection.Indexes().CreateMany(ctx, mailLogIndexes); err != nil {
@@ -195,6 +215,208 @@ func (m *MongoDB) CreateIndexes() error {
 		return err
 	}
 
+	// 沙箱邮件集合索引
+	sandboxCollection := m.GetCollection("sandbox_messages")
+	sandboxIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "credential_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	}
+
+	if _, err := sandboxCollection.Indexes().CreateMany(ctx, sandboxIndexes); err != nil {
+		return err
+	}
+
+	// S/MIME证书集合索引
+	smimeCollection := m.GetCollection("smime_certificates")
+	smimeIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "sender", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := smimeCollection.Indexes().CreateMany(ctx, smimeIndexes); err != nil {
+		return err
+	}
+
+	// 发件域名集合索引（同一域名只能被一个用户验证）
+	domainCollection := m.GetCollection("domains")
+	domainIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "domain", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "domain", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName("domain_verified_unique").
+				SetPartialFilterExpression(bson.M{"status": "verified"}),
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}},
+		},
+	}
+
+	if _, err := domainCollection.Indexes().CreateMany(ctx, domainIndexes); err != nil {
+		return err
+	}
+
+	// DKIM密钥集合索引
+	dkimCollection := m.GetCollection("dkim_keys")
+	dkimIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "domain", Value: 1}, {Key: "status", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+		{
+			Keys: bson.D{{Key: "rotated_from_id", Value: 1}},
+		},
+	}
+
+	if _, err := dkimCollection.Indexes().CreateMany(ctx, dkimIndexes); err != nil {
+		return err
+	}
+
+	// DKIM轮换策略集合索引
+	rotationPolicyCollection := m.GetCollection("dkim_rotation_policies")
+	rotationPolicyIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "domain", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "enabled", Value: 1}},
+		},
+	}
+
+	if _, err := rotationPolicyCollection.Indexes().CreateMany(ctx, rotationPolicyIndexes); err != nil {
+		return err
+	}
+
+	// 域名DKIM配置集合索引
+	dkimConfigCollection := m.GetCollection("dkim_configs")
+	dkimConfigIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "domain", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := dkimConfigCollection.Indexes().CreateMany(ctx, dkimConfigIndexes); err != nil {
+		return err
+	}
+
+	// 通知集合索引
+	notificationCollection := m.GetCollection("notifications")
+	notificationIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "dedup_key", Value: 1}, {Key: "read", Value: 1}},
+		},
+	}
+
+	if _, err := notificationCollection.Indexes().CreateMany(ctx, notificationIndexes); err != nil {
+		return err
+	}
+
+	// 凭据行为基线和异常告警集合索引
+	baselineCollection := m.GetCollection("credential_baselines")
+	baselineIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "credential_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := baselineCollection.Indexes().CreateMany(ctx, baselineIndexes); err != nil {
+		return err
+	}
+
+	anomalyCollection := m.GetCollection("credential_anomalies")
+	anomalyIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "credential_id", Value: 1}, {Key: "detected_at", Value: -1}},
+		},
+	}
+
+	if _, err := anomalyCollection.Indexes().CreateMany(ctx, anomalyIndexes); err != nil {
+		return err
+	}
+
+	// 凭据客户端历史和安全事件集合索引
+	clientCollection := m.GetCollection("credential_clients")
+	clientIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "credential_id", Value: 1},
+				{Key: "ip", Value: 1},
+				{Key: "ehlo", Value: 1},
+				{Key: "tls_version", Value: 1},
+				{Key: "auth_mechanism", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "credential_id", Value: 1}, {Key: "last_seen", Value: -1}},
+		},
+	}
+
+	if _, err := clientCollection.Indexes().CreateMany(ctx, clientIndexes); err != nil {
+		return err
+	}
+
+	securityEventCollection := m.GetCollection("security_events")
+	securityEventIndexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "credential_id", Value: 1}, {Key: "created_at", Value: -1}},
+		},
+		{
+			Keys:    bson.D{{Key: "dedup_key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetSparse(true),
+		},
+	}
+
+	if _, err := securityEventCollection.Indexes().CreateMany(ctx, securityEventIndexes); err != nil {
+		return err
+	}
+
+	// 套餐集合索引
+	planCollection := m.GetCollection("plans")
+	planIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "name", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+	}
+
+	if _, err := planCollection.Indexes().CreateMany(ctx, planIndexes); err != nil {
+		return err
+	}
+
+	// 每日用量记录集合索引（同一用户、凭据和起始时间只生成一条记录）
+	usageCollection := m.GetCollection("usage_records")
+	usageIndexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "credential_id", Value: 1}, {Key: "window_start", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}},
+		},
+	}
+
+	if _, err := usageCollection.Indexes().CreateMany(ctx, usageIndexes); err != nil {
+		return err
+	}
+
 	m.logger.Info("MongoDB索引创建完成")
 	return nil
 }
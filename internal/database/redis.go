@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// NewRedis 创建Redis连接
+func NewRedis(url, password string, logger *logrus.Logger) (*redis.Client, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		logger.WithError(err).Error("解析Redis连接地址失败")
+		return nil, err
+	}
+	if password != "" {
+		options.Password = password
+	}
+
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// 验证连接
+	if err := client.Ping(ctx).Err(); err != nil {
+		logger.WithError(err).Error("Redis连接验证失败")
+		client.Close()
+		return nil, err
+	}
+
+	logger.Info("Redis连接成功")
+	return client, nil
+}
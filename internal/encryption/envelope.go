@@ -0,0 +1,302 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// stringPrefix 字符串密文前缀，格式为 enc:v1:<keyID>:<wrappedDEK>:<ciphertext>
+	stringPrefix = "enc:v1:"
+	// dekSize 数据密钥长度（AES-256）
+	dekSize = 32
+)
+
+// binaryMagic 二进制密文头部标识
+var binaryMagic = []byte("RLENC1\x00")
+
+// ErrDisabled 未配置主密钥时解密密文返回的错误
+var ErrDisabled = errors.New("未配置加密主密钥，无法解密")
+
+// Encryptor 信封加密器：每个值使用独立的数据密钥加密，数据密钥由主密钥包装
+type Encryptor struct {
+	provider KeyProvider
+}
+
+// envelope 信封加密结构
+type envelope struct {
+	keyID   string
+	wrapped []byte
+	payload []byte
+}
+
+// NewEncryptor 创建信封加密器（provider为nil时不加密，仅透传明文）
+func NewEncryptor(provider KeyProvider) *Encryptor {
+	return &Encryptor{provider: provider}
+}
+
+// NewFromKeyFile 根据本地主密钥文件创建加密器，路径为空时返回未启用的加密器
+func NewFromKeyFile(path string) (*Encryptor, error) {
+	if path == "" {
+		return NewEncryptor(nil), nil
+	}
+
+	provider, err := NewLocalKeyProvider(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptor(provider), nil
+}
+
+// Enabled 是否启用加密
+func (e *Encryptor) Enabled() bool {
+	return e != nil && e.provider != nil
+}
+
+// CurrentKeyID 当前主密钥ID（未启用时为空）
+func (e *Encryptor) CurrentKeyID() string {
+	if !e.Enabled() {
+		return ""
+	}
+	return e.provider.CurrentKeyID()
+}
+
+// EncryptString 加密字符串，未启用或空字符串时原样返回
+func (e *Encryptor) EncryptString(plaintext string) (string, error) {
+	if !e.Enabled() || plaintext == "" {
+		return plaintext, nil
+	}
+
+	env, err := e.seal([]byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+	return env.marshalString(), nil
+}
+
+// DecryptString 解密字符串，非密文（历史明文数据）原样返回
+func (e *Encryptor) DecryptString(value string) (string, error) {
+	if !IsEncryptedString(value) {
+		return value, nil
+	}
+
+	env, err := parseString(value)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := e.open(env)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// EncryptBytes 加密二进制数据，未启用时原样返回
+func (e *Encryptor) EncryptBytes(plaintext []byte) ([]byte, error) {
+	if !e.Enabled() {
+		return plaintext, nil
+	}
+
+	env, err := e.seal(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return env.marshalBinary(), nil
+}
+
+// DecryptBytes 解密二进制数据，非密文（历史明文数据）原样返回
+func (e *Encryptor) DecryptBytes(data []byte) ([]byte, error) {
+	if !IsEncryptedBytes(data) {
+		return data, nil
+	}
+
+	env, err := parseBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	return e.open(env)
+}
+
+// RewrapString 使用当前主密钥重新包装字符串密文的数据密钥（明文数据会被加密）
+func (e *Encryptor) RewrapString(value string) (string, error) {
+	if !IsEncryptedString(value) {
+		return e.EncryptString(value)
+	}
+
+	env, err := parseString(value)
+	if err != nil {
+		return "", err
+	}
+	if err := e.rewrap(env); err != nil {
+		return "", err
+	}
+	return env.marshalString(), nil
+}
+
+// RewrapBytes 使用当前主密钥重新包装二进制密文的数据密钥（明文数据会被加密）
+func (e *Encryptor) RewrapBytes(data []byte) ([]byte, error) {
+	if !IsEncryptedBytes(data) {
+		return e.EncryptBytes(data)
+	}
+
+	env, err := parseBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.rewrap(env); err != nil {
+		return nil, err
+	}
+	return env.marshalBinary(), nil
+}
+
+// IsEncryptedString 判断字符串是否为密文
+func IsEncryptedString(value string) bool {
+	return strings.HasPrefix(value, stringPrefix)
+}
+
+// IsEncryptedBytes 判断二进制数据是否为密文
+func IsEncryptedBytes(data []byte) bool {
+	return bytes.HasPrefix(data, binaryMagic)
+}
+
+// seal 生成数据密钥并加密数据
+func (e *Encryptor) seal(plaintext []byte) (*envelope, error) {
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("生成数据密钥失败: %w", err)
+	}
+
+	gcm, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := seal(gcm, plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("加密数据失败: %w", err)
+	}
+
+	keyID := e.provider.CurrentKeyID()
+	wrapped, err := e.provider.WrapKey(keyID, dek)
+	if err != nil {
+		return nil, fmt.Errorf("包装数据密钥失败: %w", err)
+	}
+
+	return &envelope{keyID: keyID, wrapped: wrapped, payload: payload}, nil
+}
+
+// open 解包数据密钥并解密数据
+func (e *Encryptor) open(env *envelope) ([]byte, error) {
+	if !e.Enabled() {
+		return nil, ErrDisabled
+	}
+
+	dek, err := e.provider.UnwrapKey(env.keyID, env.wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("解包数据密钥失败: %w", err)
+	}
+
+	gcm, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := open(gcm, env.payload)
+	if err != nil {
+		return nil, fmt.Errorf("解密数据失败: %w", err)
+	}
+	return plaintext, nil
+}
+
+// rewrap 使用当前主密钥重新包装数据密钥（密文本身不变）
+func (e *Encryptor) rewrap(env *envelope) error {
+	if !e.Enabled() {
+		return ErrDisabled
+	}
+
+	current := e.provider.CurrentKeyID()
+	if env.keyID == current {
+		return nil
+	}
+
+	dek, err := e.provider.UnwrapKey(env.keyID, env.wrapped)
+	if err != nil {
+		return fmt.Errorf("解包数据密钥失败: %w", err)
+	}
+	wrapped, err := e.provider.WrapKey(current, dek)
+	if err != nil {
+		return fmt.Errorf("包装数据密钥失败: %w", err)
+	}
+
+	env.keyID = current
+	env.wrapped = wrapped
+	return nil
+}
+
+// marshalString 序列化为字符串密文
+func (env *envelope) marshalString() string {
+	return stringPrefix + env.keyID + ":" +
+		base64.RawStdEncoding.EncodeToString(env.wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(env.payload)
+}
+
+// parseString 解析字符串密文
+func parseString(value string) (*envelope, error) {
+	parts := strings.Split(strings.TrimPrefix(value, stringPrefix), ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("密文格式无效")
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("密文格式无效: %w", err)
+	}
+	payload, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("密文格式无效: %w", err)
+	}
+
+	return &envelope{keyID: parts[0], wrapped: wrapped, payload: payload}, nil
+}
+
+// marshalBinary 序列化为二进制密文：magic | keyID长度(1) | keyID | 包装密钥长度(2) | 包装密钥 | 密文
+func (env *envelope) marshalBinary() []byte {
+	buf := make([]byte, 0, len(binaryMagic)+1+len(env.keyID)+2+len(env.wrapped)+len(env.payload))
+	buf = append(buf, binaryMagic...)
+	buf = append(buf, byte(len(env.keyID)))
+	buf = append(buf, env.keyID...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(env.wrapped)))
+	buf = append(buf, env.wrapped...)
+	return append(buf, env.payload...)
+}
+
+// parseBinary 解析二进制密文
+func parseBinary(data []byte) (*envelope, error) {
+	rest := data[len(binaryMagic):]
+	if len(rest) < 1 {
+		return nil, fmt.Errorf("密文格式无效")
+	}
+
+	keyIDLen := int(rest[0])
+	rest = rest[1:]
+	if len(rest) < keyIDLen+2 {
+		return nil, fmt.Errorf("密文格式无效")
+	}
+	keyID := string(rest[:keyIDLen])
+	rest = rest[keyIDLen:]
+
+	wrappedLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < wrappedLen {
+		return nil, fmt.Errorf("密文格式无效")
+	}
+
+	return &envelope{
+		keyID:   keyID,
+		wrapped: rest[:wrappedLen],
+		payload: rest[wrappedLen:],
+	}, nil
+}
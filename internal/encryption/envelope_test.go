@@ -0,0 +1,263 @@
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeKeyFile 生成包含指定主密钥的密钥文件，返回文件路径
+func writeKeyFile(t *testing.T, current string, keys map[string][]byte) string {
+	t.Helper()
+	file := localKeyFile{Current: current, Keys: make(map[string]string, len(keys))}
+	for keyID, key := range keys {
+		file.Keys[keyID] = base64.StdEncoding.EncodeToString(key)
+	}
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatalf("序列化密钥文件失败: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "master.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("写入密钥文件失败: %v", err)
+	}
+	return path
+}
+
+// newTestKey 生成32字节主密钥
+func newTestKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("生成主密钥失败: %v", err)
+	}
+	return key
+}
+
+// newTestEncryptor 使用指定主密钥创建加密器
+func newTestEncryptor(t *testing.T, current string, keys map[string][]byte) *Encryptor {
+	t.Helper()
+	encryptor, err := NewFromKeyFile(writeKeyFile(t, current, keys))
+	if err != nil {
+		t.Fatalf("创建加密器失败: %v", err)
+	}
+	return encryptor
+}
+
+func TestEncryptorRoundTrip(t *testing.T) {
+	encryptor := newTestEncryptor(t, "k1", map[string][]byte{"k1": newTestKey(t)})
+	if !encryptor.Enabled() || encryptor.CurrentKeyID() != "k1" {
+		t.Fatalf("加密器状态错误: enabled=%v key=%q", encryptor.Enabled(), encryptor.CurrentKeyID())
+	}
+
+	for _, plaintext := range []string{"smtp-password", "包含中文和:冒号的值", strings.Repeat("x", 100000)} {
+		encrypted, err := encryptor.EncryptString(plaintext)
+		if err != nil {
+			t.Fatalf("加密字符串失败: %v", err)
+		}
+		if !IsEncryptedString(encrypted) || strings.Contains(encrypted, plaintext) {
+			t.Fatalf("密文格式错误: %.60q", encrypted)
+		}
+		if !strings.HasPrefix(encrypted, "enc:v1:k1:") {
+			t.Errorf("密文未记录主密钥ID: %.60q", encrypted)
+		}
+		decrypted, err := encryptor.DecryptString(encrypted)
+		if err != nil || decrypted != plaintext {
+			t.Fatalf("解密字符串结果为 %.60q（%v），期望与原文一致", decrypted, err)
+		}
+
+		again, _ := encryptor.EncryptString(plaintext)
+		if again == encrypted {
+			t.Errorf("同一明文两次加密的密文相同，数据密钥或nonce未随机生成")
+		}
+	}
+
+	for _, plaintext := range [][]byte{{}, []byte("From: a@example.com\r\n\r\nbody"), bytes.Repeat([]byte{0, 1, 2}, 50000)} {
+		encrypted, err := encryptor.EncryptBytes(plaintext)
+		if err != nil {
+			t.Fatalf("加密二进制数据失败: %v", err)
+		}
+		if !IsEncryptedBytes(encrypted) {
+			t.Fatalf("二进制密文缺少头部标识")
+		}
+		decrypted, err := encryptor.DecryptBytes(encrypted)
+		if err != nil || !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("解密二进制数据失败: %v", err)
+		}
+	}
+
+	// 空字符串不加密
+	if encrypted, err := encryptor.EncryptString(""); err != nil || encrypted != "" {
+		t.Errorf("空字符串加密结果为 %q（%v），期望原样返回", encrypted, err)
+	}
+}
+
+func TestEncryptorPlaintextPassThrough(t *testing.T) {
+	enabled := newTestEncryptor(t, "k1", map[string][]byte{"k1": newTestKey(t)})
+	disabled, err := NewFromKeyFile("")
+	if err != nil {
+		t.Fatalf("创建未启用的加密器失败: %v", err)
+	}
+	if disabled.Enabled() || disabled.CurrentKeyID() != "" {
+		t.Fatalf("未配置主密钥时加密器不应启用")
+	}
+
+	// 历史明文数据原样返回
+	for _, encryptor := range []*Encryptor{enabled, disabled} {
+		if value, err := encryptor.DecryptString("legacy-password"); err != nil || value != "legacy-password" {
+			t.Errorf("明文字符串解密结果为 %q（%v）", value, err)
+		}
+		raw := []byte("From: a@example.com\r\n\r\nlegacy body")
+		if value, err := encryptor.DecryptBytes(raw); err != nil || !bytes.Equal(value, raw) {
+			t.Errorf("明文二进制数据解密结果为 %q（%v）", value, err)
+		}
+	}
+
+	// 未启用时加密原样返回，但无法解密已有密文
+	if value, err := disabled.EncryptString("secret"); err != nil || value != "secret" {
+		t.Errorf("未启用时加密结果为 %q（%v），期望原样返回", value, err)
+	}
+	if value, err := disabled.EncryptBytes([]byte("secret")); err != nil || string(value) != "secret" {
+		t.Errorf("未启用时加密结果为 %q（%v），期望原样返回", value, err)
+	}
+	encrypted, _ := enabled.EncryptString("secret")
+	if _, err := disabled.DecryptString(encrypted); !errors.Is(err, ErrDisabled) {
+		t.Errorf("未启用时解密密文应返回ErrDisabled，实际为 %v", err)
+	}
+
+	// 重新包装明文时会加密
+	rewrapped, err := enabled.RewrapString("legacy-password")
+	if err != nil || !IsEncryptedString(rewrapped) {
+		t.Fatalf("重新包装明文字符串结果为 %.60q（%v），期望加密", rewrapped, err)
+	}
+	if value, _ := enabled.DecryptString(rewrapped); value != "legacy-password" {
+		t.Errorf("重新包装后解密结果为 %q", value)
+	}
+	rewrappedBytes, err := enabled.RewrapBytes([]byte("legacy body"))
+	if err != nil || !IsEncryptedBytes(rewrappedBytes) {
+		t.Fatalf("重新包装明文二进制数据失败: %v", err)
+	}
+}
+
+func TestEncryptorKeyRotation(t *testing.T) {
+	k1, k2 := newTestKey(t), newTestKey(t)
+	before := newTestEncryptor(t, "k1", map[string][]byte{"k1": k1})
+
+	encryptedString, err := before.EncryptString("dkim-private-key")
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	encryptedBytes, err := before.EncryptBytes([]byte("archived message"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	// 轮换后旧主密钥仍保留，旧密文可以解密
+	rotated := newTestEncryptor(t, "k2", map[string][]byte{"k1": k1, "k2": k2})
+	if value, err := rotated.DecryptString(encryptedString); err != nil || value != "dkim-private-key" {
+		t.Fatalf("轮换后解密旧字符串密文结果为 %q（%v）", value, err)
+	}
+	if value, err := rotated.DecryptBytes(encryptedBytes); err != nil || string(value) != "archived message" {
+		t.Fatalf("轮换后解密旧二进制密文结果为 %q（%v）", value, err)
+	}
+
+	rewrappedString, err := rotated.RewrapString(encryptedString)
+	if err != nil || !strings.HasPrefix(rewrappedString, "enc:v1:k2:") {
+		t.Fatalf("重新包装结果为 %.60q（%v），期望使用k2", rewrappedString, err)
+	}
+	rewrappedBytes, err := rotated.RewrapBytes(encryptedBytes)
+	if err != nil {
+		t.Fatalf("重新包装二进制密文失败: %v", err)
+	}
+	// 已使用当前主密钥的密文保持不变
+	if again, _ := rotated.RewrapString(rewrappedString); again != rewrappedString {
+		t.Errorf("已使用当前主密钥的密文被重复包装")
+	}
+
+	// 重新包装完成后移除旧主密钥，新密文仍可解密，未重新包装的旧密文无法解密
+	retired := newTestEncryptor(t, "k2", map[string][]byte{"k2": k2})
+	if value, err := retired.DecryptString(rewrappedString); err != nil || value != "dkim-private-key" {
+		t.Errorf("移除旧主密钥后解密结果为 %q（%v）", value, err)
+	}
+	if value, err := retired.DecryptBytes(rewrappedBytes); err != nil || string(value) != "archived message" {
+		t.Errorf("移除旧主密钥后解密结果为 %q（%v）", value, err)
+	}
+	if _, err := retired.DecryptString(encryptedString); err == nil || !strings.Contains(err.Error(), `"k1"不存在`) {
+		t.Errorf("使用已移除主密钥的密文应返回错误，实际为 %v", err)
+	}
+}
+
+func TestEncryptorRejectsInvalidCiphertext(t *testing.T) {
+	encryptor := newTestEncryptor(t, "k1", map[string][]byte{"k1": newTestKey(t)})
+	other := newTestEncryptor(t, "k9", map[string][]byte{"k9": newTestKey(t)})
+
+	encryptedString, _ := encryptor.EncryptString("smtp-password")
+	encryptedBytes, _ := encryptor.EncryptBytes([]byte("sandbox message body"))
+	parts := strings.Split(encryptedString, ":")
+
+	flip := func(value string, index int) string {
+		decoded, _ := base64.RawStdEncoding.DecodeString(value)
+		decoded[index] ^= 0x01
+		return base64.RawStdEncoding.EncodeToString(decoded)
+	}
+
+	stringCases := map[string]string{
+		"未知主密钥ID":    strings.Replace(encryptedString, "enc:v1:k1:", "enc:v1:missing:", 1),
+		"其他主密钥加密的密文": mustEncryptString(t, other, "smtp-password"),
+		"篡改包装密钥":     strings.Join([]string{parts[0], parts[1], parts[2], flip(parts[3], 20), parts[4]}, ":"),
+		"篡改密文":       strings.Join([]string{parts[0], parts[1], parts[2], parts[3], flip(parts[4], 15)}, ":"),
+		"缺少字段":       strings.Join(parts[:4], ":"),
+		"多余字段":       encryptedString + ":extra",
+		"无效的Base64":  strings.Join([]string{parts[0], parts[1], parts[2], "!!!", parts[4]}, ":"),
+		"空密文":        "enc:v1:k1::",
+	}
+	for name, value := range stringCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := encryptor.DecryptString(value); err == nil {
+				t.Errorf("解密无效的字符串密文应返回错误")
+			}
+		})
+	}
+
+	// 截断到任意长度都应返回错误而不是panic（短于前缀时视为明文）
+	for i := len(stringPrefix); i < len(encryptedString); i++ {
+		if _, err := encryptor.DecryptString(encryptedString[:i]); err == nil {
+			t.Errorf("截断到%d字节的字符串密文解密成功", i)
+		}
+	}
+	for i := len(binaryMagic); i < len(encryptedBytes); i++ {
+		if _, err := encryptor.DecryptBytes(encryptedBytes[:i]); err == nil {
+			t.Errorf("截断到%d字节的二进制密文解密成功", i)
+		}
+	}
+
+	// 篡改二进制密文的任意字节都应返回错误
+	for i := len(binaryMagic); i < len(encryptedBytes); i++ {
+		tampered := append([]byte(nil), encryptedBytes...)
+		tampered[i] ^= 0x01
+		if _, err := encryptor.DecryptBytes(tampered); err == nil {
+			t.Errorf("篡改第%d字节的二进制密文解密成功", i)
+		}
+	}
+
+	// 二进制密文中的主密钥ID长度超过剩余数据
+	oversized := append(append([]byte(nil), binaryMagic...), 0xff, 'k')
+	if _, err := encryptor.DecryptBytes(oversized); err == nil {
+		t.Errorf("主密钥ID长度无效的二进制密文解密成功")
+	}
+}
+
+func mustEncryptString(t *testing.T, encryptor *Encryptor, plaintext string) string {
+	t.Helper()
+	encrypted, err := encryptor.EncryptString(plaintext)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	return encrypted
+}
@@ -0,0 +1,136 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyProvider 主密钥提供者接口（本地密钥文件、KMS等）
+type KeyProvider interface {
+	// CurrentKeyID 返回当前用于加密的主密钥ID
+	CurrentKeyID() string
+	// WrapKey 使用指定主密钥加密数据密钥
+	WrapKey(keyID string, dek []byte) ([]byte, error)
+	// UnwrapKey 使用指定主密钥解密数据密钥
+	UnwrapKey(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// LocalKeyProvider 基于本地主密钥文件的密钥提供者
+//
+// 密钥文件格式：
+//
+//	{"current": "k2", "keys": {"k1": "<base64 32字节>", "k2": "<base64 32字节>"}}
+//
+// 轮换主密钥时新增密钥并修改current，旧密钥需保留到重新包装任务完成。
+type LocalKeyProvider struct {
+	current string
+	keys    map[string][]byte
+}
+
+// localKeyFile 本地主密钥文件结构
+type localKeyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// NewLocalKeyProvider 从主密钥文件创建密钥提供者
+func NewLocalKeyProvider(path string) (*LocalKeyProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取主密钥文件失败: %w", err)
+	}
+
+	var file localKeyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("解析主密钥文件失败: %w", err)
+	}
+
+	provider := &LocalKeyProvider{
+		current: file.Current,
+		keys:    make(map[string][]byte, len(file.Keys)),
+	}
+	for keyID, encoded := range file.Keys {
+		if keyID == "" || len(keyID) > 64 || strings.Contains(keyID, ":") {
+			return nil, fmt.Errorf("无效的主密钥ID: %q", keyID)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("解码主密钥%s失败: %w", keyID, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("主密钥%s长度必须为32字节", keyID)
+		}
+		provider.keys[keyID] = key
+	}
+
+	if _, ok := provider.keys[provider.current]; !ok {
+		return nil, fmt.Errorf("当前主密钥%q不存在", provider.current)
+	}
+
+	return provider, nil
+}
+
+// CurrentKeyID 返回当前用于加密的主密钥ID
+func (p *LocalKeyProvider) CurrentKeyID() string {
+	return p.current
+}
+
+// WrapKey 使用指定主密钥加密数据密钥
+func (p *LocalKeyProvider) WrapKey(keyID string, dek []byte) ([]byte, error) {
+	gcm, err := p.cipher(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return seal(gcm, dek)
+}
+
+// UnwrapKey 使用指定主密钥解密数据密钥
+func (p *LocalKeyProvider) UnwrapKey(keyID string, wrapped []byte) ([]byte, error) {
+	gcm, err := p.cipher(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return open(gcm, wrapped)
+}
+
+// cipher 获取主密钥对应的AES-GCM实例
+func (p *LocalKeyProvider) cipher(keyID string) (cipher.AEAD, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("主密钥%q不存在", keyID)
+	}
+	return newGCM(key)
+}
+
+// newGCM 创建AES-GCM实例
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal 加密数据，输出为nonce||ciphertext
+func seal(gcm cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open 解密nonce||ciphertext格式的数据
+func open(gcm cipher.AEAD, data []byte) ([]byte, error) {
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("密文长度无效")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
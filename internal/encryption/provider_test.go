@@ -0,0 +1,83 @@
+package encryption
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewLocalKeyProvider(t *testing.T) {
+	validKey := "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY=" // 32字节
+
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"有效的密钥文件", `{"current":"k2","keys":{"k1":"` + validKey + `","k2":"` + validKey + `"}}`, ""},
+		{"JSON格式错误", `{"current":`, "解析主密钥文件失败"},
+		{"当前密钥不存在", `{"current":"k3","keys":{"k1":"` + validKey + `"}}`, "当前主密钥"},
+		{"密钥长度错误", `{"current":"k1","keys":{"k1":"c2hvcnQ="}}`, "长度必须为32字节"},
+		{"密钥Base64无效", `{"current":"k1","keys":{"k1":"not base64"}}`, "解码主密钥k1失败"},
+		{"密钥ID包含冒号", `{"current":"a:b","keys":{"a:b":"` + validKey + `"}}`, "无效的主密钥ID"},
+		{"密钥ID为空", `{"current":"","keys":{"":"` + validKey + `"}}`, "无效的主密钥ID"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "master.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatalf("写入密钥文件失败: %v", err)
+			}
+
+			provider, err := NewLocalKeyProvider(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望错误包含 %q，实际为 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("加载密钥文件失败: %v", err)
+			}
+			if provider.CurrentKeyID() != "k2" {
+				t.Errorf("CurrentKeyID() = %q，期望 k2", provider.CurrentKeyID())
+			}
+		})
+	}
+
+	if _, err := NewLocalKeyProvider(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "读取主密钥文件失败") {
+		t.Errorf("密钥文件不存在时应返回读取错误，实际为 %v", err)
+	}
+}
+
+func TestLocalKeyProviderWrapKey(t *testing.T) {
+	provider, err := NewLocalKeyProvider(writeKeyFile(t, "k1", map[string][]byte{"k1": newTestKey(t), "k2": newTestKey(t)}))
+	if err != nil {
+		t.Fatalf("加载密钥文件失败: %v", err)
+	}
+	dek := newTestKey(t)
+
+	wrapped, err := provider.WrapKey("k1", dek)
+	if err != nil {
+		t.Fatalf("包装数据密钥失败: %v", err)
+	}
+	unwrapped, err := provider.UnwrapKey("k1", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, dek) {
+		t.Fatalf("解包数据密钥失败: %v", err)
+	}
+
+	if _, err := provider.UnwrapKey("k2", wrapped); err == nil {
+		t.Errorf("使用其他主密钥解包应返回错误")
+	}
+	if _, err := provider.UnwrapKey("k3", wrapped); err == nil {
+		t.Errorf("使用不存在的主密钥解包应返回错误")
+	}
+	if _, err := provider.WrapKey("k3", dek); err == nil {
+		t.Errorf("使用不存在的主密钥包装应返回错误")
+	}
+	if _, err := provider.UnwrapKey("k1", wrapped[:5]); err == nil {
+		t.Errorf("截断的包装密钥应返回错误")
+	}
+}
@@ -0,0 +1,405 @@
+package mailauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ARC链验证结果（RFC 8617 cv=取值）
+const (
+	ARCNone = "none"
+	ARCPass = "pass"
+	ARCFail = "fail"
+)
+
+// ARC头部名称
+const (
+	arcAuthResultsHeader = "ARC-Authentication-Results"
+	arcSignatureHeader   = "ARC-Message-Signature"
+	arcSealHeader        = "ARC-Seal"
+)
+
+// MaxARCInstances ARC链允许的最大实例数
+const MaxARCInstances = 50
+
+// DefaultARCSignedHeaders ARC-Message-Signature默认签名的头部
+var DefaultARCSignedHeaders = []string{
+	"From", "To", "Cc", "Subject", "Date", "Message-ID", "Reply-To",
+	"In-Reply-To", "References", "MIME-Version", "Content-Type",
+	"Content-Transfer-Encoding", "DKIM-Signature",
+}
+
+// ARCSet 同一实例编号的三个ARC头部
+type ARCSet struct {
+	Instance         int
+	AuthResults      HeaderField
+	MessageSignature HeaderField
+	Seal             HeaderField
+}
+
+// ARCResult ARC链验证结果
+type ARCResult struct {
+	Status string    // none, pass, fail
+	Reason string    // 验证失败原因
+	Sets   []*ARCSet // 按实例编号升序排列的ARC集合
+}
+
+// Instance 返回当前链中最大的实例编号
+func (r *ARCResult) Instance() int {
+	return len(r.Sets)
+}
+
+// ARCSealOptions ARC封装参数
+type ARCSealOptions struct {
+	Domain        string        // d=，签名域名
+	Selector      string        // s=，DKIM选择器
+	Signer        crypto.Signer // 签名私钥（ARC仅支持RSA）
+	AuthServID    string        // Authentication-Results的authserv-id
+	AuthResults   string        // 本跳认证结果（不含authserv-id和arc=）
+	SignedHeaders []string      // AMS签名的头部，为空时使用默认列表
+	Timestamp     time.Time     // t=，为零时使用当前时间
+}
+
+// ValidateARC 验证邮件上已有的ARC链（RFC 8617 第5.2节）
+func ValidateARC(ctx context.Context, resolver Resolver, message []byte) *ARCResult {
+	fields, body := SplitMessage(NormalizeCRLF(message))
+
+	sets, err := collectARCSets(fields)
+	if err != nil {
+		return &ARCResult{Status: ARCFail, Reason: err.Error()}
+	}
+	if len(sets) == 0 {
+		return &ARCResult{Status: ARCNone}
+	}
+
+	result := &ARCResult{Status: ARCFail, Sets: sets}
+
+	// 检查各实例的cv=取值
+	for _, set := range sets {
+		tags, err := parseTagList(set.Seal.Value())
+		if err != nil {
+			result.Reason = fmt.Sprintf("ARC-Seal i=%d 格式错误: %v", set.Instance, err)
+			return result
+		}
+		expected := ARCPass
+		if set.Instance == 1 {
+			expected = ARCNone
+		}
+		if cv := strings.ToLower(tags["cv"]); cv != expected {
+			result.Reason = fmt.Sprintf("ARC-Seal i=%d 的cv=%s 无效", set.Instance, cv)
+			return result
+		}
+	}
+
+	// 仅需验证最新实例的ARC-Message-Signature
+	latest := sets[len(sets)-1]
+	if err := verifyARCMessageSignature(ctx, resolver, fields, body, latest); err != nil {
+		result.Reason = fmt.Sprintf("ARC-Message-Signature i=%d 验证失败: %v", latest.Instance, err)
+		return result
+	}
+
+	// 自最新实例起依次验证所有ARC-Seal
+	for i := len(sets); i >= 1; i-- {
+		if err := verifyARCSeal(ctx, resolver, sets[:i]); err != nil {
+			result.Reason = fmt.Sprintf("ARC-Seal i=%d 验证失败: %v", i, err)
+			return result
+		}
+	}
+
+	result.Status = ARCPass
+	return result
+}
+
+// SealARC 为邮件添加新的ARC集合，并保留chain中已有的ARC集合
+//
+// message为待发送的邮件（其中已有的ARC头部会被替换为chain中的集合），chain为接收时的验证结果。
+// 已有链的最新实例cv=fail或实例数已达上限时不再封装，原样返回邮件。
+func SealARC(message []byte, chain *ARCResult, options *ARCSealOptions) ([]byte, error) {
+	if _, ok := options.Signer.Public().(*rsa.PublicKey); !ok {
+		return nil, errors.New("ARC仅支持RSA密钥（rsa-sha256）")
+	}
+	if chain == nil {
+		chain = &ARCResult{Status: ARCNone}
+	}
+	if chain.Instance() >= MaxARCInstances || latestSealFailed(chain) {
+		return message, nil
+	}
+
+	fields, body := SplitMessage(NormalizeCRLF(message))
+	fields = removeARCHeaders(fields)
+
+	instance := chain.Instance() + 1
+	cv := chain.Status
+	if instance == 1 {
+		cv = ARCNone
+	}
+
+	timestamp := options.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	signedHeaders := options.SignedHeaders
+	if len(signedHeaders) == 0 {
+		signedHeaders = DefaultARCSignedHeaders
+	}
+	var present []string
+	for _, name := range signedHeaders {
+		if _, ok := FindHeader(fields, name); ok {
+			present = append(present, name)
+		}
+	}
+
+	// ARC-Authentication-Results
+	authResults := fmt.Sprintf("%s; arc=%s", options.AuthServID, chain.Status)
+	if options.AuthResults != "" {
+		authResults = fmt.Sprintf("%s; %s;\r\n\tarc=%s", options.AuthServID, options.AuthResults, chain.Status)
+	}
+	aar := newHeaderField(arcAuthResultsHeader, fmt.Sprintf("i=%d; %s\r\n", instance, authResults))
+
+	// ARC-Message-Signature
+	ams := newHeaderField(arcSignatureHeader, fmt.Sprintf(
+		"i=%d; a=rsa-sha256; c=relaxed/relaxed; d=%s;\r\n\ts=%s; t=%d;\r\n\th=%s;\r\n\tbh=%s;\r\n\tb=",
+		instance, options.Domain, options.Selector, timestamp.Unix(),
+		strings.ToLower(strings.Join(present, ":")), bodyHash(body, CanonRelaxed),
+	))
+	signature, err := signDigest(options.Signer, headerDigest(selectHeaders(fields, present), ams, CanonRelaxed))
+	if err != nil {
+		return nil, err
+	}
+	ams = appendSignature(ams, signature)
+
+	// ARC-Seal
+	seal := newHeaderField(arcSealHeader, fmt.Sprintf(
+		"i=%d; a=rsa-sha256; cv=%s; d=%s;\r\n\ts=%s; t=%d;\r\n\tb=",
+		instance, cv, options.Domain, options.Selector, timestamp.Unix(),
+	))
+	sets := append(append([]*ARCSet{}, chain.Sets...), &ARCSet{
+		Instance:         instance,
+		AuthResults:      aar,
+		MessageSignature: ams,
+		Seal:             seal,
+	})
+	signature, err = signDigest(options.Signer, sealDigest(sets))
+	if err != nil {
+		return nil, err
+	}
+	sets[len(sets)-1].Seal = appendSignature(seal, signature)
+
+	// 新的ARC集合位于最上方，已有集合按实例编号降序排列在其后
+	var header []HeaderField
+	for i := len(sets) - 1; i >= 0; i-- {
+		header = append(header, sets[i].Seal, sets[i].MessageSignature, sets[i].AuthResults)
+	}
+	header = append(header, fields...)
+
+	result := append(FormatHeader(header), "\r\n"...)
+	return append(result, body...), nil
+}
+
+// collectARCSets 按实例编号收集ARC集合，并检查链的结构完整性
+func collectARCSets(fields []HeaderField) ([]*ARCSet, error) {
+	byInstance := make(map[int]*ARCSet)
+	for _, field := range fields {
+		if !isARCHeader(field.Name) {
+			continue
+		}
+
+		instance, err := arcInstance(field)
+		if err != nil {
+			return nil, err
+		}
+		if instance < 1 || instance > MaxARCInstances {
+			return nil, fmt.Errorf("%s 的实例编号 %d 超出范围", field.Name, instance)
+		}
+		set, ok := byInstance[instance]
+		if !ok {
+			set = &ARCSet{Instance: instance}
+			byInstance[instance] = set
+		}
+
+		var slot *HeaderField
+		switch {
+		case strings.EqualFold(field.Name, arcAuthResultsHeader):
+			slot = &set.AuthResults
+		case strings.EqualFold(field.Name, arcSignatureHeader):
+			slot = &set.MessageSignature
+		default:
+			slot = &set.Seal
+		}
+		if slot.Name != "" {
+			return nil, fmt.Errorf("实例 i=%d 存在重复的 %s", instance, field.Name)
+		}
+		*slot = field
+	}
+
+	sets := make([]*ARCSet, 0, len(byInstance))
+	for _, set := range byInstance {
+		if set.AuthResults.Name == "" || set.MessageSignature.Name == "" || set.Seal.Name == "" {
+			return nil, fmt.Errorf("实例 i=%d 的ARC集合不完整", set.Instance)
+		}
+		sets = append(sets, set)
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].Instance < sets[j].Instance })
+	for i, set := range sets {
+		if set.Instance != i+1 {
+			return nil, fmt.Errorf("ARC实例编号不连续，缺少 i=%d", i+1)
+		}
+	}
+	return sets, nil
+}
+
+// arcInstance 解析ARC头部的i=标签
+func arcInstance(field HeaderField) (int, error) {
+	value := strings.TrimSpace(field.Value())
+	if strings.EqualFold(field.Name, arcAuthResultsHeader) {
+		// ARC-Authentication-Results仅以i=开头，其余部分为authres语法
+		if i := strings.IndexByte(value, ';'); i >= 0 {
+			value = value[:i]
+		}
+	}
+
+	tags, err := parseTagList(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s 格式错误: %w", field.Name, err)
+	}
+	instance, err := strconv.Atoi(tags["i"])
+	if err != nil {
+		return 0, fmt.Errorf("%s 缺少有效的i=标签", field.Name)
+	}
+	return instance, nil
+}
+
+// verifyARCMessageSignature 验证ARC-Message-Signature
+func verifyARCMessageSignature(ctx context.Context, resolver Resolver, fields []HeaderField, body []byte, set *ARCSet) error {
+	tags, err := parseTagList(set.MessageSignature.Value())
+	if err != nil {
+		return err
+	}
+	if err := checkARCAlgorithm(tags); err != nil {
+		return err
+	}
+
+	headerCanon, bodyCanon := CanonSimple, CanonSimple
+	if c := tags["c"]; c != "" {
+		parts := strings.SplitN(strings.ToLower(c), "/", 2)
+		headerCanon = parts[0]
+		if len(parts) == 2 {
+			bodyCanon = parts[1]
+		}
+	}
+
+	names := splitHeaderList(tags["h"])
+	for _, name := range names {
+		if strings.EqualFold(name, arcSealHeader) {
+			return errors.New("h=中不允许包含ARC-Seal")
+		}
+	}
+
+	canonicalBody := canonicalizeBody(body, bodyCanon)
+	if l, ok := tags["l"]; ok {
+		length, err := strconv.Atoi(l)
+		if err != nil || length < 0 || length > len(canonicalBody) {
+			return errors.New("l=标签无效")
+		}
+		canonicalBody = canonicalBody[:length]
+	}
+	sum := sha256.Sum256(canonicalBody)
+	if tags["bh"] != base64.StdEncoding.EncodeToString(sum[:]) {
+		return errors.New("正文哈希不匹配")
+	}
+
+	key, err := LookupPublicKey(ctx, resolver, tags["d"], tags["s"])
+	if err != nil {
+		return err
+	}
+	return verifyDigest(key.PublicKey, headerDigest(selectHeaders(fields, names), set.MessageSignature, headerCanon), tags["b"])
+}
+
+// verifyARCSeal 验证sets中最后一个实例的ARC-Seal
+func verifyARCSeal(ctx context.Context, resolver Resolver, sets []*ARCSet) error {
+	seal := sets[len(sets)-1].Seal
+	tags, err := parseTagList(seal.Value())
+	if err != nil {
+		return err
+	}
+	if err := checkARCAlgorithm(tags); err != nil {
+		return err
+	}
+
+	key, err := LookupPublicKey(ctx, resolver, tags["d"], tags["s"])
+	if err != nil {
+		return err
+	}
+	return verifyDigest(key.PublicKey, sealDigest(sets), tags["b"])
+}
+
+// sealDigest 计算ARC-Seal的签名摘要：按实例升序依次包含AAR、AMS和AS（最后一个AS的b=为空）
+func sealDigest(sets []*ARCSet) []byte {
+	var signed []HeaderField
+	for i, set := range sets {
+		signed = append(signed, set.AuthResults, set.MessageSignature)
+		if i < len(sets)-1 {
+			signed = append(signed, set.Seal)
+		}
+	}
+	return headerDigest(signed, sets[len(sets)-1].Seal, CanonRelaxed)
+}
+
+// checkARCAlgorithm 检查签名算法（RFC 8617仅允许rsa-sha256）
+func checkARCAlgorithm(tags map[string]string) error {
+	if a := strings.ToLower(tags["a"]); a != AlgorithmRSASHA256 {
+		return fmt.Errorf("不支持的签名算法: %s", a)
+	}
+	if tags["d"] == "" || tags["s"] == "" || tags["b"] == "" {
+		return errors.New("缺少必需的d=、s=或b=标签")
+	}
+	return nil
+}
+
+// latestSealFailed 检查已有链的最新ARC-Seal是否已标记为cv=fail
+func latestSealFailed(chain *ARCResult) bool {
+	if len(chain.Sets) == 0 {
+		return false
+	}
+	tags, err := parseTagList(chain.Sets[len(chain.Sets)-1].Seal.Value())
+	return err != nil || strings.EqualFold(tags["cv"], ARCFail)
+}
+
+// removeARCHeaders 移除邮件中的ARC头部
+func removeARCHeaders(fields []HeaderField) []HeaderField {
+	kept := make([]HeaderField, 0, len(fields))
+	for _, field := range fields {
+		if !isARCHeader(field.Name) {
+			kept = append(kept, field)
+		}
+	}
+	return kept
+}
+
+// isARCHeader 判断是否为ARC头部
+func isARCHeader(name string) bool {
+	return strings.EqualFold(name, arcAuthResultsHeader) ||
+		strings.EqualFold(name, arcSignatureHeader) ||
+		strings.EqualFold(name, arcSealHeader)
+}
+
+// newHeaderField 构造头部字段（签名头部不含结尾CRLF，便于追加签名值）
+func newHeaderField(name, value string) HeaderField {
+	return HeaderField{Name: name, Raw: name + ": " + value}
+}
+
+// appendSignature 追加b=签名值并补全结尾CRLF
+func appendSignature(field HeaderField, signature string) HeaderField {
+	field.Raw += foldSignature(signature) + "\r\n"
+	return field
+}
@@ -0,0 +1,183 @@
+package mailauth
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testMessage = "From: Alice <alice@example.com>\r\n" +
+	"To: bob@example.net\r\n" +
+	"Subject: ARC test\r\n" +
+	"Date: Mon, 05 Oct 2026 10:00:00 +0000\r\n" +
+	"Message-ID: <arc-test@example.com>\r\n" +
+	"\r\n" +
+	"Hello Bob,\r\n" +
+	"\r\n" +
+	"This is a test.\r\n"
+
+func TestSealARCAndValidate(t *testing.T) {
+	ctx := context.Background()
+	resolver := newFakeResolver()
+	first, second := newTestRSAKey(t), newTestRSAKey(t)
+	resolver.publishKey(t, "relay1.example", "arc", first)
+	resolver.publishKey(t, "relay2.example", "arc", second)
+
+	// 没有ARC头部的邮件
+	if result := ValidateARC(ctx, resolver, []byte(testMessage)); result.Status != ARCNone {
+		t.Fatalf("未封装的邮件cv应为none，实际为 %s（%s）", result.Status, result.Reason)
+	}
+
+	// 第一跳封装
+	sealed, err := SealARC([]byte(testMessage), nil, &ARCSealOptions{
+		Domain:      "relay1.example",
+		Selector:    "arc",
+		Signer:      first,
+		AuthServID:  "relay1.example",
+		AuthResults: "spf=pass smtp.mailfrom=example.com",
+		Timestamp:   time.Unix(1790000000, 0),
+	})
+	if err != nil {
+		t.Fatalf("第一次封装失败: %v", err)
+	}
+	chain := ValidateARC(ctx, resolver, sealed)
+	if chain.Status != ARCPass || chain.Instance() != 1 {
+		t.Fatalf("第一次封装后验证结果为 %s（i=%d，%s），期望pass（i=1）", chain.Status, chain.Instance(), chain.Reason)
+	}
+	if !strings.Contains(string(sealed), "cv=none") {
+		t.Errorf("第一个ARC-Seal应为cv=none")
+	}
+
+	// 第二跳在已有链上继续封装（中间可能新增了头部）
+	forwarded := append([]byte("Received: from relay1.example\r\n"), sealed...)
+	resealed, err := SealARC(forwarded, ValidateARC(ctx, resolver, forwarded), &ARCSealOptions{
+		Domain:     "relay2.example",
+		Selector:   "arc",
+		Signer:     second,
+		AuthServID: "relay2.example",
+	})
+	if err != nil {
+		t.Fatalf("第二次封装失败: %v", err)
+	}
+	chain = ValidateARC(ctx, resolver, resealed)
+	if chain.Status != ARCPass || chain.Instance() != 2 {
+		t.Fatalf("第二次封装后验证结果为 %s（i=%d，%s），期望pass（i=2）", chain.Status, chain.Instance(), chain.Reason)
+	}
+
+	// 换行符为LF的邮件同样可以验证
+	if result := ValidateARC(ctx, resolver, bytes.ReplaceAll(resealed, []byte("\r\n"), []byte("\n"))); result.Status != ARCPass {
+		t.Errorf("LF换行的邮件验证结果为 %s（%s），期望pass", result.Status, result.Reason)
+	}
+}
+
+func TestValidateARCFailures(t *testing.T) {
+	ctx := context.Background()
+	resolver := newFakeResolver()
+	key := newTestRSAKey(t)
+	resolver.publishKey(t, "relay.example", "arc", key)
+
+	sealed, err := SealARC([]byte(testMessage), nil, &ARCSealOptions{
+		Domain:     "relay.example",
+		Selector:   "arc",
+		Signer:     key,
+		AuthServID: "relay.example",
+	})
+	if err != nil {
+		t.Fatalf("封装失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(string) string
+		setup   func(*fakeResolver)
+		wantErr string
+	}{
+		{
+			name:    "正文被修改",
+			mutate:  func(m string) string { return strings.Replace(m, "This is a test.", "This is a forgery.", 1) },
+			wantErr: "正文哈希不匹配",
+		},
+		{
+			name:    "签名头部被修改",
+			mutate:  func(m string) string { return strings.Replace(m, "Subject: ARC test", "Subject: ARC forged", 1) },
+			wantErr: "ARC-Message-Signature i=1",
+		},
+		{
+			name:    "cv取值错误",
+			mutate:  func(m string) string { return strings.Replace(m, "cv=none", "cv=pass", 1) },
+			wantErr: "cv=pass",
+		},
+		{
+			name: "集合不完整",
+			mutate: func(m string) string {
+				fields, body := SplitMessage([]byte(m))
+				var kept []HeaderField
+				for _, field := range fields {
+					if field.Name != arcAuthResultsHeader {
+						kept = append(kept, field)
+					}
+				}
+				return string(FormatHeader(kept)) + "\r\n" + string(body)
+			},
+			wantErr: "不完整",
+		},
+		{
+			name:    "公钥未发布",
+			setup:   func(r *fakeResolver) { delete(r.txt, "arc._domainkey.relay.example") },
+			wantErr: "验证失败",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newFakeResolver()
+			for name, records := range resolver.txt {
+				r.txt[name] = records
+			}
+			if tt.setup != nil {
+				tt.setup(r)
+			}
+			message := string(sealed)
+			if tt.mutate != nil {
+				message = tt.mutate(message)
+			}
+
+			result := ValidateARC(ctx, r, []byte(message))
+			if result.Status != ARCFail {
+				t.Fatalf("验证结果为 %s，期望fail", result.Status)
+			}
+			if !strings.Contains(result.Reason, tt.wantErr) {
+				t.Errorf("失败原因 %q 不包含 %q", result.Reason, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSealARCSkipsFailedChain(t *testing.T) {
+	key := newTestRSAKey(t)
+	failed := &ARCResult{Status: ARCFail, Sets: []*ARCSet{{
+		Instance: 1,
+		Seal:     HeaderField{Name: arcSealHeader, Raw: "ARC-Seal: i=1; a=rsa-sha256; cv=fail; d=x.example; s=s; b=abc\r\n"},
+	}}}
+
+	message := []byte(testMessage)
+	result, err := SealARC(message, failed, &ARCSealOptions{Domain: "relay.example", Selector: "arc", Signer: key, AuthServID: "relay.example"})
+	if err != nil {
+		t.Fatalf("封装失败: %v", err)
+	}
+	if !bytes.Equal(result, message) {
+		t.Errorf("最新实例cv=fail时不应继续封装")
+	}
+}
+
+func TestSealARCRejectsEd25519(t *testing.T) {
+	_, err := SealARC([]byte(testMessage), nil, &ARCSealOptions{
+		Domain:   "relay.example",
+		Selector: "arc",
+		Signer:   newTestEd25519Key(t),
+	})
+	if err == nil {
+		t.Fatal("ARC封装应拒绝Ed25519密钥")
+	}
+}
@@ -0,0 +1,283 @@
+package mailauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dkimSignatureHeader = "DKIM-Signature"
+
+// DKIM签名验证结果（RFC 8601 第2.7.1节）
+const (
+	DKIMPass      = "pass"
+	DKIMFail      = "fail"
+	DKIMTempError = "temperror"
+	DKIMPermError = "permerror"
+)
+
+// minRSAKeyBits 验证时接受的最小RSA密钥长度（RFC 8301）
+const minRSAKeyBits = 1024
+
+// DefaultDKIMSignedHeaders DKIM-Signature默认签名的头部
+var DefaultDKIMSignedHeaders = []string{
+	"From", "To", "Cc", "Subject", "Date", "Message-ID", "Reply-To",
+	"In-Reply-To", "References", "MIME-Version", "Content-Type",
+	"Content-Transfer-Encoding",
+}
+
+// DKIMSignOptions DKIM签名参数
+type DKIMSignOptions struct {
+	Domain        string        // d=
+	Selector      string        // s=
+	Signer        crypto.Signer // RSA或Ed25519私钥
+	HeaderCanon   string        // 头部规范化算法，默认relaxed
+	BodyCanon     string        // 正文规范化算法，默认relaxed
+	SignedHeaders []string      // 参与签名的头部，默认DefaultDKIMSignedHeaders
+	Oversign      []string      // 过度签名的头部：在h=中额外列出一次，使接收方能发现追加的同名头部
+	Timestamp     time.Time     // t=，默认当前时间
+}
+
+// SignDKIM 为邮件添加DKIM-Signature头部（RFC 6376，Ed25519见RFC 8463）
+// 同一封邮件可依次使用不同算法的密钥多次调用，各签名互不覆盖对方
+func SignDKIM(message []byte, options *DKIMSignOptions) ([]byte, error) {
+	if options.Signer == nil {
+		return nil, errors.New("缺少DKIM签名私钥")
+	}
+	algorithm, err := SignatureAlgorithm(options.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	headerCanon, bodyCanon := CanonRelaxed, CanonRelaxed
+	if options.HeaderCanon == CanonSimple {
+		headerCanon = CanonSimple
+	}
+	if options.BodyCanon == CanonSimple {
+		bodyCanon = CanonSimple
+	}
+
+	timestamp := options.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	fields, body := SplitMessage(NormalizeCRLF(message))
+
+	signedHeaders := options.SignedHeaders
+	if len(signedHeaders) == 0 {
+		signedHeaders = DefaultDKIMSignedHeaders
+	}
+	var present []string
+	for _, name := range signedHeaders {
+		if _, ok := FindHeader(fields, name); ok {
+			present = append(present, name)
+		}
+	}
+	if _, ok := FindHeader(fields, "From"); !ok {
+		return nil, errors.New("邮件缺少From头部，无法进行DKIM签名")
+	}
+	present = append(present, options.Oversign...)
+
+	signature := newHeaderField(dkimSignatureHeader, fmt.Sprintf(
+		"v=1; a=%s; c=%s/%s; d=%s;\r\n\ts=%s; t=%d;\r\n\th=%s;\r\n\tbh=%s;\r\n\tb=",
+		algorithm, headerCanon, bodyCanon, options.Domain, options.Selector, timestamp.Unix(),
+		strings.ToLower(strings.Join(present, ":")), bodyHash(body, bodyCanon),
+	))
+	value, err := signDigest(options.Signer, headerDigest(selectHeaders(fields, present), signature, headerCanon))
+	if err != nil {
+		return nil, err
+	}
+	signature = appendSignature(signature, value)
+
+	header := append([]HeaderField{signature}, fields...)
+	result := append(FormatHeader(header), "\r\n"...)
+	return append(result, body...), nil
+}
+
+// DKIMVerification 单个DKIM-Signature的验证结果
+type DKIMVerification struct {
+	Result           string    // pass, fail, temperror, permerror
+	Domain           string    // d=
+	Selector         string    // s=
+	Algorithm        string    // a=
+	Canonicalization string    // c=，如relaxed/relaxed
+	SignedHeaders    []string  // h=
+	Identity         string    // i=
+	BodyLength       int       // l=，-1表示未设置
+	Timestamp        time.Time // t=，未设置时为零值
+	Expiration       time.Time // x=，未设置时为零值
+	KeyBits          int       // 公钥长度（Ed25519为256）
+	Reason           string    // 未通过的原因
+}
+
+// VerifyDKIM 按出现顺序验证邮件中的所有DKIM-Signature（RFC 6376 第6节），没有签名时返回空列表
+func VerifyDKIM(ctx context.Context, resolver Resolver, message []byte) []*DKIMVerification {
+	fields, body := SplitMessage(NormalizeCRLF(message))
+
+	var results []*DKIMVerification
+	for _, field := range fields {
+		if strings.EqualFold(field.Name, dkimSignatureHeader) {
+			results = append(results, verifyDKIMSignature(ctx, resolver, fields, body, field))
+		}
+	}
+	return results
+}
+
+// verifyDKIMSignature 验证单个DKIM-Signature
+func verifyDKIMSignature(ctx context.Context, resolver Resolver, fields []HeaderField, body []byte, signature HeaderField) *DKIMVerification {
+	result := &DKIMVerification{Result: DKIMPermError, BodyLength: -1}
+
+	tags, err := parseTagList(signature.Value())
+	if err != nil {
+		result.Reason = fmt.Sprintf("签名格式错误: %v", err)
+		return result
+	}
+	result.Domain = strings.ToLower(tags["d"])
+	result.Selector = tags["s"]
+	result.Algorithm = strings.ToLower(tags["a"])
+	result.SignedHeaders = splitHeaderList(tags["h"])
+	result.Identity = tags["i"]
+
+	if tags["v"] != "1" {
+		result.Reason = fmt.Sprintf("不支持的签名版本: %s", tags["v"])
+		return result
+	}
+	for _, name := range []string{"a", "b", "bh", "d", "h", "s"} {
+		if tags[name] == "" {
+			result.Reason = fmt.Sprintf("缺少必需的%s=标签", name)
+			return result
+		}
+	}
+	if result.Algorithm != AlgorithmRSASHA256 && result.Algorithm != AlgorithmEd25519SHA256 {
+		result.Reason = fmt.Sprintf("不支持的签名算法: %s", result.Algorithm)
+		return result
+	}
+
+	hasFrom := false
+	for _, name := range result.SignedHeaders {
+		if strings.EqualFold(name, "From") {
+			hasFrom = true
+			break
+		}
+	}
+	if !hasFrom {
+		result.Reason = "h=中未包含From"
+		return result
+	}
+
+	// i=须为d=或其子域名
+	if result.Identity != "" {
+		at := strings.LastIndex(result.Identity, "@")
+		identityDomain := strings.ToLower(result.Identity[at+1:])
+		if at < 0 || (identityDomain != result.Domain && !strings.HasSuffix(identityDomain, "."+result.Domain)) {
+			result.Reason = "i=与d=不匹配"
+			return result
+		}
+	}
+
+	headerCanon, bodyCanon := CanonSimple, CanonSimple
+	if c := strings.ToLower(tags["c"]); c != "" {
+		parts := strings.SplitN(c, "/", 2)
+		headerCanon = parts[0]
+		if len(parts) == 2 {
+			bodyCanon = parts[1]
+		}
+	}
+	result.Canonicalization = headerCanon + "/" + bodyCanon
+	for _, canon := range []string{headerCanon, bodyCanon} {
+		if canon != CanonSimple && canon != CanonRelaxed {
+			result.Reason = fmt.Sprintf("不支持的规范化算法: %s", canon)
+			return result
+		}
+	}
+
+	for _, tag := range []struct {
+		name  string
+		value *time.Time
+	}{{"t", &result.Timestamp}, {"x", &result.Expiration}} {
+		if raw, ok := tags[tag.name]; ok {
+			seconds, err := strconv.ParseInt(raw, 10, 64)
+			if err != nil || seconds < 0 {
+				result.Reason = fmt.Sprintf("%s=标签无效", tag.name)
+				return result
+			}
+			*tag.value = time.Unix(seconds, 0)
+		}
+	}
+
+	// 正文哈希
+	canonicalBody := canonicalizeBody(body, bodyCanon)
+	if l, ok := tags["l"]; ok {
+		length, err := strconv.Atoi(l)
+		if err != nil || length < 0 {
+			result.Reason = "l=标签无效"
+			return result
+		}
+		result.BodyLength = length
+		if length > len(canonicalBody) {
+			result.Result = DKIMFail
+			result.Reason = "l=超过正文长度"
+			return result
+		}
+		canonicalBody = canonicalBody[:length]
+	}
+	sum := sha256.Sum256(canonicalBody)
+	if tags["bh"] != base64.StdEncoding.EncodeToString(sum[:]) {
+		result.Result = DKIMFail
+		result.Reason = "正文哈希不匹配（邮件正文在签名后被修改）"
+		return result
+	}
+
+	// 公钥
+	key, err := LookupPublicKey(ctx, resolver, result.Domain, result.Selector)
+	if err != nil {
+		if isTempDNSError(err) {
+			result.Result = DKIMTempError
+		}
+		result.Reason = err.Error()
+		return result
+	}
+	switch publicKey := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		result.KeyBits = publicKey.N.BitLen()
+		if result.Algorithm != AlgorithmRSASHA256 {
+			result.Reason = "公钥类型与签名算法不匹配"
+			return result
+		}
+		if result.KeyBits < minRSAKeyBits {
+			result.Reason = fmt.Sprintf("RSA密钥长度过短: %d位", result.KeyBits)
+			return result
+		}
+	case ed25519.PublicKey:
+		result.KeyBits = 256
+		if result.Algorithm != AlgorithmEd25519SHA256 {
+			result.Reason = "公钥类型与签名算法不匹配"
+			return result
+		}
+	}
+
+	digest := headerDigest(selectHeaders(fields, result.SignedHeaders), signature, headerCanon)
+	if err := verifyDigest(key.PublicKey, digest, tags["b"]); err != nil {
+		result.Result = DKIMFail
+		result.Reason = "头部签名验证失败（签名头部在签名后被修改或公钥不匹配）"
+		return result
+	}
+
+	if !result.Expiration.IsZero() && time.Now().After(result.Expiration) {
+		result.Result = DKIMFail
+		result.Reason = "签名已过期"
+		return result
+	}
+
+	result.Result = DKIMPass
+	return result
+}
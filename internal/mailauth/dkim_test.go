@@ -0,0 +1,249 @@
+package mailauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+)
+
+func TestSignDKIMRoundTrip(t *testing.T) {
+	resolver := newFakeResolver()
+	rsaKey, edKey := newTestRSAKey(t), newTestEd25519Key(t)
+	resolver.publishKey(t, "example.com", "rsa", rsaKey)
+	resolver.publishKey(t, "example.com", "ed", edKey)
+
+	tests := []struct {
+		name          string
+		selector      string
+		signer        crypto.Signer
+		headerCanon   string
+		bodyCanon     string
+		wantAlgorithm string
+		wantCanon     string
+	}{
+		{"RSA默认relaxed", "rsa", rsaKey, "", "", AlgorithmRSASHA256, "relaxed/relaxed"},
+		{"RSA simple/simple", "rsa", rsaKey, CanonSimple, CanonSimple, AlgorithmRSASHA256, "simple/simple"},
+		{"Ed25519 relaxed/simple", "ed", edKey, CanonRelaxed, CanonSimple, AlgorithmEd25519SHA256, "relaxed/simple"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := SignDKIM([]byte(testMessage), &DKIMSignOptions{
+				Domain:      "example.com",
+				Selector:    tt.selector,
+				Signer:      tt.signer,
+				HeaderCanon: tt.headerCanon,
+				BodyCanon:   tt.bodyCanon,
+			})
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+
+			results := VerifyDKIM(context.Background(), resolver, signed)
+			if len(results) != 1 {
+				t.Fatalf("验证结果数量为 %d，期望1", len(results))
+			}
+			result := results[0]
+			if result.Result != DKIMPass {
+				t.Fatalf("验证结果为 %s（%s），期望pass", result.Result, result.Reason)
+			}
+			if result.Algorithm != tt.wantAlgorithm || result.Canonicalization != tt.wantCanon {
+				t.Errorf("a=%s c=%s，期望 a=%s c=%s", result.Algorithm, result.Canonicalization, tt.wantAlgorithm, tt.wantCanon)
+			}
+			// 未出现的默认头部不应写入h=
+			for _, name := range result.SignedHeaders {
+				if strings.EqualFold(name, "Cc") {
+					t.Errorf("h=中包含邮件中不存在的头部Cc")
+				}
+			}
+		})
+	}
+}
+
+func TestSignDKIMDualSignature(t *testing.T) {
+	resolver := newFakeResolver()
+	rsaKey, edKey := newTestRSAKey(t), newTestEd25519Key(t)
+	resolver.publishKey(t, "example.com", "rsa", rsaKey)
+	resolver.publishKey(t, "example.com", "ed", edKey)
+
+	signed, err := SignDKIM([]byte(testMessage), &DKIMSignOptions{Domain: "example.com", Selector: "rsa", Signer: rsaKey})
+	if err != nil {
+		t.Fatalf("RSA签名失败: %v", err)
+	}
+	signed, err = SignDKIM(signed, &DKIMSignOptions{Domain: "example.com", Selector: "ed", Signer: edKey})
+	if err != nil {
+		t.Fatalf("Ed25519签名失败: %v", err)
+	}
+
+	results := VerifyDKIM(context.Background(), resolver, signed)
+	if len(results) != 2 {
+		t.Fatalf("验证结果数量为 %d，期望2", len(results))
+	}
+	// 后添加的签名位于最上方
+	want := []string{AlgorithmEd25519SHA256, AlgorithmRSASHA256}
+	for i, result := range results {
+		if result.Result != DKIMPass {
+			t.Errorf("第%d个签名验证结果为 %s（%s），期望pass", i+1, result.Result, result.Reason)
+		}
+		if result.Algorithm != want[i] {
+			t.Errorf("第%d个签名算法为 %s，期望 %s", i+1, result.Algorithm, want[i])
+		}
+	}
+}
+
+func TestSignDKIMOversign(t *testing.T) {
+	resolver := newFakeResolver()
+	key := newTestRSAKey(t)
+	resolver.publishKey(t, "example.com", "s1", key)
+
+	tests := []struct {
+		name     string
+		oversign []string
+		want     string
+	}{
+		{"未过度签名时追加From不影响验证", nil, DKIMPass},
+		{"过度签名时追加From导致验证失败", []string{"From"}, DKIMFail},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signed, err := SignDKIM([]byte(testMessage), &DKIMSignOptions{
+				Domain:   "example.com",
+				Selector: "s1",
+				Signer:   key,
+				Oversign: tt.oversign,
+			})
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+
+			tampered := append([]byte("From: Mallory <mallory@attacker.test>\r\n"), signed...)
+			results := VerifyDKIM(context.Background(), resolver, tampered)
+			if len(results) != 1 || results[0].Result != tt.want {
+				t.Fatalf("验证结果为 %+v，期望 %s", results, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignDKIMErrors(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("生成ECDSA密钥失败: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		signer  crypto.Signer
+		wantErr string
+	}{
+		{"缺少私钥", testMessage, nil, "缺少DKIM签名私钥"},
+		{"不支持的私钥类型", testMessage, ecKey, "不支持的私钥类型"},
+		{"缺少From", "To: bob@example.net\r\nSubject: hi\r\n\r\nbody\r\n", newTestEd25519Key(t), "缺少From头部"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SignDKIM([]byte(tt.message), &DKIMSignOptions{Domain: "example.com", Selector: "s1", Signer: tt.signer})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("期望错误包含 %q，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestVerifyDKIMFailures(t *testing.T) {
+	resolver := newFakeResolver()
+	key := newTestRSAKey(t)
+	resolver.publishKey(t, "example.com", "s1", key)
+	resolver.publishKey(t, "example.com", "ed", newTestEd25519Key(t))
+	resolver.txt["revoked._domainkey.example.com"] = []string{"v=DKIM1; k=rsa; p="}
+	resolver.tempFail["flaky._domainkey.example.com"] = true
+
+	signed, err := SignDKIM([]byte(testMessage), &DKIMSignOptions{Domain: "example.com", Selector: "s1", Signer: key})
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	message := string(signed)
+
+	tests := []struct {
+		name       string
+		mutate     func(string) string
+		wantResult string
+		wantReason string
+	}{
+		{
+			name:       "正文被修改",
+			mutate:     func(m string) string { return strings.Replace(m, "This is a test.", "This is a forgery.", 1) },
+			wantResult: DKIMFail,
+			wantReason: "正文哈希不匹配",
+		},
+		{
+			name:       "签名头部被修改",
+			mutate:     func(m string) string { return strings.Replace(m, "Subject: ARC test", "Subject: forged", 1) },
+			wantResult: DKIMFail,
+			wantReason: "头部签名验证失败",
+		},
+		{
+			name:       "公钥与签名算法不匹配",
+			mutate:     func(m string) string { return strings.Replace(m, "s=s1;", "s=ed;", 1) },
+			wantResult: DKIMPermError,
+			wantReason: "不匹配",
+		},
+		{
+			name:       "公钥已撤销",
+			mutate:     func(m string) string { return strings.Replace(m, "s=s1;", "s=revoked;", 1) },
+			wantResult: DKIMPermError,
+			wantReason: "撤销",
+		},
+		{
+			name:       "公钥查询临时失败",
+			mutate:     func(m string) string { return strings.Replace(m, "s=s1;", "s=flaky;", 1) },
+			wantResult: DKIMTempError,
+		},
+		{
+			name:       "h=中缺少From",
+			mutate:     func(m string) string { return strings.Replace(m, "h=from:", "h=", 1) },
+			wantResult: DKIMPermError,
+			wantReason: "未包含From",
+		},
+		{
+			name:       "不支持的签名算法",
+			mutate:     func(m string) string { return strings.Replace(m, "a=rsa-sha256", "a=rsa-sha1", 1) },
+			wantResult: DKIMPermError,
+			wantReason: "不支持的签名算法",
+		},
+		{
+			name:       "i=与d=不匹配",
+			mutate:     func(m string) string { return strings.Replace(m, "d=example.com;", "d=example.com; i=@other.test;", 1) },
+			wantResult: DKIMPermError,
+			wantReason: "i=与d=不匹配",
+		},
+		{
+			name:       "l=超过正文长度",
+			mutate:     func(m string) string { return strings.Replace(m, "d=example.com;", "d=example.com; l=100000;", 1) },
+			wantResult: DKIMFail,
+			wantReason: "l=超过正文长度",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := VerifyDKIM(context.Background(), resolver, []byte(tt.mutate(message)))
+			if len(results) != 1 {
+				t.Fatalf("验证结果数量为 %d，期望1", len(results))
+			}
+			if results[0].Result != tt.wantResult {
+				t.Fatalf("验证结果为 %s（%s），期望 %s", results[0].Result, results[0].Reason, tt.wantResult)
+			}
+			if !strings.Contains(results[0].Reason, tt.wantReason) {
+				t.Errorf("Reason = %q，期望包含 %q", results[0].Reason, tt.wantReason)
+			}
+		})
+	}
+
+	if results := VerifyDKIM(context.Background(), resolver, []byte(testMessage)); len(results) != 0 {
+		t.Errorf("未签名的邮件应返回空列表，实际为 %d 个结果", len(results))
+	}
+}
@@ -0,0 +1,227 @@
+package mailauth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DMARC对齐模式
+const (
+	AlignRelaxed = "r"
+	AlignStrict  = "s"
+)
+
+// DMARC评估结果
+const (
+	DMARCPass      = "pass"
+	DMARCFail      = "fail"
+	DMARCNone      = "none"
+	DMARCTempError = "temperror"
+	DMARCPermError = "permerror"
+)
+
+// DMARCRecord DMARC策略记录
+type DMARCRecord struct {
+	Domain          string   // 记录所在域名（可能是组织域名）
+	Policy          string   // p=
+	SubdomainPolicy string   // sp=，未设置时与p相同
+	ADKIM           string   // adkim=，默认r
+	ASPF            string   // aspf=，默认r
+	Percent         int      // pct=，默认100
+	RUA             []string // 汇总报告地址
+	RUF             []string // 失败报告地址
+	Raw             string   // 原始TXT记录
+}
+
+// DMARCResult DMARC评估结果
+type DMARCResult struct {
+	Result      string       // pass, fail, none, temperror, permerror
+	FromDomain  string       // From头部域名
+	Record      *DMARCRecord // 适用的DMARC记录，未发布时为nil
+	Policy      string       // 适用的策略（子域名使用sp=）
+	SPFAligned  bool         // SPF通过且与From域名对齐
+	DKIMAligned bool         // 存在通过且与From域名对齐的DKIM签名
+	DKIMDomain  string       // 对齐的DKIM签名域名
+	Reason      string       // 评估说明
+}
+
+// EvaluateDMARC 根据SPF和DKIM验证结果评估From域名的DMARC（RFC 7489 第6.6节）
+// spf可为nil（未进行SPF检查）
+func EvaluateDMARC(ctx context.Context, resolver Resolver, fromDomain string, spf *SPFCheck, dkim []*DKIMVerification) *DMARCResult {
+	fromDomain = strings.TrimSuffix(strings.ToLower(fromDomain), ".")
+	result := &DMARCResult{FromDomain: fromDomain}
+
+	record, err := LookupDMARC(ctx, resolver, fromDomain)
+	if err != nil {
+		result.Result = DMARCPermError
+		if isTempDNSError(err) {
+			result.Result = DMARCTempError
+		}
+		result.Reason = err.Error()
+		return result
+	}
+
+	// 未发布记录时仍按宽松模式计算对齐情况，便于排查
+	adkim, aspf := AlignRelaxed, AlignRelaxed
+	if record != nil {
+		result.Record = record
+		result.Policy = record.Policy
+		if record.Domain != fromDomain {
+			result.Policy = record.SubdomainPolicy
+		}
+		adkim, aspf = record.ADKIM, record.ASPF
+	}
+
+	if spf != nil && spf.Result == SPFPass && Aligned(fromDomain, spf.Domain, aspf) {
+		result.SPFAligned = true
+	}
+	for _, verification := range dkim {
+		if verification.Result == DKIMPass && Aligned(fromDomain, verification.Domain, adkim) {
+			result.DKIMAligned = true
+			result.DKIMDomain = verification.Domain
+			break
+		}
+	}
+
+	switch {
+	case record == nil:
+		result.Result = DMARCNone
+		result.Reason = "未发布DMARC记录"
+	case result.SPFAligned || result.DKIMAligned:
+		result.Result = DMARCPass
+	default:
+		result.Result = DMARCFail
+		result.Reason = "没有通过且与From域名对齐的SPF或DKIM结果"
+	}
+	return result
+}
+
+// LookupDMARC 查询域名的DMARC记录，未找到时回退到组织域名；均未发布时返回nil
+func LookupDMARC(ctx context.Context, resolver Resolver, domain string) (*DMARCRecord, error) {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	candidates := []string{domain}
+	if org := OrganizationalDomain(domain); org != domain {
+		candidates = append(candidates, org)
+	}
+
+	for _, candidate := range candidates {
+		records, err := resolver.LookupTXT(ctx, "_dmarc."+candidate)
+		if err != nil {
+			if IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("查询 _dmarc.%s 失败: %w", candidate, err)
+		}
+
+		var found []string
+		for _, record := range records {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(record)), "v=dmarc1") {
+				found = append(found, record)
+			}
+		}
+		if len(found) == 0 {
+			continue
+		}
+		if len(found) > 1 {
+			return nil, fmt.Errorf("_dmarc.%s 存在多条DMARC记录", candidate)
+		}
+
+		record, err := ParseDMARCRecord(found[0])
+		if err != nil {
+			return nil, err
+		}
+		record.Domain = candidate
+		return record, nil
+	}
+
+	return nil, nil
+}
+
+// ParseDMARCRecord 解析DMARC记录（RFC 7489 第6.3节）
+func ParseDMARCRecord(raw string) (*DMARCRecord, error) {
+	tags, err := parseTagList(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(tags["v"], "DMARC1") {
+		return nil, errors.New("DMARC记录缺少v=DMARC1")
+	}
+
+	record := &DMARCRecord{
+		Policy:          strings.ToLower(tags["p"]),
+		SubdomainPolicy: strings.ToLower(tags["sp"]),
+		ADKIM:           strings.ToLower(tags["adkim"]),
+		ASPF:            strings.ToLower(tags["aspf"]),
+		Percent:         100,
+		RUA:             splitDMARCURIs(tags["rua"]),
+		RUF:             splitDMARCURIs(tags["ruf"]),
+		Raw:             raw,
+	}
+
+	switch record.Policy {
+	case "none", "quarantine", "reject":
+	case "":
+		// 缺少p=但配置了rua时按none处理
+		if len(record.RUA) == 0 {
+			return nil, errors.New("DMARC记录缺少p=标签")
+		}
+		record.Policy = "none"
+	default:
+		return nil, fmt.Errorf("无效的DMARC策略: %s", record.Policy)
+	}
+	if record.SubdomainPolicy == "" {
+		record.SubdomainPolicy = record.Policy
+	}
+	if record.ADKIM != AlignStrict {
+		record.ADKIM = AlignRelaxed
+	}
+	if record.ASPF != AlignStrict {
+		record.ASPF = AlignRelaxed
+	}
+	if pct, ok := tags["pct"]; ok {
+		percent, err := strconv.Atoi(pct)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("无效的pct值: %s", pct)
+		}
+		record.Percent = percent
+	}
+
+	return record, nil
+}
+
+// OrganizationalDomain 根据公共后缀列表返回域名的组织域名（如 mail.example.com → example.com，a.github.io → a.github.io）
+func OrganizationalDomain(domain string) string {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	org, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		// 域名本身是公共后缀或格式无效时，以域名本身作为组织域名
+		return domain
+	}
+	return org
+}
+
+// Aligned 判断认证域名与From域名是否按指定模式对齐
+func Aligned(fromDomain, authDomain, mode string) bool {
+	fromDomain = strings.TrimSuffix(strings.ToLower(fromDomain), ".")
+	authDomain = strings.TrimSuffix(strings.ToLower(authDomain), ".")
+	if mode == AlignStrict {
+		return fromDomain == authDomain
+	}
+	return OrganizationalDomain(fromDomain) == OrganizationalDomain(authDomain)
+}
+
+// splitDMARCURIs 拆分逗号分隔的报告地址
+func splitDMARCURIs(value string) []string {
+	var uris []string
+	for _, uri := range strings.Split(value, ",") {
+		if uri = strings.TrimSpace(uri); uri != "" {
+			uris = append(uris, uri)
+		}
+	}
+	return uris
+}
@@ -0,0 +1,234 @@
+package mailauth
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestParseDMARCRecord(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    DMARCRecord
+		wantErr string
+	}{
+		{
+			name: "默认值",
+			raw:  "v=DMARC1; p=reject",
+			want: DMARCRecord{Policy: "reject", SubdomainPolicy: "reject", ADKIM: AlignRelaxed, ASPF: AlignRelaxed, Percent: 100},
+		},
+		{
+			name: "完整记录",
+			raw:  "v=DMARC1; p=Quarantine; sp=none; adkim=s; aspf=s; pct=25; rua=mailto:a@example.com, mailto:b@example.com",
+			want: DMARCRecord{Policy: "quarantine", SubdomainPolicy: "none", ADKIM: AlignStrict, ASPF: AlignStrict, Percent: 25,
+				RUA: []string{"mailto:a@example.com", "mailto:b@example.com"}},
+		},
+		{
+			name: "缺少p但有rua",
+			raw:  "v=DMARC1; rua=mailto:a@example.com",
+			want: DMARCRecord{Policy: "none", SubdomainPolicy: "none", ADKIM: AlignRelaxed, ASPF: AlignRelaxed, Percent: 100,
+				RUA: []string{"mailto:a@example.com"}},
+		},
+		{name: "缺少v", raw: "p=reject", wantErr: "v=DMARC1"},
+		{name: "缺少p", raw: "v=DMARC1; pct=50", wantErr: "缺少p="},
+		{name: "无效策略", raw: "v=DMARC1; p=block", wantErr: "无效的DMARC策略"},
+		{name: "无效pct", raw: "v=DMARC1; p=none; pct=150", wantErr: "无效的pct值"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record, err := ParseDMARCRecord(tt.raw)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望错误包含 %q，实际为 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if record.Policy != tt.want.Policy || record.SubdomainPolicy != tt.want.SubdomainPolicy ||
+				record.ADKIM != tt.want.ADKIM || record.ASPF != tt.want.ASPF || record.Percent != tt.want.Percent ||
+				strings.Join(record.RUA, ",") != strings.Join(tt.want.RUA, ",") {
+				t.Errorf("解析结果为 %+v，期望 %+v", *record, tt.want)
+			}
+		})
+	}
+}
+
+func TestLookupDMARC(t *testing.T) {
+	resolver := newFakeResolver()
+	resolver.txt["_dmarc.example.com"] = []string{"v=DMARC1; p=reject; sp=quarantine"}
+	resolver.txt["_dmarc.own.example.com"] = []string{"some other record", "v=DMARC1; p=none"}
+	resolver.txt["_dmarc.dup.test"] = []string{"v=DMARC1; p=none", "v=DMARC1; p=reject"}
+	resolver.tempFail["_dmarc.broken.test"] = true
+
+	tests := []struct {
+		domain     string
+		wantDomain string
+		wantPolicy string
+		wantErr    bool
+	}{
+		{domain: "example.com", wantDomain: "example.com", wantPolicy: "reject"},
+		{domain: "Mail.Example.COM.", wantDomain: "example.com", wantPolicy: "reject"},
+		{domain: "own.example.com", wantDomain: "own.example.com", wantPolicy: "none"},
+		{domain: "unpublished.test"},
+		{domain: "dup.test", wantErr: true},
+		{domain: "broken.test", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			record, err := LookupDMARC(context.Background(), resolver, tt.domain)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("期望返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("查询失败: %v", err)
+			}
+			if tt.wantDomain == "" {
+				if record != nil {
+					t.Fatalf("未发布记录时应返回nil，实际为 %+v", *record)
+				}
+				return
+			}
+			if record == nil || record.Domain != tt.wantDomain || record.Policy != tt.wantPolicy {
+				t.Fatalf("查询结果为 %+v，期望 Domain=%s Policy=%s", record, tt.wantDomain, tt.wantPolicy)
+			}
+		})
+	}
+}
+
+func TestOrganizationalDomain(t *testing.T) {
+	tests := map[string]string{
+		"example.com":          "example.com",
+		"mail.example.com":     "example.com",
+		"a.b.example.co.uk":    "example.co.uk",
+		"alice.github.io":      "alice.github.io",
+		"mail.alice.github.io": "alice.github.io",
+		"Mail.Example.COM.":    "example.com",
+		"co.uk":                "co.uk",
+	}
+	for domain, want := range tests {
+		if got := OrganizationalDomain(domain); got != want {
+			t.Errorf("OrganizationalDomain(%q) = %q，期望 %q", domain, got, want)
+		}
+	}
+}
+
+func TestAligned(t *testing.T) {
+	tests := []struct {
+		from, auth, mode string
+		want             bool
+	}{
+		{"example.com", "example.com", AlignStrict, true},
+		{"example.com", "mail.example.com", AlignStrict, false},
+		{"example.com", "mail.example.com", AlignRelaxed, true},
+		{"news.example.com", "bounce.example.com", AlignRelaxed, true},
+		{"example.com", "example.net", AlignRelaxed, false},
+		{"alice.github.io", "bob.github.io", AlignRelaxed, false},
+		{"example.co.uk", "other.co.uk", AlignRelaxed, false},
+		{"Example.COM", "example.com.", AlignStrict, true},
+	}
+	for _, tt := range tests {
+		if got := Aligned(tt.from, tt.auth, tt.mode); got != tt.want {
+			t.Errorf("Aligned(%q, %q, %q) = %v，期望 %v", tt.from, tt.auth, tt.mode, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateDMARC(t *testing.T) {
+	resolver := newFakeResolver()
+	resolver.txt["_dmarc.example.com"] = []string{"v=DMARC1; p=reject; sp=quarantine"}
+	resolver.txt["_dmarc.strict.test"] = []string{"v=DMARC1; p=reject; adkim=s; aspf=s"}
+	resolver.tempFail["_dmarc.flaky.test"] = true
+	resolver.txt["_dmarc.invalid.test"] = []string{"v=DMARC1; p=block"}
+
+	pass := func(domain string) []*DKIMVerification {
+		return []*DKIMVerification{{Result: DKIMPass, Domain: domain}}
+	}
+
+	tests := []struct {
+		name            string
+		from            string
+		spf             *SPFCheck
+		dkim            []*DKIMVerification
+		wantResult      string
+		wantPolicy      string
+		wantSPFAligned  bool
+		wantDKIMAligned bool
+	}{
+		{
+			name:           "SPF对齐通过",
+			from:           "example.com",
+			spf:            &SPFCheck{Result: SPFPass, Domain: "bounce.example.com"},
+			wantResult:     DMARCPass,
+			wantPolicy:     "reject",
+			wantSPFAligned: true,
+		},
+		{
+			name:            "DKIM对齐通过",
+			from:            "example.com",
+			spf:             &SPFCheck{Result: SPFFail, Domain: "example.com"},
+			dkim:            pass("mail.example.com"),
+			wantResult:      DMARCPass,
+			wantPolicy:      "reject",
+			wantDKIMAligned: true,
+		},
+		{
+			name:       "子域名使用sp策略",
+			from:       "news.example.com",
+			dkim:       pass("other.test"),
+			wantResult: DMARCFail,
+			wantPolicy: "quarantine",
+		},
+		{
+			name:       "未通过的DKIM签名不计入",
+			from:       "example.com",
+			dkim:       []*DKIMVerification{{Result: DKIMFail, Domain: "example.com"}},
+			wantResult: DMARCFail,
+			wantPolicy: "reject",
+		},
+		{
+			name:       "严格模式下子域名不对齐",
+			from:       "strict.test",
+			spf:        &SPFCheck{Result: SPFPass, Domain: "bounce.strict.test"},
+			dkim:       pass("mail.strict.test"),
+			wantResult: DMARCFail,
+			wantPolicy: "reject",
+		},
+		{
+			name:            "未发布记录仍计算对齐",
+			from:            "unpublished.test",
+			dkim:            pass("unpublished.test"),
+			wantResult:      DMARCNone,
+			wantDKIMAligned: true,
+		},
+		{
+			name:       "DNS临时错误",
+			from:       "flaky.test",
+			wantResult: DMARCTempError,
+		},
+		{
+			name:       "无效记录",
+			from:       "invalid.test",
+			wantResult: DMARCPermError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := EvaluateDMARC(context.Background(), resolver, tt.from, tt.spf, tt.dkim)
+			if result.Result != tt.wantResult {
+				t.Fatalf("Result = %s（%s），期望 %s", result.Result, result.Reason, tt.wantResult)
+			}
+			if result.Policy != tt.wantPolicy {
+				t.Errorf("Policy = %q，期望 %q", result.Policy, tt.wantPolicy)
+			}
+			if result.SPFAligned != tt.wantSPFAligned || result.DKIMAligned != tt.wantDKIMAligned {
+				t.Errorf("SPFAligned=%v DKIMAligned=%v，期望 %v %v", result.SPFAligned, result.DKIMAligned, tt.wantSPFAligned, tt.wantDKIMAligned)
+			}
+		})
+	}
+}
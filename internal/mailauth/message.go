@@ -0,0 +1,98 @@
+// Package mailauth 提供邮件签名与认证相关的底层实现（S/MIME、DKIM、ARC等）
+package mailauth
+
+import (
+	"bytes"
+	"strings"
+)
+
+// HeaderField 原始邮件头部字段（保留折行和原始大小写）
+type HeaderField struct {
+	Name string // 字段名
+	Raw  string // 完整原始内容（包含字段名、折行和结尾CRLF）
+}
+
+// Value 返回字段值（去掉字段名，保留折行）
+func (f HeaderField) Value() string {
+	if i := strings.IndexByte(f.Raw, ':'); i >= 0 {
+		return strings.TrimSuffix(f.Raw[i+1:], "\r\n")
+	}
+	return ""
+}
+
+// NormalizeCRLF 将换行统一为CRLF
+func NormalizeCRLF(data []byte) []byte {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+}
+
+// SplitMessage 将CRLF格式的邮件拆分为头部字段和正文
+func SplitMessage(message []byte) ([]HeaderField, []byte) {
+	var header, body []byte
+	if bytes.HasPrefix(message, []byte("\r\n")) {
+		body = message[2:]
+	} else if i := bytes.Index(message, []byte("\r\n\r\n")); i >= 0 {
+		header = message[:i+2]
+		body = message[i+4:]
+	} else {
+		header = message
+	}
+
+	return ParseHeader(header), body
+}
+
+// ParseHeader 解析头部字段，续行合并到前一个字段
+func ParseHeader(header []byte) []HeaderField {
+	var fields []HeaderField
+	for _, line := range strings.SplitAfter(string(header), "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(fields) > 0 {
+			fields[len(fields)-1].Raw += line
+			continue
+		}
+
+		name := line
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			name = line[:i]
+		}
+		if !strings.HasSuffix(line, "\r\n") {
+			line += "\r\n"
+		}
+		fields = append(fields, HeaderField{Name: strings.TrimSpace(name), Raw: line})
+	}
+	return fields
+}
+
+// FormatHeader 将头部字段拼接为原始头部
+func FormatHeader(fields []HeaderField) []byte {
+	var buf bytes.Buffer
+	for _, field := range fields {
+		buf.WriteString(field.Raw)
+	}
+	return buf.Bytes()
+}
+
+// FindHeader 查找第一个指定名称的头部字段
+func FindHeader(fields []HeaderField, name string) (HeaderField, bool) {
+	for _, field := range fields {
+		if strings.EqualFold(field.Name, name) {
+			return field, true
+		}
+	}
+	return HeaderField{}, false
+}
+
+// wrapBase64 按76字符每行折行
+func wrapBase64(encoded string) string {
+	var buf strings.Builder
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteString("\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteString("\r\n")
+	return buf.String()
+}
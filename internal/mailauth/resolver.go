@@ -0,0 +1,139 @@
+package mailauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Resolver DNS查询接口（便于测试时注入），*net.Resolver 已实现该接口
+type Resolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+// DefaultResolver 默认使用系统DNS解析器
+var DefaultResolver Resolver = net.DefaultResolver
+
+// NewResolver 创建DNS解析器，server为空时使用系统解析器，否则将所有查询发往指定的DNS服务器（host:port）
+func NewResolver(server string) Resolver {
+	if server == "" {
+		return DefaultResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// IsNotFound 判断DNS错误是否为记录不存在（NXDOMAIN或无对应记录）
+func IsNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// isTempDNSError 判断是否为临时性DNS错误（超时、SERVFAIL等，记录不存在除外）
+func isTempDNSError(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && !dnsErr.IsNotFound
+}
+
+// PublicKeyRecord DNS中发布的DKIM公钥记录
+type PublicKeyRecord struct {
+	KeyType   string           // k=，默认rsa
+	PublicKey crypto.PublicKey // 解析后的公钥
+	Raw       string           // 原始TXT记录
+}
+
+// LookupPublicKey 查询 selector._domainkey.domain 的DKIM公钥记录
+func LookupPublicKey(ctx context.Context, resolver Resolver, domain, selector string) (*PublicKeyRecord, error) {
+	name := selector + "._domainkey." + domain
+	records, err := resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("查询公钥记录 %s 失败: %w", name, err)
+	}
+
+	var lastErr error
+	for _, record := range records {
+		key, err := ParsePublicKeyRecord(record)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return key, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("未找到公钥记录 %s", name)
+}
+
+// ParsePublicKeyRecord 解析DKIM公钥TXT记录（v=DKIM1; k=rsa|ed25519; p=...）
+func ParsePublicKeyRecord(record string) (*PublicKeyRecord, error) {
+	tags, err := parseTagList(record)
+	if err != nil {
+		return nil, err
+	}
+	if v, ok := tags["v"]; ok && v != "DKIM1" {
+		return nil, fmt.Errorf("不支持的公钥记录版本: %s", v)
+	}
+
+	keyType := strings.ToLower(tags["k"])
+	if keyType == "" {
+		keyType = "rsa"
+	}
+
+	p, ok := tags["p"]
+	if !ok {
+		return nil, errors.New("公钥记录缺少p=标签")
+	}
+	if p == "" {
+		return nil, errors.New("公钥已被撤销")
+	}
+	der, err := base64.StdEncoding.DecodeString(p)
+	if err != nil {
+		return nil, fmt.Errorf("公钥Base64解码失败: %w", err)
+	}
+
+	var publicKey crypto.PublicKey
+	switch keyType {
+	case "rsa":
+		publicKey, err = x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			// 部分记录直接发布PKCS#1格式的RSA公钥
+			publicKey, err = x509.ParsePKCS1PublicKey(der)
+		}
+	case "ed25519":
+		// RFC 8463：p=直接为32字节的原始公钥
+		if len(der) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("无效的Ed25519公钥长度: %d", len(der))
+		}
+		publicKey = ed25519.PublicKey(der)
+	default:
+		return nil, fmt.Errorf("不支持的公钥类型: %s", keyType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析公钥失败: %w", err)
+	}
+
+	return &PublicKeyRecord{
+		KeyType:   keyType,
+		PublicKey: publicKey,
+		Raw:       record,
+	}, nil
+}
@@ -0,0 +1,199 @@
+package mailauth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeResolver 测试用的内存DNS解析器，未配置的名称返回NXDOMAIN，tempFail中的名称返回临时错误
+type fakeResolver struct {
+	txt      map[string][]string
+	mx       map[string][]*net.MX
+	ip       map[string][]string
+	ptr      map[string][]string
+	tempFail map[string]bool
+	queries  int
+}
+
+func newFakeResolver() *fakeResolver {
+	return &fakeResolver{
+		txt:      make(map[string][]string),
+		mx:       make(map[string][]*net.MX),
+		ip:       make(map[string][]string),
+		ptr:      make(map[string][]string),
+		tempFail: make(map[string]bool),
+	}
+}
+
+func (r *fakeResolver) lookup(name string) (string, error) {
+	r.queries++
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	if r.tempFail[name] {
+		return name, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	return name, nil
+}
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	name, err := r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if records, ok := r.txt[name]; ok {
+		return records, nil
+	}
+	return nil, notFound(name)
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	name, err := r.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+	if records, ok := r.mx[name]; ok {
+		return records, nil
+	}
+	return nil, notFound(name)
+}
+
+func (r *fakeResolver) LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error) {
+	host, err := r.lookup(host)
+	if err != nil {
+		return nil, err
+	}
+	ips, ok := r.ip[host]
+	if !ok {
+		return nil, notFound(host)
+	}
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func (r *fakeResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	addr, err := r.lookup(addr)
+	if err != nil {
+		return nil, err
+	}
+	if names, ok := r.ptr[addr]; ok {
+		return names, nil
+	}
+	return nil, notFound(addr)
+}
+
+// newTestRSAKey 生成测试用RSA密钥（1024位为验证接受的最小长度，生成较快）
+func newTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+	return key
+}
+
+// newTestEd25519Key 生成测试用Ed25519密钥
+func newTestEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成Ed25519密钥失败: %v", err)
+	}
+	return key
+}
+
+// publishKey 在解析器中发布 selector._domainkey.domain 的公钥记录
+func (r *fakeResolver) publishKey(t *testing.T, domain, selector string, key interface{}) {
+	t.Helper()
+	var record string
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+		if err != nil {
+			t.Fatalf("编码RSA公钥失败: %v", err)
+		}
+		record = "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der)
+	case ed25519.PrivateKey:
+		record = "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(k.Public().(ed25519.PublicKey))
+	default:
+		t.Fatalf("不支持的密钥类型: %T", key)
+	}
+	r.txt[selector+"._domainkey."+domain] = []string{record}
+}
+
+func TestParsePublicKeyRecord(t *testing.T) {
+	rsaKey := newTestRSAKey(t)
+	der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	pkcs1 := x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)
+	edKey := newTestEd25519Key(t)
+	edPub := base64.StdEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))
+
+	tests := []struct {
+		name    string
+		record  string
+		keyType string
+		wantErr string
+	}{
+		{"RSA PKIX", "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der), "rsa", ""},
+		{"默认RSA且值中有折行", "v=DKIM1; p=" + base64.StdEncoding.EncodeToString(der)[:20] + " \t" + base64.StdEncoding.EncodeToString(der)[20:], "rsa", ""},
+		{"RSA PKCS1", "p=" + base64.StdEncoding.EncodeToString(pkcs1), "rsa", ""},
+		{"Ed25519", "v=DKIM1; k=ed25519; p=" + edPub, "ed25519", ""},
+		{"Ed25519长度错误", "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString([]byte("short")), "", "无效的Ed25519公钥长度"},
+		{"已撤销", "v=DKIM1; p=", "", "公钥已被撤销"},
+		{"缺少p", "v=DKIM1; k=rsa", "", "缺少p="},
+		{"版本错误", "v=DKIM2; p=" + edPub, "", "不支持的公钥记录版本"},
+		{"未知类型", "k=dsa; p=" + edPub, "", "不支持的公钥类型"},
+		{"重复标签", "p=a; p=b", "", "重复的标签"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := ParsePublicKeyRecord(tt.record)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望错误包含 %q，实际为 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("解析失败: %v", err)
+			}
+			if key.KeyType != tt.keyType {
+				t.Errorf("KeyType = %q，期望 %q", key.KeyType, tt.keyType)
+			}
+		})
+	}
+}
+
+func TestLookupPublicKey(t *testing.T) {
+	resolver := newFakeResolver()
+	key := newTestRSAKey(t)
+	resolver.publishKey(t, "example.com", "s1", key)
+	resolver.txt["bad._domainkey.example.com"] = []string{"not a key record"}
+
+	record, err := LookupPublicKey(context.Background(), resolver, "example.com", "s1")
+	if err != nil {
+		t.Fatalf("查询公钥失败: %v", err)
+	}
+	if pub, ok := record.PublicKey.(*rsa.PublicKey); !ok || pub.N.Cmp(key.N) != 0 {
+		t.Errorf("返回的公钥与发布的不一致")
+	}
+
+	if _, err := LookupPublicKey(context.Background(), resolver, "example.com", "missing"); !IsNotFound(err) {
+		t.Errorf("未发布的选择器应返回NXDOMAIN，实际为 %v", err)
+	}
+	if _, err := LookupPublicKey(context.Background(), resolver, "example.com", "bad"); err == nil {
+		t.Errorf("无效的公钥记录应返回错误")
+	}
+}
@@ -0,0 +1,210 @@
+package mailauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 规范化算法
+const (
+	CanonSimple  = "simple"
+	CanonRelaxed = "relaxed"
+)
+
+// 签名算法
+const (
+	AlgorithmRSASHA256     = "rsa-sha256"
+	AlgorithmEd25519SHA256 = "ed25519-sha256" // RFC 8463
+)
+
+// signatureValuePattern 匹配签名头部中的b=标签值（不匹配bh=）
+var signatureValuePattern = regexp.MustCompile(`([:;][ \t\r\n]*b[ \t\r\n]*=)[^;]*`)
+
+// wspPattern 匹配连续的空格和制表符
+var wspPattern = regexp.MustCompile(`[ \t]+`)
+
+// parseTagList 解析 tag=value 列表（DKIM/ARC签名头部及公钥记录）
+func parseTagList(value string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(value, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		i := strings.IndexByte(part, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("无效的标签: %q", part)
+		}
+		name := strings.TrimSpace(part[:i])
+		if _, exists := tags[name]; exists {
+			return nil, fmt.Errorf("重复的标签: %s", name)
+		}
+		tags[name] = removeFWS(part[i+1:])
+	}
+	return tags, nil
+}
+
+// removeFWS 去除值中的折行和空白（用于Base64等取值）
+func removeFWS(value string) string {
+	return strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\t' || r == '\r' || r == '\n' {
+			return -1
+		}
+		return r
+	}, value)
+}
+
+// canonicalizeHeader 按指定算法规范化单个头部字段
+func canonicalizeHeader(field HeaderField, canon string) string {
+	if canon != CanonRelaxed {
+		return field.Raw
+	}
+
+	value := strings.ReplaceAll(field.Value(), "\r\n", "")
+	value = strings.TrimSpace(wspPattern.ReplaceAllString(value, " "))
+	return strings.ToLower(strings.TrimSpace(field.Name)) + ":" + value + "\r\n"
+}
+
+// canonicalizeBody 按指定算法规范化正文（RFC 6376 第3.4.3/3.4.4节）
+func canonicalizeBody(body []byte, canon string) []byte {
+	lines := strings.Split(string(body), "\r\n")
+	if canon == CanonRelaxed {
+		for i, line := range lines {
+			line = wspPattern.ReplaceAllString(line, " ")
+			lines[i] = strings.TrimRight(line, " ")
+		}
+	}
+
+	// 去掉结尾的空行
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		if canon == CanonRelaxed {
+			return nil
+		}
+		return []byte("\r\n")
+	}
+	return []byte(strings.Join(lines, "\r\n") + "\r\n")
+}
+
+// bodyHash 计算规范化后正文的SHA-256哈希（Base64）
+func bodyHash(body []byte, canon string) string {
+	sum := sha256.Sum256(canonicalizeBody(body, canon))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// selectHeaders 按h=列表自下而上选取参与签名的头部（同名多次出现时依次向上取）
+func selectHeaders(fields []HeaderField, names []string) []HeaderField {
+	used := make(map[int]bool)
+	var selected []HeaderField
+	for _, name := range names {
+		for i := len(fields) - 1; i >= 0; i-- {
+			if used[i] || !strings.EqualFold(fields[i].Name, name) {
+				continue
+			}
+			used[i] = true
+			selected = append(selected, fields[i])
+			break
+		}
+	}
+	return selected
+}
+
+// splitHeaderList 解析h=标签中的头部名称列表
+func splitHeaderList(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ":") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// stripSignatureValue 清空签名头部中的b=值，用于计算或验证签名
+func stripSignatureValue(raw string) string {
+	return signatureValuePattern.ReplaceAllString(raw, "$1")
+}
+
+// headerDigest 计算参与签名头部与签名头部本身（b=为空、不含结尾CRLF）的SHA-256摘要
+func headerDigest(signed []HeaderField, signature HeaderField, canon string) []byte {
+	hash := sha256.New()
+	for _, field := range signed {
+		hash.Write([]byte(canonicalizeHeader(field, canon)))
+	}
+	stripped := HeaderField{Name: signature.Name, Raw: stripSignatureValue(signature.Raw)}
+	hash.Write([]byte(strings.TrimSuffix(canonicalizeHeader(stripped, canon), "\r\n")))
+	return hash.Sum(nil)
+}
+
+// signDigest 使用私钥对摘要签名，返回Base64编码的签名值
+func signDigest(signer crypto.Signer, digest []byte) (string, error) {
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		// RFC 8463：Ed25519对SHA-256摘要本身执行PureEdDSA签名
+		opts = crypto.Hash(0)
+	}
+
+	signature, err := signer.Sign(rand.Reader, digest, opts)
+	if err != nil {
+		return "", fmt.Errorf("签名失败: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(signature), nil
+}
+
+// verifyDigest 使用公钥验证摘要签名
+func verifyDigest(publicKey crypto.PublicKey, digest []byte, signatureB64 string) error {
+	signature, err := base64.StdEncoding.DecodeString(signatureB64)
+	if err != nil {
+		return fmt.Errorf("签名Base64解码失败: %w", err)
+	}
+
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest, signature); err != nil {
+			return errors.New("签名验证失败")
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, digest, signature) {
+			return errors.New("签名验证失败")
+		}
+		return nil
+	default:
+		return fmt.Errorf("不支持的公钥类型: %T", publicKey)
+	}
+}
+
+// SignatureAlgorithm 返回私钥对应的签名算法（rsa-sha256 或 ed25519-sha256）
+func SignatureAlgorithm(signer crypto.Signer) (string, error) {
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		return AlgorithmRSASHA256, nil
+	case ed25519.PublicKey:
+		return AlgorithmEd25519SHA256, nil
+	default:
+		return "", fmt.Errorf("不支持的私钥类型: %T", signer.Public())
+	}
+}
+
+// foldSignature 将Base64签名值按固定宽度折行
+func foldSignature(value string) string {
+	var buf bytes.Buffer
+	for len(value) > 72 {
+		buf.WriteString(value[:72])
+		buf.WriteString("\r\n\t")
+		value = value[72:]
+	}
+	buf.WriteString(value)
+	return buf.String()
+}
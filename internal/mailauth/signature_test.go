@@ -0,0 +1,97 @@
+package mailauth
+
+import (
+	"testing"
+)
+
+func TestCanonicalizeHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		canon string
+		want  string
+	}{
+		{"simple保持原样", "Subject:  Hello\tWorld \r\n", CanonSimple, "Subject:  Hello\tWorld \r\n"},
+		{"relaxed小写字段名并压缩空白", "SUBJECT :  Hello\t World  \r\n", CanonRelaxed, "subject:Hello World\r\n"},
+		{"relaxed展开折行", "To: a@example.com,\r\n\tb@example.com\r\n", CanonRelaxed, "to:a@example.com, b@example.com\r\n"},
+		{"relaxed空值", "X-Empty:\r\n", CanonRelaxed, "x-empty:\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := ParseHeader([]byte(tt.raw))
+			if len(fields) != 1 {
+				t.Fatalf("解析出 %d 个头部字段，期望1个", len(fields))
+			}
+			if got := canonicalizeHeader(fields[0], tt.canon); got != tt.want {
+				t.Errorf("canonicalizeHeader() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalizeBody(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		canon string
+		want  string
+	}{
+		{"simple去掉结尾空行", "Hello\r\n\r\n\r\n", CanonSimple, "Hello\r\n"},
+		{"simple空正文为CRLF", "", CanonSimple, "\r\n"},
+		{"simple保留行内空白", "a  b \r\n", CanonSimple, "a  b \r\n"},
+		{"relaxed压缩并去掉行尾空白", "a \t b \t\r\nc\r\n\r\n", CanonRelaxed, "a b\r\nc\r\n"},
+		{"relaxed空正文为空", "\r\n\r\n", CanonRelaxed, ""},
+		{"relaxed补全结尾CRLF", "last line", CanonRelaxed, "last line\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(canonicalizeBody([]byte(tt.body), tt.canon)); got != tt.want {
+				t.Errorf("canonicalizeBody() = %q，期望 %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTagList(t *testing.T) {
+	tags, err := parseTagList(" v=1; a=rsa-sha256;\r\n\tb=abc\r\n def ; ")
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if tags["v"] != "1" || tags["a"] != "rsa-sha256" || tags["b"] != "abcdef" {
+		t.Errorf("解析结果错误: %v", tags)
+	}
+
+	for _, value := range []string{"v=1; novalue", "a=1; a=2"} {
+		if _, err := parseTagList(value); err == nil {
+			t.Errorf("parseTagList(%q) 应返回错误", value)
+		}
+	}
+}
+
+func TestSelectHeaders(t *testing.T) {
+	fields := ParseHeader([]byte("Received: one\r\nFrom: a@example.com\r\nReceived: two\r\nSubject: hi\r\n"))
+
+	// 同名头部自下而上依次选取，不存在的头部忽略
+	selected := selectHeaders(fields, []string{"received", "Received", "Received", "subject", "cc"})
+	var got []string
+	for _, field := range selected {
+		got = append(got, field.Raw)
+	}
+	want := []string{"Received: two\r\n", "Received: one\r\n", "Subject: hi\r\n"}
+	if len(got) != len(want) {
+		t.Fatalf("选取了 %q，期望 %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("第%d个头部为 %q，期望 %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStripSignatureValue(t *testing.T) {
+	raw := "DKIM-Signature: v=1; bh=BODYHASH;\r\n\tb=SIGNA\r\n\tTURE; d=example.com\r\n"
+	want := "DKIM-Signature: v=1; bh=BODYHASH;\r\n\tb=; d=example.com\r\n"
+	if got := stripSignatureValue(raw); got != want {
+		t.Errorf("stripSignatureValue() = %q，期望 %q", got, want)
+	}
+}
@@ -0,0 +1,222 @@
+package mailauth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mozilla.org/pkcs7"
+)
+
+// ParseCertificateChain 解析PEM格式的证书链，第一个证书为签名证书
+func ParseCertificateChain(certPEM string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(certPEM)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("解析证书失败: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("未找到PEM格式的证书")
+	}
+	return certs, nil
+}
+
+// ParsePrivateKey 解析PEM格式的私钥（PKCS#1、PKCS#8或SEC 1）
+func ParsePrivateKey(keyPEM string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, errors.New("未找到PEM格式的私钥")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.New("不支持的私钥类型")
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("不支持的私钥格式: %s", block.Type)
+	}
+}
+
+// ValidateSMIMEKeyPair 校验证书与私钥是否匹配，且证书可用于邮件签名
+func ValidateSMIMEKeyPair(cert *x509.Certificate, key crypto.Signer) error {
+	switch pub := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if !pub.Equal(key.Public()) {
+			return errors.New("私钥与证书不匹配")
+		}
+	case *ecdsa.PublicKey:
+		if !pub.Equal(key.Public()) {
+			return errors.New("私钥与证书不匹配")
+		}
+	default:
+		return errors.New("仅支持RSA和ECDSA证书")
+	}
+
+	if cert.KeyUsage != 0 && cert.KeyUsage&x509.KeyUsageDigitalSignature == 0 {
+		return errors.New("证书不允许用于数字签名")
+	}
+
+	if len(cert.ExtKeyUsage) > 0 {
+		allowed := false
+		for _, usage := range cert.ExtKeyUsage {
+			if usage == x509.ExtKeyUsageEmailProtection || usage == x509.ExtKeyUsageAny {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return errors.New("证书不允许用于邮件保护（缺少emailProtection扩展用途）")
+		}
+	}
+
+	return nil
+}
+
+// SignSMIME 生成multipart/signed（分离式PKCS#7签名）邮件
+//
+// 原邮件的Content-*头部与正文作为被签名的MIME实体，其余头部保留在外层。
+func SignSMIME(message []byte, chain []*x509.Certificate, key crypto.Signer) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, errors.New("缺少签名证书")
+	}
+
+	fields, body := SplitMessage(NormalizeCRLF(message))
+
+	var outer, content []HeaderField
+	for _, field := range fields {
+		name := strings.ToLower(field.Name)
+		switch {
+		case strings.HasPrefix(name, "content-"):
+			content = append(content, field)
+		case name == "mime-version":
+			// 外层重新生成
+		default:
+			outer = append(outer, field)
+		}
+	}
+
+	content, body = encodeForSigning(content, body)
+	entity := append(FormatHeader(content), "\r\n"...)
+	entity = append(entity, body...)
+
+	signature, err := detachedSignature(entity, chain, key)
+	if err != nil {
+		return nil, err
+	}
+
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(FormatHeader(outer))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/signed; protocol=\"application/pkcs7-signature\"; micalg=sha-256;\r\n\tboundary=\"%s\"\r\n", boundary)
+	buf.WriteString("\r\n")
+	buf.WriteString("This is an S/MIME signed message\r\n\r\n")
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	buf.Write(entity)
+	// 分隔符前的CRLF属于分隔符本身，不计入被签名内容
+	buf.WriteString("\r\n")
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	buf.WriteString("Content-Type: application/pkcs7-signature; name=\"smime.p7s\"\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n")
+	buf.WriteString("Content-Disposition: attachment; filename=\"smime.p7s\"\r\n")
+	buf.WriteString("Content-Description: S/MIME Cryptographic Signature\r\n\r\n")
+	buf.WriteString(wrapBase64(base64.StdEncoding.EncodeToString(signature)))
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+
+	return buf.Bytes(), nil
+}
+
+// encodeForSigning 单部件8bit正文改用base64编码，避免中转时被转码导致签名失效
+func encodeForSigning(content []HeaderField, body []byte) ([]HeaderField, []byte) {
+	if is7bit(body) {
+		return content, body
+	}
+	if field, ok := FindHeader(content, "Content-Type"); ok && strings.Contains(strings.ToLower(field.Value()), "multipart/") {
+		return content, body
+	}
+
+	encoded := make([]HeaderField, 0, len(content)+1)
+	for _, field := range content {
+		if !strings.EqualFold(field.Name, "Content-Transfer-Encoding") {
+			encoded = append(encoded, field)
+		}
+	}
+	encoded = append(encoded, HeaderField{
+		Name: "Content-Transfer-Encoding",
+		Raw:  "Content-Transfer-Encoding: base64\r\n",
+	})
+
+	return encoded, []byte(wrapBase64(base64.StdEncoding.EncodeToString(body)))
+}
+
+// detachedSignature 生成分离式PKCS#7签名（SHA-256）
+func detachedSignature(content []byte, chain []*x509.Certificate, key crypto.Signer) ([]byte, error) {
+	signedData, err := pkcs7.NewSignedData(content)
+	if err != nil {
+		return nil, fmt.Errorf("创建PKCS#7签名失败: %w", err)
+	}
+	signedData.SetDigestAlgorithm(pkcs7.OIDDigestAlgorithmSHA256)
+
+	if err := signedData.AddSignerChain(chain[0], key, chain[1:], pkcs7.SignerInfoConfig{}); err != nil {
+		return nil, fmt.Errorf("添加签名者失败: %w", err)
+	}
+	signedData.Detach()
+
+	return signedData.Finish()
+}
+
+// is7bit 检查内容是否仅包含7位ASCII字符
+func is7bit(data []byte) bool {
+	for _, b := range data {
+		if b >= 0x80 || b == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// randomBoundary 生成随机MIME分隔符
+func randomBoundary() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "----=_smime_" + hex.EncodeToString(buf), nil
+}
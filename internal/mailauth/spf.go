@@ -0,0 +1,608 @@
+package mailauth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// SPF检查结果（RFC 7208 第2.6节）
+const (
+	SPFPass      = "pass"
+	SPFFail      = "fail"
+	SPFSoftFail  = "softfail"
+	SPFNeutral   = "neutral"
+	SPFNone      = "none"
+	SPFTempError = "temperror"
+	SPFPermError = "permerror"
+)
+
+const (
+	// MaxSPFLookups SPF评估允许的DNS查询次数上限
+	MaxSPFLookups = 10
+	// maxSPFVoidLookups 返回空结果的DNS查询次数上限
+	maxSPFVoidLookups = 2
+	// maxSPFMXHosts mx机制最多解析的MX主机数
+	maxSPFMXHosts = 10
+)
+
+// SPFCheck SPF检查结果
+type SPFCheck struct {
+	Result    string // pass, fail, softfail, neutral, none, temperror, permerror
+	Domain    string // 检查的域名
+	Record    string // 顶层SPF记录
+	Mechanism string // 命中的机制
+	Lookups   int    // 评估过程中的DNS查询次数
+	Reason    string // 错误原因
+}
+
+// spfEvaluator 单次SPF评估的状态
+type spfEvaluator struct {
+	ctx         context.Context
+	resolver    Resolver
+	ip          net.IP
+	sender      string
+	helo        string
+	lookups     int
+	voidLookups int
+}
+
+// spfError 评估过程中的错误（result为temperror或permerror）
+type spfError struct {
+	result string
+	reason string
+}
+
+func (e *spfError) Error() string {
+	return e.reason
+}
+
+// CheckSPF 评估ip是否被domain的SPF记录授权（sender为MAIL FROM地址，helo为HELO域名）
+func CheckSPF(ctx context.Context, resolver Resolver, ip net.IP, domain, sender, helo string) *SPFCheck {
+	if sender == "" {
+		sender = "postmaster@" + domain
+	} else if !strings.Contains(sender, "@") {
+		sender = "postmaster@" + sender
+	}
+
+	evaluator := &spfEvaluator{
+		ctx:      ctx,
+		resolver: resolver,
+		ip:       ip,
+		sender:   sender,
+		helo:     helo,
+	}
+
+	check := &SPFCheck{Domain: domain}
+	record, err := evaluator.lookupRecord(domain)
+	check.Record = record
+	if err == nil && record == "" {
+		check.Result = SPFNone
+		return check
+	}
+	if err == nil {
+		check.Result, check.Mechanism, err = evaluator.evaluate(domain, record, 0)
+	}
+	if err != nil {
+		check.Result = SPFPermError
+		if spfErr, ok := err.(*spfError); ok {
+			check.Result = spfErr.result
+		}
+		check.Reason = err.Error()
+	}
+	check.Lookups = evaluator.lookups
+	return check
+}
+
+// CountSPFLookups 统计域名SPF记录（含所有include和redirect）在最坏情况下需要的DNS查询次数
+func CountSPFLookups(ctx context.Context, resolver Resolver, domain string) (int, error) {
+	evaluator := &spfEvaluator{ctx: ctx, resolver: resolver}
+	return evaluator.countLookups(domain, 0)
+}
+
+// lookupRecord 查询域名的SPF记录，未发布时返回空字符串
+func (e *spfEvaluator) lookupRecord(domain string) (string, error) {
+	records, err := e.resolver.LookupTXT(e.ctx, domain)
+	if err != nil {
+		if IsNotFound(err) {
+			return "", nil
+		}
+		return "", &spfError{SPFTempError, fmt.Sprintf("查询 %s 的SPF记录失败: %v", domain, err)}
+	}
+
+	var found []string
+	for _, record := range records {
+		lower := strings.ToLower(record)
+		if lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ") {
+			found = append(found, record)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	default:
+		return "", &spfError{SPFPermError, fmt.Sprintf("%s 存在多条SPF记录", domain)}
+	}
+}
+
+// evaluate 评估一条SPF记录，返回结果和命中的机制
+func (e *spfEvaluator) evaluate(domain, record string, depth int) (string, string, error) {
+	if depth > MaxSPFLookups {
+		return "", "", &spfError{SPFPermError, "SPF记录嵌套过深"}
+	}
+
+	var redirect string
+	for _, term := range strings.Fields(record)[1:] {
+		name, value, isModifier := parseSPFModifier(term)
+		if isModifier {
+			if name == "redirect" {
+				if redirect != "" {
+					return "", "", &spfError{SPFPermError, "存在多个redirect修饰符"}
+				}
+				redirect = value
+			}
+			continue
+		}
+
+		qualifier := SPFPass
+		switch term[0] {
+		case '+':
+			term = term[1:]
+		case '-':
+			qualifier, term = SPFFail, term[1:]
+		case '~':
+			qualifier, term = SPFSoftFail, term[1:]
+		case '?':
+			qualifier, term = SPFNeutral, term[1:]
+		}
+
+		matched, err := e.matchMechanism(domain, term, depth)
+		if err != nil {
+			return "", "", err
+		}
+		if matched {
+			return qualifier, term, nil
+		}
+	}
+
+	if redirect != "" {
+		target, err := e.expand(redirect, domain)
+		if err != nil {
+			return "", "", err
+		}
+		if err := e.countLookup(); err != nil {
+			return "", "", err
+		}
+		targetRecord, err := e.lookupRecord(target)
+		if err != nil {
+			return "", "", err
+		}
+		if targetRecord == "" {
+			return "", "", &spfError{SPFPermError, fmt.Sprintf("redirect目标 %s 未发布SPF记录", target)}
+		}
+		return e.evaluate(target, targetRecord, depth+1)
+	}
+
+	return SPFNeutral, "", nil
+}
+
+// matchMechanism 判断单个机制是否命中
+func (e *spfEvaluator) matchMechanism(domain, term string, depth int) (bool, error) {
+	name, arg := term, ""
+	if i := strings.IndexAny(term, ":/"); i >= 0 {
+		name, arg = term[:i], term[i:]
+	}
+	name = strings.ToLower(name)
+
+	switch name {
+	case "all":
+		return true, nil
+
+	case "include":
+		target, err := e.domainSpec(strings.TrimPrefix(arg, ":"), domain, true)
+		if err != nil {
+			return false, err
+		}
+		if err := e.countLookup(); err != nil {
+			return false, err
+		}
+		record, err := e.lookupRecord(target)
+		if err != nil {
+			return false, err
+		}
+		if record == "" {
+			return false, &spfError{SPFPermError, fmt.Sprintf("include目标 %s 未发布SPF记录", target)}
+		}
+		result, _, err := e.evaluate(target, record, depth+1)
+		if err != nil {
+			return false, err
+		}
+		return result == SPFPass, nil
+
+	case "a", "mx":
+		spec, mask4, mask6, err := splitSPFCIDR(arg)
+		if err != nil {
+			return false, err
+		}
+		target, err := e.domainSpec(strings.TrimPrefix(spec, ":"), domain, false)
+		if err != nil {
+			return false, err
+		}
+		if err := e.countLookup(); err != nil {
+			return false, err
+		}
+
+		hosts := []string{target}
+		if name == "mx" {
+			records, err := e.resolver.LookupMX(e.ctx, target)
+			if err != nil && !IsNotFound(err) {
+				return false, &spfError{SPFTempError, fmt.Sprintf("查询 %s 的MX记录失败: %v", target, err)}
+			}
+			if len(records) == 0 {
+				return false, e.countVoidLookup()
+			}
+			if len(records) > maxSPFMXHosts {
+				return false, &spfError{SPFPermError, fmt.Sprintf("%s 的MX记录超过%d条", target, maxSPFMXHosts)}
+			}
+			hosts = hosts[:0]
+			for _, record := range records {
+				hosts = append(hosts, record.Host)
+			}
+		}
+
+		for _, host := range hosts {
+			addrs, err := e.resolver.LookupIPAddr(e.ctx, host)
+			if err != nil && !IsNotFound(err) {
+				return false, &spfError{SPFTempError, fmt.Sprintf("查询 %s 的地址失败: %v", host, err)}
+			}
+			if len(addrs) == 0 && name == "a" {
+				return false, e.countVoidLookup()
+			}
+			for _, addr := range addrs {
+				if ipMatches(e.ip, addr.IP, mask4, mask6) {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+
+	case "ptr":
+		target, err := e.domainSpec(strings.TrimPrefix(arg, ":"), domain, false)
+		if err != nil {
+			return false, err
+		}
+		if err := e.countLookup(); err != nil {
+			return false, err
+		}
+		target = strings.ToLower(target)
+		for _, host := range e.validatedNames() {
+			host = strings.TrimSuffix(strings.ToLower(host), ".")
+			if host == target || strings.HasSuffix(host, "."+target) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "ip4", "ip6":
+		cidr := strings.TrimPrefix(arg, ":")
+		if !strings.Contains(cidr, "/") {
+			if name == "ip4" {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil || (name == "ip4") != (network.IP.To4() != nil) {
+			return false, &spfError{SPFPermError, fmt.Sprintf("无效的%s机制: %s", name, term)}
+		}
+		return network.Contains(e.ip), nil
+
+	case "exists":
+		target, err := e.domainSpec(strings.TrimPrefix(arg, ":"), domain, true)
+		if err != nil {
+			return false, err
+		}
+		if err := e.countLookup(); err != nil {
+			return false, err
+		}
+		addrs, err := e.resolver.LookupIPAddr(e.ctx, target)
+		if err != nil && !IsNotFound(err) {
+			return false, &spfError{SPFTempError, fmt.Sprintf("查询 %s 的地址失败: %v", target, err)}
+		}
+		if len(addrs) == 0 {
+			return false, e.countVoidLookup()
+		}
+		return true, nil
+
+	default:
+		return false, &spfError{SPFPermError, fmt.Sprintf("未知的SPF机制: %s", term)}
+	}
+}
+
+// validatedNames 返回IP经正向确认的PTR主机名
+func (e *spfEvaluator) validatedNames() []string {
+	names, err := e.resolver.LookupAddr(e.ctx, e.ip.String())
+	if err != nil {
+		return nil
+	}
+
+	var validated []string
+	for i, name := range names {
+		if i >= maxSPFMXHosts {
+			break
+		}
+		addrs, err := e.resolver.LookupIPAddr(e.ctx, strings.TrimSuffix(name, "."))
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if addr.IP.Equal(e.ip) {
+				validated = append(validated, name)
+				break
+			}
+		}
+	}
+	return validated
+}
+
+// countLookup 记录一次DNS查询，超过上限时返回permerror
+func (e *spfEvaluator) countLookup() error {
+	e.lookups++
+	if e.lookups > MaxSPFLookups {
+		return &spfError{SPFPermError, fmt.Sprintf("DNS查询次数超过%d次上限", MaxSPFLookups)}
+	}
+	return nil
+}
+
+// countVoidLookup 记录一次空结果查询，超过上限时返回permerror
+func (e *spfEvaluator) countVoidLookup() error {
+	e.voidLookups++
+	if e.voidLookups > maxSPFVoidLookups {
+		return &spfError{SPFPermError, fmt.Sprintf("空结果DNS查询超过%d次上限", maxSPFVoidLookups)}
+	}
+	return nil
+}
+
+// countLookups 静态统计记录中所有分支的DNS查询次数
+func (e *spfEvaluator) countLookups(domain string, depth int) (int, error) {
+	if depth > MaxSPFLookups {
+		return 0, fmt.Errorf("SPF记录嵌套过深")
+	}
+
+	record, err := e.lookupRecord(domain)
+	if err != nil {
+		return 0, err
+	}
+	if record == "" {
+		return 0, nil
+	}
+
+	total := 0
+	for _, term := range strings.Fields(record)[1:] {
+		name, value, isModifier := parseSPFModifier(term)
+		if isModifier {
+			if name != "redirect" {
+				continue
+			}
+			term = "include:" + value
+		}
+
+		term = strings.TrimLeft(term, "+-~?")
+		name = strings.ToLower(term)
+		if i := strings.IndexAny(term, ":/"); i >= 0 {
+			name = strings.ToLower(term[:i])
+		}
+
+		switch name {
+		case "a", "mx", "ptr", "exists":
+			total++
+		case "include":
+			total++
+			target := strings.TrimPrefix(term[len(name):], ":")
+			if strings.Contains(target, "%") {
+				// 含宏的目标无法静态展开
+				continue
+			}
+			count, err := e.countLookups(target, depth+1)
+			if err != nil {
+				return total, err
+			}
+			total += count
+		}
+	}
+	return total, nil
+}
+
+// domainSpec 展开机制中的域名，为空时使用当前域名
+func (e *spfEvaluator) domainSpec(spec, domain string, required bool) (string, error) {
+	if spec == "" {
+		if required {
+			return "", &spfError{SPFPermError, "机制缺少域名参数"}
+		}
+		return domain, nil
+	}
+	return e.expand(spec, domain)
+}
+
+// expand 展开SPF宏（RFC 7208 第7节）
+func (e *spfEvaluator) expand(spec, domain string) (string, error) {
+	if !strings.Contains(spec, "%") {
+		return spec, nil
+	}
+
+	var buf strings.Builder
+	for i := 0; i < len(spec); i++ {
+		if spec[i] != '%' {
+			buf.WriteByte(spec[i])
+			continue
+		}
+		if i+1 >= len(spec) {
+			return "", &spfError{SPFPermError, "无效的SPF宏: " + spec}
+		}
+		i++
+		switch spec[i] {
+		case '%':
+			buf.WriteByte('%')
+		case '_':
+			buf.WriteByte(' ')
+		case '-':
+			buf.WriteString("%20")
+		case '{':
+			end := strings.IndexByte(spec[i:], '}')
+			if end < 0 {
+				return "", &spfError{SPFPermError, "无效的SPF宏: " + spec}
+			}
+			value, err := e.expandMacro(spec[i+1:i+end], domain)
+			if err != nil {
+				return "", err
+			}
+			buf.WriteString(value)
+			i += end
+		default:
+			return "", &spfError{SPFPermError, "无效的SPF宏: " + spec}
+		}
+	}
+	return buf.String(), nil
+}
+
+// expandMacro 展开单个宏，如 {ir} 或 {d2}
+func (e *spfEvaluator) expandMacro(macro, domain string) (string, error) {
+	if macro == "" {
+		return "", &spfError{SPFPermError, "空的SPF宏"}
+	}
+
+	local, senderDomain := e.sender, domain
+	if at := strings.LastIndex(e.sender, "@"); at >= 0 {
+		local, senderDomain = e.sender[:at], e.sender[at+1:]
+	}
+
+	var value string
+	switch strings.ToLower(macro[:1]) {
+	case "s":
+		value = e.sender
+	case "l":
+		value = local
+	case "o":
+		value = senderDomain
+	case "d":
+		value = domain
+	case "i":
+		if ip4 := e.ip.To4(); ip4 != nil {
+			value = ip4.String()
+		} else {
+			var parts []string
+			for _, b := range e.ip.To16() {
+				parts = append(parts, fmt.Sprintf("%x", b>>4), fmt.Sprintf("%x", b&0x0f))
+			}
+			value = strings.Join(parts, ".")
+		}
+	case "v":
+		value = "in-addr"
+		if e.ip.To4() == nil {
+			value = "ip6"
+		}
+	case "h":
+		value = e.helo
+		if value == "" {
+			value = domain
+		}
+	case "p":
+		value = "unknown"
+	default:
+		return "", &spfError{SPFPermError, "未知的SPF宏: " + macro}
+	}
+
+	// 解析转换规则：数字（保留右侧标签数）、r（反转）、分隔符
+	rest := macro[1:]
+	digits := 0
+	for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	keep, _ := strconv.Atoi(rest[:digits])
+	rest = rest[digits:]
+	reverse := strings.HasPrefix(strings.ToLower(rest), "r")
+	if reverse {
+		rest = rest[1:]
+	}
+	delimiters := rest
+	if delimiters == "" {
+		delimiters = "."
+	}
+
+	labels := strings.FieldsFunc(value, func(r rune) bool {
+		return strings.ContainsRune(delimiters, r)
+	})
+	if reverse {
+		for i, j := 0, len(labels)-1; i < j; i, j = i+1, j-1 {
+			labels[i], labels[j] = labels[j], labels[i]
+		}
+	}
+	if keep > 0 && keep < len(labels) {
+		labels = labels[len(labels)-keep:]
+	}
+	return strings.Join(labels, "."), nil
+}
+
+// parseSPFModifier 解析 name=value 形式的修饰符
+func parseSPFModifier(term string) (string, string, bool) {
+	i := strings.IndexByte(term, '=')
+	if i <= 0 || strings.ContainsAny(term[:i], ":/") {
+		return "", "", false
+	}
+	return strings.ToLower(term[:i]), term[i+1:], true
+}
+
+// splitSPFCIDR 拆分 a/mx 机制中的域名和前缀长度（如 :example.com/24//64）
+func splitSPFCIDR(arg string) (string, int, int, error) {
+	mask4, mask6 := 32, 128
+	spec := arg
+	if i := strings.Index(arg, "/"); i >= 0 {
+		spec = arg[:i]
+		masks := arg[i:]
+
+		v6 := ""
+		if j := strings.Index(masks, "//"); j >= 0 {
+			v6 = masks[j+2:]
+			masks = masks[:j]
+		}
+		if masks != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(masks, "/"))
+			if err != nil || n < 0 || n > 32 {
+				return "", 0, 0, &spfError{SPFPermError, "无效的IPv4前缀长度: " + arg}
+			}
+			mask4 = n
+		}
+		if v6 != "" {
+			n, err := strconv.Atoi(v6)
+			if err != nil || n < 0 || n > 128 {
+				return "", 0, 0, &spfError{SPFPermError, "无效的IPv6前缀长度: " + arg}
+			}
+			mask6 = n
+		}
+	}
+	return spec, mask4, mask6, nil
+}
+
+// ipMatches 判断ip是否位于candidate的指定前缀范围内
+func ipMatches(ip, candidate net.IP, mask4, mask6 int) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		c4 := candidate.To4()
+		if c4 == nil {
+			return false
+		}
+		mask := net.CIDRMask(mask4, 32)
+		return ip4.Mask(mask).Equal(c4.Mask(mask))
+	}
+
+	if candidate.To4() != nil {
+		return false
+	}
+	mask := net.CIDRMask(mask6, 128)
+	return ip.To16().Mask(mask).Equal(candidate.To16().Mask(mask))
+}
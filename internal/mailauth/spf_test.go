@@ -0,0 +1,280 @@
+package mailauth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCheckSPF(t *testing.T) {
+	tests := []struct {
+		name          string
+		ip            string
+		records       map[string]string
+		setup         func(*fakeResolver)
+		wantResult    string
+		wantMechanism string
+		wantReason    string
+	}{
+		{
+			name:       "未发布记录",
+			ip:         "192.0.2.1",
+			wantResult: SPFNone,
+		},
+		{
+			name:       "非SPF的TXT记录",
+			ip:         "192.0.2.1",
+			records:    map[string]string{"example.com": "google-site-verification=abc"},
+			wantResult: SPFNone,
+		},
+		{
+			name:          "ip4命中",
+			ip:            "192.0.2.10",
+			records:       map[string]string{"example.com": "v=spf1 ip4:192.0.2.0/24 -all"},
+			wantResult:    SPFPass,
+			wantMechanism: "ip4:192.0.2.0/24",
+		},
+		{
+			name:          "未命中落到-all",
+			ip:            "198.51.100.1",
+			records:       map[string]string{"example.com": "v=spf1 ip4:192.0.2.0/24 -all"},
+			wantResult:    SPFFail,
+			wantMechanism: "all",
+		},
+		{
+			name:          "softfail",
+			ip:            "198.51.100.1",
+			records:       map[string]string{"example.com": "v=spf1 ~all"},
+			wantResult:    SPFSoftFail,
+			wantMechanism: "all",
+		},
+		{
+			name:       "没有机制命中时为neutral",
+			ip:         "198.51.100.1",
+			records:    map[string]string{"example.com": "v=spf1 ip4:192.0.2.1"},
+			wantResult: SPFNeutral,
+		},
+		{
+			name:          "ip6命中",
+			ip:            "2001:db8::25",
+			records:       map[string]string{"example.com": "v=spf1 ip4:192.0.2.1 ip6:2001:db8::/32 -all"},
+			wantResult:    SPFPass,
+			wantMechanism: "ip6:2001:db8::/32",
+		},
+		{
+			name:          "a机制带前缀长度",
+			ip:            "192.0.2.77",
+			records:       map[string]string{"example.com": "v=spf1 a/24 -all"},
+			setup:         func(r *fakeResolver) { r.ip["example.com"] = []string{"192.0.2.1"} },
+			wantResult:    SPFPass,
+			wantMechanism: "a/24",
+		},
+		{
+			name:    "mx机制",
+			ip:      "192.0.2.25",
+			records: map[string]string{"example.com": "v=spf1 mx -all"},
+			setup: func(r *fakeResolver) {
+				r.mx["example.com"] = []*net.MX{{Host: "mail.example.com.", Pref: 10}}
+				r.ip["mail.example.com"] = []string{"192.0.2.25"}
+			},
+			wantResult:    SPFPass,
+			wantMechanism: "mx",
+		},
+		{
+			name: "include命中",
+			ip:   "203.0.113.5",
+			records: map[string]string{
+				"example.com":     "v=spf1 include:_spf.relay.test -all",
+				"_spf.relay.test": "v=spf1 ip4:203.0.113.0/24 ~all",
+			},
+			wantResult:    SPFPass,
+			wantMechanism: "include:_spf.relay.test",
+		},
+		{
+			name: "include中的fail不视为命中",
+			ip:   "198.51.100.1",
+			records: map[string]string{
+				"example.com":     "v=spf1 include:_spf.relay.test ?all",
+				"_spf.relay.test": "v=spf1 ip4:203.0.113.0/24 -all",
+			},
+			wantResult:    SPFNeutral,
+			wantMechanism: "all",
+		},
+		{
+			name: "redirect",
+			ip:   "203.0.113.5",
+			records: map[string]string{
+				"example.com":     "v=spf1 redirect=_spf.relay.test",
+				"_spf.relay.test": "v=spf1 ip4:203.0.113.5 -all",
+			},
+			wantResult:    SPFPass,
+			wantMechanism: "ip4:203.0.113.5",
+		},
+		{
+			name:    "ptr机制需要正向确认",
+			ip:      "192.0.2.9",
+			records: map[string]string{"example.com": "v=spf1 ptr -all"},
+			setup: func(r *fakeResolver) {
+				r.ptr["192.0.2.9"] = []string{"host.example.com."}
+				r.ip["host.example.com"] = []string{"192.0.2.9"}
+			},
+			wantResult:    SPFPass,
+			wantMechanism: "ptr",
+		},
+		{
+			name:          "exists宏展开",
+			ip:            "192.0.2.3",
+			records:       map[string]string{"example.com": "v=spf1 exists:%{ir}.%{l1r-}.allow.example.com -all"},
+			setup:         func(r *fakeResolver) { r.ip["3.2.0.192.user.allow.example.com"] = []string{"127.0.0.2"} },
+			wantResult:    SPFPass,
+			wantMechanism: "exists:%{ir}.%{l1r-}.allow.example.com",
+		},
+		{
+			name:       "多条SPF记录",
+			ip:         "192.0.2.1",
+			setup:      func(r *fakeResolver) { r.txt["example.com"] = []string{"v=spf1 -all", "v=spf1 +all"} },
+			wantResult: SPFPermError,
+			wantReason: "多条SPF记录",
+		},
+		{
+			name:       "未知机制",
+			ip:         "192.0.2.1",
+			records:    map[string]string{"example.com": "v=spf1 foo:bar -all"},
+			wantResult: SPFPermError,
+			wantReason: "未知的SPF机制",
+		},
+		{
+			name:       "include目标未发布",
+			ip:         "192.0.2.1",
+			records:    map[string]string{"example.com": "v=spf1 include:missing.test -all"},
+			wantResult: SPFPermError,
+			wantReason: "未发布SPF记录",
+		},
+		{
+			name:       "DNS临时错误",
+			ip:         "192.0.2.1",
+			setup:      func(r *fakeResolver) { r.tempFail["example.com"] = true },
+			wantResult: SPFTempError,
+		},
+		{
+			name:       "空结果查询超过上限",
+			ip:         "192.0.2.1",
+			records:    map[string]string{"example.com": "v=spf1 a:v1.test a:v2.test a:v3.test -all"},
+			wantResult: SPFPermError,
+			wantReason: "空结果DNS查询",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolver := newFakeResolver()
+			for name, record := range tt.records {
+				resolver.txt[name] = []string{record}
+			}
+			if tt.setup != nil {
+				tt.setup(resolver)
+			}
+
+			check := CheckSPF(context.Background(), resolver, net.ParseIP(tt.ip), "example.com", "user@example.com", "mail.example.com")
+			if check.Result != tt.wantResult {
+				t.Fatalf("Result = %s，期望 %s（%s）", check.Result, tt.wantResult, check.Reason)
+			}
+			if check.Mechanism != tt.wantMechanism {
+				t.Errorf("Mechanism = %q，期望 %q", check.Mechanism, tt.wantMechanism)
+			}
+			if !strings.Contains(check.Reason, tt.wantReason) {
+				t.Errorf("Reason = %q，期望包含 %q", check.Reason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestCheckSPFLookupLimit(t *testing.T) {
+	resolver := newFakeResolver()
+	var terms []string
+	for i := 0; i <= MaxSPFLookups; i++ {
+		name := fmt.Sprintf("inc%d.test", i)
+		terms = append(terms, "include:"+name)
+		resolver.txt[name] = []string{"v=spf1 ip4:203.0.113.1"}
+	}
+	resolver.txt["example.com"] = []string{"v=spf1 " + strings.Join(terms, " ") + " -all"}
+
+	check := CheckSPF(context.Background(), resolver, net.ParseIP("192.0.2.1"), "example.com", "", "")
+	if check.Result != SPFPermError || !strings.Contains(check.Reason, "上限") {
+		t.Fatalf("超过查询上限应返回permerror，实际为 %s（%s）", check.Result, check.Reason)
+	}
+	if check.Lookups != MaxSPFLookups+1 {
+		t.Errorf("Lookups = %d，期望 %d", check.Lookups, MaxSPFLookups+1)
+	}
+}
+
+func TestCountSPFLookups(t *testing.T) {
+	resolver := newFakeResolver()
+	resolver.txt["example.com"] = []string{"v=spf1 a mx include:_spf.relay.test ip4:192.0.2.0/24 redirect=_spf.other.test"}
+	resolver.txt["_spf.relay.test"] = []string{"v=spf1 exists:%{i}.rbl.test ptr include:%{d}.macro.test -all"}
+	resolver.txt["_spf.other.test"] = []string{"v=spf1 ip4:198.51.100.0/24 -all"}
+
+	// a、mx、include、redirect 各1次，_spf.relay.test 中 exists、ptr、include 各1次（宏目标不展开）
+	count, err := CountSPFLookups(context.Background(), resolver, "example.com")
+	if err != nil {
+		t.Fatalf("统计失败: %v", err)
+	}
+	if count != 7 {
+		t.Errorf("CountSPFLookups() = %d，期望 7", count)
+	}
+}
+
+func TestSPFMacroExpansion(t *testing.T) {
+	evaluator := &spfEvaluator{
+		ip:     net.ParseIP("192.0.2.3"),
+		sender: "strong-bad@email.example.com",
+		helo:   "mx.example.org",
+	}
+
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"%{s}", "strong-bad@email.example.com"},
+		{"%{o}", "email.example.com"},
+		{"%{d}", "email.example.com"},
+		{"%{d4}", "email.example.com"},
+		{"%{d2}", "example.com"},
+		{"%{d1}", "com"},
+		{"%{dr}", "com.example.email"},
+		{"%{d2r}", "example.email"},
+		{"%{l}", "strong-bad"},
+		{"%{l-}", "strong.bad"},
+		{"%{lr}", "strong-bad"},
+		{"%{lr-}", "bad.strong"},
+		{"%{l1r-}", "strong"},
+		{"%{ir}.%{v}._spf.%{d2}", "3.2.0.192.in-addr._spf.example.com"},
+		{"%{h}", "mx.example.org"},
+		{"%%-%_-%-", "%- -%20"},
+	}
+	for _, tt := range tests {
+		got, err := evaluator.expand(tt.spec, "email.example.com")
+		if err != nil {
+			t.Errorf("expand(%q) 失败: %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("expand(%q) = %q，期望 %q", tt.spec, got, tt.want)
+		}
+	}
+
+	for _, spec := range []string{"%", "%{", "%{x}", "%a"} {
+		if _, err := evaluator.expand(spec, "example.com"); err == nil {
+			t.Errorf("expand(%q) 应返回错误", spec)
+		}
+	}
+
+	ipv6 := &spfEvaluator{ip: net.ParseIP("2001:db8::cb01"), sender: "a@example.com"}
+	got, _ := ipv6.expand("%{ir}.%{v}", "example.com")
+	want := "1.0.b.c.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6"
+	if got != want {
+		t.Errorf("IPv6宏展开为 %q，期望 %q", got, want)
+	}
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 凭据异常处理方式（SMTPCredentialSettings.AnomalyAction）
+const (
+	AnomalyActionSuspend = "suspend" // 记录告警，严重偏离时自动停用凭据（默认）
+	AnomalyActionAlert   = "alert"   // 只记录告警并通知，不停用凭据
+	AnomalyActionOff     = "off"     // 不检测
+)
+
+// 异常检测指标
+const (
+	AnomalyMetricVolume           = "hourly_volume"     // 每小时邮件数
+	AnomalyMetricSourceIPs        = "source_ips"        // 每小时不同的客户端IP数
+	AnomalyMetricRecipientDomains = "recipient_domains" // 每小时不同的收件域名数
+	AnomalyMetricFailureRate      = "failure_rate"      // 投递失败率
+)
+
+// 异常记录的处理结果
+const (
+	AnomalyActionAlerted   = "alerted"
+	AnomalyActionSuspended = "suspended"
+)
+
+// BaselineMetric 基线指标（指数加权移动平均）
+type BaselineMetric struct {
+	Mean   float64 `bson:"mean" json:"mean"`
+	StdDev float64 `bson:"std_dev" json:"std_dev"`
+}
+
+// CredentialBaseline 凭据的正常行为基线，按有发信的整点小时逐步学习
+type CredentialBaseline struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID           primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CredentialID     primitive.ObjectID `bson:"credential_id" json:"credential_id"`
+	HourlyVolume     BaselineMetric     `bson:"hourly_volume" json:"hourly_volume"`
+	SourceIPs        BaselineMetric     `bson:"source_ips" json:"source_ips"`
+	RecipientDomains BaselineMetric     `bson:"recipient_domains" json:"recipient_domains"`
+	FailureRate      BaselineMetric     `bson:"failure_rate" json:"failure_rate"`
+	SampleHours      int                `bson:"sample_hours" json:"sample_hours"`                           // 已学习的小时数
+	LastHour         time.Time          `bson:"last_hour" json:"last_hour"`                                 // 最近一次学习的整点小时
+	LastAnomalyAt    *time.Time         `bson:"last_anomaly_at,omitempty" json:"last_anomaly_at,omitempty"` // 最近一次检测到异常的时间
+	LastAnomalyState string             `bson:"last_anomaly_state,omitempty" json:"-"`                      // 最近一次异常的处理结果
+	UpdatedAt        time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CredentialActivity 凭据在某个时间窗口内的发信行为
+type CredentialActivity struct {
+	WindowStart      time.Time `bson:"window_start" json:"window_start"`
+	WindowEnd        time.Time `bson:"window_end" json:"window_end"`
+	Volume           int64     `bson:"volume" json:"volume"`
+	SourceIPs        int       `bson:"source_ips" json:"source_ips"`
+	RecipientDomains int       `bson:"recipient_domains" json:"recipient_domains"`
+	Sent             int64     `bson:"sent" json:"sent"`
+	Failed           int64     `bson:"failed" json:"failed"`
+	FailureRate      float64   `bson:"failure_rate" json:"failure_rate"` // failed / (sent + failed)
+}
+
+// AnomalyDeviation 单个指标的偏离情况
+type AnomalyDeviation struct {
+	Metric    string  `bson:"metric" json:"metric"`
+	Value     float64 `bson:"value" json:"value"`
+	Baseline  float64 `bson:"baseline" json:"baseline"`   // 基线均值
+	Threshold float64 `bson:"threshold" json:"threshold"` // 触发阈值
+}
+
+// CredentialAnomaly 凭据异常告警记录
+type CredentialAnomaly struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CredentialID   primitive.ObjectID `bson:"credential_id" json:"credential_id"`
+	CredentialName string             `bson:"credential_name" json:"credential_name"`
+	Action         string             `bson:"action" json:"action"` // alerted, suspended
+	Reason         string             `bson:"reason" json:"reason"`
+	Deviations     []AnomalyDeviation `bson:"deviations" json:"deviations"`
+	Activity       CredentialActivity `bson:"activity" json:"activity"`
+	DetectedAt     time.Time          `bson:"detected_at" json:"detected_at"`
+}
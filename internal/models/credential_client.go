@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CredentialClient 使用凭据认证的客户端（按来源IP、EHLO名称、TLS版本和认证机制区分）
+type CredentialClient struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CredentialID  primitive.ObjectID `bson:"credential_id" json:"credential_id"`
+	IP            string             `bson:"ip" json:"ip"`
+	EHLO          string             `bson:"ehlo" json:"ehlo"`                                 // 客户端EHLO/HELO名称
+	TLSVersion    string             `bson:"tls_version" json:"tls_version" example:"TLS 1.3"` // 未使用TLS时为none
+	AuthMechanism string             `bson:"auth_mechanism" json:"auth_mechanism" example:"PLAIN"`
+	FirstSeen     time.Time          `bson:"first_seen" json:"first_seen"`
+	LastSeen      time.Time          `bson:"last_seen" json:"last_seen"`
+	Count         int64              `bson:"count" json:"count"` // 认证成功次数
+}
+
+// 安全事件类型
+const (
+	SecurityEventNewSourceIP = "credential_new_source_ip" // 凭据首次从某个IP认证
+)
+
+// SecurityEvent 安全事件
+type SecurityEvent struct {
+	ID           primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID       primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	CredentialID primitive.ObjectID     `bson:"credential_id" json:"credential_id"`
+	Type         string                 `bson:"type" json:"type"`
+	IP           string                 `bson:"ip,omitempty" json:"ip,omitempty"`
+	Message      string                 `bson:"message" json:"message"`
+	Details      map[string]interface{} `bson:"details,omitempty" json:"details,omitempty"`
+	DedupKey     string                 `bson:"dedup_key,omitempty" json:"-"` // 同一事件只记录一次
+	CreatedAt    time.Time              `bson:"created_at" json:"created_at"`
+}
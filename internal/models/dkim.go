@@ -1,28 +1,60 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// DKIM密钥来源
+const (
+	DKIMKeySourceGenerated = "generated" // 由本服务生成
+	DKIMKeySourceImported  = "imported"  // 从其他中继导入
+)
+
+// DKIM密钥状态
+const (
+	DKIMKeyStatusPending  = "pending"  // 轮换生成的新密钥，等待DNS记录生效
+	DKIMKeyStatusActive   = "active"   // 用于签名
+	DKIMKeyStatusExpiring = "expiring" // 已被新密钥替换，宽限期内保留DNS记录
+	DKIMKeyStatusExpired  = "expired"  // 宽限期结束，可删除DNS记录
+	DKIMKeyStatusDeleted  = "deleted"
+)
+
 // DKIMKeyPair DKIM密钥对模型
 type DKIMKeyPair struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Domain       string             `bson:"domain" json:"domain"`                         // 签名域名
-	Selector     string             `bson:"selector" json:"selector"`                     // DKIM选择器
-	PrivateKey   string             `bson:"private_key" json:"-"`                         // 私钥（不返回给前端）
-	PublicKey    string             `bson:"public_key" json:"public_key"`                 // 公钥
-	KeySize      int                `bson:"key_size" json:"key_size"`                     // 密钥长度（1024, 2048）
-	Algorithm    string             `bson:"algorithm" json:"algorithm"`                   // 签名算法（rsa-sha256）
-	Status       string             `bson:"status" json:"status"`                         // active, inactive, expired
-	DNSRecord    string             `bson:"dns_record" json:"dns_record"`                 // 生成的DNS TXT记录
-	DNSVerified  bool               `bson:"dns_verified" json:"dns_verified"`             // DNS记录是否已验证
-	LastVerified *time.Time         `bson:"last_verified,omitempty" json:"last_verified"` // 最后验证时间
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
-	ExpiresAt    *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // 密钥过期时间
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Domain          string              `bson:"domain" json:"domain"`                         // 签名域名
+	Selector        string              `bson:"selector" json:"selector"`                     // DKIM选择器
+	PrivateKey      string              `bson:"private_key" json:"-"`                         // 私钥（加密存储，不返回给前端）
+	EncryptionKeyID string              `bson:"encryption_key_id,omitempty" json:"-"`         // 加密私钥所用的主密钥ID
+	PublicKey       string              `bson:"public_key" json:"public_key"`                 // 公钥
+	KeySize         int                 `bson:"key_size" json:"key_size"`                     // 密钥长度（RSA为1024/2048/4096，Ed25519为256）
+	Algorithm       string              `bson:"algorithm" json:"algorithm"`                   // 签名算法（rsa-sha256, ed25519-sha256）
+	Status          string              `bson:"status" json:"status"`                         // pending, active, expiring, expired
+	Source          string              `bson:"source,omitempty" json:"source,omitempty"`     // generated, imported
+	DNSRecord       string              `bson:"dns_record" json:"dns_record"`                 // 生成的DNS TXT记录
+	DNSVerified     bool                `bson:"dns_verified" json:"dns_verified"`             // DNS记录是否已验证
+	LastVerified    *time.Time          `bson:"last_verified,omitempty" json:"last_verified"` // 最后验证时间
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time           `bson:"updated_at" json:"updated_at"`
+	ExpiresAt       *time.Time          `bson:"expires_at,omitempty" json:"expires_at,omitempty"`           // 密钥过期时间
+	RotatedFromID   *primitive.ObjectID `bson:"rotated_from_id,omitempty" json:"rotated_from_id,omitempty"` // 轮换前的密钥ID（新密钥DNS生效后替换该密钥）
+}
+
+// DKIMRotationPolicy 域名DKIM密钥自动轮换策略
+type DKIMRotationPolicy struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Domain          string             `bson:"domain" json:"domain"`
+	Enabled         bool               `bson:"enabled" json:"enabled"`
+	IntervalDays    int                `bson:"interval_days" json:"interval_days"`                         // 轮换周期（天）
+	GracePeriodDays int                `bson:"grace_period_days" json:"grace_period_days"`                 // 旧密钥在新密钥生效后保留的天数
+	LastRotatedAt   *time.Time         `bson:"last_rotated_at,omitempty" json:"last_rotated_at,omitempty"` // 最近一次自动轮换时间
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 // DKIMSettings DKIM配置设置
@@ -33,27 +65,42 @@ type DKIMSettings struct {
 	HeaderCanon    string              `bson:"header_canon" json:"header_canon"`                         // 头部规范化（relaxed/simple）
 	BodyCanon      string              `bson:"body_canon" json:"body_canon"`                             // 正文规范化（relaxed/simple）
 	SignHeaders    []string            `bson:"sign_headers" json:"sign_headers"`                         // 要签名的头部列表
+	OversignFrom   bool                `bson:"oversign_from" json:"oversign_from"`                       // 是否对From头部过度签名（防止追加第二个From）
 }
 
 // DKIMConfig DKIM域名配置
 type DKIMConfig struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
-	Domain    string             `bson:"domain" json:"domain"`           // 配置的域名
-	KeyPairID primitive.ObjectID `bson:"key_pair_id" json:"key_pair_id"` // 关联的密钥对ID
-	Settings  DKIMSettings       `bson:"settings" json:"settings"`       // DKIM设置
-	Active    bool               `bson:"active" json:"active"`           // 是否激活
+	Domain    string             `bson:"domain" json:"domain"`                     // 配置的域名（发件地址域名）
+	KeyPairID primitive.ObjectID `bson:"key_pair_id,omitempty" json:"key_pair_id"` // 关联的密钥对ID（与Settings.DefaultKeyID一致）
+	Settings  DKIMSettings       `bson:"settings" json:"settings"`                 // DKIM设置
+	Active    bool               `bson:"active" json:"active"`                     // 是否激活（未激活时使用默认签名设置）
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
 }
 
 // DNSRecord DNS记录结构
 type DNSRecord struct {
-	Type     string `json:"type"`     // TXT
-	Name     string `json:"name"`     // 记录名称，如 selector._domainkey.example.com
-	Value    string `json:"value"`    // 记录值
-	TTL      int    `json:"ttl"`      // TTL值
-	Priority int    `json:"priority"` // 优先级（对TXT记录通常为0）
+	Type     string   `json:"type"`              // TXT
+	Name     string   `json:"name"`              // 记录名称，如 selector._domainkey.example.com
+	Value    string   `json:"value"`             // 记录值
+	Strings  []string `json:"strings,omitempty"` // 按255字节拆分后的TXT字符串（超长记录需分段发布）
+	TTL      int      `json:"ttl"`               // TTL值
+	Priority int      `json:"priority"`          // 优先级（对TXT记录通常为0）
+}
+
+// DKIMKeyExport DKIM密钥导出数据
+type DKIMKeyExport struct {
+	ID         primitive.ObjectID `json:"id"`
+	Domain     string             `json:"domain"`
+	Selector   string             `json:"selector"`
+	Algorithm  string             `json:"algorithm"`
+	KeySize    int                `json:"key_size"`
+	PublicKey  string             `json:"public_key"`            // PEM格式公钥（SubjectPublicKeyInfo）
+	PrivateKey string             `json:"private_key,omitempty"` // PEM格式私钥，仅完整导出时返回
+	Format     string             `json:"format,omitempty"`      // 私钥格式：pkcs8, pkcs1
+	DNSRecord  *DNSRecord         `json:"dns_record"`
 }
 
 // DKIMValidationResult DKIM验证结果
@@ -78,6 +125,30 @@ func (d *DKIMKeyPair) GetDNSRecordValue() string {
 	return d.DNSRecord
 }
 
+// GetDNSRecordStrings 将DNS记录值拆分为不超过255字节的TXT字符串
+func (d *DKIMKeyPair) GetDNSRecordStrings() []string {
+	return SplitTXTValue(d.DNSRecord)
+}
+
+// GetDNSRecordZoneValue 获取区域文件格式的记录值（多个带引号的字符串）
+func (d *DKIMKeyPair) GetDNSRecordZoneValue() string {
+	parts := d.GetDNSRecordStrings()
+	for i, part := range parts {
+		parts[i] = `"` + part + `"`
+	}
+	return strings.Join(parts, " ")
+}
+
+// SplitTXTValue 按TXT记录单个字符串255字节的上限拆分记录值
+func SplitTXTValue(value string) []string {
+	var parts []string
+	for len(value) > 255 {
+		parts = append(parts, value[:255])
+		value = value[255:]
+	}
+	return append(parts, value)
+}
+
 // IsExpired 检查密钥是否过期
 func (d *DKIMKeyPair) IsExpired() bool {
 	if d.ExpiresAt == nil {
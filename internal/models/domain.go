@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 发件域名状态
+const (
+	DomainStatusPending   = "pending"   // 等待验证
+	DomainStatusVerified  = "verified"  // 已验证
+	DomainStatusSuspended = "suspended" // 验证记录消失后被暂停
+)
+
+// DomainVerificationPrefix 域名验证TXT记录值前缀
+const DomainVerificationPrefix = "smtp-relay-verification="
+
+// Domain 用户声明的发件域名
+type Domain struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Domain            string             `bson:"domain" json:"domain"`
+	Status            string             `bson:"status" json:"status"`                             // pending, verified, suspended
+	VerificationToken string             `bson:"verification_token" json:"verification_token"`     // TXT验证令牌
+	BounceDomain      string             `bson:"bounce_domain,omitempty" json:"bounce_domain"`     // 退信域名（信封发件人域名）
+	FailedChecks      int                `bson:"failed_checks" json:"failed_checks"`               // 连续重新验证失败次数
+	VerifiedAt        *time.Time         `bson:"verified_at,omitempty" json:"verified_at"`         // 首次验证通过时间
+	LastCheckedAt     *time.Time         `bson:"last_checked_at,omitempty" json:"last_checked_at"` // 最后检查时间
+	SuspendedAt       *time.Time         `bson:"suspended_at,omitempty" json:"suspended_at,omitempty"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt         time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// IsVerified 检查域名是否已验证
+func (d *Domain) IsVerified() bool {
+	return d.Status == DomainStatusVerified
+}
+
+// VerificationRecordName 验证TXT记录名称
+func (d *Domain) VerificationRecordName() string {
+	return "_smtp-relay." + d.Domain
+}
+
+// VerificationRecord 需要发布的验证TXT记录
+func (d *Domain) VerificationRecord() *DNSRecord {
+	return &DNSRecord{
+		Type:  "TXT",
+		Name:  d.VerificationRecordName(),
+		Value: DomainVerificationPrefix + d.VerificationToken,
+		TTL:   3600,
+	}
+}
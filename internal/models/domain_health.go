@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// 域名健康检查状态
+const (
+	HealthPass = "pass"
+	HealthWarn = "warn"
+	HealthFail = "fail"
+)
+
+// HealthCheck 单项检查结果
+type HealthCheck struct {
+	Status      string   `json:"status"`                // pass, warn, fail
+	Message     string   `json:"message"`               // 检查结论
+	Suggestions []string `json:"suggestions,omitempty"` // 修复建议
+}
+
+// SPFRelayResult 中继IP的SPF评估结果
+type SPFRelayResult struct {
+	IP     string `json:"ip"`
+	Result string `json:"result"` // pass, fail, softfail, neutral, none, temperror, permerror
+}
+
+// SPFHealth SPF检查结果
+type SPFHealth struct {
+	HealthCheck
+	Record       string           `json:"record,omitempty"`
+	Lookups      int              `json:"lookups"` // 最坏情况下的DNS查询次数
+	RelayResults []SPFRelayResult `json:"relay_results"`
+}
+
+// DKIMSelectorHealth 单个DKIM选择器的检查结果
+type DKIMSelectorHealth struct {
+	HealthCheck
+	Selector  string `json:"selector"`
+	KeyPairID string `json:"key_pair_id"`
+	Record    string `json:"record,omitempty"` // DNS中发布的记录
+}
+
+// DMARCHealth DMARC检查结果
+type DMARCHealth struct {
+	HealthCheck
+	Record          string   `json:"record,omitempty"`
+	RecordDomain    string   `json:"record_domain,omitempty"` // 记录所在域名（可能是组织域名）
+	Policy          string   `json:"policy,omitempty"`
+	SubdomainPolicy string   `json:"subdomain_policy,omitempty"`
+	ADKIM           string   `json:"adkim,omitempty"`
+	ASPF            string   `json:"aspf,omitempty"`
+	Percent         int      `json:"pct,omitempty"`
+	RUA             []string `json:"rua,omitempty"`
+	SPFAligned      bool     `json:"spf_aligned"`
+	DKIMAligned     bool     `json:"dkim_aligned"`
+}
+
+// MXHealth MX记录检查结果
+type MXHealth struct {
+	HealthCheck
+	Hosts []string `json:"hosts"`
+}
+
+// ReverseDNSHealth 中继IP的正反向DNS一致性检查结果
+type ReverseDNSHealth struct {
+	HealthCheck
+	IP        string   `json:"ip"`
+	Hostnames []string `json:"hostnames"`
+	Confirmed bool     `json:"confirmed"` // PTR主机名能正向解析回该IP
+}
+
+// DomainHealthReport 域名认证健康报告
+type DomainHealthReport struct {
+	Domain     string               `json:"domain"`
+	Status     string               `json:"status"` // 所有检查项中最差的状态
+	RelayIPs   []string             `json:"relay_ips"`
+	SPF        SPFHealth            `json:"spf"`
+	DKIM       []DKIMSelectorHealth `json:"dkim"`
+	DMARC      DMARCHealth          `json:"dmarc"`
+	MX         MXHealth             `json:"mx"`
+	ReverseDNS []ReverseDNSHealth   `json:"reverse_dns"`
+	CheckedAt  time.Time            `json:"checked_at"`
+}
@@ -8,32 +8,56 @@ import (
 
 // MailLog 邮件发送日志结构
 type MailLog struct {
-	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
-	UserID       primitive.ObjectID  `bson:"user_id" json:"user_id"`
-	CredentialID *primitive.ObjectID `bson:"credential_id,omitempty" json:"credential_id,omitempty"`
-	MessageID    string              `bson:"message_id" json:"message_id"`
-	From         string              `bson:"from" json:"from"`
-	To           []string            `bson:"to" json:"to"`
-	Subject      string              `bson:"subject" json:"subject"`
-	Size         int64               `bson:"size" json:"size"`
-	Status       string              `bson:"status" json:"status"` // queued, sending, sent, failed
-	Attempts     int                 `bson:"attempts" json:"attempts"`
-	LastAttempt  time.Time           `bson:"last_attempt" json:"last_attempt"`
-	ErrorMessage string              `bson:"error_message,omitempty" json:"error_message,omitempty"`
-	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
-	CompletedAt  *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
-	RelayIP      string              `bson:"relay_ip" json:"relay_ip"`
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	CredentialID    *primitive.ObjectID `bson:"credential_id,omitempty" json:"credential_id,omitempty"`
+	PasswordID      string              `bson:"password_id,omitempty" json:"password_id,omitempty"` // 提交时认证使用的凭据密码标识
+	MessageID       string              `bson:"message_id" json:"message_id"`
+	ClientMessageID string              `bson:"client_message_id,omitempty" json:"client_message_id,omitempty"` // 客户端提供的Message-ID
+	From            string              `bson:"from" json:"from"`
+	To              []string            `bson:"to" json:"to"`
+	Subject         string              `bson:"subject" json:"subject"`
+	Size            int64               `bson:"size" json:"size"`
+	Status          string              `bson:"status" json:"status"` // queued, sending, sent, failed, captured, expired
+	Attempts        int                 `bson:"attempts" json:"attempts"`
+	LastAttempt     time.Time           `bson:"last_attempt" json:"last_attempt"`
+	ErrorMessage    string              `bson:"error_message,omitempty" json:"error_message,omitempty"`
+	CreatedAt       time.Time           `bson:"created_at" json:"created_at"`
+	CompletedAt     *time.Time          `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	RelayIP         string              `bson:"relay_ip" json:"relay_ip"`
+	ClientIP        string              `bson:"client_ip,omitempty" json:"client_ip,omitempty"`                 // 提交邮件的客户端IP
+	DuplicateCount  int                 `bson:"duplicate_count,omitempty" json:"duplicate_count,omitempty"`     // 幂等窗口内的重复提交次数
+	LastDuplicateAt *time.Time          `bson:"last_duplicate_at,omitempty" json:"last_duplicate_at,omitempty"` // 最近一次重复提交时间
+	Archive         *MessageArchive     `bson:"archive,omitempty" json:"archive,omitempty"`                     // 原始邮件归档信息
+	Tags            []string            `bson:"tags,omitempty" json:"tags,omitempty"`                           // X-Relay-Tag标签
+	CampaignID      string              `bson:"campaign_id,omitempty" json:"campaign_id,omitempty"`             // X-Relay-Campaign活动ID
+	Metadata        map[string]string   `bson:"metadata,omitempty" json:"metadata,omitempty"`                   // X-Relay-Metadata自定义元数据
+	Priority        string              `bson:"priority,omitempty" json:"priority,omitempty"`                   // X-Relay-Priority优先级提示: high, normal, low
+	ExpiresAt       *time.Time          `bson:"expires_at,omitempty" json:"expires_at,omitempty"`               // X-Relay-Expires过期时间，过期未投递的邮件不再发送
+	SignerUserID    *primitive.ObjectID `bson:"signer_user_id,omitempty" json:"-"`                              // 系统邮件（UserID为空，不计入任何用户的日志和用量）使用该用户的签名密钥和退信域名
+}
+
+// MessageArchive 原始邮件归档信息
+type MessageArchive struct {
+	Backend         string     `bson:"backend" json:"backend"`                           // gridfs, filesystem, s3
+	Key             string     `bson:"key" json:"-"`                                     // 存储键
+	Size            int64      `bson:"size" json:"size"`                                 // 原始大小
+	CompressedSize  int64      `bson:"compressed_size" json:"compressed_size"`           // 压缩后大小
+	ArchivedAt      time.Time  `bson:"archived_at" json:"archived_at"`                   // 归档时间
+	ExpiresAt       *time.Time `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // 过期时间（为空表示永久保留）
+	EncryptionKeyID string     `bson:"encryption_key_id,omitempty" json:"-"`             // 加密归档对象所用的主密钥ID
 }
 
 // SMTPConfig SMTP服务器配置
 type SMTPConfig struct {
-	ID       primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name     string             `bson:"name" json:"name"`
-	Host     string             `bson:"host" json:"host"`
-	Port     int                `bson:"port" json:"port"`
-	Username string             `bson:"username" json:"username"`
-	Password string             `bson:"password" json:"-"`
-	TLS      bool               `bson:"tls" json:"tls"`
-	Active   bool               `bson:"active" json:"active"`
-	Priority int                `bson:"priority" json:"priority"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name          string             `bson:"name" json:"name"`
+	Host          string             `bson:"host" json:"host"`
+	Port          int                `bson:"port" json:"port"`
+	Username      string             `bson:"username" json:"username"`
+	Password      string             `bson:"password" json:"-"`                  // 加密存储
+	PasswordKeyID string             `bson:"password_key_id,omitempty" json:"-"` // 加密密码所用的主密钥ID
+	TLS           bool               `bson:"tls" json:"tls"`
+	Active        bool               `bson:"active" json:"active"`
+	Priority      int                `bson:"priority" json:"priority"`
 }
@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// DKIMSignatureVerification 单个DKIM签名的验证结果
+type DKIMSignatureVerification struct {
+	Result           string     `json:"result"` // pass, fail, temperror, permerror
+	Domain           string     `json:"domain"`
+	Selector         string     `json:"selector"`
+	Algorithm        string     `json:"algorithm"`
+	Canonicalization string     `json:"canonicalization,omitempty"`
+	SignedHeaders    []string   `json:"signed_headers"`
+	Identity         string     `json:"identity,omitempty"`
+	BodyLength       *int       `json:"body_length,omitempty"` // l=，未设置时省略
+	Timestamp        *time.Time `json:"timestamp,omitempty"`
+	Expiration       *time.Time `json:"expiration,omitempty"`
+	KeyBits          int        `json:"key_bits,omitempty"`
+	Aligned          bool       `json:"aligned"` // 与From域名按DMARC adkim模式对齐
+	Reason           string     `json:"reason,omitempty"`
+}
+
+// ARCVerification ARC链验证结果
+type ARCVerification struct {
+	Result      string   `json:"result"` // none, pass, fail
+	Instances   int      `json:"instances"`
+	AuthResults []string `json:"auth_results"` // 各实例的ARC-Authentication-Results（按实例升序）
+	Reason      string   `json:"reason,omitempty"`
+}
+
+// SPFVerification SPF检查结果
+type SPFVerification struct {
+	Result    string `json:"result"` // pass, fail, softfail, neutral, none, temperror, permerror
+	ClientIP  string `json:"client_ip"`
+	Sender    string `json:"sender"` // 检查使用的MAIL FROM地址
+	HELO      string `json:"helo,omitempty"`
+	Domain    string `json:"domain"`
+	Record    string `json:"record,omitempty"`
+	Mechanism string `json:"mechanism,omitempty"` // 命中的机制
+	Lookups   int    `json:"lookups"`
+	Aligned   bool   `json:"aligned"` // 与From域名按DMARC aspf模式对齐
+	Reason    string `json:"reason,omitempty"`
+}
+
+// DMARCVerification DMARC评估结果
+type DMARCVerification struct {
+	Result       string `json:"result"` // pass, fail, none, temperror, permerror
+	FromDomain   string `json:"from_domain"`
+	Record       string `json:"record,omitempty"`
+	RecordDomain string `json:"record_domain,omitempty"` // 记录所在域名（可能是组织域名）
+	Policy       string `json:"policy,omitempty"`        // 适用的策略（子域名使用sp=）
+	ADKIM        string `json:"adkim,omitempty"`
+	ASPF         string `json:"aspf,omitempty"`
+	Percent      int    `json:"pct,omitempty"`
+	SPFAligned   bool   `json:"spf_aligned"`
+	DKIMAligned  bool   `json:"dkim_aligned"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// MessageVerification 邮件认证验证报告
+type MessageVerification struct {
+	From       string                      `json:"from"`
+	MessageID  string                      `json:"message_id,omitempty"`
+	Subject    string                      `json:"subject,omitempty"`
+	DKIM       []DKIMSignatureVerification `json:"dkim"`
+	ARC        ARCVerification             `json:"arc"`
+	SPF        *SPFVerification            `json:"spf,omitempty"` // 未提供客户端IP时省略
+	DMARC      DMARCVerification           `json:"dmarc"`
+	VerifiedAt time.Time                   `json:"verified_at"`
+}
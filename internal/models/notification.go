@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// 通知级别
+const (
+	NotificationInfo     = "info"
+	NotificationWarning  = "warning"
+	NotificationCritical = "critical"
+)
+
+// Notification 用户通知（需要用户处理的事项或重要事件）
+type Notification struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID     `bson:"user_id" json:"user_id"`
+	Type      string                 `bson:"type" json:"type"`   // 通知类型，如 dkim_dns_pending
+	Level     string                 `bson:"level" json:"level"` // info, warning, critical
+	Title     string                 `bson:"title" json:"title"`
+	Message   string                 `bson:"message" json:"message"`
+	Data      map[string]interface{} `bson:"data,omitempty" json:"data,omitempty"`       // 附加数据（如需要发布的DNS记录）
+	DedupKey  string                 `bson:"dedup_key,omitempty" json:"-"`               // 去重键，存在未读的相同通知时不再重复发送
+	Read      bool                   `bson:"read" json:"read"`                           // 是否已读
+	ReadAt    *time.Time             `bson:"read_at,omitempty" json:"read_at,omitempty"` // 已读时间
+	CreatedAt time.Time              `bson:"created_at" json:"created_at"`
+}
+
+// 通知类型
+const (
+	NotificationTypeQuotaThreshold = "quota_threshold" // 配额用量达到阈值
+)
+
+// DefaultQuotaThresholds 用户未设置通知偏好时使用的配额提醒阈值（百分比）
+var DefaultQuotaThresholds = []int{80, 95, 100}
+
+// NotificationPreferences 用户通知偏好：站内通知始终保存，邮件和Webhook为额外的通知渠道
+type NotificationPreferences struct {
+	QuotaThresholds    []int  `bson:"quota_thresholds,omitempty" json:"quota_thresholds" example:"80,95,100"`                       // 配额用量提醒阈值（百分比，1-100），为空表示不发送配额提醒
+	EmailEnabled       bool   `bson:"email_enabled" json:"email_enabled" example:"true"`                                            // 是否通过中继发送邮件通知
+	Email              string `bson:"email,omitempty" json:"email,omitempty" example:"ops@example.com"`                             // 接收通知的邮箱，为空时使用账户邮箱
+	WebhookURL         string `bson:"webhook_url,omitempty" json:"webhook_url,omitempty" example:"https://example.com/hooks/relay"` // 接收通知的Webhook地址
+	WebhookSecret      string `bson:"webhook_secret,omitempty" json:"-"`                                                            // Webhook签名密钥（HMAC-SHA256，加密存储）
+	WebhookSecretKeyID string `bson:"webhook_secret_key_id,omitempty" json:"-"`                                                     // 加密签名密钥所用的主密钥ID
+	HasWebhookSecret   bool   `bson:"-" json:"has_webhook_secret"`                                                                  // 是否已设置Webhook签名密钥
+}
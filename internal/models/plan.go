@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Plan 套餐：限制用户所有凭据合计的发信量和资源数量，各项为0表示不限制
+type Plan struct {
+	ID                  primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name                string             `bson:"name" json:"name" example:"basic"`
+	Description         string             `bson:"description,omitempty" json:"description,omitempty"`
+	DailyLimit          int64              `bson:"daily_limit" json:"daily_limit" example:"10000"`                                        // 每日收件人数（所有凭据合计）
+	MonthlyLimit        int64              `bson:"monthly_limit" json:"monthly_limit" example:"200000"`                                   // 每月收件人数（所有凭据合计）
+	MaxCredentials      int                `bson:"max_credentials" json:"max_credentials" example:"10"`                                   // 最多SMTP凭据数
+	MaxDomains          int                `bson:"max_domains" json:"max_domains" example:"5"`                                            // 最多发件域名数
+	MaxMessageSize      int64              `bson:"max_message_size" json:"max_message_size" example:"0"`                                  // 单封邮件最大字节数
+	MessagesPerSecond   int                `bson:"messages_per_second,omitempty" json:"messages_per_second,omitempty" example:"50"`       // 每秒邮件数上限（所有凭据合计，0表示不限制），用户和凭据只能设置更低的值
+	RecipientsPerMinute int                `bson:"recipients_per_minute,omitempty" json:"recipients_per_minute,omitempty" example:"3000"` // 每分钟收件人数上限（所有凭据合计，0表示不限制），用户和凭据只能设置更低的值
+	Timezone            string             `bson:"timezone,omitempty" json:"timezone,omitempty" example:"Asia/Shanghai"`                  // 用户未设置时区时使用的IANA时区，默认UTC
+	IsDefault           bool               `bson:"is_default" json:"is_default"`                                                          // 未分配套餐的用户使用默认套餐
+	CreatedAt           time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time          `bson:"updated_at" json:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SandboxMessage 沙箱凭据捕获的邮件（不会被投递）
+type SandboxMessage struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+	CredentialID    primitive.ObjectID `bson:"credential_id" json:"credential_id"`
+	MailLogID       primitive.ObjectID `bson:"mail_log_id" json:"mail_log_id"`
+	MessageID       string             `bson:"message_id" json:"message_id"`
+	From            string             `bson:"from" json:"from"`
+	To              []string           `bson:"to" json:"to"`
+	Subject         string             `bson:"subject" json:"subject"`
+	Size            int64              `bson:"size" json:"size"`
+	Raw             []byte             `bson:"raw" json:"-"`                         // 原始邮件内容（加密存储）
+	EncryptionKeyID string             `bson:"encryption_key_id,omitempty" json:"-"` // 加密原始内容所用的主密钥ID
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	ExpiresAt       *time.Time         `bson:"expires_at,omitempty" json:"expires_at,omitempty"` // 自动过期时间（TTL索引）
+}
+
+// SandboxMessageDetail 沙箱邮件详情（包含解析后的头部和MIME部件）
+type SandboxMessageDetail struct {
+	*SandboxMessage
+	Headers map[string][]string  `json:"headers"`
+	Parts   []SandboxMessagePart `json:"parts"`
+}
+
+// SandboxMessagePart 沙箱邮件的MIME部件
+type SandboxMessagePart struct {
+	Index       int    `json:"index"`
+	ContentType string `json:"content_type"`
+	Charset     string `json:"charset,omitempty"`
+	Disposition string `json:"disposition,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	Size        int    `json:"size"`
+	Content     string `json:"content,omitempty"` // 仅文本部件返回解码后的内容
+}
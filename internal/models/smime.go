@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SMIMEExpiryWarningDays 证书到期前多少天开始提示
+const SMIMEExpiryWarningDays = 30
+
+// SMIMECertificate S/MIME签名证书（按发件地址或域名匹配）
+type SMIMECertificate struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID          primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Sender          string             `bson:"sender" json:"sender"`                 // 发件地址（user@example.com）或域名（example.com）
+	Certificate     string             `bson:"certificate" json:"certificate"`       // PEM格式证书链
+	PrivateKey      string             `bson:"private_key" json:"-"`                 // 私钥（加密存储，不返回给前端）
+	EncryptionKeyID string             `bson:"encryption_key_id,omitempty" json:"-"` // 加密私钥所用的主密钥ID
+	Subject         string             `bson:"subject" json:"subject"`
+	Issuer          string             `bson:"issuer" json:"issuer"`
+	SerialNumber    string             `bson:"serial_number" json:"serial_number"`
+	EmailAddresses  []string           `bson:"email_addresses" json:"email_addresses"`
+	Fingerprint     string             `bson:"fingerprint" json:"fingerprint"` // SHA-256指纹
+	NotBefore       time.Time          `bson:"not_before" json:"not_before"`
+	NotAfter        time.Time          `bson:"not_after" json:"not_after"`
+	Status          string             `bson:"status" json:"status"` // active, disabled
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt       time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// IsExpired 检查证书是否已过期
+func (c *SMIMECertificate) IsExpired() bool {
+	return time.Now().After(c.NotAfter)
+}
+
+// ExpiryStatus 返回证书有效期状态：valid, expiring, expired
+func (c *SMIMECertificate) ExpiryStatus() string {
+	switch {
+	case c.IsExpired():
+		return "expired"
+	case time.Until(c.NotAfter) < SMIMEExpiryWarningDays*24*time.Hour:
+		return "expiring"
+	default:
+		return "valid"
+	}
+}
+
+// DaysUntilExpiry 距离证书过期的天数（已过期为负数）
+func (c *SMIMECertificate) DaysUntilExpiry() int {
+	return int(time.Until(c.NotAfter).Hours() / 24)
+}
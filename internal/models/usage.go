@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UsageCounts 用量计数
+type UsageCounts struct {
+	Messages   int64 `bson:"messages" json:"messages" example:"1200"`     // 接受的邮件数
+	Recipients int64 `bson:"recipients" json:"recipients" example:"3400"` // 收件人数
+	Bytes      int64 `bson:"bytes" json:"bytes" example:"52428800"`       // 邮件字节数
+	Delivered  int64 `bson:"delivered" json:"delivered" example:"1180"`   // 已投递的邮件数（sent）
+	Bounced    int64 `bson:"bounced" json:"bounced" example:"12"`         // 投递失败的邮件数（failed）
+}
+
+// Add 累加用量
+func (c *UsageCounts) Add(other UsageCounts) {
+	c.Messages += other.Messages
+	c.Recipients += other.Recipients
+	c.Bytes += other.Bytes
+	c.Delivered += other.Delivered
+	c.Bounced += other.Bounced
+}
+
+// UsageRecord 每日用量记录（用户时区的自然日，按凭据汇总），生成后不再修改
+type UsageRecord struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID         primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	CredentialID   *primitive.ObjectID `bson:"credential_id,omitempty" json:"credential_id,omitempty"`     // 为空表示未通过SMTP凭据提交的邮件
+	CredentialName string              `bson:"credential_name,omitempty" json:"credential_name,omitempty"` // 生成记录时的凭据名称
+	Date           string              `bson:"date" json:"date" example:"2026-10-01"`                      // 用户时区的日期
+	Timezone       string              `bson:"timezone" json:"timezone" example:"Asia/Shanghai"`
+	WindowStart    time.Time           `bson:"window_start" json:"window_start"`
+	WindowEnd      time.Time           `bson:"window_end" json:"window_end"`
+	UsageCounts    `bson:",inline"`
+	CreatedAt      time.Time `bson:"created_at" json:"created_at"`
+}
+
+// UsageMeterState 用户的用量计量进度
+type UsageMeterState struct {
+	UserID       primitive.ObjectID `bson:"_id" json:"user_id"`
+	MeteredUntil time.Time          `bson:"metered_until" json:"metered_until"` // 已生成用量记录的截止时间（不包含）
+	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// UsageInvoice 月度用量汇总（账单形式）
+type UsageInvoice struct {
+	UserID      primitive.ObjectID `json:"user_id"`
+	Username    string             `json:"username"`
+	Email       string             `json:"email"`
+	Month       string             `json:"month" example:"2026-09"`
+	Timezone    string             `json:"timezone" example:"Asia/Shanghai"`
+	PeriodStart time.Time          `json:"period_start"`
+	PeriodEnd   time.Time          `json:"period_end"`
+	Plan        string             `json:"plan,omitempty" example:"basic"` // 生成汇总时用户生效的套餐
+	Lines       []UsageInvoiceLine `json:"lines"`
+	Totals      UsageCounts        `json:"totals"`
+	Complete    bool               `json:"complete" example:"true"` // 当月所有日期都已生成用量记录
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// UsageInvoiceLine 月度用量汇总中单个凭据的用量
+type UsageInvoiceLine struct {
+	CredentialID   *primitive.ObjectID `json:"credential_id,omitempty"`
+	CredentialName string              `json:"credential_name,omitempty"`
+	UsageCounts
+}
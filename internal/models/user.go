@@ -8,14 +8,48 @@ import (
 
 // User 用户信息结构
 type User struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Username     string             `bson:"username" json:"username"`
-	Email        string             `bson:"email" json:"email"`
-	PasswordHash string             `bson:"password_hash" json:"-"`
-	Status       string             `bson:"status" json:"status"` // active, suspended, deleted
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt    time.Time          `bson:"updated_at" json:"updated_at"`
-	Settings     UserSettings       `bson:"settings" json:"settings"`
+	ID           primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Username     string              `bson:"username" json:"username"`
+	Email        string              `bson:"email" json:"email"`
+	PasswordHash string              `bson:"password_hash" json:"-"`
+	Status       string              `bson:"status" json:"status"`                       // active, suspended, deleted
+	Role         string              `bson:"role,omitempty" json:"role,omitempty"`       // user（默认）, admin, read_only
+	PlanID       *primitive.ObjectID `bson:"plan_id,omitempty" json:"plan_id,omitempty"` // 分配的套餐，为空时使用默认套餐
+	CreatedAt    time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt    time.Time           `bson:"updated_at" json:"updated_at"`
+	Settings     UserSettings        `bson:"settings" json:"settings"`
+
+	SuspendedAt     *time.Time `bson:"suspended_at,omitempty" json:"suspended_at,omitempty"`         // 被管理员停用的时间
+	SuspendedReason string     `bson:"suspended_reason,omitempty" json:"suspended_reason,omitempty"` // 停用原因
+
+	NotificationPreferences *NotificationPreferences `bson:"notification_preferences,omitempty" json:"-"` // 通知偏好，为空时使用默认设置
+}
+
+// 用户状态
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+	UserStatusDeleted   = "deleted"
+)
+
+// 用户角色
+const (
+	UserRoleUser     = "user"      // 普通用户（默认）
+	UserRoleAdmin    = "admin"     // 管理员，可以管理所有用户、套餐和上游SMTP配置
+	UserRoleReadOnly = "read_only" // 只读用户，只能查看自己的数据，不能修改
+)
+
+// EffectiveRole 获取用户生效的角色，未设置时为普通用户
+func (u *User) EffectiveRole() string {
+	if u.Role == "" {
+		return UserRoleUser
+	}
+	return u.Role
+}
+
+// IsValidUserRole 检查角色是否有效
+func IsValidUserRole(role string) bool {
+	return role == UserRoleUser || role == UserRoleAdmin || role == UserRoleReadOnly
 }
 
 // SMTPCredential SMTP认证凭据（支持多个密钥对）
@@ -26,12 +60,48 @@ type SMTPCredential struct {
 	Username     string                 `bson:"username" json:"username"`       // SMTP用户名
 	PasswordHash string                 `bson:"password_hash" json:"-"`         // SMTP密码哈希
 	Description  string                 `bson:"description" json:"description"` // 描述信息
+	Mode         string                 `bson:"mode" json:"mode"`               // normal, sandbox（沙箱模式只捕获不投递）
 	Status       string                 `bson:"status" json:"status"`           // active, disabled
 	CreatedAt    time.Time              `bson:"created_at" json:"created_at"`
 	UpdatedAt    time.Time              `bson:"updated_at" json:"updated_at"`
 	LastUsed     *time.Time             `bson:"last_used,omitempty" json:"last_used,omitempty"`
 	UsageCount   int64                  `bson:"usage_count" json:"usage_count"` // 使用次数
 	Settings     SMTPCredentialSettings `bson:"settings" json:"settings"`
+
+	PasswordID        string                  `bson:"password_id,omitempty" json:"password_id,omitempty"`                 // 当前密码的标识（不含密码本身）
+	PasswordCreatedAt *time.Time              `bson:"password_created_at,omitempty" json:"password_created_at,omitempty"` // 当前密码的生成时间
+	PasswordLastUsed  *time.Time              `bson:"password_last_used,omitempty" json:"password_last_used,omitempty"`   // 当前密码最近一次认证时间
+	PreviousPassword  *SMTPCredentialPassword `bson:"previous_password,omitempty" json:"previous_password,omitempty"`     // 轮换后在宽限期内仍然有效的旧密码
+	ExpiresAt         *time.Time              `bson:"expires_at,omitempty" json:"expires_at,omitempty"`                   // 凭据过期时间，过期后自动禁用
+	DisabledAt        *time.Time              `bson:"disabled_at,omitempty" json:"disabled_at,omitempty"`
+	DisabledReason    string                  `bson:"disabled_reason,omitempty" json:"disabled_reason,omitempty"` // expired
+}
+
+// SMTP凭据状态
+const (
+	CredentialStatusActive   = "active"
+	CredentialStatusDisabled = "disabled"
+	CredentialStatusDeleted  = "deleted"
+)
+
+// 凭据被自动禁用的原因
+const (
+	CredentialDisabledExpired     = "expired"              // 凭据已过期
+	CredentialDisabledCompromised = "suspected_compromise" // 发信行为严重偏离基线，疑似泄露
+)
+
+// SMTPCredentialPassword 轮换后保留的旧密码
+type SMTPCredentialPassword struct {
+	ID        string     `bson:"id" json:"id"`  // 密码标识
+	Hash      string     `bson:"hash" json:"-"` // 密码哈希
+	CreatedAt *time.Time `bson:"created_at,omitempty" json:"created_at,omitempty"`
+	ExpiresAt time.Time  `bson:"expires_at" json:"expires_at"` // 宽限期结束时间
+	LastUsed  *time.Time `bson:"last_used,omitempty" json:"last_used,omitempty"`
+}
+
+// IsExpired 检查凭据是否已过期
+func (c *SMTPCredential) IsExpired(now time.Time) bool {
+	return c.ExpiresAt != nil && !now.Before(*c.ExpiresAt)
 }
 
 // SMTPCredentialSettings SMTP凭据设置
@@ -40,11 +110,43 @@ type SMTPCredentialSettings struct {
 	HourlyQuota    int      `bson:"hourly_quota" json:"hourly_quota"`       // 该凭据的小时配额
 	AllowedDomains []string `bson:"allowed_domains" json:"allowed_domains"` // 允许发送的域名
 	MaxRecipients  int      `bson:"max_recipients" json:"max_recipients"`   // 单封邮件最大收件人数
+
+	MessagesPerSecond   int `bson:"messages_per_second,omitempty" json:"messages_per_second,omitempty"`     // 每秒最多发送的邮件数（0表示不限制）
+	RecipientsPerMinute int `bson:"recipients_per_minute,omitempty" json:"recipients_per_minute,omitempty"` // 每分钟最多发送的收件人数（0表示不限制）
+
+	SandboxRetentionHours int  `bson:"sandbox_retention_hours" json:"sandbox_retention_hours"` // 沙箱邮件保留小时数（0表示不过期）
+	DedupEnabled          bool `bson:"dedup_enabled" json:"dedup_enabled"`                     // 是否启用重复提交检测
+	DedupWindowMinutes    int  `bson:"dedup_window_minutes" json:"dedup_window_minutes"`       // 去重时间窗口（分钟，默认10）
+
+	AllowedSenders []string       `bson:"allowed_senders,omitempty" json:"allowed_senders,omitempty"`   // 允许的发件地址，支持本地部分通配符，如 noreply@example.com、*@example.com、alert-*@example.com
+	MaxMessageSize int64          `bson:"max_message_size,omitempty" json:"max_message_size,omitempty"` // 单封邮件最大字节数（0表示使用服务器限制）
+	RequireTLS     bool           `bson:"require_tls,omitempty" json:"require_tls,omitempty"`           // 是否要求TLS连接
+	AllowedPorts   []int          `bson:"allowed_ports,omitempty" json:"allowed_ports,omitempty"`       // 允许连接的监听端口，为空表示不限制
+	SendingHours   *SendingWindow `bson:"sending_hours,omitempty" json:"sending_hours,omitempty"`       // 允许发送的时段，为空表示不限制
+
+	AnomalyAction string `bson:"anomaly_action,omitempty" json:"anomaly_action,omitempty"` // 发信行为异常时的处理方式: suspend（默认）, alert, off
+}
+
+// SendingWindow 允许发送邮件的时段
+type SendingWindow struct {
+	Start    string `bson:"start" json:"start" example:"09:00"`                                   // 开始时间 HH:MM
+	End      string `bson:"end" json:"end" example:"18:00"`                                       // 结束时间 HH:MM，早于开始时间表示跨越午夜
+	Timezone string `bson:"timezone,omitempty" json:"timezone,omitempty" example:"Asia/Shanghai"` // IANA时区，默认UTC
+	Weekdays []int  `bson:"weekdays,omitempty" json:"weekdays,omitempty" example:"1,2,3,4,5"`     // 允许的星期（0为周日），为空表示每天
+}
+
+// IsSandbox 检查凭据是否为沙箱模式
+func (c *SMTPCredential) IsSandbox() bool {
+	return c.Mode == "sandbox"
 }
 
 // UserSettings 用户设置
 type UserSettings struct {
-	DailyQuota     int      `bson:"daily_quota" json:"daily_quota"`
-	HourlyQuota    int      `bson:"hourly_quota" json:"hourly_quota"`
-	AllowedDomains []string `bson:"allowed_domains" json:"allowed_domains"`
+	DailyQuota           int      `bson:"daily_quota" json:"daily_quota"`
+	HourlyQuota          int      `bson:"hourly_quota" json:"hourly_quota"`
+	MessagesPerSecond    int      `bson:"messages_per_second,omitempty" json:"messages_per_second,omitempty"`     // 所有凭据合计每秒最多发送的邮件数（0表示不限制）
+	RecipientsPerMinute  int      `bson:"recipients_per_minute,omitempty" json:"recipients_per_minute,omitempty"` // 所有凭据合计每分钟最多发送的收件人数（0表示不限制）
+	Timezone             string   `bson:"timezone,omitempty" json:"timezone,omitempty" example:"Asia/Shanghai"`   // 配额周期、统计和报表使用的IANA时区，为空时使用套餐时区，默认UTC
+	AllowedDomains       []string `bson:"allowed_domains" json:"allowed_domains"`
+	ArchiveRetentionDays int      `bson:"archive_retention_days" json:"archive_retention_days"` // 原始邮件归档保留天数（0表示使用系统默认值）
 }
@@ -35,13 +35,16 @@ type Config struct {
 
 // MailMessage 邮件消息结构
 type MailMessage struct {
-	MailLogID primitive.ObjectID `json:"mail_log_id"`
-	From      string             `json:"from"`
-	To        []string           `json:"to"`
-	Subject   string             `json:"subject"`
-	Body      []byte             `json:"body"`
-	Priority  int                `json:"priority"` // 0-9, 9为最高优先级
-	CreatedAt time.Time          `json:"created_at"`
+	MailLogID    primitive.ObjectID  `json:"mail_log_id"`
+	UserID       primitive.ObjectID  `json:"user_id"` // 签名密钥和退信域名所属用户（系统邮件为签名所有者）
+	CredentialID *primitive.ObjectID `json:"credential_id,omitempty"`
+	From         string              `json:"from"`
+	To           []string            `json:"to"`
+	Subject      string              `json:"subject"`
+	Body         []byte              `json:"body"`
+	Priority     int                 `json:"priority"` // 0-9, 9为最高优先级
+	CreatedAt    time.Time           `json:"created_at"`
+	ExpiresAt    *time.Time          `json:"expires_at,omitempty"` // 过期时间，过期后不再投递
 }
 
 // NewService 创建队列服务
@@ -215,6 +218,26 @@ func (s *Service) setupDelayQueue() error {
 	return nil
 }
 
+// newMailMessage 根据MailLog创建队列消息，即时队列和延迟队列共用，保证Worker能按用户和凭据查找签名密钥等设置
+func (s *Service) newMailMessage(mailLog *models.MailLog, body []byte) *MailMessage {
+	userID := mailLog.UserID
+	if mailLog.SignerUserID != nil {
+		userID = *mailLog.SignerUserID
+	}
+	return &MailMessage{
+		MailLogID:    mailLog.ID,
+		UserID:       userID,
+		CredentialID: mailLog.CredentialID,
+		From:         mailLog.From,
+		To:           mailLog.To,
+		Subject:      mailLog.Subject,
+		Body:         body,
+		Priority:     s.calculatePriority(mailLog),
+		CreatedAt:    mailLog.CreatedAt,
+		ExpiresAt:    mailLog.ExpiresAt,
+	}
+}
+
 // EnqueueMail 将邮件加入队列
 func (s *Service) EnqueueMail(mailLog *models.MailLog, body []byte) error {
 	// 保存MailLog到数据库
@@ -228,15 +251,7 @@ func (s *Service) EnqueueMail(mailLog *models.MailLog, body []byte) error {
 	mailLog.ID = result.InsertedID.(primitive.ObjectID)
 
 	// 创建队列消息
-	message := &MailMessage{
-		MailLogID: mailLog.ID,
-		From:      mailLog.From,
-		To:        mailLog.To,
-		Subject:   mailLog.Subject,
-		Body:      body,
-		Priority:  s.calculatePriority(mailLog),
-		CreatedAt: mailLog.CreatedAt,
-	}
+	message := s.newMailMessage(mailLog, body)
 
 	// 序列化消息
 	messageBody, err := json.Marshal(message)
@@ -280,15 +295,7 @@ func (s *Service) EnqueueDelayedMail(mailLog *models.MailLog, body []byte, delay
 	delayQueueName := s.queueName + ".delay"
 
 	// 创建队列消息
-	message := &MailMessage{
-		MailLogID: mailLog.ID,
-		From:      mailLog.From,
-		To:        mailLog.To,
-		Subject:   mailLog.Subject,
-		Body:      body,
-		Priority:  s.calculatePriority(mailLog),
-		CreatedAt: mailLog.CreatedAt,
-	}
+	message := s.newMailMessage(mailLog, body)
 
 	// 序列化消息
 	messageBody, err := json.Marshal(message)
@@ -413,6 +420,14 @@ func (s *Service) calculatePriority(mailLog *models.MailLog) int {
 		priority -= 1
 	}
 
+	// 客户端通过X-Relay-Priority头部提供的优先级提示
+	switch mailLog.Priority {
+	case "high":
+		priority += 2
+	case "low":
+		priority -= 2
+	}
+
 	// 确保优先级在0-9范围内
 	if priority < 0 {
 		priority = 0
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"smtp-relay/internal/mailauth"
+)
+
+// ARCService ARC封装服务（RFC 8617），使用发件域名的DKIM密钥
+type ARCService struct {
+	dkimService *DKIMService
+	resolver    mailauth.Resolver
+	authServID  string
+	logger      *logrus.Logger
+}
+
+// NewARCService 创建ARC封装服务
+func NewARCService(dkimService *DKIMService, resolver mailauth.Resolver, authServID string, logger *logrus.Logger) *ARCService {
+	return &ARCService{
+		dkimService: dkimService,
+		resolver:    resolver,
+		authServID:  authServID,
+		logger:      logger,
+	}
+}
+
+// Seal 验证接收邮件上已有的ARC链，并为待发送邮件添加新的ARC集合
+//
+// received为客户端提交的原始邮件，outgoing为中继重写后的待发送邮件。
+// 发件域名未配置DKIM密钥时原样返回，ARC结果为nil。
+func (s *ARCService) Seal(userID primitive.ObjectID, from string, received, outgoing []byte) ([]byte, *mailauth.ARCResult, error) {
+	at := strings.LastIndex(from, "@")
+	if at < 0 {
+		return outgoing, nil, nil
+	}
+
+	keyPair, err := s.dkimService.FindSigningKey(userID, from[at+1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	if keyPair == nil {
+		return outgoing, nil, nil
+	}
+
+	signer, err := s.dkimService.LoadSigner(keyPair)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	chain := mailauth.ValidateARC(ctx, s.resolver, received)
+	if chain.Status == mailauth.ARCFail {
+		s.logger.WithFields(logrus.Fields{
+			"domain": keyPair.Domain,
+			"reason": chain.Reason,
+		}).Warn("接收邮件的ARC链验证失败")
+	}
+
+	sealed, err := mailauth.SealARC(outgoing, chain, &mailauth.ARCSealOptions{
+		Domain:      keyPair.Domain,
+		Selector:    keyPair.Selector,
+		Signer:      signer,
+		AuthServID:  s.authServID,
+		AuthResults: fmt.Sprintf("auth=pass smtp.mailfrom=%s", from),
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("ARC封装失败: %w", err)
+	}
+
+	return sealed, chain, nil
+}
@@ -0,0 +1,243 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/archive"
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/models"
+)
+
+// ArchiveService 原始邮件归档服务
+type ArchiveService struct {
+	db               *database.MongoDB
+	store            archive.Store
+	encryptor        *encryption.Encryptor
+	defaultRetention int // 默认保留天数（0表示永久保留）
+	logger           *logrus.Logger
+	stopChan         chan struct{}
+}
+
+// NewArchiveService 创建原始邮件归档服务
+func NewArchiveService(db *database.MongoDB, store archive.Store, encryptor *encryption.Encryptor, defaultRetentionDays int, logger *logrus.Logger) *ArchiveService {
+	return &ArchiveService{
+		db:               db,
+		store:            store,
+		encryptor:        encryptor,
+		defaultRetention: defaultRetentionDays,
+		logger:           logger,
+		stopChan:         make(chan struct{}),
+	}
+}
+
+// ArchiveMessage 压缩、加密并归档已接受的原始邮件，并在MailLog上记录归档信息
+func (s *ArchiveService) ArchiveMessage(user *models.User, mailLog *models.MailLog, raw []byte) error {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(raw); err != nil {
+		return fmt.Errorf("压缩原始邮件失败: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("压缩原始邮件失败: %w", err)
+	}
+
+	data, err := s.encryptor.EncryptBytes(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("加密原始邮件失败: %w", err)
+	}
+
+	now := time.Now()
+	info := &models.MessageArchive{
+		Backend:         s.store.Name(),
+		Key:             fmt.Sprintf("%s/%s.eml.gz", now.Format("2006/01/02"), mailLog.ID.Hex()),
+		Size:            int64(len(raw)),
+		CompressedSize:  int64(buf.Len()),
+		ArchivedAt:      now,
+		EncryptionKeyID: s.encryptor.CurrentKeyID(),
+	}
+	if days := s.retentionDays(user); days > 0 {
+		expiresAt := now.AddDate(0, 0, days)
+		info.ExpiresAt = &expiresAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := s.store.Put(ctx, info.Key, data); err != nil {
+		return fmt.Errorf("写入归档存储失败: %w", err)
+	}
+
+	_, err = s.db.GetCollection("mail_logs").UpdateOne(ctx,
+		bson.M{"_id": mailLog.ID},
+		bson.M{"$set": bson.M{"archive": info}},
+	)
+	if err != nil {
+		// 避免产生无法追踪的孤立对象
+		if delErr := s.store.Delete(ctx, info.Key); delErr != nil {
+			s.logger.WithError(delErr).WithField("key", info.Key).Warn("清理归档对象失败")
+		}
+		return fmt.Errorf("更新MailLog归档信息失败: %w", err)
+	}
+	mailLog.Archive = info
+
+	return nil
+}
+
+// GetMessage 获取归档的原始邮件（已解压）
+func (s *ArchiveService) GetMessage(userID, mailLogID primitive.ObjectID) (*models.MailLog, []byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var mailLog models.MailLog
+	err := s.db.GetCollection("mail_logs").FindOne(ctx, bson.M{
+		"_id":     mailLogID,
+		"user_id": userID,
+	}).Decode(&mailLog)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil, errors.New("MailLog不存在")
+		}
+		return nil, nil, err
+	}
+
+	if mailLog.Archive == nil {
+		return nil, nil, errors.New("归档邮件不存在")
+	}
+
+	data, err := s.store.Get(ctx, mailLog.Archive.Key)
+	if err != nil {
+		if errors.Is(err, archive.ErrNotFound) {
+			return nil, nil, errors.New("归档邮件不存在")
+		}
+		return nil, nil, fmt.Errorf("读取归档存储失败: %w", err)
+	}
+
+	compressed, err := s.encryptor.DecryptBytes(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解密归档邮件失败: %w", err)
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, nil, fmt.Errorf("解压归档邮件失败: %w", err)
+	}
+	defer reader.Close()
+
+	raw, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解压归档邮件失败: %w", err)
+	}
+
+	return &mailLog, raw, nil
+}
+
+// PurgeExpired 删除已过保留期的归档邮件，返回删除数量
+func (s *ArchiveService) PurgeExpired(batchSize int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	collection := s.db.GetCollection("mail_logs")
+	findOptions := options.Find().
+		SetLimit(int64(batchSize)).
+		SetProjection(bson.M{"archive": 1})
+
+	cursor, err := collection.Find(ctx, bson.M{
+		"archive.expires_at": bson.M{"$lte": time.Now()},
+	}, findOptions)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var mailLogs []models.MailLog
+	if err := cursor.All(ctx, &mailLogs); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, mailLog := range mailLogs {
+		if err := s.store.Delete(ctx, mailLog.Archive.Key); err != nil {
+			s.logger.WithError(err).WithField("mail_log_id", mailLog.ID.Hex()).Warn("删除归档对象失败")
+			continue
+		}
+
+		if _, err := collection.UpdateOne(ctx,
+			bson.M{"_id": mailLog.ID},
+			bson.M{"$unset": bson.M{"archive": ""}},
+		); err != nil {
+			s.logger.WithError(err).WithField("mail_log_id", mailLog.ID.Hex()).Warn("清除MailLog归档信息失败")
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// StartPurger 启动过期归档清理协程
+func (s *ArchiveService) StartPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.purgeAll()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithField("interval", interval.String()).Info("归档清理协程已启动")
+}
+
+// Stop 停止过期归档清理协程
+func (s *ArchiveService) Stop() {
+	close(s.stopChan)
+}
+
+// purgeAll 分批清理全部过期归档
+func (s *ArchiveService) purgeAll() {
+	const batchSize = 500
+
+	total := 0
+	for {
+		purged, err := s.PurgeExpired(batchSize)
+		if err != nil {
+			s.logger.WithError(err).Error("清理过期归档失败")
+			break
+		}
+		total += purged
+		// 本批存在删除失败或已无更多过期归档时结束，等待下个周期
+		if purged < batchSize {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.WithField("purged", total).Info("过期归档清理完成")
+	}
+}
+
+// retentionDays 获取用户的归档保留天数
+func (s *ArchiveService) retentionDays(user *models.User) int {
+	if user != nil && user.Settings.ArchiveRetentionDays > 0 {
+		return user.Settings.ArchiveRetentionDays
+	}
+	return s.defaultRetention
+}
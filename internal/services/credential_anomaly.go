@@ -0,0 +1,511 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+)
+
+// 异常检测参数
+const (
+	anomalyWindow           = time.Hour // 检测窗口：最近60分钟
+	baselineAlpha           = 0.05      // 基线指数加权移动平均的平滑系数
+	minBaselineHours        = 24        // 基线至少学习的小时数，不足时不检测
+	anomalySigma            = 4.0       // 偏离基线的标准差倍数
+	anomalyAlertCooldown    = time.Hour // 同一凭据重复告警的最小间隔
+	minAnomalyVolume        = 50        // 每小时邮件数低于该值时不视为异常
+	minAnomalySourceIPs     = 5         // 客户端IP数低于该值时不视为异常
+	minAnomalyDomains       = 20        // 收件域名数低于该值时不视为异常
+	minAnomalyCompleted     = 20        // 已完成投递数低于该值时不检查失败率
+	minAnomalyFailureRate   = 0.5       // 失败率低于该值时不视为异常
+	anomalyFailureRateDelta = 0.3       // 失败率至少高出基线的幅度
+	severeVolumeFactor      = 20.0      // 邮件数超过基线均值的该倍数时直接停用
+)
+
+// CredentialAnomalyService 凭据泄露检测服务：学习每个凭据的发信行为基线，行为严重偏离时告警或自动停用凭据
+type CredentialAnomalyService struct {
+	db                  *database.MongoDB
+	notificationService *NotificationService
+	logger              *logrus.Logger
+	stopChan            chan struct{}
+}
+
+// NewCredentialAnomalyService 创建凭据泄露检测服务
+func NewCredentialAnomalyService(db *database.MongoDB, notificationService *NotificationService, logger *logrus.Logger) *CredentialAnomalyService {
+	return &CredentialAnomalyService{
+		db:                  db,
+		notificationService: notificationService,
+		logger:              logger,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// GetBaseline 获取凭据的行为基线（尚未学习时返回nil）和最近60分钟的发信行为
+func (s *CredentialAnomalyService) GetBaseline(userID, credentialID primitive.ObjectID) (*models.CredentialBaseline, *models.CredentialActivity, error) {
+	if err := s.checkCredentialOwner(userID, credentialID); err != nil {
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var baseline *models.CredentialBaseline
+	var result models.CredentialBaseline
+	err := s.db.GetCollection("credential_baselines").FindOne(ctx, bson.M{"credential_id": credentialID}).Decode(&result)
+	if err == nil {
+		baseline = &result
+	} else if err != mongo.ErrNoDocuments {
+		return nil, nil, fmt.Errorf("查询凭据基线失败: %w", err)
+	}
+
+	end := time.Now()
+	activities, err := s.collectActivity(bson.M{"credential_id": credentialID}, end.Add(-anomalyWindow), end)
+	if err != nil {
+		return nil, nil, err
+	}
+	if activity, ok := activities[credentialID]; ok {
+		return baseline, &activity.CredentialActivity, nil
+	}
+	return baseline, &models.CredentialActivity{WindowStart: end.Add(-anomalyWindow), WindowEnd: end}, nil
+}
+
+// ListAnomalies 分页获取凭据的异常告警记录（最新的在前）
+func (s *CredentialAnomalyService) ListAnomalies(userID, credentialID primitive.ObjectID, page, pageSize int) ([]*models.CredentialAnomaly, int64, error) {
+	if err := s.checkCredentialOwner(userID, credentialID); err != nil {
+		return nil, 0, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("credential_anomalies")
+	filter := bson.M{"user_id": userID, "credential_id": credentialID}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询异常告警失败: %w", err)
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: "detected_at", Value: -1}}).
+		SetSkip(int64((page-1)*pageSize)).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询异常告警失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	anomalies := []*models.CredentialAnomaly{}
+	if err := cursor.All(ctx, &anomalies); err != nil {
+		return nil, 0, fmt.Errorf("解析异常告警失败: %w", err)
+	}
+	return anomalies, total, nil
+}
+
+// Start 启动异常检测协程
+func (s *CredentialAnomalyService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.run()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithField("interval", interval.String()).Info("凭据异常检测协程已启动")
+}
+
+// Stop 停止异常检测协程
+func (s *CredentialAnomalyService) Stop() {
+	close(s.stopChan)
+}
+
+// run 执行一轮检测：先用上一个整点小时的数据更新基线，再检查最近60分钟的行为
+func (s *CredentialAnomalyService) run() {
+	now := time.Now()
+	if err := s.updateBaselines(now.Truncate(time.Hour).Add(-time.Hour)); err != nil {
+		s.logger.WithError(err).Error("更新凭据行为基线失败")
+	}
+	if err := s.detect(now); err != nil {
+		s.logger.WithError(err).Error("凭据异常检测失败")
+	}
+}
+
+// updateBaselines 将指定整点小时的发信行为计入各凭据的基线
+//
+// 只学习有发信的小时，基线表示凭据活跃时的正常行为；检测到异常的时段不计入基线，避免异常流量抬高基线。
+// 服务停止期间错过的小时不会补学。
+func (s *CredentialAnomalyService) updateBaselines(hour time.Time) error {
+	activities, err := s.collectActivity(bson.M{"credential_id": bson.M{"$exists": true}}, hour, hour.Add(time.Hour))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("credential_baselines")
+	for credentialID, activity := range activities {
+		var baseline models.CredentialBaseline
+		err := collection.FindOne(ctx, bson.M{"credential_id": credentialID}).Decode(&baseline)
+		if err != nil && err != mongo.ErrNoDocuments {
+			s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Warn("查询凭据基线失败")
+			continue
+		}
+		if err == nil && !baseline.LastHour.Before(hour) {
+			continue
+		}
+		if baseline.LastAnomalyAt != nil && !baseline.LastAnomalyAt.Before(hour) && baseline.LastAnomalyAt.Before(hour.Add(anomalyWindow+time.Hour)) {
+			// 该小时处于异常检测窗口内，只推进学习进度
+			if _, err := collection.UpdateOne(ctx, bson.M{"_id": baseline.ID}, bson.M{"$set": bson.M{"last_hour": hour}}); err != nil {
+				s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Warn("更新凭据基线失败")
+			}
+			continue
+		}
+
+		first := baseline.SampleHours == 0
+		updateBaselineMetric(&baseline.HourlyVolume, float64(activity.Volume), first)
+		updateBaselineMetric(&baseline.SourceIPs, float64(activity.SourceIPs), first)
+		updateBaselineMetric(&baseline.RecipientDomains, float64(activity.RecipientDomains), first)
+		if activity.Sent+activity.Failed > 0 {
+			updateBaselineMetric(&baseline.FailureRate, activity.FailureRate, first)
+		}
+
+		_, err = collection.UpdateOne(ctx,
+			bson.M{"credential_id": credentialID},
+			bson.M{
+				"$set": bson.M{
+					"user_id":           activity.userID,
+					"hourly_volume":     baseline.HourlyVolume,
+					"source_ips":        baseline.SourceIPs,
+					"recipient_domains": baseline.RecipientDomains,
+					"failure_rate":      baseline.FailureRate,
+					"sample_hours":      baseline.SampleHours + 1,
+					"last_hour":         hour,
+					"updated_at":        time.Now(),
+				},
+			},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Warn("更新凭据基线失败")
+		}
+	}
+	return nil
+}
+
+// detect 检查最近60分钟各凭据的发信行为是否偏离基线
+func (s *CredentialAnomalyService) detect(now time.Time) error {
+	activities, err := s.collectActivity(bson.M{"credential_id": bson.M{"$exists": true}}, now.Add(-anomalyWindow), now)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for credentialID, activity := range activities {
+		var baseline models.CredentialBaseline
+		if err := s.db.GetCollection("credential_baselines").FindOne(ctx, bson.M{"credential_id": credentialID}).Decode(&baseline); err != nil {
+			if err != mongo.ErrNoDocuments {
+				s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Warn("查询凭据基线失败")
+			}
+			continue
+		}
+		deviations, severe := evaluateActivity(&baseline, &activity.CredentialActivity)
+		if len(deviations) == 0 {
+			continue
+		}
+
+		var credential models.SMTPCredential
+		if err := s.db.GetCollection("smtp_credentials").FindOne(ctx, bson.M{"_id": credentialID}).Decode(&credential); err != nil {
+			s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Warn("查询SMTP凭据失败")
+			continue
+		}
+		if credential.Status != models.CredentialStatusActive || credential.IsSandbox() || credential.Settings.AnomalyAction == models.AnomalyActionOff {
+			continue
+		}
+
+		action := models.AnomalyActionAlerted
+		if severe && credential.Settings.AnomalyAction != models.AnomalyActionAlert {
+			action = models.AnomalyActionSuspended
+		}
+
+		// 冷却期内已告警过的凭据不重复告警，除非需要升级为停用
+		if baseline.LastAnomalyAt != nil && now.Sub(*baseline.LastAnomalyAt) < anomalyAlertCooldown &&
+			(action == models.AnomalyActionAlerted || baseline.LastAnomalyState == models.AnomalyActionSuspended) {
+			continue
+		}
+
+		if err := s.recordAnomaly(&credential, action, deviations, activity.CredentialActivity, now); err != nil {
+			s.logger.WithError(err).WithField("credential_id", credentialID.Hex()).Error("处理凭据异常失败")
+		}
+	}
+	return nil
+}
+
+// recordAnomaly 记录异常告警，按需停用凭据并通知凭据所有者
+func (s *CredentialAnomalyService) recordAnomaly(credential *models.SMTPCredential, action string, deviations []models.AnomalyDeviation, activity models.CredentialActivity, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if action == models.AnomalyActionSuspended {
+		result, err := s.db.GetCollection("smtp_credentials").UpdateOne(ctx,
+			bson.M{"_id": credential.ID, "status": models.CredentialStatusActive},
+			bson.M{"$set": bson.M{
+				"status":          models.CredentialStatusDisabled,
+				"disabled_at":     now,
+				"disabled_reason": models.CredentialDisabledCompromised,
+				"updated_at":      now,
+			}},
+		)
+		if err != nil {
+			return fmt.Errorf("停用SMTP凭据失败: %w", err)
+		}
+		if result.ModifiedCount == 0 {
+			// 凭据已被停用或删除，只记录告警
+			action = models.AnomalyActionAlerted
+		}
+	}
+
+	anomaly := &models.CredentialAnomaly{
+		ID:             primitive.NewObjectID(),
+		UserID:         credential.UserID,
+		CredentialID:   credential.ID,
+		CredentialName: credential.Name,
+		Action:         action,
+		Reason:         describeDeviations(deviations),
+		Deviations:     deviations,
+		Activity:       activity,
+		DetectedAt:     now,
+	}
+	if _, err := s.db.GetCollection("credential_anomalies").InsertOne(ctx, anomaly); err != nil {
+		return fmt.Errorf("保存异常告警失败: %w", err)
+	}
+
+	if _, err := s.db.GetCollection("credential_baselines").UpdateOne(ctx,
+		bson.M{"credential_id": credential.ID},
+		bson.M{"$set": bson.M{"last_anomaly_at": now, "last_anomaly_state": action}},
+	); err != nil {
+		s.logger.WithError(err).WithField("credential_id", credential.ID.Hex()).Warn("更新凭据基线失败")
+	}
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"user_id":       credential.UserID.Hex(),
+		"credential_id": credential.ID.Hex(),
+		"action":        action,
+		"reason":        anomaly.Reason,
+	})
+	notification := &models.Notification{
+		UserID: credential.UserID,
+		Data: map[string]interface{}{
+			"credential_id": credential.ID.Hex(),
+			"anomaly_id":    anomaly.ID.Hex(),
+			"deviations":    deviations,
+		},
+	}
+	if action == models.AnomalyActionSuspended {
+		logger.Warn("SMTP凭据发信行为严重异常，疑似泄露，已自动停用")
+		notification.Type = "credential_suspended"
+		notification.Level = models.NotificationCritical
+		notification.Title = fmt.Sprintf("SMTP凭据 %s 疑似泄露，已自动停用", credential.Name)
+		notification.Message = fmt.Sprintf("凭据 %s 最近一小时的发信行为严重偏离正常基线（%s），已被自动停用。请检查凭据是否泄露，重置密码后重新启用。", credential.Name, anomaly.Reason)
+	} else {
+		logger.Warn("SMTP凭据发信行为异常")
+		notification.Type = "credential_anomaly"
+		notification.Level = models.NotificationWarning
+		notification.Title = fmt.Sprintf("SMTP凭据 %s 发信行为异常", credential.Name)
+		notification.Message = fmt.Sprintf("凭据 %s 最近一小时的发信行为偏离正常基线（%s），请确认是否为预期行为。", credential.Name, anomaly.Reason)
+	}
+	if err := s.notificationService.Notify(notification); err != nil {
+		logger.WithError(err).Warn("发送凭据异常通知失败")
+	}
+
+	return nil
+}
+
+// credentialWindowActivity 统计中的凭据行为（附带所属用户）
+type credentialWindowActivity struct {
+	models.CredentialActivity
+	userID primitive.ObjectID
+}
+
+// collectActivity 统计时间窗口内各凭据的发信行为
+func (s *CredentialAnomalyService) collectActivity(filter bson.M, start, end time.Time) (map[primitive.ObjectID]*credentialWindowActivity, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	filter["created_at"] = bson.M{"$gte": start, "$lt": end}
+	cursor, err := s.db.GetCollection("mail_logs").Find(ctx, filter, options.Find().SetProjection(bson.M{
+		"user_id":       1,
+		"credential_id": 1,
+		"client_ip":     1,
+		"to":            1,
+		"status":        1,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("查询邮件日志失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	activities := make(map[primitive.ObjectID]*credentialWindowActivity)
+	ips := make(map[primitive.ObjectID]map[string]bool)
+	domains := make(map[primitive.ObjectID]map[string]bool)
+	for cursor.Next(ctx) {
+		var mailLog models.MailLog
+		if err := cursor.Decode(&mailLog); err != nil || mailLog.CredentialID == nil {
+			continue
+		}
+		id := *mailLog.CredentialID
+		activity, ok := activities[id]
+		if !ok {
+			activity = &credentialWindowActivity{
+				CredentialActivity: models.CredentialActivity{WindowStart: start, WindowEnd: end},
+				userID:             mailLog.UserID,
+			}
+			activities[id] = activity
+			ips[id] = make(map[string]bool)
+			domains[id] = make(map[string]bool)
+		}
+
+		activity.Volume++
+		if mailLog.ClientIP != "" {
+			ips[id][mailLog.ClientIP] = true
+		}
+		for _, recipient := range mailLog.To {
+			if domain := addressDomain(recipient); domain != "" {
+				domains[id][domain] = true
+			}
+		}
+		switch mailLog.Status {
+		case "sent":
+			activity.Sent++
+		case "failed":
+			activity.Failed++
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("读取邮件日志失败: %w", err)
+	}
+
+	for id, activity := range activities {
+		activity.SourceIPs = len(ips[id])
+		activity.RecipientDomains = len(domains[id])
+		if completed := activity.Sent + activity.Failed; completed > 0 {
+			activity.FailureRate = float64(activity.Failed) / float64(completed)
+		}
+	}
+	return activities, nil
+}
+
+// checkCredentialOwner 检查凭据是否属于该用户
+func (s *CredentialAnomalyService) checkCredentialOwner(userID, credentialID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := s.db.GetCollection("smtp_credentials").CountDocuments(ctx, bson.M{
+		"_id":     credentialID,
+		"user_id": userID,
+		"status":  bson.M{"$in": visibleCredentialStatuses},
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("SMTP凭据不存在")
+	}
+	return nil
+}
+
+// updateBaselineMetric 按指数加权移动平均更新基线指标
+func updateBaselineMetric(metric *models.BaselineMetric, value float64, first bool) {
+	if first {
+		metric.Mean = value
+		metric.StdDev = 0
+		return
+	}
+	diff := value - metric.Mean
+	variance := (1 - baselineAlpha) * (metric.StdDev*metric.StdDev + baselineAlpha*diff*diff)
+	metric.Mean += baselineAlpha * diff
+	metric.StdDev = math.Sqrt(variance)
+}
+
+// evaluateActivity 比较发信行为与基线，返回偏离的指标；多个指标同时偏离或邮件数暴增时视为严重异常
+//
+// 基线学习不足minBaselineHours小时时不检测。
+func evaluateActivity(baseline *models.CredentialBaseline, activity *models.CredentialActivity) ([]models.AnomalyDeviation, bool) {
+	if baseline.SampleHours < minBaselineHours {
+		return nil, false
+	}
+
+	var deviations []models.AnomalyDeviation
+	severe := false
+
+	check := func(metric string, value float64, base models.BaselineMetric, factor, floor float64) bool {
+		threshold := math.Max(math.Max(base.Mean+anomalySigma*base.StdDev, base.Mean*factor), floor)
+		if value < threshold {
+			return false
+		}
+		deviations = append(deviations, models.AnomalyDeviation{
+			Metric:    metric,
+			Value:     value,
+			Baseline:  base.Mean,
+			Threshold: threshold,
+		})
+		return true
+	}
+
+	volume := float64(activity.Volume)
+	if check(models.AnomalyMetricVolume, volume, baseline.HourlyVolume, 5, minAnomalyVolume) &&
+		volume >= baseline.HourlyVolume.Mean*severeVolumeFactor {
+		severe = true
+	}
+	check(models.AnomalyMetricSourceIPs, float64(activity.SourceIPs), baseline.SourceIPs, 3, minAnomalySourceIPs)
+	check(models.AnomalyMetricRecipientDomains, float64(activity.RecipientDomains), baseline.RecipientDomains, 5, minAnomalyDomains)
+	if activity.Sent+activity.Failed >= minAnomalyCompleted {
+		check(models.AnomalyMetricFailureRate, activity.FailureRate, baseline.FailureRate, 1,
+			math.Max(minAnomalyFailureRate, baseline.FailureRate.Mean+anomalyFailureRateDelta))
+	}
+
+	if len(deviations) >= 2 {
+		severe = true
+	}
+	return deviations, severe
+}
+
+// describeDeviations 生成异常原因描述
+func describeDeviations(deviations []models.AnomalyDeviation) string {
+	names := map[string]string{
+		models.AnomalyMetricVolume:           "每小时邮件数",
+		models.AnomalyMetricSourceIPs:        "客户端IP数",
+		models.AnomalyMetricRecipientDomains: "收件域名数",
+		models.AnomalyMetricFailureRate:      "投递失败率",
+	}
+	parts := make([]string, 0, len(deviations))
+	for _, deviation := range deviations {
+		if deviation.Metric == models.AnomalyMetricFailureRate {
+			parts = append(parts, fmt.Sprintf("%s %.0f%%（基线 %.0f%%）", names[deviation.Metric], deviation.Value*100, deviation.Baseline*100))
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %.0f（基线 %.1f）", names[deviation.Metric], deviation.Value, deviation.Baseline))
+	}
+	return strings.Join(parts, "；")
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"smtp-relay/internal/models"
+)
+
+// newAnomalyBaseline 返回已完成学习的基线：每小时约10封邮件、1个客户端IP、2个收件域名、5%失败率
+func newAnomalyBaseline() *models.CredentialBaseline {
+	return &models.CredentialBaseline{
+		HourlyVolume:     models.BaselineMetric{Mean: 10, StdDev: 2},
+		SourceIPs:        models.BaselineMetric{Mean: 1, StdDev: 0.5},
+		RecipientDomains: models.BaselineMetric{Mean: 2, StdDev: 1},
+		FailureRate:      models.BaselineMetric{Mean: 0.05, StdDev: 0.02},
+		SampleHours:      48,
+	}
+}
+
+// newAnomalyActivity 返回与newAnomalyBaseline一致的正常发信行为
+func newAnomalyActivity() *models.CredentialActivity {
+	return &models.CredentialActivity{Volume: 10, SourceIPs: 1, RecipientDomains: 2, Sent: 19, Failed: 1, FailureRate: 0.05}
+}
+
+func TestEvaluateActivity(t *testing.T) {
+	tests := []struct {
+		name         string
+		baseline     func(*models.CredentialBaseline)
+		activity     func(*models.CredentialActivity)
+		wantMetrics  []string
+		wantSevere   bool
+		wantBaseline float64
+		wantLimit    float64
+	}{
+		{"正常发信", nil, nil, nil, false, 0, 0},
+
+		// 学习期内不检测
+		{"尚未学习", func(b *models.CredentialBaseline) { *b = models.CredentialBaseline{} },
+			func(a *models.CredentialActivity) { a.Volume, a.SourceIPs, a.RecipientDomains = 100000, 500, 5000 }, nil, false, 0, 0},
+		{"学习时长不足", func(b *models.CredentialBaseline) { b.SampleHours = minBaselineHours - 1 },
+			func(a *models.CredentialActivity) { a.Volume, a.SourceIPs, a.RecipientDomains = 100000, 500, 5000 }, nil, false, 0, 0},
+		{"学习时长刚好足够", func(b *models.CredentialBaseline) { b.SampleHours = minBaselineHours },
+			func(a *models.CredentialActivity) { a.Volume = 50 }, []string{models.AnomalyMetricVolume}, false, 10, 50},
+
+		// 邮件数：阈值为max(均值+4σ, 均值×5, 50)，达到均值×20时直接停用
+		{"邮件数低于最小值", nil, func(a *models.CredentialActivity) { a.Volume = 49 }, nil, false, 0, 0},
+		{"邮件数达到最小值", nil, func(a *models.CredentialActivity) { a.Volume = 50 }, []string{models.AnomalyMetricVolume}, false, 10, 50},
+		{"邮件数低于严重阈值", nil, func(a *models.CredentialActivity) { a.Volume = 199 }, []string{models.AnomalyMetricVolume}, false, 10, 50},
+		{"邮件数达到严重阈值", nil, func(a *models.CredentialActivity) { a.Volume = 200 }, []string{models.AnomalyMetricVolume}, true, 10, 50},
+		{"邮件数阈值由标准差决定", func(b *models.CredentialBaseline) { b.HourlyVolume = models.BaselineMetric{Mean: 100, StdDev: 200} },
+			func(a *models.CredentialActivity) { a.Volume = 899 }, nil, false, 0, 0},
+		{"邮件数达到标准差阈值", func(b *models.CredentialBaseline) { b.HourlyVolume = models.BaselineMetric{Mean: 100, StdDev: 200} },
+			func(a *models.CredentialActivity) { a.Volume = 900 }, []string{models.AnomalyMetricVolume}, false, 100, 900},
+		{"邮件数阈值由均值倍数决定", func(b *models.CredentialBaseline) { b.HourlyVolume = models.BaselineMetric{Mean: 100, StdDev: 10} },
+			func(a *models.CredentialActivity) { a.Volume = 500 }, []string{models.AnomalyMetricVolume}, false, 100, 500},
+
+		// 客户端IP数：阈值为max(均值+4σ, 均值×3, 5)，单独偏离时只告警
+		{"IP数低于最小值", nil, func(a *models.CredentialActivity) { a.SourceIPs = 4 }, nil, false, 0, 0},
+		{"IP数达到最小值", nil, func(a *models.CredentialActivity) { a.SourceIPs = 5 }, []string{models.AnomalyMetricSourceIPs}, false, 1, 5},
+		{"IP数大幅偏离时仍只告警", nil, func(a *models.CredentialActivity) { a.SourceIPs = 500 }, []string{models.AnomalyMetricSourceIPs}, false, 1, 5},
+		{"IP数阈值由均值倍数决定", func(b *models.CredentialBaseline) { b.SourceIPs = models.BaselineMetric{Mean: 3, StdDev: 0.5} },
+			func(a *models.CredentialActivity) { a.SourceIPs = 8 }, nil, false, 0, 0},
+
+		// 收件域名数：阈值为max(均值+4σ, 均值×5, 20)
+		{"收件域名数低于最小值", nil, func(a *models.CredentialActivity) { a.RecipientDomains = 19 }, nil, false, 0, 0},
+		{"收件域名数达到最小值", nil, func(a *models.CredentialActivity) { a.RecipientDomains = 20 }, []string{models.AnomalyMetricRecipientDomains}, false, 2, 20},
+		{"收件域名数阈值由标准差决定", func(b *models.CredentialBaseline) { b.RecipientDomains = models.BaselineMetric{Mean: 10, StdDev: 15} },
+			func(a *models.CredentialActivity) { a.RecipientDomains = 70 }, []string{models.AnomalyMetricRecipientDomains}, false, 10, 70},
+
+		// 失败率：已完成投递不少于20封时检查，阈值为max(均值+4σ, 50%, 均值+30%)
+		{"失败率低于最小值", nil, func(a *models.CredentialActivity) { a.Sent, a.Failed, a.FailureRate = 11, 9, 0.45 }, nil, false, 0, 0},
+		{"失败率达到最小值", nil, func(a *models.CredentialActivity) { a.Sent, a.Failed, a.FailureRate = 10, 10, 0.5 },
+			[]string{models.AnomalyMetricFailureRate}, false, 0.05, 0.5},
+		{"已完成投递数不足时不检查失败率", nil, func(a *models.CredentialActivity) { a.Sent, a.Failed, a.FailureRate = 0, 19, 1 }, nil, false, 0, 0},
+		{"失败率需高出基线30%", func(b *models.CredentialBaseline) { b.FailureRate = models.BaselineMetric{Mean: 0.3, StdDev: 0.01} },
+			func(a *models.CredentialActivity) { a.Sent, a.Failed, a.FailureRate = 41, 59, 0.59 }, nil, false, 0, 0},
+		{"失败率高出基线30%", func(b *models.CredentialBaseline) { b.FailureRate = models.BaselineMetric{Mean: 0.3, StdDev: 0.01} },
+			func(a *models.CredentialActivity) { a.Sent, a.Failed, a.FailureRate = 40, 60, 0.6 },
+			[]string{models.AnomalyMetricFailureRate}, false, 0.3, 0.6},
+
+		// 多个指标同时偏离时视为严重异常
+		{"IP数和收件域名数同时偏离", nil, func(a *models.CredentialActivity) { a.SourceIPs, a.RecipientDomains = 5, 20 },
+			[]string{models.AnomalyMetricSourceIPs, models.AnomalyMetricRecipientDomains}, true, 1, 5},
+		{"邮件数和失败率同时偏离", nil, func(a *models.CredentialActivity) { a.Volume, a.Sent, a.Failed, a.FailureRate = 60, 30, 30, 0.5 },
+			[]string{models.AnomalyMetricVolume, models.AnomalyMetricFailureRate}, true, 10, 50},
+		{"其中一个指标未达到阈值", nil, func(a *models.CredentialActivity) { a.SourceIPs, a.RecipientDomains = 5, 19 },
+			[]string{models.AnomalyMetricSourceIPs}, false, 1, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			baseline, activity := newAnomalyBaseline(), newAnomalyActivity()
+			if tt.baseline != nil {
+				tt.baseline(baseline)
+			}
+			if tt.activity != nil {
+				tt.activity(activity)
+			}
+
+			deviations, severe := evaluateActivity(baseline, activity)
+			if len(deviations) != len(tt.wantMetrics) {
+				t.Fatalf("偏离指标为 %+v，期望 %v", deviations, tt.wantMetrics)
+			}
+			for i, metric := range tt.wantMetrics {
+				if deviations[i].Metric != metric {
+					t.Errorf("第%d个偏离指标为 %s，期望 %s", i+1, deviations[i].Metric, metric)
+				}
+			}
+			if severe != tt.wantSevere {
+				t.Errorf("severe = %v，期望 %v", severe, tt.wantSevere)
+			}
+			if len(deviations) > 0 {
+				if deviations[0].Baseline != tt.wantBaseline || math.Abs(deviations[0].Threshold-tt.wantLimit) > 1e-9 {
+					t.Errorf("基线 %v、阈值 %v，期望 %v、%v", deviations[0].Baseline, deviations[0].Threshold, tt.wantBaseline, tt.wantLimit)
+				}
+			}
+		})
+	}
+}
+
+func TestUpdateBaselineMetric(t *testing.T) {
+	// 第一个样本直接作为均值
+	metric := models.BaselineMetric{Mean: 99, StdDev: 99}
+	updateBaselineMetric(&metric, 10, true)
+	if metric.Mean != 10 || metric.StdDev != 0 {
+		t.Fatalf("第一个样本后的基线为 %+v，期望均值10、标准差0", metric)
+	}
+
+	// 之后按平滑系数0.05更新：均值 += 0.05×差值，方差 = 0.95×(方差 + 0.05×差值²)
+	tests := []struct {
+		name       string
+		value      float64
+		wantMean   float64
+		wantStdDev float64
+	}{
+		{"高于均值", 30, 11, math.Sqrt(19)},
+		{"等于均值时方差衰减", 11, 11, math.Sqrt(19 * 0.95)},
+		{"低于均值", 1, 10.5, math.Sqrt(0.95 * (19*0.95 + 0.05*100))},
+	}
+	for _, tt := range tests {
+		updateBaselineMetric(&metric, tt.value, false)
+		if math.Abs(metric.Mean-tt.wantMean) > 1e-9 || math.Abs(metric.StdDev-tt.wantStdDev) > 1e-9 {
+			t.Errorf("%s: 基线为 %+v，期望均值 %v、标准差 %v", tt.name, metric, tt.wantMean, tt.wantStdDev)
+		}
+	}
+
+	// 持续稳定的发信量使均值收敛、标准差趋近于0
+	for i := 0; i < 500; i++ {
+		updateBaselineMetric(&metric, 20, false)
+	}
+	if math.Abs(metric.Mean-20) > 0.01 || metric.StdDev > 0.01 {
+		t.Errorf("稳定发信后的基线为 %+v，期望收敛到均值20", metric)
+	}
+}
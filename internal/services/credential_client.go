@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+)
+
+// CredentialClientService 凭据客户端历史服务：记录每个凭据的来源IP、EHLO名称、TLS版本和认证机制，首次出现的IP记为安全事件
+type CredentialClientService struct {
+	db                  *database.MongoDB
+	notificationService *NotificationService
+	logger              *logrus.Logger
+}
+
+// NewCredentialClientService 创建凭据客户端历史服务
+func NewCredentialClientService(db *database.MongoDB, notificationService *NotificationService, logger *logrus.Logger) *CredentialClientService {
+	return &CredentialClientService{
+		db:                  db,
+		notificationService: notificationService,
+		logger:              logger,
+	}
+}
+
+// RecordClient 记录一次成功的SMTP认证；凭据首次从该IP认证时记录安全事件并通知凭据所有者
+func (s *CredentialClientService) RecordClient(credential *models.SMTPCredential, ip, ehlo, tlsVersion, mechanism string) error {
+	if tlsVersion == "" {
+		tlsVersion = "none"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := s.db.GetCollection("credential_clients").UpdateOne(ctx,
+		bson.M{
+			"credential_id":  credential.ID,
+			"ip":             ip,
+			"ehlo":           ehlo,
+			"tls_version":    tlsVersion,
+			"auth_mechanism": mechanism,
+		},
+		bson.M{
+			"$set":         bson.M{"last_seen": now},
+			"$setOnInsert": bson.M{"user_id": credential.UserID, "first_seen": now},
+			"$inc":         bson.M{"count": 1},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		return fmt.Errorf("记录凭据客户端失败: %w", err)
+	}
+	if result.UpsertedCount == 0 || ip == "" {
+		return nil
+	}
+
+	// 新的客户端组合，检查是否为首次出现的IP（去重键保证每个IP只记录一次）
+	event := &models.SecurityEvent{
+		ID:           primitive.NewObjectID(),
+		UserID:       credential.UserID,
+		CredentialID: credential.ID,
+		Type:         models.SecurityEventNewSourceIP,
+		IP:           ip,
+		Message:      fmt.Sprintf("SMTP凭据 %s 首次从 %s 认证", credential.Name, ip),
+		Details: map[string]interface{}{
+			"ehlo":           ehlo,
+			"tls_version":    tlsVersion,
+			"auth_mechanism": mechanism,
+		},
+		DedupKey:  fmt.Sprintf("%s:%s:%s", models.SecurityEventNewSourceIP, credential.ID.Hex(), ip),
+		CreatedAt: now,
+	}
+	if _, err := s.db.GetCollection("security_events").InsertOne(ctx, event); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil
+		}
+		return fmt.Errorf("保存安全事件失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       credential.UserID.Hex(),
+		"credential_id": credential.ID.Hex(),
+		"ip":            ip,
+		"ehlo":          ehlo,
+	}).Warn("SMTP凭据首次从新IP认证")
+
+	if err := s.notificationService.Notify(&models.Notification{
+		UserID:   credential.UserID,
+		Type:     models.SecurityEventNewSourceIP,
+		Level:    models.NotificationWarning,
+		Title:    fmt.Sprintf("SMTP凭据 %s 从新IP认证", credential.Name),
+		Message:  fmt.Sprintf("凭据 %s 首次从 %s（EHLO %s）认证。如果这不是您的服务器，请立即重置凭据密码。", credential.Name, ip, ehlo),
+		Data:     map[string]interface{}{"credential_id": credential.ID.Hex(), "ip": ip, "ehlo": ehlo},
+		DedupKey: event.DedupKey,
+	}); err != nil {
+		s.logger.WithError(err).WithField("credential_id", credential.ID.Hex()).Warn("发送新IP认证通知失败")
+	}
+
+	return nil
+}
+
+// ListClients 分页获取凭据的客户端历史（最近使用的在前），ip不为空时只返回该IP的记录
+func (s *CredentialClientService) ListClients(userID, credentialID primitive.ObjectID, ip string, page, pageSize int) ([]*models.CredentialClient, int64, error) {
+	filter := bson.M{"user_id": userID, "credential_id": credentialID}
+	if ip != "" {
+		filter["ip"] = ip
+	}
+	clients := []*models.CredentialClient{}
+	total, err := s.findPage(userID, credentialID, "credential_clients", filter, "last_seen", page, pageSize, &clients)
+	return clients, total, err
+}
+
+// ListSecurityEvents 分页获取凭据的安全事件（最新的在前）
+func (s *CredentialClientService) ListSecurityEvents(userID, credentialID primitive.ObjectID, page, pageSize int) ([]*models.SecurityEvent, int64, error) {
+	filter := bson.M{"user_id": userID, "credential_id": credentialID}
+	events := []*models.SecurityEvent{}
+	total, err := s.findPage(userID, credentialID, "security_events", filter, "created_at", page, pageSize, &events)
+	return events, total, err
+}
+
+// findPage 检查凭据归属后按时间倒序分页查询
+func (s *CredentialClientService) findPage(userID, credentialID primitive.ObjectID, collectionName string, filter bson.M, sortField string, page, pageSize int, results interface{}) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := s.db.GetCollection("smtp_credentials").CountDocuments(ctx, bson.M{
+		"_id":     credentialID,
+		"user_id": userID,
+		"status":  bson.M{"$in": visibleCredentialStatuses},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, errors.New("SMTP凭据不存在")
+	}
+
+	collection := s.db.GetCollection(collectionName)
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().
+		SetSort(bson.D{{Key: sortField, Value: -1}}).
+		SetSkip(int64((page-1)*pageSize)).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	if err := cursor.All(ctx, results); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
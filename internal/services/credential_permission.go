@@ -0,0 +1,213 @@
+package services
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"smtp-relay/internal/models"
+)
+
+// maxAllowedSenders 每个凭据允许配置的发件地址规则上限
+const maxAllowedSenders = 100
+
+// ValidateCredentialSettings 校验并规范化凭据的权限设置（发件地址、邮件大小、速率限制、端口、发送时段、异常处理方式）
+func ValidateCredentialSettings(settings *models.SMTPCredentialSettings) error {
+	if len(settings.AllowedSenders) > maxAllowedSenders {
+		return fmt.Errorf("发件地址规则不能超过%d条", maxAllowedSenders)
+	}
+	senders := make([]string, 0, len(settings.AllowedSenders))
+	seenSenders := make(map[string]bool)
+	for _, pattern := range settings.AllowedSenders {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if err := validateSenderPattern(pattern); err != nil {
+			return err
+		}
+		if !seenSenders[pattern] {
+			seenSenders[pattern] = true
+			senders = append(senders, pattern)
+		}
+	}
+	settings.AllowedSenders = senders
+
+	if settings.MaxMessageSize < 0 {
+		return fmt.Errorf("邮件大小限制不能为负数")
+	}
+
+	if settings.MessagesPerSecond < 0 || settings.MessagesPerSecond > 1000 {
+		return fmt.Errorf("每秒邮件数限制必须在0-1000之间")
+	}
+	if settings.RecipientsPerMinute < 0 || settings.RecipientsPerMinute > 100000 {
+		return fmt.Errorf("每分钟收件人数限制必须在0-100000之间")
+	}
+
+	ports := make([]int, 0, len(settings.AllowedPorts))
+	seenPorts := make(map[int]bool)
+	for _, port := range settings.AllowedPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("无效的端口: %d", port)
+		}
+		if !seenPorts[port] {
+			seenPorts[port] = true
+			ports = append(ports, port)
+		}
+	}
+	settings.AllowedPorts = ports
+
+	if window := settings.SendingHours; window != nil {
+		start, err := parseClock(window.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseClock(window.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("发送时段的开始和结束时间不能相同")
+		}
+		if window.Timezone != "" {
+			if _, err := time.LoadLocation(window.Timezone); err != nil {
+				return fmt.Errorf("无效的时区: %s", window.Timezone)
+			}
+		}
+		for _, day := range window.Weekdays {
+			if day < 0 || day > 6 {
+				return fmt.Errorf("无效的星期: %d", day)
+			}
+		}
+	}
+
+	switch settings.AnomalyAction {
+	case "", models.AnomalyActionSuspend, models.AnomalyActionAlert, models.AnomalyActionOff:
+	default:
+		return fmt.Errorf("无效的异常处理方式: %s", settings.AnomalyAction)
+	}
+
+	return nil
+}
+
+// ValidateCredentialRateLimits 校验凭据的速率限制不超过用户的速率上限（见RateLimitCeiling）；存在上限时不能设置为0（不限制）
+func ValidateCredentialRateLimits(settings *models.SMTPCredentialSettings, user *models.User, plan *models.Plan) error {
+	messagesCeiling, recipientsCeiling := RateLimitCeiling(user, plan)
+	if messagesCeiling > 0 && (settings.MessagesPerSecond <= 0 || settings.MessagesPerSecond > messagesCeiling) {
+		return fmt.Errorf("每秒邮件数限制必须在1-%d之间", messagesCeiling)
+	}
+	if recipientsCeiling > 0 && (settings.RecipientsPerMinute <= 0 || settings.RecipientsPerMinute > recipientsCeiling) {
+		return fmt.Errorf("每分钟收件人数限制必须在1-%d之间", recipientsCeiling)
+	}
+	return nil
+}
+
+// SenderPatternDomains 返回发件地址规则中的域名（用于检查域名验证状态）
+func SenderPatternDomains(patterns []string) []string {
+	var domains []string
+	seen := make(map[string]bool)
+	for _, pattern := range patterns {
+		domain := addressDomain(pattern)
+		if domain != "" && !seen[domain] {
+			seen[domain] = true
+			domains = append(domains, domain)
+		}
+	}
+	return domains
+}
+
+// SenderAllowed 检查发件地址是否符合凭据的发件地址规则（未配置规则时不限制）
+func SenderAllowed(settings models.SMTPCredentialSettings, address string) bool {
+	if len(settings.AllowedSenders) == 0 {
+		return true
+	}
+	address = strings.ToLower(address)
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+	for _, pattern := range settings.AllowedSenders {
+		patternAt := strings.LastIndex(pattern, "@")
+		if patternAt < 0 || pattern[patternAt+1:] != address[at+1:] {
+			continue
+		}
+		// 通配符只出现在本地部分，域名必须完全一致
+		if matched, _ := path.Match(pattern[:patternAt], address[:at]); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// SendingAllowedAt 检查指定时间是否处于允许发送的时段内（未配置时段时不限制）
+func SendingAllowedAt(window *models.SendingWindow, t time.Time) bool {
+	if window == nil {
+		return true
+	}
+	start, err := parseClock(window.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(window.End)
+	if err != nil {
+		return false
+	}
+	location := time.UTC
+	if window.Timezone != "" {
+		if location, err = time.LoadLocation(window.Timezone); err != nil {
+			return false
+		}
+	}
+
+	local := t.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	weekday := int(local.Weekday())
+	if end < start && minute < end {
+		// 跨越午夜的时段，凌晨部分属于前一天开始的时段
+		weekday = (weekday + 6) % 7
+	}
+
+	if len(window.Weekdays) > 0 {
+		allowedDay := false
+		for _, day := range window.Weekdays {
+			if day == weekday {
+				allowedDay = true
+				break
+			}
+		}
+		if !allowedDay {
+			return false
+		}
+	}
+
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// validateSenderPattern 校验发件地址规则：域名须完整，通配符*和?只能出现在本地部分
+func validateSenderPattern(pattern string) error {
+	at := strings.LastIndex(pattern, "@")
+	if at <= 0 || at == len(pattern)-1 {
+		return fmt.Errorf("无效的发件地址规则: %s", pattern)
+	}
+	local, domain := pattern[:at], pattern[at+1:]
+	if strings.ContainsAny(local, "@[]\\ ") {
+		return fmt.Errorf("无效的发件地址规则: %s", pattern)
+	}
+	if _, err := path.Match(local, ""); err != nil {
+		return fmt.Errorf("无效的发件地址规则: %s", pattern)
+	}
+	if normalized, err := normalizeDomain(domain); err != nil || normalized != domain {
+		return fmt.Errorf("无效的发件地址规则: %s", pattern)
+	}
+	return nil
+}
+
+// parseClock 解析HH:MM格式的时间，返回当天的分钟数
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("发送时段格式错误，应为HH:MM: %s", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
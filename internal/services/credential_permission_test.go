@@ -0,0 +1,122 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"smtp-relay/internal/models"
+)
+
+func TestSenderAllowed(t *testing.T) {
+	patterns := []string{"noreply@example.com", "alert-*@example.com", "*@mail.example.com", "user?@example.org"}
+
+	tests := []struct {
+		name     string
+		patterns []string
+		address  string
+		want     bool
+	}{
+		{"未配置规则时不限制", nil, "anyone@anywhere.test", true},
+		{"完全匹配", patterns, "noreply@example.com", true},
+		{"忽略大小写", patterns, "NoReply@Example.COM", true},
+		{"本地部分前缀通配", patterns, "alert-disk@example.com", true},
+		{"前缀不匹配", patterns, "info@example.com", false},
+		{"整个本地部分通配", patterns, "anything@mail.example.com", true},
+		{"通配符不跨越域名", patterns, "anything@sub.mail.example.com", false},
+		{"父域名不匹配子域名规则", patterns, "noreply@mail.example.com.evil.test", false},
+		{"单字符通配", patterns, "user1@example.org", true},
+		{"单字符通配不匹配多个字符", patterns, "user12@example.org", false},
+		{"缺少@", patterns, "noreply", false},
+		{"域名部分包含@", patterns, "noreply@evil.test@example.com", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := models.SMTPCredentialSettings{AllowedSenders: tt.patterns}
+			if got := SenderAllowed(settings, tt.address); got != tt.want {
+				t.Errorf("SenderAllowed(%q) = %v，期望 %v", tt.address, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendingAllowedAt(t *testing.T) {
+	// 2026-10-05 为周一
+	at := func(value string) time.Time {
+		t.Helper()
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			t.Fatalf("解析时间失败: %v", err)
+		}
+		return parsed
+	}
+	workdays := []int{1, 2, 3, 4, 5}
+
+	tests := []struct {
+		name   string
+		window *models.SendingWindow
+		time   string
+		want   bool
+	}{
+		{"未配置时段时不限制", nil, "2026-10-05T03:00:00Z", true},
+		{"时段内", &models.SendingWindow{Start: "09:00", End: "18:00"}, "2026-10-05T09:00:00Z", true},
+		{"结束时间不包含在内", &models.SendingWindow{Start: "09:00", End: "18:00"}, "2026-10-05T18:00:00Z", false},
+		{"时段前", &models.SendingWindow{Start: "09:00", End: "18:00"}, "2026-10-05T08:59:59Z", false},
+		{"按时区换算", &models.SendingWindow{Start: "09:00", End: "18:00", Timezone: "Asia/Shanghai"}, "2026-10-05T01:30:00Z", true},
+		{"按时区换算后在时段外", &models.SendingWindow{Start: "09:00", End: "18:00", Timezone: "Asia/Shanghai"}, "2026-10-05T12:00:00Z", false},
+		{"工作日", &models.SendingWindow{Start: "09:00", End: "18:00", Weekdays: workdays}, "2026-10-05T10:00:00Z", true},
+		{"周末", &models.SendingWindow{Start: "09:00", End: "18:00", Weekdays: workdays}, "2026-10-04T10:00:00Z", false},
+		{"时区换算后跨日", &models.SendingWindow{Start: "00:00", End: "12:00", Timezone: "Asia/Shanghai", Weekdays: workdays}, "2026-10-04T17:00:00Z", true},
+		{"跨午夜时段的夜间部分", &models.SendingWindow{Start: "22:00", End: "06:00"}, "2026-10-05T23:00:00Z", true},
+		{"跨午夜时段的凌晨部分", &models.SendingWindow{Start: "22:00", End: "06:00"}, "2026-10-06T05:59:00Z", true},
+		{"跨午夜时段之外", &models.SendingWindow{Start: "22:00", End: "06:00"}, "2026-10-05T12:00:00Z", false},
+		// 周五22:00开始的时段延续到周六凌晨；周一凌晨属于周日开始的时段，不允许
+		{"跨午夜时段凌晨属于前一天", &models.SendingWindow{Start: "22:00", End: "06:00", Weekdays: workdays}, "2026-10-10T02:00:00Z", true},
+		{"跨午夜时段前一天不允许", &models.SendingWindow{Start: "22:00", End: "06:00", Weekdays: workdays}, "2026-10-05T02:00:00Z", false},
+		{"无效的时间格式", &models.SendingWindow{Start: "9am", End: "18:00"}, "2026-10-05T10:00:00Z", false},
+		{"无效的时区", &models.SendingWindow{Start: "09:00", End: "18:00", Timezone: "Mars/Olympus"}, "2026-10-05T10:00:00Z", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SendingAllowedAt(tt.window, at(tt.time)); got != tt.want {
+				t.Errorf("SendingAllowedAt(%s) = %v，期望 %v", tt.time, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateCredentialRateLimits(t *testing.T) {
+	tests := []struct {
+		name         string
+		userSettings models.UserSettings
+		plan         *models.Plan
+		messages     int
+		recipients   int
+		wantErr      string
+	}{
+		{"没有上限时可以不限制", models.UserSettings{}, nil, 0, 0, ""},
+		{"没有上限时可以设置任意值", models.UserSettings{}, &models.Plan{}, 500, 50000, ""},
+		{"等于套餐上限", models.UserSettings{}, &models.Plan{MessagesPerSecond: 10, RecipientsPerMinute: 600}, 10, 600, ""},
+		{"低于套餐上限", models.UserSettings{}, &models.Plan{MessagesPerSecond: 10}, 1, 0, ""},
+		{"超过套餐上限", models.UserSettings{}, &models.Plan{MessagesPerSecond: 10}, 11, 0, "每秒邮件数限制必须在1-10之间"},
+		{"存在套餐上限时不能不限制", models.UserSettings{}, &models.Plan{RecipientsPerMinute: 600}, 0, 0, "每分钟收件人数限制必须在1-600之间"},
+		{"用户设置低于套餐上限时使用用户设置", models.UserSettings{MessagesPerSecond: 5}, &models.Plan{MessagesPerSecond: 10}, 6, 0, "每秒邮件数限制必须在1-5之间"},
+		{"用户设置高于套餐上限时使用套餐上限", models.UserSettings{RecipientsPerMinute: 1000}, &models.Plan{RecipientsPerMinute: 600}, 0, 601, "每分钟收件人数限制必须在1-600之间"},
+		{"没有套餐时使用用户设置", models.UserSettings{MessagesPerSecond: 5}, nil, 0, 0, "每秒邮件数限制必须在1-5之间"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := &models.User{Settings: tt.userSettings}
+			settings := &models.SMTPCredentialSettings{MessagesPerSecond: tt.messages, RecipientsPerMinute: tt.recipients}
+			err := ValidateCredentialRateLimits(settings, user, tt.plan)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateCredentialRateLimits() 返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Fatalf("期望错误 %q，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
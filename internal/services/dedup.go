@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+)
+
+const (
+	// defaultDedupWindow 默认去重时间窗口
+	defaultDedupWindow = 10 * time.Minute
+	// maxDedupWindowMinutes 去重时间窗口上限（分钟）
+	maxDedupWindowMinutes = 24 * 60
+	// dedupPending 去重键已占用但邮件尚未入队时的占位值
+	dedupPending = "pending"
+)
+
+// DedupService 重复提交检测服务（基于Redis的幂等窗口）
+type DedupService struct {
+	db     *database.MongoDB
+	redis  *redis.Client
+	logger *logrus.Logger
+}
+
+// NewDedupService 创建重复提交检测服务
+func NewDedupService(db *database.MongoDB, redisClient *redis.Client, logger *logrus.Logger) *DedupService {
+	return &DedupService{
+		db:     db,
+		redis:  redisClient,
+		logger: logger,
+	}
+}
+
+// Window 获取凭据的去重时间窗口
+func (s *DedupService) Window(settings models.SMTPCredentialSettings) time.Duration {
+	if settings.DedupWindowMinutes <= 0 {
+		return defaultDedupWindow
+	}
+	if settings.DedupWindowMinutes > maxDedupWindowMinutes {
+		return maxDedupWindowMinutes * time.Minute
+	}
+	return time.Duration(settings.DedupWindowMinutes) * time.Minute
+}
+
+// ComputeKey 计算去重键：优先使用客户端Message-ID，缺失时使用信封和正文的哈希
+func (s *DedupService) ComputeKey(clientMessageID, from string, to []string, body []byte) string {
+	if clientMessageID != "" {
+		sum := sha256.Sum256([]byte(strings.ToLower(clientMessageID)))
+		return "mid:" + hex.EncodeToString(sum[:])
+	}
+
+	recipients := make([]string, len(to))
+	for i, rcpt := range to {
+		recipients[i] = strings.ToLower(rcpt)
+	}
+	sort.Strings(recipients)
+
+	hash := sha256.New()
+	hash.Write([]byte(strings.ToLower(from)))
+	hash.Write([]byte{0})
+	hash.Write([]byte(strings.Join(recipients, ",")))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return "hash:" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// Claim 尝试占用去重键；键已存在时返回false及原始MailLogID（可能为空）
+func (s *DedupService) Claim(credentialID primitive.ObjectID, key string, window time.Duration) (bool, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	redisKey := s.redisKey(credentialID, key)
+	claimed, err := s.redis.SetNX(ctx, redisKey, dedupPending, window).Result()
+	if err != nil {
+		return false, "", fmt.Errorf("占用去重键失败: %w", err)
+	}
+	if claimed {
+		return true, "", nil
+	}
+
+	originalID, err := s.redis.Get(ctx, redisKey).Result()
+	if err != nil && err != redis.Nil {
+		return false, "", fmt.Errorf("读取去重键失败: %w", err)
+	}
+	if originalID == dedupPending {
+		originalID = ""
+	}
+
+	return false, originalID, nil
+}
+
+// Bind 将去重键关联到已入队的MailLog（保持原有过期时间）
+func (s *DedupService) Bind(credentialID primitive.ObjectID, key string, mailLogID primitive.ObjectID) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := s.redis.SetXX(ctx, s.redisKey(credentialID, key), mailLogID.Hex(), redis.KeepTTL).Err(); err != nil {
+		s.logger.WithError(err).WithField("mail_log_id", mailLogID.Hex()).Warn("关联去重键失败")
+	}
+}
+
+// Release 释放去重键（邮件未被接受时调用，允许客户端重试）
+func (s *DedupService) Release(credentialID primitive.ObjectID, key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := s.redis.Del(ctx, s.redisKey(credentialID, key)).Err(); err != nil {
+		s.logger.WithError(err).Warn("释放去重键失败")
+	}
+}
+
+// MarkDuplicate 在原始MailLog上记录一次重复提交
+func (s *DedupService) MarkDuplicate(originalID string) error {
+	mailLogID, err := primitive.ObjectIDFromHex(originalID)
+	if err != nil {
+		return fmt.Errorf("无效的原始MailLogID: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = s.db.GetCollection("mail_logs").UpdateOne(ctx,
+		bson.M{"_id": mailLogID},
+		bson.M{
+			"$inc": bson.M{"duplicate_count": 1},
+			"$set": bson.M{"last_duplicate_at": time.Now()},
+		},
+	)
+	return err
+}
+
+// redisKey 生成凭据维度的Redis去重键
+func (s *DedupService) redisKey(credentialID primitive.ObjectID, key string) string {
+	return fmt.Sprintf("dedup:%s:%s", credentialID.Hex(), key)
+}
@@ -2,47 +2,80 @@ package services
 
 import (
 	"context"
+	"crypto"
+	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
 	"encoding/pem"
+	"errors"
 	"fmt"
-	"net"
 	"strings"
 	"time"
 
 	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/mailauth"
 	"smtp-relay/internal/models"
 
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// 密钥轮换的状态冲突错误
+var (
+	// ErrRotationPending 密钥对已有等待DNS生效的新密钥，不能再次轮换
+	ErrRotationPending = errors.New("该密钥对已有等待DNS生效的新密钥")
+	// ErrRotationInactiveKey 只有有效状态的密钥对可以轮换
+	ErrRotationInactiveKey = errors.New("只能轮换有效的密钥对")
 )
 
 // DKIMService DKIM服务
 type DKIMService struct {
-	db     *database.MongoDB
-	logger *logrus.Logger
+	db        *database.MongoDB
+	encryptor *encryption.Encryptor
+	resolver  mailauth.Resolver
+	logger    *logrus.Logger
 }
 
 // NewDKIMService 创建DKIM服务实例
-func NewDKIMService(db *database.MongoDB, logger *logrus.Logger) *DKIMService {
+func NewDKIMService(db *database.MongoDB, encryptor *encryption.Encryptor, resolver mailauth.Resolver, logger *logrus.Logger) *DKIMService {
 	return &DKIMService{
-		db:     db,
-		logger: logger,
+		db:        db,
+		encryptor: encryptor,
+		resolver:  resolver,
+		logger:    logger,
 	}
 }
 
-// GenerateKeyPair 生成DKIM密钥对
-func (s *DKIMService) GenerateKeyPair(userID primitive.ObjectID, domain, selector string, keySize int) (*models.DKIMKeyPair, error) {
+// DefaultDKIMGracePeriod 新密钥生效后旧密钥默认保留的时间
+const DefaultDKIMGracePeriod = 30 * 24 * time.Hour
+
+// GenerateKeyPair 生成DKIM密钥对，algorithm为rsa-sha256（默认）或ed25519-sha256
+func (s *DKIMService) GenerateKeyPair(userID primitive.ObjectID, domain, selector, algorithm string, keySize int) (*models.DKIMKeyPair, error) {
+	return s.generateKeyPair(userID, domain, selector, algorithm, keySize, nil)
+}
+
+// generateKeyPair 生成DKIM密钥对，rotatedFrom不为空时新密钥处于pending状态，等待DNS生效后替换旧密钥
+func (s *DKIMService) generateKeyPair(userID primitive.ObjectID, domain, selector, algorithm string, keySize int, rotatedFrom *primitive.ObjectID) (*models.DKIMKeyPair, error) {
 	// 验证参数
 	if domain == "" || selector == "" {
 		return nil, fmt.Errorf("域名和选择器不能为空")
 	}
-	if keySize != 1024 && keySize != 2048 {
-		keySize = 2048 // 默认使用2048位
+	switch algorithm {
+	case "", mailauth.AlgorithmRSASHA256:
+		algorithm = mailauth.AlgorithmRSASHA256
+		if keySize != 1024 && keySize != 2048 && keySize != 4096 {
+			keySize = 2048 // 默认使用2048位
+		}
+	case mailauth.AlgorithmEd25519SHA256:
+		keySize = 256
+	default:
+		return nil, fmt.Errorf("不支持的签名算法: %s", algorithm)
 	}
 
 	// 检查是否已存在相同的域名+选择器组合
@@ -50,34 +83,44 @@ func (s *DKIMService) GenerateKeyPair(userID primitive.ObjectID, domain, selecto
 	defer cancel()
 
 	collection := s.db.GetCollection("dkim_keys")
-	existingCount, err := collection.CountDocuments(ctx, bson.M{
-		"user_id":  userID,
-		"domain":   domain,
-		"selector": selector,
-		"status":   bson.M{"$ne": "deleted"},
-	})
-	if err != nil {
-		return nil, fmt.Errorf("检查现有密钥失败: %w", err)
-	}
-	if existingCount > 0 {
-		return nil, fmt.Errorf("该域名和选择器的密钥对已存在")
+	if err := s.ensureSelectorAvailable(ctx, userID, domain, selector); err != nil {
+		return nil, err
 	}
 
-	// 生成RSA密钥对
-	privateKey, err := rsa.GenerateKey(rand.Reader, keySize)
-	if err != nil {
-		return nil, fmt.Errorf("生成RSA密钥失败: %w", err)
+	// 生成密钥对
+	var privateKeyPEM *pem.Block
+	var publicKey crypto.PublicKey
+	if algorithm == mailauth.AlgorithmEd25519SHA256 {
+		edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("生成Ed25519密钥失败: %w", err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(edPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("编码私钥失败: %w", err)
+		}
+		privateKeyPEM = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		publicKey = edPublicKey
+	} else {
+		rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, keySize)
+		if err != nil {
+			return nil, fmt.Errorf("生成RSA密钥失败: %w", err)
+		}
+		privateKeyPEM = &pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(rsaPrivateKey),
+		}
+		publicKey = &rsaPrivateKey.PublicKey
 	}
 
 	// 编码私钥
-	privateKeyPEM := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	privateKeyStr, err := s.encryptor.EncryptString(string(pem.EncodeToMemory(privateKeyPEM)))
+	if err != nil {
+		return nil, fmt.Errorf("加密私钥失败: %w", err)
 	}
-	privateKeyStr := string(pem.EncodeToMemory(privateKeyPEM))
 
 	// 编码公钥
-	publicKeyDER, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
 	if err != nil {
 		return nil, fmt.Errorf("编码公钥失败: %w", err)
 	}
@@ -88,23 +131,34 @@ func (s *DKIMService) GenerateKeyPair(userID primitive.ObjectID, domain, selecto
 	publicKeyStr := string(pem.EncodeToMemory(publicKeyPEM))
 
 	// 生成DNS记录
-	dnsRecord := s.generateDNSRecord(&privateKey.PublicKey)
+	dnsRecord, err := generateDNSRecord(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	status := models.DKIMKeyStatusActive
+	if rotatedFrom != nil {
+		status = models.DKIMKeyStatusPending
+	}
 
 	// 创建DKIM密钥对记录
 	keyPair := &models.DKIMKeyPair{
-		ID:          primitive.NewObjectID(),
-		UserID:      userID,
-		Domain:      domain,
-		Selector:    selector,
-		PrivateKey:  privateKeyStr,
-		PublicKey:   publicKeyStr,
-		KeySize:     keySize,
-		Algorithm:   "rsa-sha256",
-		Status:      "active",
-		DNSRecord:   dnsRecord,
-		DNSVerified: false,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
+		ID:              primitive.NewObjectID(),
+		UserID:          userID,
+		Domain:          domain,
+		Selector:        selector,
+		PrivateKey:      privateKeyStr,
+		EncryptionKeyID: s.encryptor.CurrentKeyID(),
+		PublicKey:       publicKeyStr,
+		KeySize:         keySize,
+		Algorithm:       algorithm,
+		Status:          status,
+		Source:          models.DKIMKeySourceGenerated,
+		DNSRecord:       dnsRecord,
+		DNSVerified:     false,
+		RotatedFromID:   rotatedFrom,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
 	}
 
 	// 保存到数据库
@@ -114,30 +168,226 @@ func (s *DKIMService) GenerateKeyPair(userID primitive.ObjectID, domain, selecto
 	}
 
 	s.logger.WithFields(logrus.Fields{
-		"user_id":  userID.Hex(),
-		"domain":   domain,
-		"selector": selector,
-		"key_size": keySize,
+		"user_id":   userID.Hex(),
+		"domain":    domain,
+		"selector":  selector,
+		"algorithm": algorithm,
+		"key_size":  keySize,
+		"status":    status,
 	}).Info("DKIM密钥对生成成功")
 
 	return keyPair, nil
 }
 
-// generateDNSRecord 生成DNS TXT记录值
-func (s *DKIMService) generateDNSRecord(publicKey *rsa.PublicKey) string {
-	// 提取公钥的模数和指数
-	publicKeyDER, err := x509.MarshalPKIXPublicKey(publicKey)
+// ImportKeyPair 导入已有的PEM私钥（PKCS#1/PKCS#8，RSA或Ed25519），要求与DNS中已发布的公钥一致
+func (s *DKIMService) ImportKeyPair(userID primitive.ObjectID, domain, selector, privateKeyPEM string) (*models.DKIMKeyPair, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	selector = strings.TrimSpace(selector)
+	if domain == "" || selector == "" {
+		return nil, fmt.Errorf("域名和选择器不能为空")
+	}
+
+	signer, err := mailauth.ParsePrivateKey(privateKeyPEM)
 	if err != nil {
-		s.logger.WithError(err).Error("编码公钥失败")
-		return ""
+		return nil, fmt.Errorf("无效的私钥: %v", err)
+	}
+	algorithm, err := mailauth.SignatureAlgorithm(signer)
+	if err != nil {
+		return nil, err
+	}
+	keySize := 256
+	if key, ok := signer.Public().(*rsa.PublicKey); ok {
+		keySize = key.N.BitLen()
+		if keySize < 1024 {
+			return nil, fmt.Errorf("RSA密钥长度不能小于1024位")
+		}
 	}
 
-	// Base64编码公钥
-	publicKeyB64 := base64.StdEncoding.EncodeToString(publicKeyDER)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := s.ensureSelectorAvailable(ctx, userID, domain, selector); err != nil {
+		return nil, err
+	}
+
+	// 校验DNS中已发布的公钥
+	published, err := mailauth.LookupPublicKey(ctx, s.resolver, domain, selector)
+	if err != nil {
+		return nil, fmt.Errorf("DNS中未找到有效的公钥记录: %v", err)
+	}
+	dnsRecord, err := generateDNSRecord(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	if !publicKeyRecordMatches(published.Raw, expectedPublicKey(dnsRecord)) {
+		return nil, fmt.Errorf("私钥与DNS中发布的公钥不匹配")
+	}
+
+	// 统一以PKCS#8格式加密存储
+	der, err := x509.MarshalPKCS8PrivateKey(signer)
+	if err != nil {
+		return nil, fmt.Errorf("编码私钥失败: %w", err)
+	}
+	privateKeyStr, err := s.encryptor.EncryptString(string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})))
+	if err != nil {
+		return nil, fmt.Errorf("加密私钥失败: %w", err)
+	}
+	publicKeyDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return nil, fmt.Errorf("编码公钥失败: %w", err)
+	}
+
+	now := time.Now()
+	keyPair := &models.DKIMKeyPair{
+		ID:              primitive.NewObjectID(),
+		UserID:          userID,
+		Domain:          domain,
+		Selector:        selector,
+		PrivateKey:      privateKeyStr,
+		EncryptionKeyID: s.encryptor.CurrentKeyID(),
+		PublicKey:       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyDER})),
+		KeySize:         keySize,
+		Algorithm:       algorithm,
+		Status:          "active",
+		Source:          models.DKIMKeySourceImported,
+		DNSRecord:       published.Raw,
+		DNSVerified:     true,
+		LastVerified:    &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if _, err := s.db.GetCollection("dkim_keys").InsertOne(ctx, keyPair); err != nil {
+		return nil, fmt.Errorf("保存DKIM密钥对失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":   userID.Hex(),
+		"domain":    domain,
+		"selector":  selector,
+		"algorithm": algorithm,
+		"key_size":  keySize,
+	}).Info("DKIM密钥对导入成功")
 
-	// 构建DNS记录
-	// 格式: v=DKIM1; h=sha256; k=rsa; p=<base64-encoded-public-key>
-	return fmt.Sprintf("v=DKIM1; h=sha256; k=rsa; p=%s", publicKeyB64)
+	return keyPair, nil
+}
+
+// ExportPublicKey 导出密钥对的公钥（PEM格式）及DNS记录
+func (s *DKIMService) ExportPublicKey(userID, keyPairID primitive.ObjectID) (*models.DKIMKeyExport, error) {
+	keyPair, err := s.GetKeyPair(userID, keyPairID)
+	if err != nil {
+		return nil, err
+	}
+	return newDKIMKeyExport(keyPair), nil
+}
+
+// ExportPrivateKey 导出完整密钥材料，format为pkcs8（默认）或pkcs1（仅RSA）
+// 调用方须在调用前完成用户的重新认证
+func (s *DKIMService) ExportPrivateKey(userID, keyPairID primitive.ObjectID, format string) (*models.DKIMKeyExport, error) {
+	keyPair, err := s.GetKeyPair(userID, keyPairID)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := s.LoadSigner(keyPair)
+	if err != nil {
+		return nil, err
+	}
+
+	var block *pem.Block
+	switch format {
+	case "", "pkcs8":
+		format = "pkcs8"
+		der, err := x509.MarshalPKCS8PrivateKey(signer)
+		if err != nil {
+			return nil, fmt.Errorf("编码私钥失败: %w", err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	case "pkcs1":
+		key, ok := signer.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("PKCS#1格式仅支持RSA密钥")
+		}
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	default:
+		return nil, fmt.Errorf("不支持的导出格式: %s", format)
+	}
+
+	export := newDKIMKeyExport(keyPair)
+	export.PrivateKey = string(pem.EncodeToMemory(block))
+	export.Format = format
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     userID.Hex(),
+		"key_pair_id": keyPairID.Hex(),
+		"domain":      keyPair.Domain,
+		"selector":    keyPair.Selector,
+	}).Warn("DKIM私钥已导出")
+
+	return export, nil
+}
+
+// newDKIMKeyExport 组装导出数据（不含私钥）
+func newDKIMKeyExport(keyPair *models.DKIMKeyPair) *models.DKIMKeyExport {
+	return &models.DKIMKeyExport{
+		ID:        keyPair.ID,
+		Domain:    keyPair.Domain,
+		Selector:  keyPair.Selector,
+		Algorithm: keyPair.Algorithm,
+		KeySize:   keyPair.KeySize,
+		PublicKey: keyPair.PublicKey,
+		DNSRecord: &models.DNSRecord{
+			Type:    "TXT",
+			Name:    keyPair.GetDNSRecordName(),
+			Value:   keyPair.GetDNSRecordValue(),
+			Strings: keyPair.GetDNSRecordStrings(),
+			TTL:     3600,
+		},
+	}
+}
+
+// ensureSelectorAvailable 检查域名+选择器组合未被占用
+func (s *DKIMService) ensureSelectorAvailable(ctx context.Context, userID primitive.ObjectID, domain, selector string) error {
+	existingCount, err := s.db.GetCollection("dkim_keys").CountDocuments(ctx, bson.M{
+		"user_id":  userID,
+		"domain":   domain,
+		"selector": selector,
+		"status":   bson.M{"$ne": "deleted"},
+	})
+	if err != nil {
+		return fmt.Errorf("检查现有密钥失败: %w", err)
+	}
+	if existingCount > 0 {
+		return fmt.Errorf("该域名和选择器的密钥对已存在")
+	}
+	return nil
+}
+
+// expectedPublicKey 解析期望的公钥记录，失败时返回nil（视为不匹配）
+func expectedPublicKey(record string) *mailauth.PublicKeyRecord {
+	parsed, err := mailauth.ParsePublicKeyRecord(record)
+	if err != nil {
+		return nil
+	}
+	return parsed
+}
+
+// generateDNSRecord 生成DNS TXT记录值
+func generateDNSRecord(publicKey crypto.PublicKey) (string, error) {
+	switch key := publicKey.(type) {
+	case ed25519.PublicKey:
+		// 格式: v=DKIM1; k=ed25519; p=<base64-encoded-raw-public-key>（RFC 8463）
+		return fmt.Sprintf("v=DKIM1; k=ed25519; p=%s", base64.StdEncoding.EncodeToString(key)), nil
+	case *rsa.PublicKey:
+		publicKeyDER, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			return "", fmt.Errorf("编码公钥失败: %w", err)
+		}
+		// 格式: v=DKIM1; h=sha256; k=rsa; p=<base64-encoded-public-key>
+		return fmt.Sprintf("v=DKIM1; h=sha256; k=rsa; p=%s", base64.StdEncoding.EncodeToString(publicKeyDER)), nil
+	default:
+		return "", fmt.Errorf("不支持的公钥类型: %T", publicKey)
+	}
 }
 
 // ListKeyPairs 获取用户的DKIM密钥对列表
@@ -240,8 +490,11 @@ func (s *DKIMService) VerifyDNSRecord(userID, keyPairID primitive.ObjectID) (*mo
 	}
 
 	// 查询DNS记录
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
 	dnsName := keyPair.GetDNSRecordName()
-	txtRecords, err := net.LookupTXT(dnsName)
+	txtRecords, err := s.resolver.LookupTXT(ctx, dnsName)
 	if err != nil {
 		result.Valid = false
 		result.DNSFound = false
@@ -249,9 +502,16 @@ func (s *DKIMService) VerifyDNSRecord(userID, keyPairID primitive.ObjectID) (*mo
 		return result, nil
 	}
 
-	// 检查是否找到匹配的记录
+	// 检查是否找到匹配的记录（多段TXT字符串已由解析器拼接）
+	expected := expectedPublicKey(keyPair.DNSRecord)
 	result.DNSFound = len(txtRecords) > 0
 	for _, record := range txtRecords {
+		if publicKeyRecordMatches(record, expected) {
+			result.Valid = true
+			result.DNSRecord = record
+			break
+		}
+
 		// 移除空格和换行符进行比较
 		cleanRecord := strings.ReplaceAll(strings.ReplaceAll(record, " ", ""), "\n", "")
 		cleanExpected := strings.ReplaceAll(strings.ReplaceAll(keyPair.DNSRecord, " ", ""), "\n", "")
@@ -272,14 +532,54 @@ func (s *DKIMService) VerifyDNSRecord(userID, keyPairID primitive.ObjectID) (*mo
 		result.ErrorMessage = "未找到DNS记录"
 	}
 
-	// 更新数据库中的验证状态
+	// 更新数据库中的验证状态，轮换生成的新密钥在DNS生效后立即切换
 	if result.Valid {
 		s.updateVerificationStatus(keyPairID, true, time.Now())
+		if keyPair.Status == models.DKIMKeyStatusPending {
+			if err := s.ActivateKeyPair(keyPair); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	return result, nil
 }
 
+// publicKeyRecordMatches 检查DNS记录发布的公钥（及密钥类型）是否与期望记录一致
+func publicKeyRecordMatches(record string, expected *mailauth.PublicKeyRecord) bool {
+	if expected == nil {
+		return false
+	}
+	published, err := mailauth.ParsePublicKeyRecord(record)
+	if err != nil || published.KeyType != expected.KeyType {
+		return false
+	}
+	key, ok := published.PublicKey.(interface{ Equal(x crypto.PublicKey) bool })
+	return ok && key.Equal(expected.PublicKey)
+}
+
+// checkPublishedKey 检查DNS中是否发布了与密钥对一致的公钥，记录不存在时返回false，临时错误时返回error
+func (s *DKIMService) checkPublishedKey(keyPair *models.DKIMKeyPair) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	records, err := s.resolver.LookupTXT(ctx, keyPair.GetDNSRecordName())
+	if err != nil {
+		if mailauth.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	expected := expectedPublicKey(keyPair.DNSRecord)
+	for _, record := range records {
+		if publicKeyRecordMatches(record, expected) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // updateVerificationStatus 更新验证状态
 func (s *DKIMService) updateVerificationStatus(keyPairID primitive.ObjectID, verified bool, verifiedAt time.Time) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -311,6 +611,7 @@ func (s *DKIMService) GetDNSRecord(userID, keyPairID primitive.ObjectID) (*model
 		Type:     "TXT",
 		Name:     keyPair.GetDNSRecordName(),
 		Value:    keyPair.GetDNSRecordValue(),
+		Strings:  keyPair.GetDNSRecordStrings(),
 		TTL:      3600,
 		Priority: 0,
 	}, nil
@@ -342,42 +643,42 @@ func (s *DKIMService) GetKeyPairsByDomain(userID primitive.ObjectID, domain stri
 	return keyPairs, nil
 }
 
-// RotateKeyPair 轮换密钥对
+// RotateKeyPair 轮换密钥对：生成新选择器的密钥并等待其DNS记录生效，生效后才切换签名并让旧密钥进入宽限期
 func (s *DKIMService) RotateKeyPair(userID, keyPairID primitive.ObjectID) (*models.DKIMKeyPair, error) {
 	// 获取现有密钥对
 	oldKeyPair, err := s.GetKeyPair(userID, keyPairID)
 	if err != nil {
 		return nil, err
 	}
-
-	// 生成新的选择器（在原选择器后加上时间戳）
-	newSelector := fmt.Sprintf("%s-%d", oldKeyPair.Selector, time.Now().Unix())
-
-	// 生成新密钥对
-	newKeyPair, err := s.GenerateKeyPair(userID, oldKeyPair.Domain, newSelector, oldKeyPair.KeySize)
-	if err != nil {
-		return nil, fmt.Errorf("生成新密钥对失败: %w", err)
+	if oldKeyPair.Status != models.DKIMKeyStatusActive {
+		return nil, ErrRotationInactiveKey
 	}
 
-	// 标记旧密钥对为即将过期
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	collection := s.db.GetCollection("dkim_keys")
-	expiresAt := time.Now().Add(30 * 24 * time.Hour) // 30天后过期
+	pending, err := s.db.GetCollection("dkim_keys").CountDocuments(ctx, bson.M{
+		"rotated_from_id": keyPairID,
+		"status":          models.DKIMKeyStatusPending,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("检查轮换状态失败: %w", err)
+	}
+	if pending > 0 {
+		return nil, ErrRotationPending
+	}
 
-	_, err = collection.UpdateOne(ctx,
-		bson.M{"_id": keyPairID},
-		bson.M{
-			"$set": bson.M{
-				"status":     "expiring",
-				"expires_at": expiresAt,
-				"updated_at": time.Now(),
-			},
-		},
-	)
+	// 生成新的选择器（在原选择器后加上时间戳）
+	baseSelector := oldKeyPair.Selector
+	if i := strings.LastIndex(baseSelector, "-"); i > 0 && isUnixTimestamp(baseSelector[i+1:]) {
+		baseSelector = baseSelector[:i]
+	}
+	newSelector := fmt.Sprintf("%s-%d", baseSelector, time.Now().Unix())
+
+	// 生成新密钥对
+	newKeyPair, err := s.generateKeyPair(userID, oldKeyPair.Domain, newSelector, oldKeyPair.Algorithm, oldKeyPair.KeySize, &oldKeyPair.ID)
 	if err != nil {
-		s.logger.WithError(err).Error("更新旧密钥对状态失败")
+		return nil, fmt.Errorf("生成新密钥对失败: %w", err)
 	}
 
 	s.logger.WithFields(logrus.Fields{
@@ -387,7 +688,237 @@ func (s *DKIMService) RotateKeyPair(userID, keyPairID primitive.ObjectID) (*mode
 		"domain":       oldKeyPair.Domain,
 		"old_selector": oldKeyPair.Selector,
 		"new_selector": newSelector,
-	}).Info("DKIM密钥对轮换成功")
+	}).Info("DKIM密钥对轮换已开始，等待新选择器DNS记录生效")
 
 	return newKeyPair, nil
 }
+
+// ActivateKeyPair 新密钥DNS生效后切换为签名密钥，被替换的旧密钥进入宽限期
+func (s *DKIMService) ActivateKeyPair(keyPair *models.DKIMKeyPair) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("dkim_keys")
+	now := time.Now()
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": keyPair.ID, "status": models.DKIMKeyStatusPending},
+		bson.M{"$set": bson.M{
+			"status":        models.DKIMKeyStatusActive,
+			"dns_verified":  true,
+			"last_verified": now,
+			"updated_at":    now,
+		}},
+	)
+	if err != nil {
+		return fmt.Errorf("启用新密钥对失败: %w", err)
+	}
+
+	if keyPair.RotatedFromID != nil {
+		expiresAt := now.Add(s.rotationGracePeriod(ctx, keyPair.UserID, keyPair.Domain))
+		_, err = collection.UpdateOne(ctx,
+			bson.M{"_id": *keyPair.RotatedFromID, "status": models.DKIMKeyStatusActive},
+			bson.M{"$set": bson.M{
+				"status":     models.DKIMKeyStatusExpiring,
+				"expires_at": expiresAt,
+				"updated_at": now,
+			}},
+		)
+		if err != nil {
+			return fmt.Errorf("更新旧密钥对状态失败: %w", err)
+		}
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":  keyPair.UserID.Hex(),
+		"key_id":   keyPair.ID.Hex(),
+		"domain":   keyPair.Domain,
+		"selector": keyPair.Selector,
+	}).Info("新DKIM密钥DNS已生效，签名已切换")
+
+	return nil
+}
+
+// rotationGracePeriod 获取域名轮换策略中的旧密钥宽限期，未配置时使用默认值
+func (s *DKIMService) rotationGracePeriod(ctx context.Context, userID primitive.ObjectID, domain string) time.Duration {
+	var policy models.DKIMRotationPolicy
+	err := s.db.GetCollection("dkim_rotation_policies").FindOne(ctx, bson.M{
+		"user_id": userID,
+		"domain":  domain,
+	}).Decode(&policy)
+	if err != nil || policy.GracePeriodDays <= 0 {
+		return DefaultDKIMGracePeriod
+	}
+	return time.Duration(policy.GracePeriodDays) * 24 * time.Hour
+}
+
+// isUnixTimestamp 判断轮换生成的选择器后缀是否为时间戳
+func isUnixTimestamp(value string) bool {
+	if len(value) < 9 {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FindSigningKey 查找域名当前可用于签名的RSA密钥（优先已验证DNS的最新密钥），未配置时返回nil
+func (s *DKIMService) FindSigningKey(userID primitive.ObjectID, domain string) (*models.DKIMKeyPair, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("dkim_keys")
+	filter := bson.M{
+		"user_id":   userID,
+		"domain":    strings.ToLower(domain),
+		"status":    "active",
+		"algorithm": mailauth.AlgorithmRSASHA256,
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "dns_verified", Value: -1}, {Key: "created_at", Value: -1}})
+
+	var keyPair models.DKIMKeyPair
+	if err := collection.FindOne(ctx, filter, opts).Decode(&keyPair); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("查询DKIM签名密钥失败: %w", err)
+	}
+
+	return &keyPair, nil
+}
+
+// LoadSigner 解密并解析密钥对的私钥
+func (s *DKIMService) LoadSigner(keyPair *models.DKIMKeyPair) (crypto.Signer, error) {
+	keyPEM, err := s.encryptor.DecryptString(keyPair.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("解密DKIM私钥失败: %w", err)
+	}
+
+	signer, err := mailauth.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("解析DKIM私钥失败: %w", err)
+	}
+
+	return signer, nil
+}
+
+// FindSigningKeys 查找域名每种签名算法当前可用的密钥（RSA与Ed25519同时存在时进行双重签名）
+func (s *DKIMService) FindSigningKeys(userID primitive.ObjectID, domain string) ([]*models.DKIMKeyPair, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("dkim_keys")
+	filter := bson.M{
+		"user_id": userID,
+		"domain":  strings.ToLower(domain),
+		"status":  "active",
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "dns_verified", Value: -1}, {Key: "created_at", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("查询DKIM签名密钥失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var keyPairs []*models.DKIMKeyPair
+	if err := cursor.All(ctx, &keyPairs); err != nil {
+		return nil, fmt.Errorf("解析DKIM签名密钥失败: %w", err)
+	}
+
+	// 每种算法只取排序后的第一个密钥
+	seen := make(map[string]bool)
+	var signingKeys []*models.DKIMKeyPair
+	for _, keyPair := range keyPairs {
+		if seen[keyPair.Algorithm] {
+			continue
+		}
+		seen[keyPair.Algorithm] = true
+		signingKeys = append(signingKeys, keyPair)
+	}
+
+	return signingKeys, nil
+}
+
+// SignMessage 按发件域名的DKIM设置对邮件签名，返回签名后的邮件及使用的密钥，未启用或未配置密钥时原样返回
+func (s *DKIMService) SignMessage(userID primitive.ObjectID, from string, message []byte) ([]byte, []*models.DKIMKeyPair, error) {
+	at := strings.LastIndex(from, "@")
+	if at < 0 {
+		return message, nil, nil
+	}
+
+	settings, err := s.signingConfig(userID, from[at+1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	if !settings.Enabled {
+		return message, nil, nil
+	}
+
+	var oversign []string
+	if settings.OversignFrom {
+		oversign = []string{"From"}
+	}
+
+	var used []*models.DKIMKeyPair
+	for _, domain := range settings.SigningDomains {
+		keyPairs, err := s.FindSigningKeys(userID, domain)
+		if err != nil {
+			return nil, nil, err
+		}
+		keyPairs, err = s.applyDefaultKey(userID, keyPairs, domain, settings.DefaultKeyID)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, keyPair := range keyPairs {
+			signer, err := s.LoadSigner(keyPair)
+			if err != nil {
+				return nil, nil, err
+			}
+			message, err = mailauth.SignDKIM(message, &mailauth.DKIMSignOptions{
+				Domain:        keyPair.Domain,
+				Selector:      keyPair.Selector,
+				Signer:        signer,
+				HeaderCanon:   settings.HeaderCanon,
+				BodyCanon:     settings.BodyCanon,
+				SignedHeaders: settings.SignHeaders,
+				Oversign:      oversign,
+			})
+			if err != nil {
+				return nil, nil, fmt.Errorf("DKIM签名失败（选择器 %s）: %w", keyPair.Selector, err)
+			}
+			used = append(used, keyPair)
+		}
+	}
+
+	return message, used, nil
+}
+
+// applyDefaultKey 用配置的默认密钥替换同算法的自动选择结果（默认密钥须属于该签名域名且仍有效）
+func (s *DKIMService) applyDefaultKey(userID primitive.ObjectID, keyPairs []*models.DKIMKeyPair, domain string, defaultKeyID *primitive.ObjectID) ([]*models.DKIMKeyPair, error) {
+	if defaultKeyID == nil {
+		return keyPairs, nil
+	}
+
+	defaultKey, err := s.GetKeyPair(userID, *defaultKeyID)
+	if err != nil {
+		if err.Error() == "DKIM密钥对不存在" {
+			return keyPairs, nil
+		}
+		return nil, err
+	}
+	if defaultKey.Domain != domain || defaultKey.Status != models.DKIMKeyStatusActive {
+		return keyPairs, nil
+	}
+
+	result := []*models.DKIMKeyPair{defaultKey}
+	for _, keyPair := range keyPairs {
+		if keyPair.Algorithm != defaultKey.Algorithm {
+			result = append(result, keyPair)
+		}
+	}
+	return result, nil
+}
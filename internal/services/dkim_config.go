@@ -0,0 +1,288 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"smtp-relay/internal/mailauth"
+	"smtp-relay/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// headerNamePattern 合法的头部字段名（RFC 5322 ftext）
+var headerNamePattern = regexp.MustCompile(`^[!-9;-~]+$`)
+
+// DefaultDKIMSettings 未配置域名DKIM设置时使用的默认值
+func DefaultDKIMSettings(domain string) models.DKIMSettings {
+	return models.DKIMSettings{
+		Enabled:        true,
+		SigningDomains: []string{domain},
+		HeaderCanon:    mailauth.CanonRelaxed,
+		BodyCanon:      mailauth.CanonRelaxed,
+		SignHeaders:    mailauth.DefaultDKIMSignedHeaders,
+	}
+}
+
+// ListConfigs 获取用户的域名DKIM配置列表
+func (s *DKIMService) ListConfigs(userID primitive.ObjectID) ([]*models.DKIMConfig, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("dkim_configs").Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "domain", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询DKIM配置失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	configs := []*models.DKIMConfig{}
+	if err := cursor.All(ctx, &configs); err != nil {
+		return nil, fmt.Errorf("解析DKIM配置失败: %w", err)
+	}
+	return configs, nil
+}
+
+// GetConfig 获取指定域名的DKIM配置
+func (s *DKIMService) GetConfig(userID primitive.ObjectID, domain string) (*models.DKIMConfig, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var config models.DKIMConfig
+	err = s.db.GetCollection("dkim_configs").FindOne(ctx, bson.M{"user_id": userID, "domain": domain}).Decode(&config)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("DKIM配置不存在")
+		}
+		return nil, fmt.Errorf("获取DKIM配置失败: %w", err)
+	}
+	return &config, nil
+}
+
+// CreateConfig 创建域名DKIM配置
+func (s *DKIMService) CreateConfig(userID primitive.ObjectID, domain string, settings models.DKIMSettings, active bool) (*models.DKIMConfig, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateSettings(userID, domain, &settings); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	config := &models.DKIMConfig{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Domain:    domain,
+		Settings:  settings,
+		Active:    active,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if settings.DefaultKeyID != nil {
+		config.KeyPairID = *settings.DefaultKeyID
+	}
+
+	if _, err := s.db.GetCollection("dkim_configs").InsertOne(ctx, config); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("该域名的DKIM配置已存在")
+		}
+		return nil, fmt.Errorf("保存DKIM配置失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID.Hex(),
+		"domain":  domain,
+	}).Info("DKIM配置创建成功")
+
+	return config, nil
+}
+
+// UpdateConfig 更新域名DKIM配置
+func (s *DKIMService) UpdateConfig(userID primitive.ObjectID, domain string, settings models.DKIMSettings, active bool) (*models.DKIMConfig, error) {
+	config, err := s.GetConfig(userID, domain)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateSettings(userID, config.Domain, &settings); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var keyPairID primitive.ObjectID
+	if settings.DefaultKeyID != nil {
+		keyPairID = *settings.DefaultKeyID
+	}
+
+	_, err = s.db.GetCollection("dkim_configs").UpdateOne(ctx,
+		bson.M{"_id": config.ID},
+		bson.M{"$set": bson.M{
+			"settings":    settings,
+			"key_pair_id": keyPairID,
+			"active":      active,
+			"updated_at":  time.Now(),
+		}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("更新DKIM配置失败: %w", err)
+	}
+
+	return s.GetConfig(userID, config.Domain)
+}
+
+// DeleteConfig 删除域名DKIM配置（删除后恢复默认签名设置）
+func (s *DKIMService) DeleteConfig(userID primitive.ObjectID, domain string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("dkim_configs").DeleteOne(ctx, bson.M{"user_id": userID, "domain": domain})
+	if err != nil {
+		return fmt.Errorf("删除DKIM配置失败: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("DKIM配置不存在")
+	}
+	return nil
+}
+
+// validateSettings 校验并补全DKIM设置
+func (s *DKIMService) validateSettings(userID primitive.ObjectID, domain string, settings *models.DKIMSettings) error {
+	// 规范化算法
+	for _, canon := range []*string{&settings.HeaderCanon, &settings.BodyCanon} {
+		*canon = strings.ToLower(strings.TrimSpace(*canon))
+		if *canon == "" {
+			*canon = mailauth.CanonRelaxed
+		}
+		if *canon != mailauth.CanonRelaxed && *canon != mailauth.CanonSimple {
+			return fmt.Errorf("规范化算法必须是relaxed或simple")
+		}
+	}
+
+	// 签名头部
+	if len(settings.SignHeaders) == 0 {
+		settings.SignHeaders = mailauth.DefaultDKIMSignedHeaders
+	}
+	hasFrom := false
+	for i, name := range settings.SignHeaders {
+		name = strings.TrimSpace(name)
+		if !headerNamePattern.MatchString(name) {
+			return fmt.Errorf("无效的头部名称: %q", name)
+		}
+		if strings.EqualFold(name, "DKIM-Signature") || strings.HasPrefix(strings.ToLower(name), "arc-") {
+			return fmt.Errorf("不能签名 %s 头部", name)
+		}
+		if strings.EqualFold(name, "From") {
+			if hasFrom {
+				return fmt.Errorf("From头部重复，请使用oversign_from进行过度签名")
+			}
+			hasFrom = true
+		}
+		settings.SignHeaders[i] = name
+	}
+	if !hasFrom {
+		return fmt.Errorf("签名头部必须包含From")
+	}
+
+	// 签名域名须为该域名或其上级域名（DMARC宽松对齐）
+	if len(settings.SigningDomains) == 0 {
+		settings.SigningDomains = []string{domain}
+	}
+	seen := make(map[string]bool)
+	signingDomains := make([]string, 0, len(settings.SigningDomains))
+	for _, signingDomain := range settings.SigningDomains {
+		signingDomain, err := normalizeDomain(signingDomain)
+		if err != nil {
+			return err
+		}
+		if signingDomain != domain && !strings.HasSuffix(domain, "."+signingDomain) {
+			return fmt.Errorf("签名域名必须是该域名或其上级域名: %s", signingDomain)
+		}
+		if !seen[signingDomain] {
+			seen[signingDomain] = true
+			signingDomains = append(signingDomains, signingDomain)
+		}
+	}
+	settings.SigningDomains = signingDomains
+
+	// 默认密钥须属于签名域名
+	if settings.DefaultKeyID != nil {
+		keyPair, err := s.GetKeyPair(userID, *settings.DefaultKeyID)
+		if err != nil {
+			return err
+		}
+		if !seen[keyPair.Domain] {
+			return fmt.Errorf("默认密钥必须属于签名域名")
+		}
+	}
+
+	return nil
+}
+
+// signingConfig 获取发件域名生效的DKIM设置：优先本域名配置，其次上级域名配置，均未配置时使用默认值
+func (s *DKIMService) signingConfig(userID primitive.ObjectID, domain string) (models.DKIMSettings, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	domain = strings.ToLower(domain)
+	candidates := []string{domain}
+	for name := domain; strings.Contains(name, "."); {
+		name = name[strings.IndexByte(name, '.')+1:]
+		if strings.Contains(name, ".") {
+			candidates = append(candidates, name)
+		}
+	}
+
+	cursor, err := s.db.GetCollection("dkim_configs").Find(ctx, bson.M{
+		"user_id": userID,
+		"domain":  bson.M{"$in": candidates},
+		"active":  true,
+	})
+	if err != nil {
+		return models.DKIMSettings{}, fmt.Errorf("查询DKIM配置失败: %w", err)
+	}
+	var configs []*models.DKIMConfig
+	if err := cursor.All(ctx, &configs); err != nil {
+		return models.DKIMSettings{}, fmt.Errorf("解析DKIM配置失败: %w", err)
+	}
+
+	// 取最具体（最长）的域名配置
+	var best *models.DKIMConfig
+	for _, config := range configs {
+		if best == nil || len(config.Domain) > len(best.Domain) {
+			best = config
+		}
+	}
+	if best == nil {
+		return DefaultDKIMSettings(domain), nil
+	}
+
+	settings := best.Settings
+	if len(settings.SigningDomains) == 0 {
+		settings.SigningDomains = []string{best.Domain}
+	}
+	return settings, nil
+}
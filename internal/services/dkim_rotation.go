@@ -0,0 +1,354 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DKIMRotationService DKIM密钥轮换调度服务：定期验证DNS、切换新密钥、过期旧密钥并按策略自动轮换
+type DKIMRotationService struct {
+	db                  *database.MongoDB
+	dkimService         *DKIMService
+	notificationService *NotificationService
+	logger              *logrus.Logger
+	stopChan            chan struct{}
+}
+
+// NewDKIMRotationService 创建DKIM密钥轮换调度服务实例
+func NewDKIMRotationService(db *database.MongoDB, dkimService *DKIMService, notificationService *NotificationService, logger *logrus.Logger) *DKIMRotationService {
+	return &DKIMRotationService{
+		db:                  db,
+		dkimService:         dkimService,
+		notificationService: notificationService,
+		logger:              logger,
+		stopChan:            make(chan struct{}),
+	}
+}
+
+// ListPolicies 获取用户的轮换策略列表
+func (s *DKIMRotationService) ListPolicies(userID primitive.ObjectID) ([]*models.DKIMRotationPolicy, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("dkim_rotation_policies").Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "domain", Value: 1}}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("查询轮换策略失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	policies := []*models.DKIMRotationPolicy{}
+	if err := cursor.All(ctx, &policies); err != nil {
+		return nil, fmt.Errorf("解析轮换策略失败: %w", err)
+	}
+	return policies, nil
+}
+
+// SetPolicy 创建或更新域名的轮换策略，gracePeriodDays为0时使用默认宽限期（不超过轮换周期的一半）
+func (s *DKIMRotationService) SetPolicy(userID primitive.ObjectID, domain string, enabled bool, intervalDays, gracePeriodDays int) (*models.DKIMRotationPolicy, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+	if gracePeriodDays == 0 {
+		gracePeriodDays = int(DefaultDKIMGracePeriod / (24 * time.Hour))
+		if gracePeriodDays > intervalDays/2 {
+			gracePeriodDays = intervalDays / 2
+		}
+	}
+	if intervalDays < 7 || intervalDays > 730 {
+		return nil, fmt.Errorf("轮换周期必须在7-730天之间")
+	}
+	if gracePeriodDays < 1 || gracePeriodDays > 90 {
+		return nil, fmt.Errorf("宽限期必须在1-90天之间")
+	}
+	if gracePeriodDays >= intervalDays {
+		return nil, fmt.Errorf("宽限期必须小于轮换周期")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var policy models.DKIMRotationPolicy
+	err = s.db.GetCollection("dkim_rotation_policies").FindOneAndUpdate(ctx,
+		bson.M{"user_id": userID, "domain": domain},
+		bson.M{
+			"$set": bson.M{
+				"enabled":           enabled,
+				"interval_days":     intervalDays,
+				"grace_period_days": gracePeriodDays,
+				"updated_at":        now,
+			},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&policy)
+	if err != nil {
+		return nil, fmt.Errorf("保存轮换策略失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID.Hex(),
+		"domain":        domain,
+		"enabled":       enabled,
+		"interval_days": intervalDays,
+	}).Info("DKIM轮换策略已更新")
+
+	return &policy, nil
+}
+
+// DeletePolicy 删除域名的轮换策略
+func (s *DKIMRotationService) DeletePolicy(userID primitive.ObjectID, domain string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("dkim_rotation_policies").DeleteOne(ctx, bson.M{"user_id": userID, "domain": domain})
+	if err != nil {
+		return fmt.Errorf("删除轮换策略失败: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("轮换策略不存在")
+	}
+	return nil
+}
+
+// Start 启动轮换调度协程
+func (s *DKIMRotationService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.run()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithField("interval", interval.String()).Info("DKIM密钥轮换调度协程已启动")
+}
+
+// Stop 停止轮换调度协程
+func (s *DKIMRotationService) Stop() {
+	close(s.stopChan)
+}
+
+// run 执行一轮调度：先处理已有密钥的状态，再按策略发起新的轮换
+func (s *DKIMRotationService) run() {
+	s.activatePending()
+	s.reverifyActive()
+	s.expireKeys()
+	s.applyPolicies()
+}
+
+// activatePending 检查等待DNS生效的新密钥，生效后切换签名
+func (s *DKIMRotationService) activatePending() {
+	keyPairs, err := s.findKeys(bson.M{"status": models.DKIMKeyStatusPending})
+	if err != nil {
+		s.logger.WithError(err).Error("查询待生效DKIM密钥失败")
+		return
+	}
+
+	for _, keyPair := range keyPairs {
+		published, err := s.dkimService.checkPublishedKey(keyPair)
+		if err != nil {
+			s.logger.WithError(err).WithField("key_id", keyPair.ID.Hex()).Warn("查询DKIM DNS记录失败，下次重试")
+			continue
+		}
+		if !published {
+			continue
+		}
+
+		if err := s.dkimService.ActivateKeyPair(keyPair); err != nil {
+			s.logger.WithError(err).WithField("key_id", keyPair.ID.Hex()).Error("切换DKIM签名密钥失败")
+			continue
+		}
+		s.notify(keyPair, "dkim_rotation_completed", models.NotificationInfo,
+			"DKIM签名已切换到新选择器",
+			fmt.Sprintf("域名 %s 已开始使用选择器 %s 签名，旧选择器的DNS记录请在宽限期结束前保留。", keyPair.Domain, keyPair.Selector))
+	}
+}
+
+// reverifyActive 定期重新验证有效密钥的DNS记录（NeedsVerification）
+func (s *DKIMRotationService) reverifyActive() {
+	keyPairs, err := s.findKeys(bson.M{"status": models.DKIMKeyStatusActive})
+	if err != nil {
+		s.logger.WithError(err).Error("查询有效DKIM密钥失败")
+		return
+	}
+
+	for _, keyPair := range keyPairs {
+		if !keyPair.NeedsVerification() {
+			continue
+		}
+
+		published, err := s.dkimService.checkPublishedKey(keyPair)
+		if err != nil {
+			s.logger.WithError(err).WithField("key_id", keyPair.ID.Hex()).Warn("查询DKIM DNS记录失败，下次重试")
+			continue
+		}
+		if err := s.dkimService.updateVerificationStatus(keyPair.ID, published, time.Now()); err != nil {
+			s.logger.WithError(err).WithField("key_id", keyPair.ID.Hex()).Error("更新DKIM验证状态失败")
+			continue
+		}
+		// 仅在记录从有效变为缺失时提醒
+		if !published && keyPair.DNSVerified {
+			s.notify(keyPair, "dkim_dns_missing", models.NotificationWarning,
+				"DKIM DNS记录缺失或不匹配",
+				fmt.Sprintf("域名 %s 的DKIM选择器 %s 在DNS中未找到匹配的公钥，接收方将无法验证签名。请添加TXT记录 %s %s。",
+					keyPair.Domain, keyPair.Selector, keyPair.GetDNSRecordName(), keyPair.GetDNSRecordZoneValue()))
+		}
+	}
+}
+
+// expireKeys 将宽限期已结束的旧密钥标记为过期
+func (s *DKIMRotationService) expireKeys() {
+	keyPairs, err := s.findKeys(bson.M{
+		"status":     models.DKIMKeyStatusExpiring,
+		"expires_at": bson.M{"$lte": time.Now()},
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("查询待过期DKIM密钥失败")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	for _, keyPair := range keyPairs {
+		_, err := s.db.GetCollection("dkim_keys").UpdateOne(ctx,
+			bson.M{"_id": keyPair.ID, "status": models.DKIMKeyStatusExpiring},
+			bson.M{"$set": bson.M{"status": models.DKIMKeyStatusExpired, "updated_at": time.Now()}},
+		)
+		if err != nil {
+			s.logger.WithError(err).WithField("key_id", keyPair.ID.Hex()).Error("标记DKIM密钥过期失败")
+			continue
+		}
+		s.notify(keyPair, "dkim_key_expired", models.NotificationInfo,
+			"旧DKIM选择器已过期",
+			fmt.Sprintf("域名 %s 的DKIM选择器 %s 宽限期已结束，可以删除DNS记录 %s。", keyPair.Domain, keyPair.Selector, keyPair.GetDNSRecordName()))
+	}
+}
+
+// applyPolicies 对到期的域名按轮换策略发起轮换
+func (s *DKIMRotationService) applyPolicies() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("dkim_rotation_policies")
+	cursor, err := collection.Find(ctx, bson.M{"enabled": true})
+	if err != nil {
+		s.logger.WithError(err).Error("查询DKIM轮换策略失败")
+		return
+	}
+	var policies []*models.DKIMRotationPolicy
+	if err := cursor.All(ctx, &policies); err != nil {
+		s.logger.WithError(err).Error("解析DKIM轮换策略失败")
+		return
+	}
+
+	for _, policy := range policies {
+		keyPairs, err := s.dkimService.GetKeyPairsByDomain(policy.UserID, policy.Domain)
+		if err != nil {
+			s.logger.WithError(err).WithField("domain", policy.Domain).Error("查询域名DKIM密钥失败")
+			continue
+		}
+
+		interval := time.Duration(policy.IntervalDays) * 24 * time.Hour
+		rotated := false
+		for _, keyPair := range keyPairs {
+			if time.Since(keyPair.CreatedAt) < interval {
+				continue
+			}
+
+			newKeyPair, err := s.dkimService.RotateKeyPair(policy.UserID, keyPair.ID)
+			if err != nil {
+				if !errors.Is(err, ErrRotationPending) {
+					s.logger.WithError(err).WithFields(logrus.Fields{
+						"domain": policy.Domain,
+						"key_id": keyPair.ID.Hex(),
+					}).Error("自动轮换DKIM密钥失败")
+				}
+				continue
+			}
+			rotated = true
+			s.notify(newKeyPair, "dkim_dns_pending", models.NotificationWarning,
+				"请发布新的DKIM选择器DNS记录",
+				fmt.Sprintf("域名 %s 的DKIM密钥已按策略自动轮换，请添加TXT记录 %s %s，记录生效后将自动切换签名。",
+					newKeyPair.Domain, newKeyPair.GetDNSRecordName(), newKeyPair.GetDNSRecordZoneValue()))
+		}
+
+		if rotated {
+			if _, err := collection.UpdateOne(ctx,
+				bson.M{"_id": policy.ID},
+				bson.M{"$set": bson.M{"last_rotated_at": time.Now()}},
+			); err != nil {
+				s.logger.WithError(err).WithField("domain", policy.Domain).Warn("更新轮换时间失败")
+			}
+		}
+	}
+}
+
+// findKeys 查询符合条件的全部DKIM密钥
+func (s *DKIMRotationService) findKeys(filter bson.M) ([]*models.DKIMKeyPair, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("dkim_keys").Find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	var keyPairs []*models.DKIMKeyPair
+	if err := cursor.All(ctx, &keyPairs); err != nil {
+		return nil, err
+	}
+	return keyPairs, nil
+}
+
+// notify 发送与密钥相关的通知（同一密钥同类通知未读前不重复发送）
+func (s *DKIMRotationService) notify(keyPair *models.DKIMKeyPair, notificationType, level, title, message string) {
+	err := s.notificationService.Notify(&models.Notification{
+		UserID:   keyPair.UserID,
+		Type:     notificationType,
+		Level:    level,
+		Title:    title,
+		Message:  message,
+		DedupKey: notificationType + ":" + keyPair.ID.Hex(),
+		Data: map[string]interface{}{
+			"key_pair_id": keyPair.ID.Hex(),
+			"domain":      keyPair.Domain,
+			"selector":    keyPair.Selector,
+			"dns_record": &models.DNSRecord{
+				Type:    "TXT",
+				Name:    keyPair.GetDNSRecordName(),
+				Value:   keyPair.GetDNSRecordValue(),
+				Strings: keyPair.GetDNSRecordStrings(),
+				TTL:     3600,
+			},
+		},
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("key_id", keyPair.ID.Hex()).Error("发送DKIM通知失败")
+	}
+}
@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/mailauth"
+	"smtp-relay/internal/models"
+)
+
+// domainSuspendThreshold 连续多少次重新验证找不到验证记录后暂停域名
+const domainSuspendThreshold = 3
+
+// DomainService 发件域名所有权验证服务
+type DomainService struct {
+	db       *database.MongoDB
+	resolver mailauth.Resolver
+	logger   *logrus.Logger
+	stopChan chan struct{}
+}
+
+// NewDomainService 创建发件域名服务
+func NewDomainService(db *database.MongoDB, resolver mailauth.Resolver, logger *logrus.Logger) *DomainService {
+	return &DomainService{
+		db:       db,
+		resolver: resolver,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// ClaimDomain 声明域名并生成TXT验证令牌
+func (s *DomainService) ClaimDomain(userID primitive.ObjectID, domain string) (*models.Domain, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("domains")
+	count, err := collection.CountDocuments(ctx, bson.M{"user_id": userID, "domain": domain})
+	if err != nil {
+		return nil, fmt.Errorf("检查域名失败: %w", err)
+	}
+	if count > 0 {
+		return nil, fmt.Errorf("该域名已添加")
+	}
+
+	// 套餐限制域名数量
+	plan, err := userPlanByID(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil && plan.MaxDomains > 0 {
+		total, err := collection.CountDocuments(ctx, bson.M{"user_id": userID})
+		if err != nil {
+			return nil, fmt.Errorf("检查域名失败: %w", err)
+		}
+		if total >= int64(plan.MaxDomains) {
+			return nil, fmt.Errorf("域名数量已达到套餐上限")
+		}
+	}
+
+	owned, err := s.verifiedByOther(ctx, userID, domain)
+	if err != nil {
+		return nil, err
+	}
+	if owned {
+		return nil, fmt.Errorf("该域名已被其他用户验证")
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("生成验证令牌失败: %w", err)
+	}
+
+	now := time.Now()
+	record := &models.Domain{
+		ID:                primitive.NewObjectID(),
+		UserID:            userID,
+		Domain:            domain,
+		Status:            models.DomainStatusPending,
+		VerificationToken: hex.EncodeToString(token),
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if _, err := collection.InsertOne(ctx, record); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("该域名已添加")
+		}
+		return nil, fmt.Errorf("保存域名失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID.Hex(),
+		"domain":  domain,
+	}).Info("域名声明成功")
+
+	return record, nil
+}
+
+// ListDomains 获取用户的域名列表
+func (s *DomainService) ListDomains(userID primitive.ObjectID) ([]*models.Domain, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("domains").Find(ctx, bson.M{"user_id": userID})
+	if err != nil {
+		return nil, fmt.Errorf("查询域名失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	domains := []*models.Domain{}
+	if err := cursor.All(ctx, &domains); err != nil {
+		return nil, fmt.Errorf("解析域名失败: %w", err)
+	}
+
+	return domains, nil
+}
+
+// GetDomain 获取用户的指定域名
+func (s *DomainService) GetDomain(userID primitive.ObjectID, domain string) (*models.Domain, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var record models.Domain
+	err := s.db.GetCollection("domains").FindOne(ctx, bson.M{
+		"user_id": userID,
+		"domain":  strings.ToLower(strings.TrimSuffix(domain, ".")),
+	}).Decode(&record)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("域名不存在")
+		}
+		return nil, fmt.Errorf("获取域名失败: %w", err)
+	}
+
+	return &record, nil
+}
+
+// DeleteDomain 删除域名声明
+func (s *DomainService) DeleteDomain(userID primitive.ObjectID, domain string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("domains").DeleteOne(ctx, bson.M{
+		"user_id": userID,
+		"domain":  strings.ToLower(strings.TrimSuffix(domain, ".")),
+	})
+	if err != nil {
+		return fmt.Errorf("删除域名失败: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("域名不存在")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID.Hex(),
+		"domain":  domain,
+	}).Info("域名删除成功")
+
+	return nil
+}
+
+// VerifyDomain 检查TXT验证记录并标记域名为已验证
+func (s *DomainService) VerifyDomain(userID primitive.ObjectID, domain string) (*models.Domain, error) {
+	record, err := s.GetDomain(userID, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	found, err := s.lookupToken(ctx, record)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("未找到验证TXT记录")
+	}
+
+	owned, err := s.verifiedByOther(ctx, userID, record.Domain)
+	if err != nil {
+		return nil, err
+	}
+	if owned {
+		return nil, fmt.Errorf("该域名已被其他用户验证")
+	}
+
+	now := time.Now()
+	set := bson.M{
+		"status":          models.DomainStatusVerified,
+		"failed_checks":   0,
+		"last_checked_at": now,
+		"updated_at":      now,
+	}
+	if record.VerifiedAt == nil {
+		set["verified_at"] = now
+	}
+
+	_, err = s.db.GetCollection("domains").UpdateOne(ctx,
+		bson.M{"_id": record.ID},
+		bson.M{"$set": set, "$unset": bson.M{"suspended_at": ""}},
+	)
+	if err != nil {
+		// 唯一索引保证同一域名只能被一个用户验证
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("该域名已被其他用户验证")
+		}
+		return nil, fmt.Errorf("更新域名状态失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID.Hex(),
+		"domain":  record.Domain,
+	}).Info("域名验证成功")
+
+	return s.GetDomain(userID, record.Domain)
+}
+
+// UpdateBounceDomain 设置域名的退信域名（须为该域名本身或其子域名），为空时清除
+func (s *DomainService) UpdateBounceDomain(userID primitive.ObjectID, domain, bounceDomain string) (*models.Domain, error) {
+	record, err := s.GetDomain(userID, domain)
+	if err != nil {
+		return nil, err
+	}
+	if !record.IsVerified() {
+		return nil, fmt.Errorf("域名未验证")
+	}
+
+	if bounceDomain != "" {
+		bounceDomain, err = normalizeDomain(bounceDomain)
+		if err != nil {
+			return nil, err
+		}
+		if bounceDomain != record.Domain && !strings.HasSuffix(bounceDomain, "."+record.Domain) {
+			return nil, fmt.Errorf("退信域名必须是该域名或其子域名")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err = s.db.GetCollection("domains").UpdateOne(ctx,
+		bson.M{"_id": record.ID},
+		bson.M{"$set": bson.M{"bounce_domain": bounceDomain, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("更新退信域名失败: %w", err)
+	}
+
+	record.BounceDomain = bounceDomain
+	return record, nil
+}
+
+// RequireVerifiedDomain 检查域名（或其上级域名）已被该用户验证
+func (s *DomainService) RequireVerifiedDomain(userID primitive.ObjectID, domain string) error {
+	record, err := s.findVerified(userID, domain)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		return fmt.Errorf("域名未验证")
+	}
+	return nil
+}
+
+// BounceAddress 返回投递时使用的信封发件人：域名配置了退信域名时使用VERP地址，否则使用原发件人
+func (s *DomainService) BounceAddress(userID primitive.ObjectID, from string, mailLogID primitive.ObjectID) (string, error) {
+	at := strings.LastIndex(from, "@")
+	if at < 0 {
+		return from, nil
+	}
+
+	record, err := s.findVerified(userID, from[at+1:])
+	if err != nil {
+		return "", err
+	}
+	if record == nil || record.BounceDomain == "" {
+		return from, nil
+	}
+	return fmt.Sprintf("bounce+%s@%s", mailLogID.Hex(), record.BounceDomain), nil
+}
+
+// StartVerifier 启动定期重新验证协程
+func (s *DomainService) StartVerifier(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.reverifyAll()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithField("interval", interval.String()).Info("域名重新验证协程已启动")
+}
+
+// Stop 停止定期重新验证协程
+func (s *DomainService) Stop() {
+	close(s.stopChan)
+}
+
+// reverifyAll 重新验证所有已验证和已暂停的域名
+func (s *DomainService) reverifyAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("domains").Find(ctx, bson.M{
+		"status": bson.M{"$in": bson.A{models.DomainStatusVerified, models.DomainStatusSuspended}},
+	})
+	if err != nil {
+		s.logger.WithError(err).Error("查询待重新验证的域名失败")
+		return
+	}
+	var records []*models.Domain
+	err = cursor.All(ctx, &records)
+	cursor.Close(ctx)
+	if err != nil {
+		s.logger.WithError(err).Error("解析待重新验证的域名失败")
+		return
+	}
+
+	for _, record := range records {
+		s.reverify(record)
+	}
+}
+
+// reverify 重新验证单个域名：连续多次找不到验证记录时暂停，记录恢复后重新启用
+func (s *DomainService) reverify(record *models.Domain) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	logger := s.logger.WithFields(logrus.Fields{
+		"user_id": record.UserID.Hex(),
+		"domain":  record.Domain,
+	})
+
+	found, err := s.lookupToken(ctx, record)
+	if err != nil {
+		// DNS临时故障不计入失败次数
+		logger.WithError(err).Warn("重新验证域名时DNS查询失败")
+		return
+	}
+
+	now := time.Now()
+	collection := s.db.GetCollection("domains")
+
+	if found {
+		set := bson.M{"failed_checks": 0, "last_checked_at": now, "updated_at": now}
+		if record.Status == models.DomainStatusSuspended {
+			set["status"] = models.DomainStatusVerified
+		}
+		_, err := collection.UpdateOne(ctx,
+			bson.M{"_id": record.ID},
+			bson.M{"$set": set, "$unset": bson.M{"suspended_at": ""}},
+		)
+		switch {
+		case mongo.IsDuplicateKeyError(err):
+			logger.Warn("域名已被其他用户验证，无法恢复")
+		case err != nil:
+			logger.WithError(err).Error("更新域名验证状态失败")
+		case record.Status == models.DomainStatusSuspended:
+			logger.Info("验证记录已恢复，域名重新启用")
+		}
+		return
+	}
+
+	failedChecks := record.FailedChecks + 1
+	set := bson.M{"failed_checks": failedChecks, "last_checked_at": now, "updated_at": now}
+	suspend := record.Status == models.DomainStatusVerified && failedChecks >= domainSuspendThreshold
+	if suspend {
+		set["status"] = models.DomainStatusSuspended
+		set["suspended_at"] = now
+	}
+
+	if _, err := collection.UpdateOne(ctx, bson.M{"_id": record.ID}, bson.M{"$set": set}); err != nil {
+		logger.WithError(err).Error("更新域名验证状态失败")
+		return
+	}
+
+	if suspend {
+		logger.WithField("failed_checks", failedChecks).Warn("验证记录已消失，域名已暂停")
+	} else {
+		logger.WithField("failed_checks", failedChecks).Warn("未找到域名验证记录")
+	}
+}
+
+// lookupToken 查询验证TXT记录中是否包含域名的令牌
+func (s *DomainService) lookupToken(ctx context.Context, record *models.Domain) (bool, error) {
+	records, err := s.resolver.LookupTXT(ctx, record.VerificationRecordName())
+	if err != nil {
+		if mailauth.IsNotFound(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("查询验证记录失败: %w", err)
+	}
+
+	expected := models.DomainVerificationPrefix + record.VerificationToken
+	for _, value := range records {
+		if strings.TrimSpace(value) == expected {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifiedByOther 检查域名是否已被其他用户验证
+func (s *DomainService) verifiedByOther(ctx context.Context, userID primitive.ObjectID, domain string) (bool, error) {
+	count, err := s.db.GetCollection("domains").CountDocuments(ctx, bson.M{
+		"domain":  domain,
+		"user_id": bson.M{"$ne": userID},
+		"status":  models.DomainStatusVerified,
+	})
+	if err != nil {
+		return false, fmt.Errorf("检查域名归属失败: %w", err)
+	}
+	return count > 0, nil
+}
+
+// findVerified 查找覆盖该域名的已验证域名（域名本身优先，其次为上级域名）
+func (s *DomainService) findVerified(userID primitive.ObjectID, domain string) (*models.Domain, error) {
+	domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	labels := strings.Split(domain, ".")
+	var candidates bson.A
+	for i := 0; i < len(labels)-1; i++ {
+		candidates = append(candidates, strings.Join(labels[i:], "."))
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("domains").Find(ctx, bson.M{
+		"user_id": userID,
+		"domain":  bson.M{"$in": candidates},
+		"status":  models.DomainStatusVerified,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询已验证域名失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []*models.Domain
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("解析已验证域名失败: %w", err)
+	}
+
+	var matched *models.Domain
+	for _, record := range records {
+		if matched == nil || len(record.Domain) > len(matched.Domain) {
+			matched = record
+		}
+	}
+	return matched, nil
+}
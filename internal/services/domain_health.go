@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"smtp-relay/internal/mailauth"
+	"smtp-relay/internal/models"
+)
+
+// DomainHealthService 域名认证健康检查服务（SPF、DKIM、DMARC、MX、正反向DNS）
+type DomainHealthService struct {
+	dkimService *DKIMService
+	resolver    mailauth.Resolver
+	relayIPs    []string
+	relayDomain string
+	logger      *logrus.Logger
+}
+
+// NewDomainHealthService 创建域名健康检查服务，relayIPs为空时通过relayDomain解析中继IP
+func NewDomainHealthService(dkimService *DKIMService, resolver mailauth.Resolver, relayIPs []string, relayDomain string, logger *logrus.Logger) *DomainHealthService {
+	return &DomainHealthService{
+		dkimService: dkimService,
+		resolver:    resolver,
+		relayIPs:    relayIPs,
+		relayDomain: relayDomain,
+		logger:      logger,
+	}
+}
+
+// Check 检查域名的邮件认证配置并生成报告
+func (s *DomainHealthService) Check(userID primitive.ObjectID, domain string) (*models.DomainHealthReport, error) {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	report := &models.DomainHealthReport{
+		Domain:    domain,
+		RelayIPs:  s.resolveRelayIPs(ctx),
+		CheckedAt: time.Now(),
+	}
+
+	report.SPF = s.checkSPF(ctx, domain, report.RelayIPs)
+	report.DKIM, err = s.checkDKIM(ctx, userID, domain)
+	if err != nil {
+		return nil, err
+	}
+	report.DMARC = s.checkDMARC(ctx, domain, report.SPF, report.DKIM)
+	report.MX = s.checkMX(ctx, domain)
+	report.ReverseDNS = s.checkReverseDNS(ctx, report.RelayIPs)
+
+	statuses := []string{report.SPF.Status, report.DMARC.Status, report.MX.Status}
+	for _, check := range report.DKIM {
+		statuses = append(statuses, check.Status)
+	}
+	for _, check := range report.ReverseDNS {
+		statuses = append(statuses, check.Status)
+	}
+	report.Status = worstStatus(statuses...)
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID.Hex(),
+		"domain":  domain,
+		"status":  report.Status,
+	}).Info("域名健康检查完成")
+
+	return report, nil
+}
+
+// resolveRelayIPs 获取中继出口IP列表
+func (s *DomainHealthService) resolveRelayIPs(ctx context.Context) []string {
+	if len(s.relayIPs) > 0 {
+		return s.relayIPs
+	}
+	if s.relayDomain == "" {
+		return nil
+	}
+
+	addrs, err := s.resolver.LookupIPAddr(ctx, s.relayDomain)
+	if err != nil {
+		s.logger.WithError(err).WithField("relay_domain", s.relayDomain).Warn("解析中继域名失败")
+		return nil
+	}
+
+	ips := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP.String())
+	}
+	return ips
+}
+
+// checkSPF 检查SPF记录是否授权所有中继IP及DNS查询次数
+func (s *DomainHealthService) checkSPF(ctx context.Context, domain string, relayIPs []string) models.SPFHealth {
+	health := models.SPFHealth{RelayResults: []models.SPFRelayResult{}}
+	health.Status = models.HealthPass
+	health.Message = "SPF记录已授权所有中继IP"
+
+	mechanisms := make([]string, 0, len(relayIPs))
+	for _, ip := range relayIPs {
+		if strings.Contains(ip, ":") {
+			mechanisms = append(mechanisms, "ip6:"+ip)
+		} else {
+			mechanisms = append(mechanisms, "ip4:"+ip)
+		}
+	}
+
+	var unauthorized []string
+	for _, ip := range relayIPs {
+		check := mailauth.CheckSPF(ctx, s.resolver, net.ParseIP(ip), domain, "", "")
+		health.Record = check.Record
+		health.RelayResults = append(health.RelayResults, models.SPFRelayResult{IP: ip, Result: check.Result})
+
+		switch check.Result {
+		case mailauth.SPFNone:
+			health.Status = models.HealthFail
+			health.Message = "未发布SPF记录"
+			health.Suggestions = []string{fmt.Sprintf("添加TXT记录: %s \"v=spf1 %s ~all\"", domain, strings.Join(mechanisms, " "))}
+			return health
+		case mailauth.SPFPermError, mailauth.SPFTempError:
+			health.Status = models.HealthFail
+			health.Message = "SPF记录评估出错: " + check.Reason
+			health.Suggestions = []string{"检查SPF记录语法，确保只发布一条以v=spf1开头的TXT记录"}
+			return health
+		case mailauth.SPFPass:
+		default:
+			unauthorized = append(unauthorized, ip)
+		}
+	}
+
+	if len(relayIPs) == 0 {
+		health.Status = models.HealthWarn
+		health.Message = "未配置中继IP，无法检查SPF授权"
+		health.Suggestions = append(health.Suggestions, "设置RELAY_IPS环境变量为中继出口IP列表")
+	} else if len(unauthorized) > 0 {
+		health.Status = models.HealthFail
+		health.Message = fmt.Sprintf("SPF记录未授权中继IP: %s", strings.Join(unauthorized, ", "))
+		for _, ip := range unauthorized {
+			mechanism := "ip4:" + ip
+			if strings.Contains(ip, ":") {
+				mechanism = "ip6:" + ip
+			}
+			health.Suggestions = append(health.Suggestions, fmt.Sprintf("在SPF记录的all机制之前添加 %s", mechanism))
+		}
+	}
+
+	lookups, err := mailauth.CountSPFLookups(ctx, s.resolver, domain)
+	if err != nil {
+		health.Status = worstStatus(health.Status, models.HealthWarn)
+		health.Suggestions = append(health.Suggestions, "无法统计SPF记录的DNS查询次数: "+err.Error())
+		return health
+	}
+	health.Lookups = lookups
+	if lookups > mailauth.MaxSPFLookups {
+		health.Status = models.HealthFail
+		health.Message = fmt.Sprintf("SPF记录需要%d次DNS查询，超过%d次上限", lookups, mailauth.MaxSPFLookups)
+		health.Suggestions = append(health.Suggestions, "减少include/a/mx/ptr/exists机制，或改用ip4/ip6直接列出地址")
+	}
+
+	record := strings.ToLower(health.Record)
+	if strings.Contains(record, "+all") || strings.HasSuffix(record, " all") {
+		health.Status = worstStatus(health.Status, models.HealthWarn)
+		health.Suggestions = append(health.Suggestions, "SPF记录以+all结尾会授权任何服务器，建议改为~all或-all")
+	}
+
+	return health
+}
+
+// checkDKIM 检查域名所有有效DKIM选择器的DNS记录
+func (s *DomainHealthService) checkDKIM(ctx context.Context, userID primitive.ObjectID, domain string) ([]models.DKIMSelectorHealth, error) {
+	keyPairs, err := s.dkimService.GetKeyPairsByDomain(userID, domain)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(keyPairs) == 0 {
+		health := models.DKIMSelectorHealth{}
+		health.Status = models.HealthFail
+		health.Message = "该域名没有有效的DKIM密钥"
+		health.Suggestions = []string{"通过 POST /api/v1/dkim/keys 为该域名生成DKIM密钥并发布DNS记录"}
+		return []models.DKIMSelectorHealth{health}, nil
+	}
+
+	results := make([]models.DKIMSelectorHealth, 0, len(keyPairs))
+	for _, keyPair := range keyPairs {
+		health := models.DKIMSelectorHealth{
+			Selector:  keyPair.Selector,
+			KeyPairID: keyPair.ID.Hex(),
+		}
+
+		published, err := mailauth.LookupPublicKey(ctx, s.resolver, domain, keyPair.Selector)
+		if err != nil {
+			health.Status = models.HealthFail
+			health.Message = "DKIM公钥记录无效或未发布: " + err.Error()
+			health.Suggestions = []string{fmt.Sprintf("添加TXT记录: %s %s", keyPair.GetDNSRecordName(), keyPair.GetDNSRecordZoneValue())}
+			results = append(results, health)
+			continue
+		}
+		health.Record = published.Raw
+
+		expected, err := mailauth.ParsePublicKeyRecord(keyPair.DNSRecord)
+		matched := err == nil
+		if matched {
+			key, ok := published.PublicKey.(interface{ Equal(x crypto.PublicKey) bool })
+			matched = ok && key.Equal(expected.PublicKey)
+		}
+		if !matched {
+			health.Status = models.HealthFail
+			health.Message = "DNS中发布的公钥与密钥对不一致"
+			health.Suggestions = []string{fmt.Sprintf("将 %s 的TXT记录更新为: %s", keyPair.GetDNSRecordName(), keyPair.GetDNSRecordZoneValue())}
+		} else {
+			health.Status = models.HealthPass
+			health.Message = "DKIM公钥记录与密钥对一致"
+		}
+		results = append(results, health)
+	}
+
+	return results, nil
+}
+
+// checkDMARC 检查DMARC策略及SPF/DKIM对齐情况
+func (s *DomainHealthService) checkDMARC(ctx context.Context, domain string, spf models.SPFHealth, dkim []models.DKIMSelectorHealth) models.DMARCHealth {
+	health := models.DMARCHealth{}
+
+	// 中继使用发件地址作为信封发件人并以发件域名签名，认证域名即为该域名
+	health.SPFAligned = spf.Status != models.HealthFail && len(spf.RelayResults) > 0
+	for _, check := range dkim {
+		if check.Status == models.HealthPass {
+			health.DKIMAligned = true
+			break
+		}
+	}
+
+	record, err := mailauth.LookupDMARC(ctx, s.resolver, domain)
+	if err != nil {
+		health.Status = models.HealthFail
+		health.Message = "DMARC记录无效: " + err.Error()
+		health.Suggestions = []string{"确保 _dmarc 下只发布一条以v=DMARC1开头的TXT记录"}
+		return health
+	}
+	if record == nil {
+		health.Status = models.HealthWarn
+		health.Message = "未发布DMARC记录"
+		health.Suggestions = []string{fmt.Sprintf("添加TXT记录: _dmarc.%s \"v=DMARC1; p=none; rua=mailto:dmarc@%s\"", domain, domain)}
+		return health
+	}
+
+	health.Record = record.Raw
+	health.RecordDomain = record.Domain
+	health.Policy = record.Policy
+	health.SubdomainPolicy = record.SubdomainPolicy
+	health.ADKIM = record.ADKIM
+	health.ASPF = record.ASPF
+	health.Percent = record.Percent
+	health.RUA = record.RUA
+
+	health.Status = models.HealthPass
+	health.Message = fmt.Sprintf("DMARC策略为%s", record.Policy)
+
+	if !health.SPFAligned && !health.DKIMAligned {
+		health.Status = models.HealthFail
+		health.Message = "SPF和DKIM均未通过，经中继发送的邮件无法通过DMARC"
+		health.Suggestions = append(health.Suggestions, "修复上方的SPF或DKIM问题，至少需要一项通过并对齐")
+	}
+	if record.Policy == "none" {
+		health.Status = worstStatus(health.Status, models.HealthWarn)
+		health.Suggestions = append(health.Suggestions, "确认报告无异常后，将策略逐步调整为p=quarantine或p=reject")
+	}
+	if record.Percent < 100 {
+		health.Status = worstStatus(health.Status, models.HealthWarn)
+		health.Suggestions = append(health.Suggestions, fmt.Sprintf("当前策略仅应用于%d%%的邮件，建议设置pct=100", record.Percent))
+	}
+	if len(record.RUA) == 0 {
+		health.Status = worstStatus(health.Status, models.HealthWarn)
+		health.Suggestions = append(health.Suggestions, "添加rua=mailto:地址以接收DMARC汇总报告")
+	}
+
+	return health
+}
+
+// checkMX 检查MX记录是否存在
+func (s *DomainHealthService) checkMX(ctx context.Context, domain string) models.MXHealth {
+	health := models.MXHealth{Hosts: []string{}}
+
+	records, err := s.resolver.LookupMX(ctx, domain)
+	if err != nil && !mailauth.IsNotFound(err) {
+		health.Status = models.HealthWarn
+		health.Message = "查询MX记录失败: " + err.Error()
+		return health
+	}
+
+	for _, record := range records {
+		health.Hosts = append(health.Hosts, strings.TrimSuffix(record.Host, "."))
+	}
+
+	switch {
+	case len(records) == 0:
+		health.Status = models.HealthWarn
+		health.Message = "未找到MX记录，退信和回复邮件将无法送达"
+		health.Suggestions = []string{fmt.Sprintf("为 %s 添加MX记录指向接收邮件的服务器", domain)}
+	case len(records) == 1 && (records[0].Host == "." || records[0].Host == ""):
+		health.Status = models.HealthWarn
+		health.Message = "域名发布了空MX记录（不接收邮件），退信和回复邮件将无法送达"
+	default:
+		health.Status = models.HealthPass
+		health.Message = "MX记录存在"
+	}
+
+	return health
+}
+
+// checkReverseDNS 检查中继IP的PTR记录是否能正向解析回该IP
+func (s *DomainHealthService) checkReverseDNS(ctx context.Context, relayIPs []string) []models.ReverseDNSHealth {
+	results := make([]models.ReverseDNSHealth, 0, len(relayIPs))
+	for _, ip := range relayIPs {
+		health := models.ReverseDNSHealth{IP: ip, Hostnames: []string{}}
+
+		names, err := s.resolver.LookupAddr(ctx, ip)
+		if err != nil || len(names) == 0 {
+			health.Status = models.HealthFail
+			health.Message = "中继IP没有PTR记录"
+			health.Suggestions = []string{fmt.Sprintf("联系IP提供商为 %s 设置PTR记录，指向中继主机名", ip)}
+			results = append(results, health)
+			continue
+		}
+
+		for _, name := range names {
+			name = strings.TrimSuffix(name, ".")
+			health.Hostnames = append(health.Hostnames, name)
+
+			addrs, err := s.resolver.LookupIPAddr(ctx, name)
+			if err != nil {
+				continue
+			}
+			for _, addr := range addrs {
+				if addr.IP.Equal(net.ParseIP(ip)) {
+					health.Confirmed = true
+				}
+			}
+		}
+
+		if health.Confirmed {
+			health.Status = models.HealthPass
+			health.Message = "PTR记录与正向解析一致"
+		} else {
+			health.Status = models.HealthFail
+			health.Message = "PTR主机名未正向解析回中继IP"
+			health.Suggestions = []string{fmt.Sprintf("为 %s 添加指向 %s 的A/AAAA记录", health.Hostnames[0], ip)}
+		}
+		results = append(results, health)
+	}
+
+	return results
+}
+
+// normalizeDomain 规范化并校验域名
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if len(domain) == 0 || len(domain) > 253 || !strings.Contains(domain, ".") {
+		return "", fmt.Errorf("无效的域名")
+	}
+	for _, label := range strings.Split(domain, ".") {
+		if len(label) == 0 || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return "", fmt.Errorf("无效的域名")
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+				return "", fmt.Errorf("无效的域名")
+			}
+		}
+	}
+	return domain, nil
+}
+
+// worstStatus 返回最差的检查状态
+func worstStatus(statuses ...string) string {
+	worst := models.HealthPass
+	for _, status := range statuses {
+		switch {
+		case status == models.HealthFail:
+			return models.HealthFail
+		case status == models.HealthWarn:
+			worst = models.HealthWarn
+		}
+	}
+	return worst
+}
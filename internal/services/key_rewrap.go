@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/archive"
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/models"
+)
+
+// rewrapBatchSize 每批重新包装的文档数量
+const rewrapBatchSize = 100
+
+// KeyRewrapService 主密钥轮换后的数据重新包装服务
+//
+// 扫描未使用当前主密钥加密的数据（包括历史明文数据），使用当前主密钥重新包装数据密钥。
+type KeyRewrapService struct {
+	db        *database.MongoDB
+	encryptor *encryption.Encryptor
+	store     archive.Store
+	logger    *logrus.Logger
+	stopChan  chan struct{}
+}
+
+// NewKeyRewrapService 创建数据重新包装服务
+func NewKeyRewrapService(db *database.MongoDB, encryptor *encryption.Encryptor, store archive.Store, logger *logrus.Logger) *KeyRewrapService {
+	return &KeyRewrapService{
+		db:        db,
+		encryptor: encryptor,
+		store:     store,
+		logger:    logger,
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// Start 启动重新包装协程（启动时立即执行一次）
+func (s *KeyRewrapService) Start(interval time.Duration) {
+	if !s.encryptor.Enabled() {
+		s.logger.Warn("未配置加密主密钥，敏感数据将以明文存储")
+		return
+	}
+
+	go func() {
+		s.RewrapAll()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.RewrapAll()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithFields(logrus.Fields{
+		"key_id":   s.encryptor.CurrentKeyID(),
+		"interval": interval.String(),
+	}).Info("数据重新包装协程已启动")
+}
+
+// Stop 停止重新包装协程
+func (s *KeyRewrapService) Stop() {
+	close(s.stopChan)
+}
+
+// RewrapAll 重新包装全部敏感数据
+func (s *KeyRewrapService) RewrapAll() {
+	jobs := []struct {
+		name string
+		run  func() (int, error)
+	}{
+		{"dkim_keys", s.rewrapDKIMKeys},
+		{"smime_certificates", s.rewrapSMIMEKeys},
+		{"smtp_configs", s.rewrapSMTPConfigs},
+		{"sandbox_messages", s.rewrapSandboxMessages},
+		{"archives", s.rewrapArchives},
+		{"webhook_secrets", s.rewrapWebhookSecrets},
+	}
+
+	for _, job := range jobs {
+		total := 0
+		for {
+			count, err := job.run()
+			if err != nil {
+				s.logger.WithError(err).WithField("target", job.name).Error("重新包装数据失败")
+				break
+			}
+			total += count
+			if count < rewrapBatchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			s.logger.WithFields(logrus.Fields{
+				"target": job.name,
+				"count":  total,
+				"key_id": s.encryptor.CurrentKeyID(),
+			}).Info("数据重新包装完成")
+		}
+	}
+}
+
+// rewrapDKIMKeys 重新包装DKIM私钥
+func (s *KeyRewrapService) rewrapDKIMKeys() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	collection := s.db.GetCollection("dkim_keys")
+	var keyPairs []models.DKIMKeyPair
+	if err := s.findStale(ctx, collection, bson.M{}, "encryption_key_id", &keyPairs); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, keyPair := range keyPairs {
+		privateKey, err := s.encryptor.RewrapString(keyPair.PrivateKey)
+		if err != nil {
+			return count, err
+		}
+
+		if err := s.update(ctx, collection, keyPair.ID, bson.M{
+			"private_key":       privateKey,
+			"encryption_key_id": s.encryptor.CurrentKeyID(),
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// rewrapSMIMEKeys 重新包装S/MIME私钥
+func (s *KeyRewrapService) rewrapSMIMEKeys() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	collection := s.db.GetCollection("smime_certificates")
+	var certificates []models.SMIMECertificate
+	if err := s.findStale(ctx, collection, bson.M{}, "encryption_key_id", &certificates); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, certificate := range certificates {
+		privateKey, err := s.encryptor.RewrapString(certificate.PrivateKey)
+		if err != nil {
+			return count, err
+		}
+
+		if err := s.update(ctx, collection, certificate.ID, bson.M{
+			"private_key":       privateKey,
+			"encryption_key_id": s.encryptor.CurrentKeyID(),
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// rewrapSMTPConfigs 重新包装上游SMTP密码
+func (s *KeyRewrapService) rewrapSMTPConfigs() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	collection := s.db.GetCollection("smtp_configs")
+	var configs []models.SMTPConfig
+	if err := s.findStale(ctx, collection, bson.M{"password": bson.M{"$nin": bson.A{"", nil}}}, "password_key_id", &configs); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, config := range configs {
+		password, err := s.encryptor.RewrapString(config.Password)
+		if err != nil {
+			return count, err
+		}
+
+		if err := s.update(ctx, collection, config.ID, bson.M{
+			"password":        password,
+			"password_key_id": s.encryptor.CurrentKeyID(),
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// rewrapSandboxMessages 重新包装沙箱邮件原文
+func (s *KeyRewrapService) rewrapSandboxMessages() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	collection := s.db.GetCollection("sandbox_messages")
+	var messages []models.SandboxMessage
+	if err := s.findStale(ctx, collection, bson.M{}, "encryption_key_id", &messages); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, message := range messages {
+		raw, err := s.encryptor.RewrapBytes(message.Raw)
+		if err != nil {
+			return count, err
+		}
+
+		if err := s.update(ctx, collection, message.ID, bson.M{
+			"raw":               raw,
+			"encryption_key_id": s.encryptor.CurrentKeyID(),
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// rewrapArchives 重新包装归档存储中的原始邮件
+func (s *KeyRewrapService) rewrapArchives() (int, error) {
+	if s.store == nil {
+		return 0, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	collection := s.db.GetCollection("mail_logs")
+	var mailLogs []models.MailLog
+	filter := bson.M{"archive": bson.M{"$exists": true}}
+	if err := s.findStale(ctx, collection, filter, "archive.encryption_key_id", &mailLogs); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mailLog := range mailLogs {
+		data, err := s.store.Get(ctx, mailLog.Archive.Key)
+		if err != nil && !errors.Is(err, archive.ErrNotFound) {
+			return count, err
+		}
+
+		// 归档对象已被清理时只更新记录，避免反复扫描
+		if err == nil {
+			rewrapped, err := s.encryptor.RewrapBytes(data)
+			if err != nil {
+				return count, err
+			}
+			if err := s.store.Put(ctx, mailLog.Archive.Key, rewrapped); err != nil {
+				return count, err
+			}
+		}
+
+		if err := s.update(ctx, collection, mailLog.ID, bson.M{
+			"archive.encryption_key_id": s.encryptor.CurrentKeyID(),
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// rewrapWebhookSecrets 重新包装用户通知偏好中的Webhook签名密钥
+func (s *KeyRewrapService) rewrapWebhookSecrets() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	collection := s.db.GetCollection("users")
+	var users []models.User
+	filter := bson.M{"notification_preferences.webhook_secret": bson.M{"$nin": bson.A{"", nil}}}
+	if err := s.findStale(ctx, collection, filter, "notification_preferences.webhook_secret_key_id", &users); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, user := range users {
+		secret, err := s.encryptor.RewrapString(user.NotificationPreferences.WebhookSecret)
+		if err != nil {
+			return count, err
+		}
+
+		if err := s.update(ctx, collection, user.ID, bson.M{
+			"notification_preferences.webhook_secret":        secret,
+			"notification_preferences.webhook_secret_key_id": s.encryptor.CurrentKeyID(),
+		}); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// findStale 查询未使用当前主密钥加密的文档
+func (s *KeyRewrapService) findStale(ctx context.Context, collection *mongo.Collection, filter bson.M, keyField string, results interface{}) error {
+	filter[keyField] = bson.M{"$ne": s.encryptor.CurrentKeyID()}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetLimit(rewrapBatchSize))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	return cursor.All(ctx, results)
+}
+
+// update 更新文档的加密字段
+func (s *KeyRewrapService) update(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, fields bson.M) error {
+	_, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	return err
+}
@@ -2,6 +2,9 @@ package services
 
 import (
 	"context"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -12,6 +15,7 @@ import (
 
 	"smtp-relay/internal/database"
 	"smtp-relay/internal/models"
+	"smtp-relay/internal/timeutil"
 )
 
 // MailLogService MailLog管理服务
@@ -28,8 +32,66 @@ func NewMailLogService(db *database.MongoDB, logger *logrus.Logger) *MailLogServ
 	}
 }
 
+// MailAttributionFilter 按客户端通过X-Relay-*头部附加的标签、活动ID和元数据过滤邮件
+type MailAttributionFilter struct {
+	Tag        string
+	CampaignID string
+	Metadata   map[string]string // 所有键值对都须匹配
+}
+
+// MailStatsGroup 分组邮件统计
+type MailStatsGroup struct {
+	Value        string           `json:"value"` // 分组值，空字符串表示未设置
+	Total        int64            `json:"total"`
+	StatusCounts map[string]int64 `json:"status_counts"`
+	SuccessRate  float64          `json:"success_rate"`
+}
+
+// 统计分组维度
+const (
+	MailStatsGroupByTag      = "tag"
+	MailStatsGroupByCampaign = "campaign"
+	MailStatsGroupByMetadata = "metadata"
+)
+
+// maxMailStatsGroups 分组统计返回的最大分组数
+const maxMailStatsGroups = 100
+
+// ParseMetadataFilters 解析key:value格式的元数据过滤条件
+func ParseMetadataFilters(values []string) (map[string]string, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]string, len(values))
+	for _, value := range values {
+		colon := strings.IndexByte(value, ':')
+		if colon < 0 {
+			return nil, fmt.Errorf("元数据过滤格式错误，应为key:value: %s", value)
+		}
+		key := strings.TrimSpace(value[:colon])
+		if err := ValidateMetadataKey(key); err != nil {
+			return nil, err
+		}
+		metadata[key] = value[colon+1:]
+	}
+	return metadata, nil
+}
+
+// apply 将过滤条件加入查询条件
+func (f MailAttributionFilter) apply(filter bson.M) {
+	if f.Tag != "" {
+		filter["tags"] = f.Tag
+	}
+	if f.CampaignID != "" {
+		filter["campaign_id"] = f.CampaignID
+	}
+	for key, value := range f.Metadata {
+		filter["metadata."+key] = value
+	}
+}
+
 // GetMailLogsByUser 获取用户的MailLog列表（支持分页和过滤）
-func (s *MailLogService) GetMailLogsByUser(userID primitive.ObjectID, page, pageSize int, status, from, to, dateFrom, dateTo string) ([]*models.MailLog, int64, error) {
+func (s *MailLogService) GetMailLogsByUser(userID primitive.ObjectID, page, pageSize int, status, from, to, dateFrom, dateTo string, attribution MailAttributionFilter) ([]*models.MailLog, int64, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -53,17 +115,21 @@ func (s *MailLogService) GetMailLogsByUser(userID primitive.ObjectID, page, page
 		filter["to"] = bson.M{"$elemMatch": bson.M{"$regex": to, "$options": "i"}}
 	}
 
-	// 日期范围过滤
+	// 日期范围过滤（日期按用户时区解析）
 	if dateFrom != "" || dateTo != "" {
+		location, err := UserLocation(ctx, s.db, userID)
+		if err != nil {
+			return nil, 0, err
+		}
 		dateFilter := bson.M{}
 		if dateFrom != "" {
-			if fromTime, err := time.Parse("2006-01-02", dateFrom); err == nil {
+			if fromTime, err := timeutil.ParseDate(dateFrom, location); err == nil {
 				dateFilter["$gte"] = fromTime
 			}
 		}
 		if dateTo != "" {
-			if toTime, err := time.Parse("2006-01-02", dateTo); err == nil {
-				dateFilter["$lte"] = toTime.Add(24 * time.Hour)
+			if toTime, err := timeutil.ParseDate(dateTo, location); err == nil {
+				dateFilter["$lt"] = toTime.AddDate(0, 0, 1)
 			}
 		}
 		if len(dateFilter) > 0 {
@@ -71,6 +137,9 @@ func (s *MailLogService) GetMailLogsByUser(userID primitive.ObjectID, page, page
 		}
 	}
 
+	// 标签、活动ID和元数据过滤
+	attribution.apply(filter)
+
 	// 获取总数
 	total, err := collection.CountDocuments(ctx, filter)
 	if err != nil {
@@ -176,9 +245,14 @@ func (s *MailLogService) GetUserMailStats(userID primitive.ObjectID) (map[string
 
 	collection := s.db.GetCollection("mail_logs")
 
-	// 获取今日统计
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
+	location, err := UserLocation(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取今日统计（按用户时区）
+	now := time.Now()
+	today, tomorrow := timeutil.Window(timeutil.PeriodDay, now, location)
 
 	todayFilter := bson.M{
 		"user_id": userID,
@@ -191,8 +265,7 @@ func (s *MailLogService) GetUserMailStats(userID primitive.ObjectID) (map[string
 	todayTotal, _ := collection.CountDocuments(ctx, todayFilter)
 
 	// 获取本月统计
-	thisMonth := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
-	nextMonth := thisMonth.AddDate(0, 1, 0)
+	thisMonth, nextMonth := timeutil.Window(timeutil.PeriodMonth, now, location)
 
 	monthFilter := bson.M{
 		"user_id": userID,
@@ -259,9 +332,14 @@ func (s *MailLogService) GetCredentialMailStats(userID, credentialID primitive.O
 
 	collection := s.db.GetCollection("mail_logs")
 
-	// 获取今日统计
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
+	location, err := UserLocation(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 获取今日统计（按用户时区）
+	now := time.Now()
+	today, tomorrow := timeutil.Window(timeutil.PeriodDay, now, location)
 
 	todayFilter := bson.M{
 		"user_id":       userID,
@@ -275,8 +353,7 @@ func (s *MailLogService) GetCredentialMailStats(userID, credentialID primitive.O
 	todayTotal, _ := collection.CountDocuments(ctx, todayFilter)
 
 	// 获取本小时统计
-	thisHour := time.Now().Truncate(time.Hour)
-	nextHour := thisHour.Add(time.Hour)
+	thisHour, nextHour := timeutil.Window(timeutil.PeriodHour, now, location)
 
 	hourFilter := bson.M{
 		"user_id":       userID,
@@ -295,6 +372,48 @@ func (s *MailLogService) GetCredentialMailStats(userID, credentialID primitive.O
 	}, nil
 }
 
+// QuotaUsage 当前配额周期内已使用的收件人数
+type QuotaUsage struct {
+	HourlyUsed  int64 `json:"hourly_used" example:"25"`
+	DailyUsed   int64 `json:"daily_used" example:"320"`
+	MonthlyUsed int64 `json:"monthly_used" example:"8200"`
+}
+
+// GetQuotaUsage 获取当前配额周期（按用户时区的整点小时、自然日和自然月）内已使用的收件人数，credentialID为nil时统计整个用户
+func (s *MailLogService) GetQuotaUsage(userID primitive.ObjectID, credentialID *primitive.ObjectID) (*QuotaUsage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID}
+	if credentialID != nil {
+		filter["credential_id"] = *credentialID
+	}
+
+	location, err := UserLocation(ctx, s.db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	usage := &QuotaUsage{}
+	for _, item := range []struct {
+		period string
+		used   *int64
+	}{
+		{timeutil.PeriodHour, &usage.HourlyUsed},
+		{timeutil.PeriodDay, &usage.DailyUsed},
+		{timeutil.PeriodMonth, &usage.MonthlyUsed},
+	} {
+		start, end := timeutil.Window(item.period, now, location)
+		used, err := countRecipients(ctx, s.db, filter, start, end)
+		if err != nil {
+			return nil, err
+		}
+		*item.used = used
+	}
+	return usage, nil
+}
+
 // GetRecentMailLogsByUser 获取用户近期发信历史（优化版本）
 func (s *MailLogService) GetRecentMailLogsByUser(userID primitive.ObjectID, days, page, pageSize int, status string) ([]*models.MailLog, int64, map[string]interface{}, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -302,6 +421,11 @@ func (s *MailLogService) GetRecentMailLogsByUser(userID primitive.ObjectID, days
 
 	collection := s.db.GetCollection("mail_logs")
 
+	location, err := UserLocation(ctx, s.db, userID)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+
 	// 计算日期范围
 	endDate := time.Now()
 	startDate := endDate.AddDate(0, 0, -days)
@@ -383,10 +507,99 @@ func (s *MailLogService) GetRecentMailLogsByUser(userID primitive.ObjectID, days
 		"total_queued":  statusCounts["queued"],
 		"total_sending": statusCounts["sending"],
 		"date_range": map[string]string{
-			"from": startDate.Format("2006-01-02"),
-			"to":   endDate.Format("2006-01-02"),
+			"from": startDate.In(location).Format("2006-01-02"),
+			"to":   endDate.In(location).Format("2006-01-02"),
 		},
 	}
 
 	return mailLogs, total, statistics, nil
 }
+
+// GetMailStatsByGroup 按标签、活动ID或元数据键分组统计最近N天的邮件数量和状态
+func (s *MailLogService) GetMailStatsByGroup(userID primitive.ObjectID, groupBy, metadataKey string, days int, attribution MailAttributionFilter) ([]*MailStatsGroup, error) {
+	var groupField string
+	var pipeline []bson.M
+
+	filter := bson.M{
+		"user_id":    userID,
+		"created_at": bson.M{"$gte": time.Now().AddDate(0, 0, -days)},
+	}
+	attribution.apply(filter)
+	pipeline = append(pipeline, bson.M{"$match": filter})
+
+	switch groupBy {
+	case MailStatsGroupByTag:
+		// 一封邮件有多个标签时分别计入每个标签
+		pipeline = append(pipeline, bson.M{"$unwind": bson.M{"path": "$tags", "preserveNullAndEmptyArrays": true}})
+		groupField = "$tags"
+	case MailStatsGroupByCampaign:
+		groupField = "$campaign_id"
+	case MailStatsGroupByMetadata:
+		if err := ValidateMetadataKey(metadataKey); err != nil {
+			return nil, err
+		}
+		groupField = "$metadata." + metadataKey
+	default:
+		return nil, fmt.Errorf("无效的分组维度: %s", groupBy)
+	}
+
+	pipeline = append(pipeline, bson.M{"$group": bson.M{
+		"_id": bson.M{
+			"value":  bson.M{"$ifNull": bson.A{groupField, ""}},
+			"status": "$status",
+		},
+		"count": bson.M{"$sum": 1},
+	}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("mail_logs").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	groups := make(map[string]*MailStatsGroup)
+	for cursor.Next(ctx) {
+		var result struct {
+			ID struct {
+				Value  string `bson:"value"`
+				Status string `bson:"status"`
+			} `bson:"_id"`
+			Count int64 `bson:"count"`
+		}
+		if err := cursor.Decode(&result); err != nil {
+			continue
+		}
+		group, ok := groups[result.ID.Value]
+		if !ok {
+			group = &MailStatsGroup{Value: result.ID.Value, StatusCounts: make(map[string]int64)}
+			groups[result.ID.Value] = group
+		}
+		group.StatusCounts[result.ID.Status] += result.Count
+		group.Total += result.Count
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+
+	stats := make([]*MailStatsGroup, 0, len(groups))
+	for _, group := range groups {
+		if group.Total > 0 {
+			group.SuccessRate = float64(group.StatusCounts["sent"]) / float64(group.Total) * 100
+		}
+		stats = append(stats, group)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Total != stats[j].Total {
+			return stats[i].Total > stats[j].Total
+		}
+		return stats[i].Value < stats[j].Value
+	})
+	if len(stats) > maxMailStatsGroups {
+		stats = stats[:maxMailStatsGroups]
+	}
+
+	return stats, nil
+}
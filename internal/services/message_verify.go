@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"smtp-relay/internal/mailauth"
+	"smtp-relay/internal/models"
+)
+
+// MessageVerifyService 邮件认证验证服务：对原始邮件进行DKIM、ARC、SPF和DMARC检查
+type MessageVerifyService struct {
+	resolver mailauth.Resolver
+	logger   *logrus.Logger
+}
+
+// NewMessageVerifyService 创建邮件认证验证服务
+func NewMessageVerifyService(resolver mailauth.Resolver, logger *logrus.Logger) *MessageVerifyService {
+	return &MessageVerifyService{
+		resolver: resolver,
+		logger:   logger,
+	}
+}
+
+// addressParser 解析From等地址头部；只需要地址本身，未知字符集的显示名称不做转换
+var addressParser = &mail.AddressParser{
+	WordDecoder: &mime.WordDecoder{
+		CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+			return input, nil
+		},
+	},
+}
+
+// Verify 验证原始邮件的认证结果
+//
+// clientIP为发送方IP，为空时跳过SPF检查；mailFrom为SMTP MAIL FROM地址，为空时依次使用Return-Path和From头部。
+func (s *MessageVerifyService) Verify(message []byte, clientIP, mailFrom, helo string) (*models.MessageVerification, error) {
+	message = mailauth.NormalizeCRLF(message)
+	fields, _ := mailauth.SplitMessage(message)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("无效的邮件内容")
+	}
+
+	var ip net.IP
+	if clientIP != "" {
+		if ip = net.ParseIP(strings.TrimSpace(clientIP)); ip == nil {
+			return nil, fmt.Errorf("无效的客户端IP")
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	report := &models.MessageVerification{
+		MessageID:  headerValue(fields, "Message-ID"),
+		Subject:    decodeHeaderValue(headerValue(fields, "Subject")),
+		VerifiedAt: time.Now(),
+	}
+
+	fromAddress, fromErr := parseFromHeader(fields)
+	report.From = fromAddress
+	fromDomain := addressDomain(fromAddress)
+
+	// DKIM
+	dkim := mailauth.VerifyDKIM(ctx, s.resolver, message)
+
+	// ARC
+	chain := mailauth.ValidateARC(ctx, s.resolver, message)
+	report.ARC = models.ARCVerification{
+		Result:      chain.Status,
+		Instances:   chain.Instance(),
+		AuthResults: []string{},
+		Reason:      chain.Reason,
+	}
+	for _, set := range chain.Sets {
+		report.ARC.AuthResults = append(report.ARC.AuthResults, unfoldHeader(set.AuthResults.Value()))
+	}
+
+	// SPF
+	var spf *mailauth.SPFCheck
+	if ip != nil {
+		sender := strings.TrimSpace(mailFrom)
+		if sender == "" {
+			sender = returnPath(fields)
+		}
+		if sender == "" {
+			sender = fromAddress
+		}
+		spfDomain := addressDomain(sender)
+		if spfDomain == "" {
+			// 空的MAIL FROM（退信）使用HELO域名检查
+			spfDomain = strings.ToLower(helo)
+		}
+		if spfDomain != "" {
+			spf = mailauth.CheckSPF(ctx, s.resolver, ip, spfDomain, sender, helo)
+		} else {
+			spf = &mailauth.SPFCheck{Result: mailauth.SPFNone, Reason: "无法确定SPF检查域名（未提供MAIL FROM和HELO）"}
+		}
+		report.SPF = &models.SPFVerification{
+			Result:    spf.Result,
+			ClientIP:  ip.String(),
+			Sender:    sender,
+			HELO:      helo,
+			Domain:    spf.Domain,
+			Record:    spf.Record,
+			Mechanism: spf.Mechanism,
+			Lookups:   spf.Lookups,
+			Reason:    spf.Reason,
+		}
+	}
+
+	// DMARC
+	if fromErr != nil {
+		report.DMARC = models.DMARCVerification{Result: mailauth.DMARCPermError, Reason: fromErr.Error()}
+	} else {
+		dmarc := mailauth.EvaluateDMARC(ctx, s.resolver, fromDomain, spf, dkim)
+		report.DMARC = models.DMARCVerification{
+			Result:      dmarc.Result,
+			FromDomain:  dmarc.FromDomain,
+			Policy:      dmarc.Policy,
+			SPFAligned:  dmarc.SPFAligned,
+			DKIMAligned: dmarc.DKIMAligned,
+			Reason:      dmarc.Reason,
+		}
+		if dmarc.Record != nil {
+			report.DMARC.Record = dmarc.Record.Raw
+			report.DMARC.RecordDomain = dmarc.Record.Domain
+			report.DMARC.ADKIM = dmarc.Record.ADKIM
+			report.DMARC.ASPF = dmarc.Record.ASPF
+			report.DMARC.Percent = dmarc.Record.Percent
+		}
+		if report.SPF != nil {
+			report.SPF.Aligned = dmarc.SPFAligned
+		}
+	}
+
+	adkim := report.DMARC.ADKIM
+	if adkim == "" {
+		adkim = mailauth.AlignRelaxed
+	}
+	report.DKIM = make([]models.DKIMSignatureVerification, 0, len(dkim))
+	for _, verification := range dkim {
+		result := models.DKIMSignatureVerification{
+			Result:           verification.Result,
+			Domain:           verification.Domain,
+			Selector:         verification.Selector,
+			Algorithm:        verification.Algorithm,
+			Canonicalization: verification.Canonicalization,
+			SignedHeaders:    verification.SignedHeaders,
+			Identity:         verification.Identity,
+			KeyBits:          verification.KeyBits,
+			Aligned:          fromDomain != "" && mailauth.Aligned(fromDomain, verification.Domain, adkim),
+			Reason:           verification.Reason,
+		}
+		if verification.BodyLength >= 0 {
+			length := verification.BodyLength
+			result.BodyLength = &length
+		}
+		if !verification.Timestamp.IsZero() {
+			timestamp := verification.Timestamp
+			result.Timestamp = &timestamp
+		}
+		if !verification.Expiration.IsZero() {
+			expiration := verification.Expiration
+			result.Expiration = &expiration
+		}
+		report.DKIM = append(report.DKIM, result)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"from":  report.From,
+		"dkim":  len(report.DKIM),
+		"arc":   report.ARC.Result,
+		"dmarc": report.DMARC.Result,
+	}).Info("邮件认证验证完成")
+
+	return report, nil
+}
+
+// parseFromHeader 解析From头部，DMARC要求其中恰好有一个地址
+func parseFromHeader(fields []mailauth.HeaderField) (string, error) {
+	var values []string
+	for _, field := range fields {
+		if strings.EqualFold(field.Name, "From") {
+			values = append(values, unfoldHeader(field.Value()))
+		}
+	}
+	if len(values) == 0 {
+		return "", fmt.Errorf("邮件缺少From头部")
+	}
+	if len(values) > 1 {
+		return "", fmt.Errorf("邮件包含多个From头部")
+	}
+
+	addresses, err := addressParser.ParseList(values[0])
+	if err != nil || len(addresses) == 0 {
+		return "", fmt.Errorf("无法解析From头部: %s", values[0])
+	}
+	if len(addresses) > 1 {
+		return addresses[0].Address, fmt.Errorf("From头部包含多个地址")
+	}
+	if addressDomain(addresses[0].Address) == "" {
+		return addresses[0].Address, fmt.Errorf("From地址缺少域名")
+	}
+	return addresses[0].Address, nil
+}
+
+// returnPath 获取Return-Path头部中的地址
+func returnPath(fields []mailauth.HeaderField) string {
+	value := headerValue(fields, "Return-Path")
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">"))
+}
+
+// headerValue 获取头部的第一个值（已展开折行）
+func headerValue(fields []mailauth.HeaderField, name string) string {
+	field, ok := mailauth.FindHeader(fields, name)
+	if !ok {
+		return ""
+	}
+	return unfoldHeader(field.Value())
+}
+
+// unfoldHeader 展开头部值中的折行并去除首尾空白
+func unfoldHeader(value string) string {
+	return strings.TrimSpace(strings.ReplaceAll(value, "\r\n", ""))
+}
+
+// addressDomain 返回邮件地址的域名（小写），无域名时返回空字符串
+func addressDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(address[at+1:]), ".")
+}
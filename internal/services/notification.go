@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// NotificationService 用户通知服务
+type NotificationService struct {
+	db        *database.MongoDB
+	encryptor *encryption.Encryptor
+	logger    *logrus.Logger
+}
+
+// NewNotificationService 创建通知服务实例
+func NewNotificationService(db *database.MongoDB, encryptor *encryption.Encryptor, logger *logrus.Logger) *NotificationService {
+	return &NotificationService{
+		db:        db,
+		encryptor: encryptor,
+		logger:    logger,
+	}
+}
+
+// Notify 发送通知；设置了DedupKey且存在未读的相同通知时不重复发送
+func (s *NotificationService) Notify(notification *models.Notification) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("notifications")
+	if notification.DedupKey != "" {
+		count, err := collection.CountDocuments(ctx, bson.M{
+			"user_id":   notification.UserID,
+			"dedup_key": notification.DedupKey,
+			"read":      false,
+		})
+		if err != nil {
+			return fmt.Errorf("检查重复通知失败: %w", err)
+		}
+		if count > 0 {
+			return nil
+		}
+	}
+
+	if notification.Level == "" {
+		notification.Level = models.NotificationInfo
+	}
+	notification.ID = primitive.NewObjectID()
+	notification.Read = false
+	notification.CreatedAt = time.Now()
+
+	if _, err := collection.InsertOne(ctx, notification); err != nil {
+		return fmt.Errorf("保存通知失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id": notification.UserID.Hex(),
+		"type":    notification.Type,
+		"level":   notification.Level,
+	}).Info(notification.Title)
+
+	return nil
+}
+
+// ListNotifications 分页获取用户通知（最新的在前）
+func (s *NotificationService) ListNotifications(userID primitive.ObjectID, unreadOnly bool, page, pageSize int) ([]*models.Notification, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read"] = false
+	}
+
+	collection := s.db.GetCollection("notifications")
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计通知数量失败: %w", err)
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询通知失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	notifications := []*models.Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, 0, fmt.Errorf("解析通知失败: %w", err)
+	}
+
+	return notifications, total, nil
+}
+
+// MarkRead 将通知标记为已读
+func (s *NotificationService) MarkRead(userID, notificationID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("notifications").UpdateOne(ctx,
+		bson.M{"_id": notificationID, "user_id": userID},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}},
+	)
+	if err != nil {
+		return fmt.Errorf("更新通知失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("通知不存在")
+	}
+	return nil
+}
+
+// MarkAllRead 将用户的全部未读通知标记为已读
+func (s *NotificationService) MarkAllRead(userID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("notifications").UpdateMany(ctx,
+		bson.M{"user_id": userID, "read": false},
+		bson.M{"$set": bson.M{"read": true, "read_at": time.Now()}},
+	)
+	if err != nil {
+		return 0, fmt.Errorf("更新通知失败: %w", err)
+	}
+	return result.ModifiedCount, nil
+}
+
+// GetPreferences 获取用户的通知偏好，未设置时返回默认偏好
+func (s *NotificationService) GetPreferences(userID primitive.ObjectID) (*models.NotificationPreferences, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := s.db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+
+	return EffectiveNotificationPreferences(&user), nil
+}
+
+// UpdatePreferences 更新用户的通知偏好；keepSecret为true时保留已设置的Webhook签名密钥
+func (s *NotificationService) UpdatePreferences(userID primitive.ObjectID, preferences *models.NotificationPreferences, keepSecret bool) (*models.NotificationPreferences, error) {
+	if err := ValidateNotificationPreferences(preferences); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("users")
+	if keepSecret {
+		current, err := s.GetPreferences(userID)
+		if err != nil {
+			return nil, err
+		}
+		preferences.WebhookSecret = current.WebhookSecret
+		preferences.WebhookSecretKeyID = current.WebhookSecretKeyID
+	} else if preferences.WebhookSecret != "" {
+		encrypted, err := s.encryptor.EncryptString(preferences.WebhookSecret)
+		if err != nil {
+			return nil, fmt.Errorf("加密Webhook签名密钥失败: %w", err)
+		}
+		preferences.WebhookSecret = encrypted
+		preferences.WebhookSecretKeyID = s.encryptor.CurrentKeyID()
+	}
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$set": bson.M{"notification_preferences": preferences, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("更新通知偏好失败: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, fmt.Errorf("用户不存在")
+	}
+
+	preferences.HasWebhookSecret = preferences.WebhookSecret != ""
+	return preferences, nil
+}
+
+// WebhookSecret 解密通知偏好中的Webhook签名密钥
+func (s *NotificationService) WebhookSecret(preferences *models.NotificationPreferences) (string, error) {
+	secret, err := s.encryptor.DecryptString(preferences.WebhookSecret)
+	if err != nil {
+		return "", fmt.Errorf("解密Webhook签名密钥失败: %w", err)
+	}
+	return secret, nil
+}
+
+// EffectiveNotificationPreferences 获取用户生效的通知偏好，未设置时只发送默认阈值的站内配额提醒
+func EffectiveNotificationPreferences(user *models.User) *models.NotificationPreferences {
+	if user.NotificationPreferences == nil {
+		return &models.NotificationPreferences{
+			QuotaThresholds: append([]int(nil), models.DefaultQuotaThresholds...),
+		}
+	}
+	preferences := *user.NotificationPreferences
+	preferences.HasWebhookSecret = preferences.WebhookSecret != ""
+	return &preferences
+}
+
+// ValidateNotificationPreferences 校验通知偏好，阈值去重后按升序排列
+func ValidateNotificationPreferences(preferences *models.NotificationPreferences) error {
+	if len(preferences.QuotaThresholds) > 10 {
+		return fmt.Errorf("配额提醒阈值最多10个")
+	}
+	seen := make(map[int]bool)
+	thresholds := make([]int, 0, len(preferences.QuotaThresholds))
+	for _, threshold := range preferences.QuotaThresholds {
+		if threshold < 1 || threshold > 100 {
+			return fmt.Errorf("配额提醒阈值必须在1-100之间")
+		}
+		if !seen[threshold] {
+			seen[threshold] = true
+			thresholds = append(thresholds, threshold)
+		}
+	}
+	sort.Ints(thresholds)
+	preferences.QuotaThresholds = thresholds
+
+	// 只保存解析出的邮箱地址（去掉显示名称），用作投递信封的收件人
+	preferences.Email = strings.TrimSpace(preferences.Email)
+	if preferences.Email != "" {
+		addr, err := mail.ParseAddress(preferences.Email)
+		if err != nil {
+			return fmt.Errorf("无效的通知邮箱")
+		}
+		preferences.Email = addr.Address
+	}
+
+	preferences.WebhookURL = strings.TrimSpace(preferences.WebhookURL)
+	if preferences.WebhookURL != "" {
+		if err := ValidateWebhookURL(preferences.WebhookURL); err != nil {
+			return err
+		}
+	}
+	if len(preferences.WebhookSecret) > 256 {
+		return fmt.Errorf("Webhook签名密钥不能超过256个字符")
+	}
+	return nil
+}
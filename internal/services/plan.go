@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+	"smtp-relay/internal/timeutil"
+)
+
+// DefaultMaxCredentials 未分配套餐且没有默认套餐时每个用户最多的SMTP凭据数
+const DefaultMaxCredentials = 10
+
+// PlanService 套餐服务：管理套餐定义和用户的套餐分配
+type PlanService struct {
+	db     *database.MongoDB
+	logger *logrus.Logger
+}
+
+// NewPlanService 创建套餐服务
+func NewPlanService(db *database.MongoDB, logger *logrus.Logger) *PlanService {
+	return &PlanService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// ValidatePlan 校验套餐定义
+func ValidatePlan(plan *models.Plan) error {
+	plan.Name = strings.TrimSpace(plan.Name)
+	if plan.Name == "" {
+		return fmt.Errorf("套餐名称不能为空")
+	}
+	if len(plan.Name) > 64 {
+		return fmt.Errorf("套餐名称不能超过64个字符")
+	}
+	if plan.DailyLimit < 0 || plan.MonthlyLimit < 0 || plan.MaxCredentials < 0 || plan.MaxDomains < 0 || plan.MaxMessageSize < 0 {
+		return fmt.Errorf("套餐限制不能为负数")
+	}
+	if plan.MessagesPerSecond < 0 || plan.MessagesPerSecond > 1000 {
+		return fmt.Errorf("每秒邮件数上限必须在0-1000之间")
+	}
+	if plan.RecipientsPerMinute < 0 || plan.RecipientsPerMinute > 100000 {
+		return fmt.Errorf("每分钟收件人数上限必须在0-100000之间")
+	}
+	plan.Timezone = strings.TrimSpace(plan.Timezone)
+	if _, err := timeutil.LoadLocation(plan.Timezone); err != nil {
+		return err
+	}
+	return nil
+}
+
+// CreatePlan 创建套餐
+func (s *PlanService) CreatePlan(plan *models.Plan) (*models.Plan, error) {
+	if err := ValidatePlan(plan); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	plan.ID = primitive.NewObjectID()
+	plan.CreatedAt = now
+	plan.UpdatedAt = now
+
+	if _, err := s.db.GetCollection("plans").InsertOne(ctx, plan); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("套餐名称已存在")
+		}
+		return nil, fmt.Errorf("保存套餐失败: %w", err)
+	}
+	if err := s.clearOtherDefaults(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"plan_id": plan.ID.Hex(),
+		"name":    plan.Name,
+	}).Info("创建套餐成功")
+	return plan, nil
+}
+
+// ListPlans 获取所有套餐
+func (s *PlanService) ListPlans() ([]*models.Plan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("plans").Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	plans := []*models.Plan{}
+	if err := cursor.All(ctx, &plans); err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// GetPlan 获取套餐
+func (s *PlanService) GetPlan(planID primitive.ObjectID) (*models.Plan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var plan models.Plan
+	if err := s.db.GetCollection("plans").FindOne(ctx, bson.M{"_id": planID}).Decode(&plan); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("套餐不存在")
+		}
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// UpdatePlan 更新套餐（立即对分配了该套餐的所有用户生效）
+func (s *PlanService) UpdatePlan(planID primitive.ObjectID, plan *models.Plan) (*models.Plan, error) {
+	if err := ValidatePlan(plan); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var updated models.Plan
+	err := s.db.GetCollection("plans").FindOneAndUpdate(ctx,
+		bson.M{"_id": planID},
+		bson.M{"$set": bson.M{
+			"name":                  plan.Name,
+			"description":           plan.Description,
+			"daily_limit":           plan.DailyLimit,
+			"monthly_limit":         plan.MonthlyLimit,
+			"max_credentials":       plan.MaxCredentials,
+			"max_domains":           plan.MaxDomains,
+			"max_message_size":      plan.MaxMessageSize,
+			"messages_per_second":   plan.MessagesPerSecond,
+			"recipients_per_minute": plan.RecipientsPerMinute,
+			"is_default":            plan.IsDefault,
+			"timezone":              plan.Timezone,
+			"updated_at":            time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("套餐不存在")
+		}
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, errors.New("套餐名称已存在")
+		}
+		return nil, fmt.Errorf("更新套餐失败: %w", err)
+	}
+	if err := s.clearOtherDefaults(ctx, &updated); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"plan_id": planID.Hex(),
+		"name":    updated.Name,
+	}).Info("更新套餐成功")
+	return &updated, nil
+}
+
+// DeletePlan 删除套餐（仍有用户使用的套餐不能删除）
+func (s *PlanService) DeletePlan(planID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	count, err := s.db.GetCollection("users").CountDocuments(ctx, bson.M{"plan_id": planID})
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("套餐正在被%d个用户使用，无法删除", count)
+	}
+
+	result, err := s.db.GetCollection("plans").DeleteOne(ctx, bson.M{"_id": planID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return errors.New("套餐不存在")
+	}
+
+	s.logger.WithField("plan_id", planID.Hex()).Info("删除套餐成功")
+	return nil
+}
+
+// AssignPlan 为用户分配套餐，planID为nil时取消分配（使用默认套餐）
+func (s *PlanService) AssignPlan(userID primitive.ObjectID, planID *primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	update := bson.M{"$unset": bson.M{"plan_id": ""}, "$set": bson.M{"updated_at": time.Now()}}
+	if planID != nil {
+		count, err := s.db.GetCollection("plans").CountDocuments(ctx, bson.M{"_id": *planID})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return errors.New("套餐不存在")
+		}
+		update = bson.M{"$set": bson.M{"plan_id": *planID, "updated_at": time.Now()}}
+	}
+
+	result, err := s.db.GetCollection("users").UpdateOne(ctx, bson.M{"_id": userID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return errors.New("用户不存在")
+	}
+
+	planName := "default"
+	if planID != nil {
+		planName = planID.Hex()
+	}
+	s.logger.WithFields(logrus.Fields{
+		"user_id": userID.Hex(),
+		"plan_id": planName,
+	}).Info("分配套餐成功")
+	return nil
+}
+
+// GetUserPlan 获取用户生效的套餐：已分配的套餐，否则为默认套餐；都没有时返回nil
+func (s *PlanService) GetUserPlan(user *models.User) (*models.Plan, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return userPlan(ctx, s.db, user.PlanID)
+}
+
+// clearOtherDefaults 保证最多只有一个默认套餐
+func (s *PlanService) clearOtherDefaults(ctx context.Context, plan *models.Plan) error {
+	if !plan.IsDefault {
+		return nil
+	}
+	_, err := s.db.GetCollection("plans").UpdateMany(ctx,
+		bson.M{"_id": bson.M{"$ne": plan.ID}, "is_default": true},
+		bson.M{"$set": bson.M{"is_default": false, "updated_at": time.Now()}},
+	)
+	return err
+}
+
+// userPlan 查询用户生效的套餐（分配的套餐已被删除时使用默认套餐）
+func userPlan(ctx context.Context, db *database.MongoDB, planID *primitive.ObjectID) (*models.Plan, error) {
+	collection := db.GetCollection("plans")
+	var plan models.Plan
+	if planID != nil {
+		err := collection.FindOne(ctx, bson.M{"_id": *planID}).Decode(&plan)
+		if err == nil {
+			return &plan, nil
+		}
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	err := collection.FindOne(ctx, bson.M{"is_default": true}).Decode(&plan)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// userPlanByID 按用户ID查询用户生效的套餐
+func userPlanByID(ctx context.Context, db *database.MongoDB, userID primitive.ObjectID) (*models.Plan, error) {
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("用户不存在")
+		}
+		return nil, err
+	}
+	return userPlan(ctx, db, user.PlanID)
+}
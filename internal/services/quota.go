@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+	"smtp-relay/internal/timeutil"
+)
+
+// quotaKeyTTLSlack 配额计数器在周期结束后的保留时间
+const quotaKeyTTLSlack = time.Hour
+
+// quotaReserveScript 原子地检查并预占所有配额计数器：任一计数器超限时不做任何修改，返回超限计数器的序号（从1开始）和当前用量；
+// 预占成功时返回0和各计数器预占后的用量
+//
+// KEYS: 计数器键；ARGV[1]: 预占数量；ARGV[2..n+1]: 各计数器的上限（0表示不限制）；ARGV[n+2..2n+1]: 各计数器的过期秒数
+var quotaReserveScript = redis.NewScript(`
+local amount = tonumber(ARGV[1])
+local n = #KEYS
+for i = 1, n do
+	local limit = tonumber(ARGV[i + 1])
+	if limit > 0 then
+		local current = tonumber(redis.call('GET', KEYS[i]) or '0')
+		if current + amount > limit then
+			return {i, current}
+		end
+	end
+end
+local values = {0}
+for i = 1, n do
+	values[i + 1] = redis.call('INCRBY', KEYS[i], amount)
+	if redis.call('TTL', KEYS[i]) < 0 then
+		redis.call('EXPIRE', KEYS[i], tonumber(ARGV[n + i + 1]))
+	end
+end
+return values
+`)
+
+// quotaRaiseScript 对账时只调高计数器：计数器低于邮件日志中的用量时增加差值（INCRBY保留原有过期时间），从不调低，
+// 以免抹掉已预占但邮件日志尚未保存的收件人数。计数器不存在时不做修改，返回增加的数量
+//
+// KEYS[1]: 计数器键；ARGV[1]: 邮件日志中的用量
+var quotaRaiseScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+local delta = tonumber(ARGV[1]) - tonumber(current)
+if delta <= 0 then
+	return 0
+end
+redis.call('INCRBY', KEYS[1], delta)
+return delta
+`)
+
+// QuotaService 配额服务：以收件人数为计量单位，使用Redis计数器原子地预占凭据和用户的小时/日配额，MongoDB邮件日志作为持久的对账来源
+type QuotaService struct {
+	db       *database.MongoDB
+	redis    *redis.Client
+	logger   *logrus.Logger
+	stopChan chan struct{}
+}
+
+// NewQuotaService 创建配额服务
+func NewQuotaService(db *database.MongoDB, redisClient *redis.Client, logger *logrus.Logger) *QuotaService {
+	return &QuotaService{
+		db:       db,
+		redis:    redisClient,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// QuotaReservation 已预占的配额，邮件最终未被接受时需要退还
+type QuotaReservation struct {
+	service *QuotaService
+	keys    []string
+	amount  int64
+	usage   []QuotaCounterUsage
+}
+
+// 配额计数器的范围
+const (
+	QuotaScopeCredential = "credential"
+	QuotaScopeUser       = "user"
+)
+
+// QuotaCounterUsage 单个配额计数器在当前周期的用量（只包含设置了上限的计数器）
+type QuotaCounterUsage struct {
+	Label  string    `json:"quota"`  // 如"凭据小时配额"
+	Scope  string    `json:"scope"`  // credential, user
+	Period string    `json:"period"` // hour, day, month
+	Used   int64     `json:"used"`
+	Limit  int64     `json:"limit"`
+	Start  time.Time `json:"window_start"`
+	End    time.Time `json:"window_end"`
+
+	key string // 计数器的Redis键，用于配额提醒去重
+}
+
+// quotaCounter 单个配额计数器
+type quotaCounter struct {
+	key    string
+	limit  int64
+	ttl    time.Duration
+	label  string // 超限时的错误描述，如"凭据小时配额"
+	scope  string
+	period string
+	filter bson.M // 对应的邮件日志过滤条件（用于从MongoDB初始化计数器）
+	start  time.Time
+	end    time.Time
+}
+
+// usage 转换为计数器用量
+func (c quotaCounter) usage(used int64) QuotaCounterUsage {
+	return QuotaCounterUsage{
+		Label: c.label, Scope: c.scope, Period: c.period, Used: used, Limit: c.limit, Start: c.start, End: c.end, key: c.key,
+	}
+}
+
+// Reserve 为一封邮件预占recipients个收件人的配额，plan为用户生效的套餐（可为nil）
+//
+// 超过配额时返回"xxx配额已用完（已用/上限）"错误；Redis不可用时退化为直接统计MongoDB（不保证原子性），此时返回的预占为空操作。
+func (s *QuotaService) Reserve(user *models.User, plan *models.Plan, credential *models.SMTPCredential, recipients int) (*QuotaReservation, error) {
+	counters := s.counters(user, plan, credential, time.Now())
+	amount := int64(recipients)
+
+	reservation, err := s.reserveRedis(counters, amount)
+	if err == nil {
+		return reservation, nil
+	}
+	if _, exceeded := err.(*quotaExceededError); exceeded {
+		return nil, err
+	}
+
+	s.logger.WithError(err).WithField("credential_id", credential.ID.Hex()).Warn("Redis配额计数失败，使用MongoDB统计")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	usage := make([]QuotaCounterUsage, 0, len(counters))
+	for _, counter := range counters {
+		if counter.limit <= 0 {
+			continue
+		}
+		used, err := countRecipients(ctx, s.db, counter.filter, counter.start, counter.end)
+		if err != nil {
+			return nil, fmt.Errorf("统计配额用量失败: %w", err)
+		}
+		if used+amount > counter.limit {
+			return nil, &quotaExceededError{usage: counter.usage(used)}
+		}
+		usage = append(usage, counter.usage(used+amount))
+	}
+	return &QuotaReservation{service: s, usage: usage}, nil
+}
+
+// Usage 返回预占后各配额计数器的用量
+func (r *QuotaReservation) Usage() []QuotaCounterUsage {
+	if r == nil {
+		return nil
+	}
+	return r.usage
+}
+
+// Refund 退还预占的配额（邮件被拒绝、重复提交或入队失败时调用）
+func (r *QuotaReservation) Refund() {
+	if r == nil || len(r.keys) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	pipe := r.service.redis.TxPipeline()
+	for _, key := range r.keys {
+		pipe.DecrBy(ctx, key, r.amount)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		r.service.logger.WithError(err).Warn("退还配额失败")
+	}
+	r.keys = nil
+}
+
+// StartReconciler 启动配额对账协程：定期用MongoDB邮件日志校正Redis计数器
+func (s *QuotaService) StartReconciler(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.reconcile()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithField("interval", interval.String()).Info("配额对账协程已启动")
+}
+
+// Stop 停止配额对账协程
+func (s *QuotaService) Stop() {
+	close(s.stopChan)
+}
+
+// reserveRedis 通过Redis计数器原子地预占配额，不存在的计数器先从MongoDB初始化
+func (s *QuotaService) reserveRedis(counters []quotaCounter, amount int64) (*QuotaReservation, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	keys := make([]string, len(counters))
+	args := make([]interface{}, 0, 1+2*len(counters))
+	args = append(args, amount)
+	for i, counter := range counters {
+		if err := s.seed(ctx, counter); err != nil {
+			return nil, err
+		}
+		keys[i] = counter.key
+		args = append(args, counter.limit)
+	}
+	for _, counter := range counters {
+		args = append(args, int64(counter.ttl/time.Second))
+	}
+
+	result, err := quotaReserveScript.Run(ctx, s.redis, keys, args...).Slice()
+	if err != nil {
+		return nil, fmt.Errorf("预占配额失败: %w", err)
+	}
+	if len(result) < 2 {
+		return nil, fmt.Errorf("预占配额失败: 无效的返回值")
+	}
+	index, _ := result[0].(int64)
+	if index > 0 && int(index) <= len(counters) {
+		used, _ := result[1].(int64)
+		return nil, &quotaExceededError{usage: counters[index-1].usage(used)}
+	}
+	if len(result) != len(counters)+1 {
+		return nil, fmt.Errorf("预占配额失败: 无效的返回值")
+	}
+
+	usage := make([]QuotaCounterUsage, 0, len(counters))
+	for i, counter := range counters {
+		if counter.limit > 0 {
+			used, _ := result[i+1].(int64)
+			usage = append(usage, counter.usage(used))
+		}
+	}
+	return &QuotaReservation{service: s, keys: keys, amount: amount, usage: usage}, nil
+}
+
+// seed 计数器不存在时（新周期或Redis数据丢失）从MongoDB邮件日志初始化
+func (s *QuotaService) seed(ctx context.Context, counter quotaCounter) error {
+	exists, err := s.redis.Exists(ctx, counter.key).Result()
+	if err != nil {
+		return err
+	}
+	if exists > 0 {
+		return nil
+	}
+
+	used, err := countRecipients(ctx, s.db, counter.filter, counter.start, counter.end)
+	if err != nil {
+		return fmt.Errorf("统计配额用量失败: %w", err)
+	}
+	return s.redis.SetNX(ctx, counter.key, used, counter.ttl).Err()
+}
+
+// reconcile 用MongoDB邮件日志校正Redis中所有当前存在的配额计数器
+//
+// 计数器键中记录了用户、凭据和周期的起止时间，因此不同时区的用户可以统一对账。
+// 计数器只会被调高（补上Redis数据丢失或未经预占写入的用量），不会被调低：统计邮件日志与更新计数器之间发生的预占，
+// 以及已预占但邮件日志尚未保存的收件人数都只存在于计数器中。进程在预占后异常退出会使计数器偏高，直到周期结束。
+func (s *QuotaService) reconcile() {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	corrected := 0
+	iter := s.redis.Scan(ctx, 0, "quota:*", 500).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		filter, start, end, ok := parseQuotaKey(key)
+		if !ok || !now.Before(end) {
+			continue
+		}
+
+		used, err := countRecipients(ctx, s.db, filter, start, end)
+		if err != nil {
+			s.logger.WithError(err).Error("配额对账统计失败")
+			return
+		}
+		raised, err := quotaRaiseScript.Run(ctx, s.redis, []string{key}, used).Int64()
+		if err != nil {
+			s.logger.WithError(err).Warn("校正配额计数器失败")
+			return
+		}
+		if raised > 0 {
+			corrected++
+		}
+	}
+	if err := iter.Err(); err != nil {
+		s.logger.WithError(err).Warn("扫描配额计数器失败")
+	}
+	if corrected > 0 {
+		s.logger.WithField("count", corrected).Info("已按邮件日志校正配额计数器")
+	}
+}
+
+// counters 构建凭据和用户在当前小时和当天的配额计数器，以及套餐的月计数器（套餐日限额与用户日配额共用同一计数器，取较小值）
+//
+// 周期按用户的时区计算，见QuotaLocation。
+func (s *QuotaService) counters(user *models.User, plan *models.Plan, credential *models.SMTPCredential, now time.Time) []quotaCounter {
+	location := QuotaLocation(user, plan)
+	hourStart, hourEnd := timeutil.Window(timeutil.PeriodHour, now, location)
+	dayStart, dayEnd := timeutil.Window(timeutil.PeriodDay, now, location)
+	credentialFilter := bson.M{"credential_id": credential.ID}
+	userFilter := bson.M{"user_id": user.ID}
+
+	userDailyLimit, userDailyLabel := int64(user.Settings.DailyQuota), "用户日配额"
+	if plan != nil && plan.DailyLimit > 0 && (userDailyLimit <= 0 || plan.DailyLimit < userDailyLimit) {
+		userDailyLimit, userDailyLabel = plan.DailyLimit, "套餐日配额"
+	}
+
+	counters := []quotaCounter{
+		{
+			key: quotaKey(user.ID, &credential.ID, timeutil.PeriodHour, hourStart, hourEnd), limit: int64(credential.Settings.HourlyQuota),
+			ttl: time.Until(hourEnd) + quotaKeyTTLSlack, label: "凭据小时配额", scope: QuotaScopeCredential, period: timeutil.PeriodHour, filter: credentialFilter, start: hourStart, end: hourEnd,
+		},
+		{
+			key: quotaKey(user.ID, &credential.ID, timeutil.PeriodDay, dayStart, dayEnd), limit: int64(credential.Settings.DailyQuota),
+			ttl: time.Until(dayEnd) + quotaKeyTTLSlack, label: "凭据日配额", scope: QuotaScopeCredential, period: timeutil.PeriodDay, filter: credentialFilter, start: dayStart, end: dayEnd,
+		},
+		{
+			key: quotaKey(user.ID, nil, timeutil.PeriodHour, hourStart, hourEnd), limit: int64(user.Settings.HourlyQuota),
+			ttl: time.Until(hourEnd) + quotaKeyTTLSlack, label: "用户小时配额", scope: QuotaScopeUser, period: timeutil.PeriodHour, filter: userFilter, start: hourStart, end: hourEnd,
+		},
+		{
+			key: quotaKey(user.ID, nil, timeutil.PeriodDay, dayStart, dayEnd), limit: userDailyLimit,
+			ttl: time.Until(dayEnd) + quotaKeyTTLSlack, label: userDailyLabel, scope: QuotaScopeUser, period: timeutil.PeriodDay, filter: userFilter, start: dayStart, end: dayEnd,
+		},
+	}
+
+	if plan != nil && plan.MonthlyLimit > 0 {
+		monthStart, monthEnd := timeutil.Window(timeutil.PeriodMonth, now, location)
+		counters = append(counters, quotaCounter{
+			key: quotaKey(user.ID, nil, timeutil.PeriodMonth, monthStart, monthEnd), limit: plan.MonthlyLimit,
+			ttl: time.Until(monthEnd) + quotaKeyTTLSlack, label: "套餐月配额", scope: QuotaScopeUser, period: timeutil.PeriodMonth, filter: userFilter, start: monthStart, end: monthEnd,
+		})
+	}
+	return counters
+}
+
+// quotaExceededError 配额超限错误
+type quotaExceededError struct {
+	usage QuotaCounterUsage
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("%s已用完（%d/%d）", e.usage.Label, e.usage.Used, e.usage.Limit)
+}
+
+// IsQuotaExceeded 检查错误是否为配额超限
+func IsQuotaExceeded(err error) bool {
+	_, ok := err.(*quotaExceededError)
+	return ok
+}
+
+// ExceededQuotaUsage 返回配额超限错误对应的计数器用量
+func ExceededQuotaUsage(err error) (QuotaCounterUsage, bool) {
+	exceeded, ok := err.(*quotaExceededError)
+	if !ok {
+		return QuotaCounterUsage{}, false
+	}
+	return exceeded.usage, true
+}
+
+// QuotaLocation 获取用户配额周期、统计和报表使用的时区：用户设置的时区，其次为套餐的时区，默认UTC
+func QuotaLocation(user *models.User, plan *models.Plan) *time.Location {
+	planTimezone := ""
+	if plan != nil {
+		planTimezone = plan.Timezone
+	}
+	return timeutil.Location(user.Settings.Timezone, planTimezone)
+}
+
+// UserLocation 按用户ID查询用户的配额时区（见QuotaLocation）
+func UserLocation(ctx context.Context, db *database.MongoDB, userID primitive.ObjectID) (*time.Location, error) {
+	var user models.User
+	if err := db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		return nil, err
+	}
+	plan, err := userPlan(ctx, db, user.PlanID)
+	if err != nil {
+		return nil, err
+	}
+	return QuotaLocation(&user, plan), nil
+}
+
+// quotaKey 生成配额计数器的Redis键，包含周期的起止时间（Unix秒）以便对账
+//
+// 同一用户的计数器使用相同的哈希标签，保证在Redis集群中位于同一分片。
+func quotaKey(userID primitive.ObjectID, credentialID *primitive.ObjectID, period string, start, end time.Time) string {
+	scope := "user"
+	if credentialID != nil {
+		scope = "cred:" + credentialID.Hex()
+	}
+	return fmt.Sprintf("quota:{%s}:%s:%s:%d:%d", userID.Hex(), scope, period, start.Unix(), end.Unix())
+}
+
+// parseQuotaKey 解析配额计数器的Redis键，返回对应的邮件日志过滤条件和周期起止时间
+func parseQuotaKey(key string) (bson.M, time.Time, time.Time, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) != 6 && len(parts) != 7 {
+		return nil, time.Time{}, time.Time{}, false
+	}
+	userID, err := primitive.ObjectIDFromHex(strings.Trim(parts[1], "{}"))
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, false
+	}
+	filter := bson.M{"user_id": userID}
+	if len(parts) == 7 {
+		if parts[2] != "cred" {
+			return nil, time.Time{}, time.Time{}, false
+		}
+		credentialID, err := primitive.ObjectIDFromHex(parts[3])
+		if err != nil {
+			return nil, time.Time{}, time.Time{}, false
+		}
+		filter["credential_id"] = credentialID
+	} else if parts[2] != "user" {
+		return nil, time.Time{}, time.Time{}, false
+	}
+
+	start, err := strconv.ParseInt(parts[len(parts)-2], 10, 64)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, false
+	}
+	end, err := strconv.ParseInt(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, false
+	}
+	return filter, time.Unix(start, 0), time.Unix(end, 0), true
+}
+
+// countRecipients 统计时间范围内邮件日志的收件人总数
+func countRecipients(ctx context.Context, db *database.MongoDB, filter bson.M, start, end time.Time) (int64, error) {
+	match := bson.M{"created_at": bson.M{"$gte": start, "$lt": end}}
+	for key, value := range filter {
+		match[key] = value
+	}
+
+	cursor, err := db.GetCollection("mail_logs").Aggregate(ctx, []bson.M{
+		{"$match": match},
+		{"$group": bson.M{
+			"_id":        nil,
+			"recipients": bson.M{"$sum": bson.M{"$size": bson.M{"$ifNull": bson.A{"$to", bson.A{}}}}},
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Recipients int64 `bson:"recipients"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return 0, err
+		}
+	}
+	return result.Recipients, cursor.Err()
+}
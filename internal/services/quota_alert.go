@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"smtp-relay/internal/models"
+)
+
+// quotaAlertWebhookTimeout 配额提醒Webhook的请求超时
+const quotaAlertWebhookTimeout = 10 * time.Second
+
+// MailEnqueuer 将邮件加入投递队列（由队列服务实现），用于通过中继自身发送通知邮件
+type MailEnqueuer interface {
+	EnqueueMail(mailLog *models.MailLog, body []byte) error
+}
+
+// QuotaAlertEvent 配额提醒事件，作为站内通知的附加数据和Webhook的请求体
+type QuotaAlertEvent struct {
+	Event          string    `json:"event"` // quota.threshold
+	UserID         string    `json:"user_id"`
+	CredentialID   string    `json:"credential_id,omitempty"`
+	CredentialName string    `json:"credential_name,omitempty"`
+	Quota          string    `json:"quota"`  // 如"凭据日配额"
+	Scope          string    `json:"scope"`  // credential, user
+	Period         string    `json:"period"` // hour, day, month
+	Threshold      int       `json:"threshold"`
+	Used           int64     `json:"used"`
+	Limit          int64     `json:"limit"`
+	Exhausted      bool      `json:"exhausted"` // 配额已用完，邮件已被拒绝
+	WindowStart    time.Time `json:"window_start"`
+	WindowEnd      time.Time `json:"window_end"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// QuotaAlertService 配额提醒服务：凭据或用户的配额用量达到阈值时，每个周期每个阈值只通知一次
+//
+// 通知保存为站内通知，并按用户的通知偏好通过中继发送邮件和/或调用Webhook。
+type QuotaAlertService struct {
+	redis               *redis.Client
+	notificationService *NotificationService
+	mailer              MailEnqueuer
+	from                string
+	mailOwnerID         *primitive.ObjectID
+	httpClient          *http.Client
+	logger              *logrus.Logger
+}
+
+// NewQuotaAlertService 创建配额提醒服务，from为通知邮件的发件地址（为空时不发送邮件通知）；
+// mailOwnerID为拥有发件域名的用户，Worker使用其DKIM、ARC、S/MIME和退信域名设置处理通知邮件，为空时通知邮件不签名
+func NewQuotaAlertService(redisClient *redis.Client, notificationService *NotificationService, mailer MailEnqueuer, from string, mailOwnerID *primitive.ObjectID, logger *logrus.Logger) *QuotaAlertService {
+	return &QuotaAlertService{
+		redis:               redisClient,
+		notificationService: notificationService,
+		mailer:              mailer,
+		from:                from,
+		mailOwnerID:         mailOwnerID,
+		httpClient:          newWebhookHTTPClient(quotaAlertWebhookTimeout),
+		logger:              logger,
+	}
+}
+
+// Check 检查邮件被接受后各配额计数器的用量，达到阈值时异步发送提醒
+func (s *QuotaAlertService) Check(user *models.User, credential *models.SMTPCredential, usage []QuotaCounterUsage) {
+	s.check(user, credential, usage, false)
+}
+
+// CheckExceeded 配额超限导致邮件被拒绝时发送配额已用完的提醒
+func (s *QuotaAlertService) CheckExceeded(user *models.User, credential *models.SMTPCredential, err error) {
+	usage, ok := ExceededQuotaUsage(err)
+	if !ok {
+		return
+	}
+	s.check(user, credential, []QuotaCounterUsage{usage}, true)
+}
+
+// check 找出每个计数器已达到的最高阈值并发送提醒
+func (s *QuotaAlertService) check(user *models.User, credential *models.SMTPCredential, usage []QuotaCounterUsage, exhausted bool) {
+	preferences := EffectiveNotificationPreferences(user)
+	if len(preferences.QuotaThresholds) == 0 {
+		return
+	}
+
+	for _, counter := range usage {
+		threshold := reachedThreshold(preferences.QuotaThresholds, counter, exhausted)
+		if threshold == 0 {
+			continue
+		}
+		go s.alert(user, credential, preferences, counter, threshold, exhausted)
+	}
+}
+
+// reachedThreshold 返回计数器已达到的最高阈值，未达到任何阈值时返回0；配额已用完时视为达到100%
+func reachedThreshold(thresholds []int, counter QuotaCounterUsage, exhausted bool) int {
+	if counter.Limit <= 0 {
+		return 0
+	}
+	reached := 0
+	for _, threshold := range thresholds {
+		if exhausted || counter.Used*100 >= int64(threshold)*counter.Limit {
+			reached = threshold
+		}
+	}
+	return reached
+}
+
+// alert 发送单个阈值的提醒，同一计数器周期内的每个阈值只发送一次（多个SMTP节点共享）
+func (s *QuotaAlertService) alert(user *models.User, credential *models.SMTPCredential, preferences *models.NotificationPreferences, counter QuotaCounterUsage, threshold int, exhausted bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	key := fmt.Sprintf("quota-alert%s:%d", strings.TrimPrefix(counter.key, "quota"), threshold)
+	claimed, err := s.redis.SetNX(ctx, key, time.Now().Unix(), time.Until(counter.End)+quotaKeyTTLSlack).Result()
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID.Hex()).Warn("配额提醒去重失败，跳过提醒")
+		return
+	}
+	if !claimed {
+		return
+	}
+
+	event := &QuotaAlertEvent{
+		Event:       "quota.threshold",
+		UserID:      user.ID.Hex(),
+		Quota:       counter.Label,
+		Scope:       counter.Scope,
+		Period:      counter.Period,
+		Threshold:   threshold,
+		Used:        counter.Used,
+		Limit:       counter.Limit,
+		Exhausted:   exhausted,
+		WindowStart: counter.Start,
+		WindowEnd:   counter.End,
+		Timestamp:   time.Now(),
+	}
+	if counter.Scope == QuotaScopeCredential {
+		event.CredentialID = credential.ID.Hex()
+		event.CredentialName = credential.Name
+	}
+	title, message := quotaAlertText(event)
+
+	level := models.NotificationWarning
+	if exhausted || threshold >= 100 {
+		level = models.NotificationCritical
+	}
+	if err := s.notificationService.Notify(&models.Notification{
+		UserID:  user.ID,
+		Type:    models.NotificationTypeQuotaThreshold,
+		Level:   level,
+		Title:   title,
+		Message: message,
+		Data: map[string]interface{}{
+			"credential_id": event.CredentialID,
+			"quota":         event.Quota,
+			"scope":         event.Scope,
+			"period":        event.Period,
+			"threshold":     event.Threshold,
+			"used":          event.Used,
+			"limit":         event.Limit,
+			"exhausted":     event.Exhausted,
+			"window_start":  event.WindowStart,
+			"window_end":    event.WindowEnd,
+		},
+	}); err != nil {
+		s.logger.WithError(err).WithField("user_id", user.ID.Hex()).Warn("保存配额提醒失败")
+	}
+
+	if preferences.EmailEnabled {
+		recipient := preferences.Email
+		if recipient == "" {
+			recipient = user.Email
+		}
+		// 兼容保存时带有显示名称的旧设置，信封收件人只能是纯邮箱地址
+		if addr, err := mail.ParseAddress(recipient); err == nil {
+			recipient = addr.Address
+		}
+		if err := s.sendEmail(user, recipient, title, message); err != nil {
+			s.logger.WithError(err).WithField("user_id", user.ID.Hex()).Warn("发送配额提醒邮件失败")
+		}
+	}
+
+	if preferences.WebhookURL != "" {
+		secret, err := s.notificationService.WebhookSecret(preferences)
+		if err != nil {
+			s.logger.WithError(err).WithField("user_id", user.ID.Hex()).Error("调用配额提醒Webhook失败")
+		} else if err := s.sendWebhook(preferences.WebhookURL, secret, event); err != nil {
+			s.logger.WithError(err).WithField("user_id", user.ID.Hex()).Warn("调用配额提醒Webhook失败")
+		}
+	}
+}
+
+// quotaAlertText 生成提醒的标题和正文
+func quotaAlertText(event *QuotaAlertEvent) (string, string) {
+	title := fmt.Sprintf("%s已使用%d%%", event.Quota, event.Threshold)
+	if event.Exhausted {
+		title = fmt.Sprintf("%s已用完", event.Quota)
+	}
+	if event.CredentialName != "" {
+		title = fmt.Sprintf("凭据 %s 的%s", event.CredentialName, title)
+	}
+
+	message := fmt.Sprintf("当前周期（%s 至 %s）已使用 %d/%d 个收件人。",
+		event.WindowStart.Format("2006-01-02 15:04 MST"), event.WindowEnd.Format("2006-01-02 15:04 MST"), event.Used, event.Limit)
+	if event.Exhausted || event.Used >= event.Limit {
+		message += "配额用完后邮件将被临时拒绝（451 4.7.1），直到下一个周期开始。"
+	}
+	return title, message
+}
+
+// sendEmail 通过中继自身发送通知邮件：系统邮件不属于任何用户（不出现在用户的邮件日志中，也不计入配额和用量），按签名所有者的设置签名
+func (s *QuotaAlertService) sendEmail(user *models.User, recipient, subject, text string) error {
+	if s.mailer == nil || s.from == "" {
+		return fmt.Errorf("未配置通知邮件发件地址")
+	}
+
+	domain := s.from[strings.LastIndex(s.from, "@")+1:]
+	messageID := fmt.Sprintf("<%s@%s>", primitive.NewObjectID().Hex(), domain)
+	now := time.Now()
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "From: %s\r\n", s.from)
+	fmt.Fprintf(&body, "To: %s\r\n", recipient)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	fmt.Fprintf(&body, "Message-ID: %s\r\n", messageID)
+	body.WriteString("MIME-Version: 1.0\r\n")
+	body.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	body.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	body.WriteString("Auto-Submitted: auto-generated\r\n")
+	body.WriteString("\r\n")
+	body.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	body.WriteString("\r\n")
+
+	mailLog := &models.MailLog{
+		MessageID:    messageID,
+		From:         s.from,
+		To:           []string{recipient},
+		Subject:      subject,
+		Size:         int64(body.Len()),
+		Status:       "queued",
+		CreatedAt:    now,
+		Tags:         []string{"system", "quota-alert"},
+		Metadata:     map[string]string{"user_id": user.ID.Hex()},
+		SignerUserID: s.mailOwnerID,
+	}
+	return s.mailer.EnqueueMail(mailLog, body.Bytes())
+}
+
+// sendWebhook 以JSON调用Webhook，设置了签名密钥时在X-Relay-Signature头部中附带请求体的HMAC-SHA256签名
+func (s *QuotaAlertService) sendWebhook(webhookURL, secret string, event *QuotaAlertEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Relay-Event", event.Event)
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(payload)
+		req.Header.Set("X-Relay-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
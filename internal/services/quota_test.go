@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestRedis 启动内存Redis并返回连接到它的客户端，测试结束时自动关闭
+func newTestRedis(t *testing.T) (*miniredis.Miniredis, *redis.Client) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return server, client
+}
+
+// newTestLogger 返回丢弃输出的日志记录器
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func TestQuotaReserveScript(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestRedis(t)
+
+	reserve := func(amount int64, limits ...int64) []interface{} {
+		t.Helper()
+		args := []interface{}{amount}
+		for _, limit := range limits {
+			args = append(args, limit)
+		}
+		for range limits {
+			args = append(args, 3600)
+		}
+		result, err := quotaReserveScript.Run(ctx, client, []string{"quota:hour", "quota:day"}, args...).Slice()
+		if err != nil {
+			t.Fatalf("执行脚本失败: %v", err)
+		}
+		return result
+	}
+	counter := func(key string) string {
+		value, _ := server.Get(key)
+		return value
+	}
+
+	server.Set("quota:day", "95")
+	server.SetTTL("quota:day", 10*time.Minute)
+
+	// 预占成功：返回0和各计数器预占后的用量，新计数器设置过期时间，已有计数器保留原过期时间
+	result := reserve(3, 10, 100)
+	if len(result) != 3 || result[0] != int64(0) || result[1] != int64(3) || result[2] != int64(98) {
+		t.Fatalf("预占结果为 %v，期望 [0 3 98]", result)
+	}
+	if ttl := server.TTL("quota:hour"); ttl != time.Hour {
+		t.Errorf("新计数器的过期时间为 %v，期望 1h", ttl)
+	}
+	if ttl := server.TTL("quota:day"); ttl != 10*time.Minute {
+		t.Errorf("已有计数器的过期时间被修改为 %v", ttl)
+	}
+
+	// 第二个计数器超限：返回其序号和当前用量，所有计数器均不修改
+	result = reserve(5, 10, 100)
+	if len(result) != 2 || result[0] != int64(2) || result[1] != int64(98) {
+		t.Fatalf("超限结果为 %v，期望 [2 98]", result)
+	}
+	if counter("quota:hour") != "3" || counter("quota:day") != "98" {
+		t.Errorf("超限时计数器被修改: hour=%s day=%s", counter("quota:hour"), counter("quota:day"))
+	}
+
+	// 恰好达到上限时允许
+	result = reserve(2, 10, 100)
+	if result[0] != int64(0) || result[2] != int64(100) {
+		t.Fatalf("达到上限时的预占结果为 %v，期望成功", result)
+	}
+
+	// 上限为0的计数器不限制，但仍然计数
+	result = reserve(50, 0, 0)
+	if result[0] != int64(0) || result[1] != int64(55) || result[2] != int64(150) {
+		t.Fatalf("不限制时的预占结果为 %v，期望 [0 55 150]", result)
+	}
+}
+
+func TestQuotaRaiseScript(t *testing.T) {
+	ctx := context.Background()
+	server, client := newTestRedis(t)
+
+	server.Set("quota:counter", "40")
+	server.SetTTL("quota:counter", 30*time.Minute)
+
+	raise := func(key string, used int64) int64 {
+		t.Helper()
+		raised, err := quotaRaiseScript.Run(ctx, client, []string{key}, used).Int64()
+		if err != nil {
+			t.Fatalf("执行脚本失败: %v", err)
+		}
+		return raised
+	}
+
+	tests := []struct {
+		name       string
+		used       int64
+		wantRaised int64
+		wantValue  string
+	}{
+		{"邮件日志用量较低时不调低", 25, 0, "40"},
+		{"用量相同时不修改", 40, 0, "40"},
+		{"邮件日志用量较高时调高", 52, 12, "52"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if raised := raise("quota:counter", tt.used); raised != tt.wantRaised {
+				t.Errorf("增加了 %d，期望 %d", raised, tt.wantRaised)
+			}
+			if value, _ := server.Get("quota:counter"); value != tt.wantValue {
+				t.Errorf("计数器为 %s，期望 %s", value, tt.wantValue)
+			}
+			if ttl := server.TTL("quota:counter"); ttl != 30*time.Minute {
+				t.Errorf("过期时间被修改为 %v", ttl)
+			}
+		})
+	}
+
+	if raised := raise("quota:missing", 10); raised != 0 || server.Exists("quota:missing") {
+		t.Errorf("不存在的计数器不应被创建")
+	}
+}
+
+func TestQuotaReserveRedisAndRefund(t *testing.T) {
+	server, client := newTestRedis(t)
+	service := NewQuotaService(nil, client, newTestLogger())
+
+	now := time.Now()
+	counters := []quotaCounter{
+		{key: "quota:cred", limit: 10, ttl: time.Hour, label: "凭据小时配额", end: now.Add(time.Hour)},
+		{key: "quota:user", limit: 0, ttl: time.Hour, label: "用户小时配额", end: now.Add(time.Hour)},
+	}
+	// 计数器已存在时不会从MongoDB初始化
+	server.Set("quota:cred", "4")
+	server.Set("quota:user", "4")
+
+	reservation, err := service.reserveRedis(counters, 5)
+	if err != nil {
+		t.Fatalf("预占失败: %v", err)
+	}
+	usage := reservation.Usage()
+	if len(usage) != 1 || usage[0].Used != 9 || usage[0].Limit != 10 {
+		t.Fatalf("用量为 %+v，期望只包含凭据计数器且已用9", usage)
+	}
+
+	_, err = service.reserveRedis(counters, 2)
+	if !IsQuotaExceeded(err) {
+		t.Fatalf("超过配额时应返回配额超限错误，实际为 %v", err)
+	}
+	if exceeded, _ := ExceededQuotaUsage(err); exceeded.Used != 9 || err.Error() != "凭据小时配额已用完（9/10）" {
+		t.Errorf("超限错误为 %q（已用%d）", err.Error(), exceeded.Used)
+	}
+
+	reservation.Refund()
+	reservation.Refund() // 重复退还不应重复扣减
+	for _, key := range []string{"quota:cred", "quota:user"} {
+		if value, _ := server.Get(key); value != "4" {
+			t.Errorf("退还后 %s 为 %s，期望 4", key, value)
+		}
+	}
+}
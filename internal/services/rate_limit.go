@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"smtp-relay/internal/models"
+)
+
+// 速率限制类型
+const (
+	RateLimitMessages   = "messages"   // 每秒邮件数
+	RateLimitRecipients = "recipients" // 每分钟收件人数
+)
+
+// rateLimitScript 原子地从多个令牌桶中取令牌：任一桶令牌不足时不做任何修改，返回该桶的序号（从1开始）和需要等待的毫秒数
+//
+// 每个桶以哈希保存剩余令牌数(tokens)和上次更新时间(ts，毫秒)，使用Redis服务器时间保证多个SMTP节点一致。
+// ARGV按桶依次为：每毫秒补充的令牌数、桶容量、本次消耗的令牌数。
+var rateLimitScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+local n = #KEYS
+local tokens = {}
+for i = 1, n do
+	local rate = tonumber(ARGV[i * 3 - 2])
+	local capacity = tonumber(ARGV[i * 3 - 1])
+	local cost = tonumber(ARGV[i * 3])
+	local state = redis.call('HMGET', KEYS[i], 'tokens', 'ts')
+	local current = tonumber(state[1])
+	local ts = tonumber(state[2])
+	if current == nil or ts == nil then
+		current = capacity
+	else
+		current = math.min(capacity, current + math.max(0, now - ts) * rate)
+	end
+	if current < cost then
+		return {i, math.ceil((cost - current) / rate)}
+	end
+	tokens[i] = current - cost
+end
+for i = 1, n do
+	local rate = tonumber(ARGV[i * 3 - 2])
+	local capacity = tonumber(ARGV[i * 3 - 1])
+	redis.call('HSET', KEYS[i], 'tokens', tostring(tokens[i]), 'ts', now)
+	redis.call('PEXPIRE', KEYS[i], math.ceil(capacity / rate) + 1000)
+end
+return {0, 0}
+`)
+
+// RateLimitService 速率限制服务：使用Redis令牌桶限制凭据和用户的每秒邮件数和每分钟收件人数，多个SMTP节点共享同一组令牌桶
+type RateLimitService struct {
+	redis  *redis.Client
+	logger *logrus.Logger
+}
+
+// NewRateLimitService 创建速率限制服务
+func NewRateLimitService(redisClient *redis.Client, logger *logrus.Logger) *RateLimitService {
+	return &RateLimitService{
+		redis:  redisClient,
+		logger: logger,
+	}
+}
+
+// RateLimitStatus 令牌桶的当前状态
+type RateLimitStatus struct {
+	Scope     string  `json:"scope" example:"credential"` // credential 或 user
+	Type      string  `json:"type" example:"messages"`    // messages（每秒邮件数）或 recipients（每分钟收件人数）
+	Limit     int     `json:"limit" example:"10"`         // 配置的速率
+	Available float64 `json:"available" example:"7.5"`    // 当前可用的令牌数
+}
+
+// rateBucket 单个令牌桶
+type rateBucket struct {
+	key      string
+	scope    string
+	kind     string
+	limit    int
+	rate     float64 // 每毫秒补充的令牌数
+	capacity float64
+	label    string // 超限时的错误描述，如"凭据每秒邮件数"
+}
+
+// Take 为一封邮件从凭据和用户的令牌桶中取令牌（每封邮件1个邮件令牌，每个收件人1个收件人令牌），plan为用户生效的套餐，没有套餐时为nil
+//
+// 超过速率限制时返回"xxx超过限制"错误（包含建议的等待秒数）；Redis不可用时不限制。
+// 收件人数超过桶容量的邮件永远无法取得足够的令牌，直接返回永久错误（见IsRecipientBatchTooLarge），不消耗任何令牌。
+func (s *RateLimitService) Take(user *models.User, credential *models.SMTPCredential, plan *models.Plan, recipients int) error {
+	buckets := s.buckets(user, credential, plan)
+	if len(buckets) == 0 {
+		return nil
+	}
+
+	keys := make([]string, len(buckets))
+	args := make([]interface{}, 0, 3*len(buckets))
+	for i, bucket := range buckets {
+		cost := 1.0
+		if bucket.kind == RateLimitRecipients {
+			cost = float64(recipients)
+			if cost > bucket.capacity {
+				return &recipientBatchError{label: bucket.label, limit: bucket.limit, recipients: recipients}
+			}
+		}
+		keys[i] = bucket.key
+		args = append(args, bucket.rate, bucket.capacity, cost)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	result, err := rateLimitScript.Run(ctx, s.redis, keys, args...).Slice()
+	if err != nil || len(result) != 2 {
+		s.logger.WithError(err).WithField("credential_id", credential.ID.Hex()).Warn("速率限制检查失败，跳过限制")
+		return nil
+	}
+	index, _ := result[0].(int64)
+	if index > 0 && int(index) <= len(buckets) {
+		waitMillis, _ := result[1].(int64)
+		return &rateLimitedError{label: buckets[index-1].label, limit: buckets[index-1].limit, retryAfter: time.Duration(waitMillis) * time.Millisecond}
+	}
+	return nil
+}
+
+// Status 获取凭据和所属用户各令牌桶的当前状态（未配置的限制不返回）
+func (s *RateLimitService) Status(user *models.User, credential *models.SMTPCredential, plan *models.Plan) ([]RateLimitStatus, error) {
+	buckets := s.buckets(user, credential, plan)
+	statuses := make([]RateLimitStatus, 0, len(buckets))
+	if len(buckets) == 0 {
+		return statuses, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	now, err := s.redis.Time(ctx).Result()
+	if err != nil {
+		return nil, err
+	}
+	nowMillis := float64(now.UnixNano() / int64(time.Millisecond))
+
+	for _, bucket := range buckets {
+		available := bucket.capacity
+		values, err := s.redis.HMGet(ctx, bucket.key, "tokens", "ts").Result()
+		if err != nil {
+			return nil, err
+		}
+		tokens, tokensOK := parseRedisFloat(values[0])
+		ts, tsOK := parseRedisFloat(values[1])
+		if tokensOK && tsOK {
+			available = math.Min(bucket.capacity, tokens+math.Max(0, nowMillis-ts)*bucket.rate)
+		}
+		statuses = append(statuses, RateLimitStatus{
+			Scope:     bucket.scope,
+			Type:      bucket.kind,
+			Limit:     bucket.limit,
+			Available: math.Floor(available*100) / 100,
+		})
+	}
+	return statuses, nil
+}
+
+// buckets 构建凭据和用户已配置的令牌桶，用户令牌桶使用套餐上限和用户设置中较小的值
+func (s *RateLimitService) buckets(user *models.User, credential *models.SMTPCredential, plan *models.Plan) []rateBucket {
+	var buckets []rateBucket
+	add := func(scope string, id primitive.ObjectID, kind string, limit int, label string) {
+		if limit <= 0 {
+			return
+		}
+		bucket := rateBucket{
+			key:      fmt.Sprintf("ratelimit:{%s}:%s:%s:%s", user.ID.Hex(), scope, id.Hex(), kind),
+			scope:    scope,
+			kind:     kind,
+			limit:    limit,
+			capacity: float64(limit),
+			label:    label,
+		}
+		if kind == RateLimitMessages {
+			bucket.rate = float64(limit) / 1000
+		} else {
+			bucket.rate = float64(limit) / 60000
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	add("credential", credential.ID, RateLimitMessages, credential.Settings.MessagesPerSecond, "凭据每秒邮件数")
+	add("credential", credential.ID, RateLimitRecipients, credential.Settings.RecipientsPerMinute, "凭据每分钟收件人数")
+	messagesPerSecond, recipientsPerMinute := RateLimitCeiling(user, plan)
+	add("user", user.ID, RateLimitMessages, messagesPerSecond, "用户每秒邮件数")
+	add("user", user.ID, RateLimitRecipients, recipientsPerMinute, "用户每分钟收件人数")
+	return buckets
+}
+
+// RateLimitCeiling 获取用户所有凭据合计的速率上限：套餐上限和管理员为用户设置的速率中较小的值，0表示不限制
+func RateLimitCeiling(user *models.User, plan *models.Plan) (messagesPerSecond, recipientsPerMinute int) {
+	messagesPerSecond, recipientsPerMinute = user.Settings.MessagesPerSecond, user.Settings.RecipientsPerMinute
+	if plan != nil {
+		messagesPerSecond = lowerRateLimit(messagesPerSecond, plan.MessagesPerSecond)
+		recipientsPerMinute = lowerRateLimit(recipientsPerMinute, plan.RecipientsPerMinute)
+	}
+	return messagesPerSecond, recipientsPerMinute
+}
+
+// lowerRateLimit 返回两个速率限制中较严格的一个（0表示不限制）
+func lowerRateLimit(a, b int) int {
+	if a <= 0 || (b > 0 && b < a) {
+		return b
+	}
+	return a
+}
+
+// rateLimitedError 速率超限错误
+type rateLimitedError struct {
+	label      string
+	limit      int
+	retryAfter time.Duration
+}
+
+func (e *rateLimitedError) Error() string {
+	return fmt.Sprintf("%s超过限制（%d），请%d秒后重试", e.label, e.limit, int(math.Ceil(e.retryAfter.Seconds())))
+}
+
+// IsRateLimited 检查错误是否为速率超限
+func IsRateLimited(err error) bool {
+	_, ok := err.(*rateLimitedError)
+	return ok
+}
+
+// recipientBatchError 单封邮件的收件人数超过每分钟收件人数限制
+type recipientBatchError struct {
+	label      string
+	limit      int
+	recipients int
+}
+
+func (e *recipientBatchError) Error() string {
+	return fmt.Sprintf("邮件收件人数（%d）超过%s限制（%d），请拆分后发送", e.recipients, e.label, e.limit)
+}
+
+// IsRecipientBatchTooLarge 检查错误是否为单封邮件收件人数超过每分钟收件人数限制（重试也无法成功）
+func IsRecipientBatchTooLarge(err error) bool {
+	_, ok := err.(*recipientBatchError)
+	return ok
+}
+
+// parseRedisFloat 解析HMGET返回的数值
+func parseRedisFloat(value interface{}) (float64, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	result, err := strconv.ParseFloat(str, 64)
+	return result, err == nil
+}
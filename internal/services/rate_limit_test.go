@@ -0,0 +1,151 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"smtp-relay/internal/models"
+)
+
+func newRateLimitFixtures(userSettings models.UserSettings, credentialSettings models.SMTPCredentialSettings) (*models.User, *models.SMTPCredential) {
+	user := &models.User{ID: primitive.NewObjectID(), Settings: userSettings}
+	credential := &models.SMTPCredential{ID: primitive.NewObjectID(), UserID: user.ID, Settings: credentialSettings}
+	return user, credential
+}
+
+func TestRateLimitTakeMessages(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.SetTime(time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC))
+	service := NewRateLimitService(client, newTestLogger())
+	user, credential := newRateLimitFixtures(models.UserSettings{}, models.SMTPCredentialSettings{MessagesPerSecond: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := service.Take(user, credential, nil, 1); err != nil {
+			t.Fatalf("第%d封邮件被限制: %v", i+1, err)
+		}
+	}
+
+	err := service.Take(user, credential, nil, 1)
+	if !IsRateLimited(err) {
+		t.Fatalf("令牌用完后应返回速率超限错误，实际为 %v", err)
+	}
+	if !strings.Contains(err.Error(), "凭据每秒邮件数超过限制（2）") {
+		t.Errorf("错误信息为 %q", err.Error())
+	}
+
+	// 每秒补充2个令牌，500毫秒后可以再发送一封
+	server.SetTime(time.Date(2026, 10, 5, 10, 0, 0, int(500*time.Millisecond), time.UTC))
+	if err := service.Take(user, credential, nil, 1); err != nil {
+		t.Fatalf("补充令牌后仍被限制: %v", err)
+	}
+	if err := service.Take(user, credential, nil, 1); !IsRateLimited(err) {
+		t.Fatalf("补充的令牌用完后应被限制，实际为 %v", err)
+	}
+}
+
+func TestRateLimitTakeIsAtomicAcrossBuckets(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.SetTime(time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC))
+	service := NewRateLimitService(client, newTestLogger())
+	user, credential := newRateLimitFixtures(
+		models.UserSettings{MessagesPerSecond: 100},
+		models.SMTPCredentialSettings{RecipientsPerMinute: 10},
+	)
+
+	if err := service.Take(user, credential, nil, 8); err != nil {
+		t.Fatalf("取令牌失败: %v", err)
+	}
+	err := service.Take(user, credential, nil, 5)
+	if !IsRateLimited(err) || !strings.Contains(err.Error(), "凭据每分钟收件人数") {
+		t.Fatalf("收件人令牌不足时应返回凭据每分钟收件人数超限，实际为 %v", err)
+	}
+
+	// 被拒绝的邮件不消耗任何桶的令牌
+	statuses, err := service.Status(user, credential, nil)
+	if err != nil {
+		t.Fatalf("获取令牌桶状态失败: %v", err)
+	}
+	want := map[string]float64{"credential/recipients": 2, "user/messages": 99}
+	if len(statuses) != len(want) {
+		t.Fatalf("令牌桶状态为 %+v", statuses)
+	}
+	for _, status := range statuses {
+		if available := want[status.Scope+"/"+status.Type]; status.Available != available {
+			t.Errorf("%s/%s 剩余令牌 %v，期望 %v", status.Scope, status.Type, status.Available, available)
+		}
+	}
+}
+
+func TestRateLimitTakeRecipientBatchTooLarge(t *testing.T) {
+	server, client := newTestRedis(t)
+	service := NewRateLimitService(client, newTestLogger())
+	user, credential := newRateLimitFixtures(
+		models.UserSettings{RecipientsPerMinute: 20},
+		models.SMTPCredentialSettings{MessagesPerSecond: 5, RecipientsPerMinute: 50},
+	)
+
+	err := service.Take(user, credential, nil, 21)
+	if !IsRecipientBatchTooLarge(err) || IsRateLimited(err) {
+		t.Fatalf("收件人数超过桶容量时应返回永久错误，实际为 %v", err)
+	}
+	if !strings.Contains(err.Error(), "用户每分钟收件人数限制（20）") {
+		t.Errorf("错误信息为 %q", err.Error())
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("超过容量的邮件不应消耗令牌，实际创建了 %v", keys)
+	}
+
+	// 恰好等于容量时允许
+	if err := service.Take(user, credential, nil, 20); err != nil {
+		t.Fatalf("收件人数等于桶容量时被拒绝: %v", err)
+	}
+}
+
+func TestRateLimitTakeWithoutLimits(t *testing.T) {
+	server, client := newTestRedis(t)
+	service := NewRateLimitService(client, newTestLogger())
+	user, credential := newRateLimitFixtures(models.UserSettings{}, models.SMTPCredentialSettings{})
+
+	if err := service.Take(user, credential, nil, 1000); err != nil {
+		t.Fatalf("未配置速率限制时不应限制: %v", err)
+	}
+	if keys := server.Keys(); len(keys) != 0 {
+		t.Errorf("未配置速率限制时不应创建令牌桶: %v", keys)
+	}
+}
+
+func TestRateLimitTakeRedisUnavailable(t *testing.T) {
+	server, client := newTestRedis(t)
+	service := NewRateLimitService(client, newTestLogger())
+	user, credential := newRateLimitFixtures(models.UserSettings{}, models.SMTPCredentialSettings{MessagesPerSecond: 1})
+	server.Close()
+
+	// Redis不可用时不限制
+	for i := 0; i < 3; i++ {
+		if err := service.Take(user, credential, nil, 1); err != nil {
+			t.Fatalf("Redis不可用时不应限制: %v", err)
+		}
+	}
+}
+
+func TestRateLimitTakeEnforcesPlanCeiling(t *testing.T) {
+	server, client := newTestRedis(t)
+	server.SetTime(time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC))
+	service := NewRateLimitService(client, newTestLogger())
+	// 历史数据中用户和凭据都未限制，套餐上限仍然生效
+	user, credential := newRateLimitFixtures(models.UserSettings{MessagesPerSecond: 100}, models.SMTPCredentialSettings{})
+	plan := &models.Plan{MessagesPerSecond: 2}
+
+	for i := 0; i < 2; i++ {
+		if err := service.Take(user, credential, plan, 1); err != nil {
+			t.Fatalf("第%d封邮件被限制: %v", i+1, err)
+		}
+	}
+	err := service.Take(user, credential, plan, 1)
+	if !IsRateLimited(err) || !strings.Contains(err.Error(), "用户每秒邮件数超过限制（2）") {
+		t.Fatalf("超过套餐上限时应返回用户每秒邮件数超限，实际为 %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 中继控制头部：客户端通过这些头部为邮件附加标签、活动ID、元数据、优先级和过期时间，投递前会被移除
+const (
+	RelayHeaderPrefix   = "X-Relay-"
+	RelayHeaderTag      = "X-Relay-Tag"
+	RelayHeaderCampaign = "X-Relay-Campaign"
+	RelayHeaderMetadata = "X-Relay-Metadata"
+	RelayHeaderPriority = "X-Relay-Priority"
+	RelayHeaderExpires  = "X-Relay-Expires"
+)
+
+// 邮件优先级提示
+const (
+	RelayPriorityHigh   = "high"
+	RelayPriorityNormal = "normal"
+	RelayPriorityLow    = "low"
+)
+
+// 中继头部的数量和长度限制
+const (
+	maxRelayTags          = 10
+	maxRelayTagLength     = 64
+	maxRelayCampaignLen   = 128
+	maxRelayMetadataKeys  = 20
+	maxRelayMetadataKey   = 64
+	maxRelayMetadataValue = 256
+	maxRelayExpiry        = 7 * 24 * time.Hour
+)
+
+var (
+	// relayTagPattern 标签和活动ID允许的字符
+	relayTagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.:\-]*$`)
+	// relayMetadataKeyPattern 元数据键允许的字符（不允许.和$，可直接用作MongoDB字段名）
+	relayMetadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+)
+
+// RelayHeaders 从邮件头部解析出的中继控制信息
+type RelayHeaders struct {
+	Tags       []string
+	CampaignID string
+	Metadata   map[string]string
+	Priority   string
+	ExpiresAt  *time.Time
+}
+
+// ParseRelayHeaders 解析并校验邮件中的X-Relay-*头部，返回解析结果和移除了所有X-Relay-*头部的邮件内容
+//
+// X-Relay-Tag和X-Relay-Metadata可以出现多次，也可以在一个头部中用逗号（标签）或分号（元数据）分隔多个值；
+// X-Relay-Expires接受RFC 3339或RFC 5322格式的时间。未识别的X-Relay-*头部同样会被移除。
+func ParseRelayHeaders(data []byte, now time.Time) (*RelayHeaders, []byte, error) {
+	headers := &RelayHeaders{}
+	var kept bytes.Buffer
+	kept.Grow(len(data))
+
+	seen := make(map[string]bool)
+	rest := data
+	for len(rest) > 0 {
+		// 空行表示头部结束
+		if bytes.HasPrefix(rest, []byte("\r\n")) || rest[0] == '\n' {
+			break
+		}
+
+		// 取出一个完整的头部字段（包含续行）
+		end := 0
+		for {
+			i := bytes.IndexByte(rest[end:], '\n')
+			if i < 0 {
+				end = len(rest)
+				break
+			}
+			end += i + 1
+			if end >= len(rest) || (rest[end] != ' ' && rest[end] != '\t') {
+				break
+			}
+		}
+		field := rest[:end]
+		rest = rest[end:]
+
+		colon := bytes.IndexByte(field, ':')
+		if colon <= 0 {
+			kept.Write(field)
+			continue
+		}
+		name := string(bytes.TrimSpace(field[:colon]))
+		if len(name) < len(RelayHeaderPrefix) || !strings.EqualFold(name[:len(RelayHeaderPrefix)], RelayHeaderPrefix) {
+			kept.Write(field)
+			continue
+		}
+
+		// 展开折行（兼容LF换行）
+		value := strings.TrimSpace(strings.NewReplacer("\r\n", "", "\n", "").Replace(string(field[colon+1:])))
+		canonical := strings.ToLower(name)
+		switch canonical {
+		case strings.ToLower(RelayHeaderTag):
+			if err := headers.addTags(value); err != nil {
+				return nil, nil, err
+			}
+		case strings.ToLower(RelayHeaderMetadata):
+			if err := headers.addMetadata(value); err != nil {
+				return nil, nil, err
+			}
+		case strings.ToLower(RelayHeaderCampaign), strings.ToLower(RelayHeaderPriority), strings.ToLower(RelayHeaderExpires):
+			if seen[canonical] {
+				return nil, nil, fmt.Errorf("%s头部只能出现一次", name)
+			}
+			seen[canonical] = true
+			if err := headers.setSingle(canonical, value, now); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	kept.Write(rest)
+
+	return headers, kept.Bytes(), nil
+}
+
+// addTags 添加X-Relay-Tag头部中的标签（逗号分隔，重复标签只保留一个）
+func (h *RelayHeaders) addTags(value string) error {
+	for _, tag := range strings.Split(value, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if len(tag) > maxRelayTagLength || !relayTagPattern.MatchString(tag) {
+			return fmt.Errorf("无效的标签: %s", tag)
+		}
+		duplicate := false
+		for _, existing := range h.Tags {
+			if existing == tag {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+		if len(h.Tags) >= maxRelayTags {
+			return fmt.Errorf("标签不能超过%d个", maxRelayTags)
+		}
+		h.Tags = append(h.Tags, tag)
+	}
+	return nil
+}
+
+// addMetadata 添加X-Relay-Metadata头部中的键值对（key=value，分号分隔；值可使用RFC 2047编码）
+func (h *RelayHeaders) addMetadata(value string) error {
+	for _, pair := range strings.Split(value, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		eq := strings.IndexByte(pair, '=')
+		if eq < 0 {
+			return fmt.Errorf("元数据格式错误，应为key=value: %s", pair)
+		}
+		key := strings.TrimSpace(pair[:eq])
+		val := decodeHeaderValue(strings.TrimSpace(pair[eq+1:]))
+		if err := ValidateMetadataKey(key); err != nil {
+			return err
+		}
+		if len(val) > maxRelayMetadataValue {
+			return fmt.Errorf("元数据值不能超过%d个字符: %s", maxRelayMetadataValue, key)
+		}
+		if h.Metadata == nil {
+			h.Metadata = make(map[string]string)
+		}
+		if _, exists := h.Metadata[key]; exists {
+			return fmt.Errorf("重复的元数据键: %s", key)
+		}
+		if len(h.Metadata) >= maxRelayMetadataKeys {
+			return fmt.Errorf("元数据不能超过%d项", maxRelayMetadataKeys)
+		}
+		h.Metadata[key] = val
+	}
+	return nil
+}
+
+// setSingle 设置只能出现一次的头部（活动ID、优先级、过期时间）
+func (h *RelayHeaders) setSingle(name, value string, now time.Time) error {
+	switch name {
+	case strings.ToLower(RelayHeaderCampaign):
+		if value == "" {
+			return nil
+		}
+		if len(value) > maxRelayCampaignLen || !relayTagPattern.MatchString(value) {
+			return fmt.Errorf("无效的活动ID: %s", value)
+		}
+		h.CampaignID = value
+	case strings.ToLower(RelayHeaderPriority):
+		priority := strings.ToLower(value)
+		switch priority {
+		case RelayPriorityHigh, RelayPriorityNormal, RelayPriorityLow:
+			h.Priority = priority
+		default:
+			return fmt.Errorf("无效的优先级: %s（可选值: high, normal, low）", value)
+		}
+	case strings.ToLower(RelayHeaderExpires):
+		expiresAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			if expiresAt, err = mail.ParseDate(value); err != nil {
+				return fmt.Errorf("无效的过期时间: %s", value)
+			}
+		}
+		if !expiresAt.After(now) {
+			return fmt.Errorf("过期时间必须晚于当前时间")
+		}
+		if expiresAt.Sub(now) > maxRelayExpiry {
+			return fmt.Errorf("过期时间不能超过7天")
+		}
+		expiresAt = expiresAt.UTC()
+		h.ExpiresAt = &expiresAt
+	}
+	return nil
+}
+
+// ValidateMetadataKey 校验元数据键（也用于日志过滤和统计分组参数）
+func ValidateMetadataKey(key string) error {
+	if key == "" || len(key) > maxRelayMetadataKey || !relayMetadataKeyPattern.MatchString(key) {
+		return fmt.Errorf("无效的元数据键: %s", key)
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRelayHeaders(t *testing.T) {
+	now := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+
+	data := "From: alice@example.com\r\n" +
+		"X-Relay-Tag: welcome, onboarding\r\n" +
+		"To: bob@example.net\r\n" +
+		"x-relay-tag: welcome,\r\n\tdigest\r\n" +
+		"X-Relay-Campaign: 2026-10-newsletter\r\n" +
+		"X-Relay-Metadata: user_id=42; plan=pro\r\n" +
+		"X-Relay-Metadata: name==?UTF-8?B?5byg5LiJ?=\r\n" +
+		"X-Relay-Priority: HIGH\r\n" +
+		"X-Relay-Expires: 2026-10-06T10:00:00+08:00\r\n" +
+		"X-Relay-Unknown: dropped\r\n" +
+		"Subject: Hello\r\n" +
+		"\r\n" +
+		"X-Relay-Tag: not-a-header\r\n"
+
+	headers, body, err := ParseRelayHeaders([]byte(data), now)
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+
+	if got := strings.Join(headers.Tags, ","); got != "welcome,onboarding,digest" {
+		t.Errorf("Tags = %q，期望 welcome,onboarding,digest", got)
+	}
+	if headers.CampaignID != "2026-10-newsletter" {
+		t.Errorf("CampaignID = %q", headers.CampaignID)
+	}
+	if headers.Metadata["user_id"] != "42" || headers.Metadata["plan"] != "pro" || headers.Metadata["name"] != "张三" {
+		t.Errorf("Metadata = %v", headers.Metadata)
+	}
+	if headers.Priority != RelayPriorityHigh {
+		t.Errorf("Priority = %q，期望 high", headers.Priority)
+	}
+	if headers.ExpiresAt == nil || !headers.ExpiresAt.Equal(time.Date(2026, 10, 6, 2, 0, 0, 0, time.UTC)) || headers.ExpiresAt.Location() != time.UTC {
+		t.Errorf("ExpiresAt = %v，期望 2026-10-06T02:00:00Z", headers.ExpiresAt)
+	}
+
+	// 头部中的X-Relay-*全部移除，正文保持不变
+	want := "From: alice@example.com\r\n" +
+		"To: bob@example.net\r\n" +
+		"Subject: Hello\r\n" +
+		"\r\n" +
+		"X-Relay-Tag: not-a-header\r\n"
+	if string(body) != want {
+		t.Errorf("移除中继头部后的邮件为 %q，期望 %q", body, want)
+	}
+}
+
+func TestParseRelayHeadersWithoutRelayHeaders(t *testing.T) {
+	data := "From: alice@example.com\nSubject: LF line endings\n\nbody\n"
+	headers, body, err := ParseRelayHeaders([]byte(data), time.Now())
+	if err != nil {
+		t.Fatalf("解析失败: %v", err)
+	}
+	if string(body) != data {
+		t.Errorf("邮件内容被修改: %q", body)
+	}
+	if len(headers.Tags) != 0 || headers.Metadata != nil || headers.CampaignID != "" || headers.Priority != "" || headers.ExpiresAt != nil {
+		t.Errorf("不应解析出中继信息: %+v", headers)
+	}
+}
+
+func TestParseRelayHeadersErrors(t *testing.T) {
+	now := time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)
+
+	var manyTags, manyMetadata []string
+	for i := 0; i <= maxRelayTags; i++ {
+		manyTags = append(manyTags, fmt.Sprintf("tag%d", i))
+	}
+	for i := 0; i <= maxRelayMetadataKeys; i++ {
+		manyMetadata = append(manyMetadata, fmt.Sprintf("k%d=v", i))
+	}
+
+	tests := []struct {
+		name    string
+		header  string
+		wantErr string
+	}{
+		{"无效的标签字符", "X-Relay-Tag: hello world", "无效的标签"},
+		{"标签过长", "X-Relay-Tag: " + strings.Repeat("a", maxRelayTagLength+1), "无效的标签"},
+		{"标签过多", "X-Relay-Tag: " + strings.Join(manyTags, ","), "标签不能超过"},
+		{"无效的活动ID", "X-Relay-Campaign: -spring", "无效的活动ID"},
+		{"活动ID重复出现", "X-Relay-Campaign: a\r\nX-Relay-Campaign: b", "只能出现一次"},
+		{"元数据缺少等号", "X-Relay-Metadata: user_id", "key=value"},
+		{"元数据键包含点号", "X-Relay-Metadata: user.id=1", "无效的元数据键"},
+		{"元数据键包含$", "X-Relay-Metadata: $where=1", "无效的元数据键"},
+		{"元数据值过长", "X-Relay-Metadata: note=" + strings.Repeat("x", maxRelayMetadataValue+1), "元数据值不能超过"},
+		{"元数据键重复", "X-Relay-Metadata: a=1\r\nX-Relay-Metadata: a=2", "重复的元数据键"},
+		{"元数据过多", "X-Relay-Metadata: " + strings.Join(manyMetadata, ";"), "元数据不能超过"},
+		{"无效的优先级", "X-Relay-Priority: urgent", "无效的优先级"},
+		{"无效的过期时间", "X-Relay-Expires: tomorrow", "无效的过期时间"},
+		{"过期时间早于当前时间", "X-Relay-Expires: Mon, 05 Oct 2026 09:00:00 +0000", "必须晚于当前时间"},
+		{"过期时间超过7天", "X-Relay-Expires: 2026-10-13T10:00:00Z", "不能超过7天"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "From: alice@example.com\r\n" + tt.header + "\r\n\r\nbody\r\n"
+			_, _, err := ParseRelayHeaders([]byte(data), now)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("期望错误包含 %q，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/models"
+)
+
+// MaxSandboxMessageSize 沙箱邮件最大大小（MongoDB单文档限制为16MB）
+const MaxSandboxMessageSize = 15 * 1024 * 1024
+
+// SandboxService 沙箱邮件捕获服务
+type SandboxService struct {
+	db        *database.MongoDB
+	encryptor *encryption.Encryptor
+	logger    *logrus.Logger
+}
+
+// NewSandboxService 创建沙箱邮件捕获服务
+func NewSandboxService(db *database.MongoDB, encryptor *encryption.Encryptor, logger *logrus.Logger) *SandboxService {
+	return &SandboxService{
+		db:        db,
+		encryptor: encryptor,
+		logger:    logger,
+	}
+}
+
+// CaptureMessage 捕获沙箱凭据提交的邮件（写入MailLog并保存原始邮件，不进入投递队列）
+func (s *SandboxService) CaptureMessage(credential *models.SMTPCredential, mailLog *models.MailLog, raw []byte) error {
+	if len(raw) > MaxSandboxMessageSize {
+		return fmt.Errorf("沙箱邮件大小超过限制（最大%dMB）", MaxSandboxMessageSize/1024/1024)
+	}
+
+	encrypted, err := s.encryptor.EncryptBytes(raw)
+	if err != nil {
+		return fmt.Errorf("加密沙箱邮件失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	mailLog.Status = "captured"
+	mailLog.CompletedAt = &now
+
+	result, err := s.db.GetCollection("mail_logs").InsertOne(ctx, mailLog)
+	if err != nil {
+		return fmt.Errorf("保存MailLog失败: %w", err)
+	}
+	mailLog.ID = result.InsertedID.(primitive.ObjectID)
+
+	message := &models.SandboxMessage{
+		UserID:          credential.UserID,
+		CredentialID:    credential.ID,
+		MailLogID:       mailLog.ID,
+		MessageID:       mailLog.MessageID,
+		From:            mailLog.From,
+		To:              mailLog.To,
+		Subject:         mailLog.Subject,
+		Size:            int64(len(raw)),
+		Raw:             encrypted,
+		EncryptionKeyID: s.encryptor.CurrentKeyID(),
+		CreatedAt:       now,
+	}
+
+	if credential.Settings.SandboxRetentionHours > 0 {
+		expiresAt := now.Add(time.Duration(credential.Settings.SandboxRetentionHours) * time.Hour)
+		message.ExpiresAt = &expiresAt
+	}
+
+	if _, err := s.db.GetCollection("sandbox_messages").InsertOne(ctx, message); err != nil {
+		// 删除已写入的MailLog，避免留下没有邮件内容、却计入用量的捕获记录（插入可能因超时失败，使用新的上下文）
+		deleteCtx, deleteCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer deleteCancel()
+		if _, deleteErr := s.db.GetCollection("mail_logs").DeleteOne(deleteCtx, bson.M{"_id": mailLog.ID}); deleteErr != nil {
+			s.logger.WithError(deleteErr).WithField("mail_log_id", mailLog.ID.Hex()).Error("删除未保存沙箱邮件的MailLog失败")
+		}
+		return fmt.Errorf("保存沙箱邮件失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"credential_id": credential.ID.Hex(),
+		"mail_log_id":   mailLog.ID.Hex(),
+		"size":          len(raw),
+	}).Info("沙箱邮件已捕获")
+
+	return nil
+}
+
+// ListMessages 获取凭据捕获的沙箱邮件列表
+func (s *SandboxService) ListMessages(userID, credentialID primitive.ObjectID, page, pageSize int) ([]*models.SandboxMessage, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("sandbox_messages")
+	filter := bson.M{
+		"user_id":       userID,
+		"credential_id": credentialID,
+	}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSkip(int64((page - 1) * pageSize))
+	findOptions.SetLimit(int64(pageSize))
+	findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}})
+	findOptions.SetProjection(bson.M{"raw": 0}) // 列表不返回原始内容
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	messages := make([]*models.SandboxMessage, 0)
+	if err := cursor.All(ctx, &messages); err != nil {
+		return nil, 0, err
+	}
+
+	return messages, total, nil
+}
+
+// GetMessage 获取单封沙箱邮件（包含原始内容）
+func (s *SandboxService) GetMessage(userID, credentialID, messageID primitive.ObjectID) (*models.SandboxMessage, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter := bson.M{
+		"_id":           messageID,
+		"user_id":       userID,
+		"credential_id": credentialID,
+	}
+
+	var message models.SandboxMessage
+	err := s.db.GetCollection("sandbox_messages").FindOne(ctx, filter).Decode(&message)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errors.New("沙箱邮件不存在")
+		}
+		return nil, err
+	}
+
+	message.Raw, err = s.encryptor.DecryptBytes(message.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("解密沙箱邮件失败: %w", err)
+	}
+
+	return &message, nil
+}
+
+// GetMessageDetail 获取沙箱邮件详情（解析头部和MIME部件）
+func (s *SandboxService) GetMessageDetail(userID, credentialID, messageID primitive.ObjectID) (*models.SandboxMessageDetail, error) {
+	message, err := s.GetMessage(userID, credentialID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	detail, err := ParseSandboxMessage(message.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("解析沙箱邮件失败: %w", err)
+	}
+	detail.SandboxMessage = message
+
+	return detail, nil
+}
+
+// DeleteMessage 删除单封沙箱邮件
+func (s *SandboxService) DeleteMessage(userID, credentialID, messageID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("sandbox_messages").DeleteOne(ctx, bson.M{
+		"_id":           messageID,
+		"user_id":       userID,
+		"credential_id": credentialID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if result.DeletedCount == 0 {
+		return errors.New("沙箱邮件不存在")
+	}
+
+	return nil
+}
+
+// ClearMessages 清空凭据的全部沙箱邮件
+func (s *SandboxService) ClearMessages(userID, credentialID primitive.ObjectID) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("sandbox_messages").DeleteMany(ctx, bson.M{
+		"user_id":       userID,
+		"credential_id": credentialID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID.Hex(),
+		"credential_id": credentialID.Hex(),
+		"deleted":       result.DeletedCount,
+	}).Info("清空沙箱邮件")
+
+	return result.DeletedCount, nil
+}
+
+// ParseSandboxMessage 解析原始邮件的头部和MIME部件
+func ParseSandboxMessage(raw []byte) (*models.SandboxMessageDetail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &models.SandboxMessageDetail{
+		Headers: make(map[string][]string),
+		Parts:   make([]models.SandboxMessagePart, 0),
+	}
+	for key, values := range msg.Header {
+		decoded := make([]string, 0, len(values))
+		for _, value := range values {
+			decoded = append(decoded, decodeHeaderValue(value))
+		}
+		detail.Headers[key] = decoded
+	}
+
+	err = collectMIMEParts(msg.Header, msg.Body, &detail.Parts)
+	return detail, err
+}
+
+// collectMIMEParts 递归收集MIME部件
+func collectMIMEParts(header map[string][]string, body io.Reader, parts *[]models.SandboxMessagePart) error {
+	contentType := firstHeader(header, "Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "application/octet-stream"
+		params = map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := collectMIMEParts(part.Header, part, parts); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(decodeTransferEncoding(firstHeader(header, "Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	part := models.SandboxMessagePart{
+		Index:       len(*parts),
+		ContentType: mediaType,
+		Charset:     params["charset"],
+		Size:        len(content),
+	}
+
+	if disposition := firstHeader(header, "Content-Disposition"); disposition != "" {
+		dispType, dispParams, err := mime.ParseMediaType(disposition)
+		if err == nil {
+			part.Disposition = dispType
+			part.Filename = decodeHeaderValue(dispParams["filename"])
+		}
+	}
+	if part.Filename == "" && params["name"] != "" {
+		part.Filename = decodeHeaderValue(params["name"])
+	}
+
+	if strings.HasPrefix(mediaType, "text/") && part.Disposition != "attachment" {
+		part.Content = string(content)
+	}
+
+	*parts = append(*parts, part)
+	return nil
+}
+
+// decodeTransferEncoding 按Content-Transfer-Encoding解码正文
+func decodeTransferEncoding(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeHeaderValue 解码RFC 2047编码的头部值
+func decodeHeaderValue(value string) string {
+	decoded, err := new(mime.WordDecoder).DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// firstHeader 获取头部的第一个值
+func firstHeader(header map[string][]string, key string) string {
+	for k, values := range header {
+		if strings.EqualFold(k, key) && len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
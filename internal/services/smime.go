@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/mailauth"
+	"smtp-relay/internal/models"
+)
+
+// SMIMEService S/MIME证书管理与签名服务
+type SMIMEService struct {
+	db        *database.MongoDB
+	encryptor *encryption.Encryptor
+	logger    *logrus.Logger
+}
+
+// NewSMIMEService 创建S/MIME服务
+func NewSMIMEService(db *database.MongoDB, encryptor *encryption.Encryptor, logger *logrus.Logger) *SMIMEService {
+	return &SMIMEService{
+		db:        db,
+		encryptor: encryptor,
+		logger:    logger,
+	}
+}
+
+// UploadCertificate 上传发件地址或域名的S/MIME证书和私钥
+func (s *SMIMEService) UploadCertificate(userID primitive.ObjectID, sender, certPEM, keyPEM string) (*models.SMIMECertificate, error) {
+	sender = strings.ToLower(strings.TrimSpace(sender))
+	if !isValidSMIMESender(sender) {
+		return nil, fmt.Errorf("发件人必须是邮箱地址或域名")
+	}
+
+	chain, err := mailauth.ParseCertificateChain(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	key, err := mailauth.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %w", err)
+	}
+
+	cert := chain[0]
+	if err := mailauth.ValidateSMIMEKeyPair(cert, key); err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return nil, fmt.Errorf("证书链顺序错误或不完整: %w", err)
+		}
+	}
+	if time.Now().After(cert.NotAfter) {
+		return nil, fmt.Errorf("证书已于%s过期", cert.NotAfter.Format("2006-01-02"))
+	}
+
+	// 指定具体发件地址时，证书必须包含该地址
+	if strings.Contains(sender, "@") && !containsFold(cert.EmailAddresses, sender) {
+		return nil, fmt.Errorf("证书未包含发件地址%s", sender)
+	}
+
+	encryptedKey, err := s.encryptor.EncryptString(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("加密私钥失败: %w", err)
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+	now := time.Now()
+	certificate := &models.SMIMECertificate{
+		ID:              primitive.NewObjectID(),
+		UserID:          userID,
+		Sender:          sender,
+		Certificate:     certPEM,
+		PrivateKey:      encryptedKey,
+		EncryptionKeyID: s.encryptor.CurrentKeyID(),
+		Subject:         cert.Subject.String(),
+		Issuer:          cert.Issuer.String(),
+		SerialNumber:    cert.SerialNumber.Text(16),
+		EmailAddresses:  cert.EmailAddresses,
+		Fingerprint:     hex.EncodeToString(fingerprint[:]),
+		NotBefore:       cert.NotBefore,
+		NotAfter:        cert.NotAfter,
+		Status:          "active",
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.GetCollection("smime_certificates").InsertOne(ctx, certificate); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("该发件人已配置S/MIME证书")
+		}
+		return nil, fmt.Errorf("保存S/MIME证书失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":     userID.Hex(),
+		"sender":      sender,
+		"fingerprint": certificate.Fingerprint,
+		"not_after":   certificate.NotAfter,
+	}).Info("S/MIME证书上传成功")
+
+	return certificate, nil
+}
+
+// ListCertificates 获取用户的S/MIME证书列表
+func (s *SMIMEService) ListCertificates(userID primitive.ObjectID) ([]*models.SMIMECertificate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "sender", Value: 1}})
+	cursor, err := s.db.GetCollection("smime_certificates").Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, fmt.Errorf("查询S/MIME证书失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	certificates := make([]*models.SMIMECertificate, 0)
+	if err := cursor.All(ctx, &certificates); err != nil {
+		return nil, fmt.Errorf("解析S/MIME证书失败: %w", err)
+	}
+
+	return certificates, nil
+}
+
+// GetCertificate 获取指定的S/MIME证书
+func (s *SMIMEService) GetCertificate(userID, certificateID primitive.ObjectID) (*models.SMIMECertificate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var certificate models.SMIMECertificate
+	err := s.db.GetCollection("smime_certificates").FindOne(ctx, bson.M{
+		"_id":     certificateID,
+		"user_id": userID,
+	}).Decode(&certificate)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("S/MIME证书不存在")
+		}
+		return nil, fmt.Errorf("获取S/MIME证书失败: %w", err)
+	}
+
+	return &certificate, nil
+}
+
+// DeleteCertificate 删除S/MIME证书
+func (s *SMIMEService) DeleteCertificate(userID, certificateID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("smime_certificates").DeleteOne(ctx, bson.M{
+		"_id":     certificateID,
+		"user_id": userID,
+	})
+	if err != nil {
+		return fmt.Errorf("删除S/MIME证书失败: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("S/MIME证书不存在")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":        userID.Hex(),
+		"certificate_id": certificateID.Hex(),
+	}).Info("S/MIME证书删除成功")
+
+	return nil
+}
+
+// FindSigningCertificate 查找发件人可用的签名证书（精确地址优先于域名），未配置时返回nil
+func (s *SMIMEService) FindSigningCertificate(userID primitive.ObjectID, from string) (*models.SMIMECertificate, error) {
+	from = strings.ToLower(strings.TrimSpace(from))
+	at := strings.LastIndex(from, "@")
+	if at < 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("smime_certificates").Find(ctx, bson.M{
+		"user_id":   userID,
+		"sender":    bson.M{"$in": bson.A{from, from[at+1:]}},
+		"status":    "active",
+		"not_after": bson.M{"$gt": time.Now()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询S/MIME证书失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var certificates []*models.SMIMECertificate
+	if err := cursor.All(ctx, &certificates); err != nil {
+		return nil, fmt.Errorf("解析S/MIME证书失败: %w", err)
+	}
+
+	var matched *models.SMIMECertificate
+	for _, certificate := range certificates {
+		if certificate.Sender == from {
+			return certificate, nil
+		}
+		matched = certificate
+	}
+
+	return matched, nil
+}
+
+// SignMessage 使用证书对邮件进行S/MIME签名
+func (s *SMIMEService) SignMessage(certificate *models.SMIMECertificate, message []byte) ([]byte, error) {
+	chain, key, err := s.loadKeyPair(certificate)
+	if err != nil {
+		return nil, err
+	}
+	return mailauth.SignSMIME(message, chain, key)
+}
+
+// loadKeyPair 解析证书链并解密私钥
+func (s *SMIMEService) loadKeyPair(certificate *models.SMIMECertificate) ([]*x509.Certificate, crypto.Signer, error) {
+	chain, err := mailauth.ParseCertificateChain(certificate.Certificate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyPEM, err := s.encryptor.DecryptString(certificate.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解密S/MIME私钥失败: %w", err)
+	}
+	key, err := mailauth.ParsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("解析S/MIME私钥失败: %w", err)
+	}
+
+	return chain, key, nil
+}
+
+// isValidSMIMESender 检查发件人格式（邮箱地址或域名）
+func isValidSMIMESender(sender string) bool {
+	domain := sender
+	if at := strings.LastIndex(sender, "@"); at >= 0 {
+		if at == 0 {
+			return false
+		}
+		domain = sender[at+1:]
+	}
+	return strings.Contains(domain, ".") && !strings.ContainsAny(domain, " @/")
+}
+
+// containsFold 不区分大小写检查字符串是否在列表中
+func containsFold(values []string, target string) bool {
+	for _, value := range values {
+		if strings.EqualFold(value, target) {
+			return true
+		}
+	}
+	return false
+}
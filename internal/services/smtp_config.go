@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
+	"smtp-relay/internal/models"
+)
+
+// SMTPConfigService 上游SMTP服务器配置管理服务（Worker定期重新加载启用的配置）
+type SMTPConfigService struct {
+	db        *database.MongoDB
+	encryptor *encryption.Encryptor
+	logger    *logrus.Logger
+}
+
+// NewSMTPConfigService 创建上游SMTP配置服务
+func NewSMTPConfigService(db *database.MongoDB, encryptor *encryption.Encryptor, logger *logrus.Logger) *SMTPConfigService {
+	return &SMTPConfigService{
+		db:        db,
+		encryptor: encryptor,
+		logger:    logger,
+	}
+}
+
+// ListConfigs 获取所有上游SMTP配置（按优先级排序）
+func (s *SMTPConfigService) ListConfigs() ([]*models.SMTPConfig, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := s.db.GetCollection("smtp_configs").Find(ctx, bson.M{},
+		options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "name", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("查询SMTP配置失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	configs := []*models.SMTPConfig{}
+	if err := cursor.All(ctx, &configs); err != nil {
+		return nil, fmt.Errorf("解析SMTP配置失败: %w", err)
+	}
+	return configs, nil
+}
+
+// CreateConfig 创建上游SMTP配置，密码加密存储
+func (s *SMTPConfigService) CreateConfig(config *models.SMTPConfig, password string) (*models.SMTPConfig, error) {
+	if err := ValidateSMTPConfig(config); err != nil {
+		return nil, err
+	}
+
+	encrypted, err := s.encryptor.EncryptString(password)
+	if err != nil {
+		return nil, fmt.Errorf("加密SMTP密码失败: %w", err)
+	}
+	config.ID = primitive.NewObjectID()
+	config.Password = encrypted
+	config.PasswordKeyID = s.encryptor.CurrentKeyID()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := s.db.GetCollection("smtp_configs").InsertOne(ctx, config); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("该主机和端口的SMTP配置已存在")
+		}
+		return nil, fmt.Errorf("保存SMTP配置失败: %w", err)
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"config_id": config.ID.Hex(),
+		"host":      config.Host,
+		"port":      config.Port,
+	}).Info("上游SMTP配置已创建")
+	return config, nil
+}
+
+// UpdateConfig 更新上游SMTP配置，password为nil时保留原密码
+func (s *SMTPConfigService) UpdateConfig(configID primitive.ObjectID, config *models.SMTPConfig, password *string) (*models.SMTPConfig, error) {
+	if err := ValidateSMTPConfig(config); err != nil {
+		return nil, err
+	}
+
+	set := bson.M{
+		"name":     config.Name,
+		"host":     config.Host,
+		"port":     config.Port,
+		"username": config.Username,
+		"tls":      config.TLS,
+		"active":   config.Active,
+		"priority": config.Priority,
+	}
+	if password != nil {
+		encrypted, err := s.encryptor.EncryptString(*password)
+		if err != nil {
+			return nil, fmt.Errorf("加密SMTP密码失败: %w", err)
+		}
+		set["password"] = encrypted
+		set["password_key_id"] = s.encryptor.CurrentKeyID()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var updated models.SMTPConfig
+	err := s.db.GetCollection("smtp_configs").FindOneAndUpdate(ctx,
+		bson.M{"_id": configID},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("SMTP配置不存在")
+	}
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, fmt.Errorf("该主机和端口的SMTP配置已存在")
+		}
+		return nil, fmt.Errorf("更新SMTP配置失败: %w", err)
+	}
+
+	s.logger.WithField("config_id", configID.Hex()).Info("上游SMTP配置已更新")
+	return &updated, nil
+}
+
+// DeleteConfig 删除上游SMTP配置
+func (s *SMTPConfigService) DeleteConfig(configID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result, err := s.db.GetCollection("smtp_configs").DeleteOne(ctx, bson.M{"_id": configID})
+	if err != nil {
+		return fmt.Errorf("删除SMTP配置失败: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("SMTP配置不存在")
+	}
+
+	s.logger.WithField("config_id", configID.Hex()).Info("上游SMTP配置已删除")
+	return nil
+}
+
+// ValidateSMTPConfig 校验上游SMTP配置
+func ValidateSMTPConfig(config *models.SMTPConfig) error {
+	config.Name = strings.TrimSpace(config.Name)
+	config.Host = strings.TrimSpace(config.Host)
+	if config.Name == "" {
+		return fmt.Errorf("SMTP配置名称不能为空")
+	}
+	if config.Host == "" {
+		return fmt.Errorf("SMTP主机不能为空")
+	}
+	if config.Port < 1 || config.Port > 65535 {
+		return fmt.Errorf("SMTP端口必须在1-65535之间")
+	}
+	return nil
+}
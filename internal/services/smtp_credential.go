@@ -18,22 +18,34 @@ import (
 	"smtp-relay/internal/models"
 )
 
+// DefaultPasswordGracePeriod 密码轮换后旧密码默认继续有效的时长
+const DefaultPasswordGracePeriod = 24 * time.Hour
+
+// MaxPasswordGracePeriod 旧密码宽限期上限
+const MaxPasswordGracePeriod = 30 * 24 * time.Hour
+
 // SMTPCredentialService SMTP凭据管理服务
 type SMTPCredentialService struct {
-	db     *database.MongoDB
-	logger *logrus.Logger
+	db       *database.MongoDB
+	logger   *logrus.Logger
+	stopChan chan struct{}
 }
 
 // NewSMTPCredentialService 创建SMTP凭据管理服务
 func NewSMTPCredentialService(db *database.MongoDB, logger *logrus.Logger) *SMTPCredentialService {
 	return &SMTPCredentialService{
-		db:     db,
-		logger: logger,
+		db:       db,
+		logger:   logger,
+		stopChan: make(chan struct{}),
 	}
 }
 
-// CreateCredential 创建新的SMTP凭据
-func (s *SMTPCredentialService) CreateCredential(userID primitive.ObjectID, name, description string) (*models.SMTPCredential, string, error) {
+// CreateCredential 创建新的SMTP凭据（expiresAt为空表示永不过期）
+func (s *SMTPCredentialService) CreateCredential(userID primitive.ObjectID, name, description, mode string, expiresAt *time.Time) (*models.SMTPCredential, string, error) {
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", errors.New("过期时间必须晚于当前时间")
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -50,13 +62,26 @@ func (s *SMTPCredentialService) CreateCredential(userID primitive.ObjectID, name
 
 	// 检查凭据数量限制
 	credentialCollection := s.db.GetCollection("smtp_credentials")
-	count, err := credentialCollection.CountDocuments(ctx, bson.M{"user_id": userID, "status": "active"})
+	count, err := credentialCollection.CountDocuments(ctx, bson.M{"user_id": userID, "status": bson.M{"$in": visibleCredentialStatuses}})
 	if err != nil {
 		return nil, "", err
 	}
 
-	if count >= 10 { // 每个用户最多10个凭据
-		return nil, "", errors.New("SMTP凭据数量已达到上限（10个）")
+	// 套餐限制凭据数量，没有套餐时使用默认上限
+	maxCredentials := DefaultMaxCredentials
+	plan, err := userPlan(ctx, s.db, user.PlanID)
+	if err != nil {
+		return nil, "", err
+	}
+	if plan != nil {
+		maxCredentials = plan.MaxCredentials
+	}
+	if maxCredentials > 0 && count >= int64(maxCredentials) {
+		return nil, "", fmt.Errorf("SMTP凭据数量已达到上限（%d个）", maxCredentials)
+	}
+
+	if mode == "" {
+		mode = "normal"
 	}
 
 	// 生成唯一的SMTP用户名
@@ -69,22 +94,31 @@ func (s *SMTPCredentialService) CreateCredential(userID primitive.ObjectID, name
 		return nil, "", err
 	}
 
-	// 创建SMTP凭据
+	// 创建SMTP凭据，存在速率上限时默认使用该上限
+	messagesPerSecond, recipientsPerMinute := RateLimitCeiling(&user, plan)
+	now := time.Now()
 	credential := &models.SMTPCredential{
-		UserID:       userID,
-		Name:         name,
-		Username:     smtpUsername,
-		PasswordHash: string(passwordHash),
-		Description:  description,
-		Status:       "active",
-		CreatedAt:    time.Now(),
-		UpdatedAt:    time.Now(),
-		UsageCount:   0,
+		UserID:            userID,
+		Name:              name,
+		Username:          smtpUsername,
+		PasswordHash:      string(passwordHash),
+		PasswordID:        generatePasswordID(),
+		PasswordCreatedAt: &now,
+		ExpiresAt:         expiresAt,
+		Description:       description,
+		Mode:              mode,
+		Status:            models.CredentialStatusActive,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+		UsageCount:        0,
 		Settings: models.SMTPCredentialSettings{
 			DailyQuota:     user.Settings.DailyQuota,     // 继承用户设置
 			HourlyQuota:    user.Settings.HourlyQuota,    // 继承用户设置
 			AllowedDomains: user.Settings.AllowedDomains, // 继承用户设置
 			MaxRecipients:  100,
+
+			MessagesPerSecond:   messagesPerSecond,
+			RecipientsPerMinute: recipientsPerMinute,
 		},
 	}
 
@@ -100,18 +134,22 @@ func (s *SMTPCredentialService) CreateCredential(userID primitive.ObjectID, name
 		"credential_id": credential.ID.Hex(),
 		"name":          name,
 		"username":      smtpUsername,
+		"mode":          mode,
 	}).Info("创建SMTP凭据成功")
 
 	return credential, password, nil
 }
 
+// visibleCredentialStatuses 用户可见的凭据状态（包括因过期被禁用的凭据）
+var visibleCredentialStatuses = bson.A{models.CredentialStatusActive, models.CredentialStatusDisabled}
+
 // ListCredentials 获取用户的SMTP凭据列表
 func (s *SMTPCredentialService) ListCredentials(userID primitive.ObjectID) ([]*models.SMTPCredential, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	collection := s.db.GetCollection("smtp_credentials")
-	filter := bson.M{"user_id": userID, "status": "active"}
+	filter := bson.M{"user_id": userID, "status": bson.M{"$in": visibleCredentialStatuses}}
 
 	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
@@ -140,7 +178,7 @@ func (s *SMTPCredentialService) GetCredential(userID, credentialID primitive.Obj
 	filter := bson.M{
 		"_id":     credentialID,
 		"user_id": userID,
-		"status":  "active",
+		"status":  bson.M{"$in": visibleCredentialStatuses},
 	}
 
 	var credential models.SMTPCredential
@@ -155,8 +193,8 @@ func (s *SMTPCredentialService) GetCredential(userID, credentialID primitive.Obj
 	return &credential, nil
 }
 
-// UpdateCredential 更新SMTP凭据
-func (s *SMTPCredentialService) UpdateCredential(userID, credentialID primitive.ObjectID, name, description string, settings models.SMTPCredentialSettings) error {
+// UpdateCredential 更新SMTP凭据（mode为空时保持原有模式）
+func (s *SMTPCredentialService) UpdateCredential(userID, credentialID primitive.ObjectID, name, description, mode string, settings models.SMTPCredentialSettings) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -164,7 +202,7 @@ func (s *SMTPCredentialService) UpdateCredential(userID, credentialID primitive.
 	filter := bson.M{
 		"_id":     credentialID,
 		"user_id": userID,
-		"status":  "active",
+		"status":  bson.M{"$in": visibleCredentialStatuses},
 	}
 
 	update := bson.M{
@@ -175,6 +213,9 @@ func (s *SMTPCredentialService) UpdateCredential(userID, credentialID primitive.
 			"updated_at":  time.Now(),
 		},
 	}
+	if mode != "" {
+		update["$set"].(bson.M)["mode"] = mode
+	}
 
 	result, err := collection.UpdateOne(ctx, filter, update)
 	if err != nil {
@@ -189,13 +230,14 @@ func (s *SMTPCredentialService) UpdateCredential(userID, credentialID primitive.
 		"user_id":       userID.Hex(),
 		"credential_id": credentialID.Hex(),
 		"name":          name,
+		"mode":          mode,
 	}).Info("更新SMTP凭据成功")
 
 	return nil
 }
 
-// ResetPassword 重置SMTP凭据密码
-func (s *SMTPCredentialService) ResetPassword(userID, credentialID primitive.ObjectID) (string, error) {
+// ResetPassword 重置SMTP凭据密码，旧密码（包括宽限期内的旧密码）立即失效
+func (s *SMTPCredentialService) ResetPassword(userID, credentialID primitive.ObjectID) (string, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -203,38 +245,254 @@ func (s *SMTPCredentialService) ResetPassword(userID, credentialID primitive.Obj
 	filter := bson.M{
 		"_id":     credentialID,
 		"user_id": userID,
-		"status":  "active",
+		"status":  bson.M{"$in": visibleCredentialStatuses},
 	}
 
 	// 生成新密码
 	newPassword := s.generateRandomPassword()
 	passwordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
+	now := time.Now()
+	passwordID := generatePasswordID()
 	update := bson.M{
 		"$set": bson.M{
-			"password_hash": string(passwordHash),
-			"updated_at":    time.Now(),
+			"password_hash":       string(passwordHash),
+			"password_id":         passwordID,
+			"password_created_at": now,
+			"updated_at":          now,
+		},
+		"$unset": bson.M{
+			"password_last_used": "",
+			"previous_password":  "",
 		},
 	}
 
 	result, err := collection.UpdateOne(ctx, filter, update)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
 	if result.MatchedCount == 0 {
-		return "", errors.New("SMTP凭据不存在")
+		return "", "", errors.New("SMTP凭据不存在")
 	}
 
 	s.logger.WithFields(logrus.Fields{
 		"user_id":       userID.Hex(),
 		"credential_id": credentialID.Hex(),
+		"password_id":   passwordID,
 	}).Info("重置SMTP凭据密码成功")
 
-	return newPassword, nil
+	return newPassword, passwordID, nil
+}
+
+// RotatePassword 轮换SMTP凭据密码：生成新密码，旧密码在宽限期内仍可认证，便于逐台更新应用服务器
+func (s *SMTPCredentialService) RotatePassword(userID, credentialID primitive.ObjectID, gracePeriod time.Duration) (*models.SMTPCredential, string, error) {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultPasswordGracePeriod
+	}
+	if gracePeriod > MaxPasswordGracePeriod {
+		return nil, "", errors.New("宽限期不能超过30天")
+	}
+
+	credential, err := s.GetCredential(userID, credentialID)
+	if err != nil {
+		return nil, "", err
+	}
+	if credential.Status != models.CredentialStatusActive {
+		return nil, "", errors.New("SMTP凭据已禁用")
+	}
+
+	now := time.Now()
+	if credential.PreviousPassword != nil && now.Before(credential.PreviousPassword.ExpiresAt) {
+		return nil, "", errors.New("上一次轮换的旧密码仍在宽限期内")
+	}
+
+	newPassword := s.generateRandomPassword()
+	passwordHash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// 早期创建的凭据没有密码标识，轮换时补充
+	previousID := credential.PasswordID
+	if previousID == "" {
+		previousID = generatePasswordID()
+	}
+	previous := &models.SMTPCredentialPassword{
+		ID:        previousID,
+		Hash:      credential.PasswordHash,
+		CreatedAt: credential.PasswordCreatedAt,
+		ExpiresAt: now.Add(gracePeriod),
+		LastUsed:  credential.PasswordLastUsed,
+	}
+	passwordID := generatePasswordID()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// 以当前密码哈希为条件，避免并发轮换覆盖
+	result, err := s.db.GetCollection("smtp_credentials").UpdateOne(ctx,
+		bson.M{"_id": credential.ID, "status": models.CredentialStatusActive, "password_hash": credential.PasswordHash},
+		bson.M{
+			"$set": bson.M{
+				"password_hash":       string(passwordHash),
+				"password_id":         passwordID,
+				"password_created_at": now,
+				"previous_password":   previous,
+				"updated_at":          now,
+			},
+			"$unset": bson.M{"password_last_used": ""},
+		},
+	)
+	if err != nil {
+		return nil, "", err
+	}
+	if result.MatchedCount == 0 {
+		return nil, "", errors.New("SMTP凭据已被修改，请重试")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":              userID.Hex(),
+		"credential_id":        credentialID.Hex(),
+		"password_id":          passwordID,
+		"previous_password_id": previous.ID,
+		"grace_until":          previous.ExpiresAt,
+	}).Info("轮换SMTP凭据密码成功")
+
+	credential.PasswordHash = string(passwordHash)
+	credential.PasswordID = passwordID
+	credential.PasswordCreatedAt = &now
+	credential.PasswordLastUsed = nil
+	credential.PreviousPassword = previous
+	credential.UpdatedAt = now
+	return credential, newPassword, nil
+}
+
+// RevokePreviousPassword 提前结束旧密码的宽限期
+func (s *SMTPCredentialService) RevokePreviousPassword(userID, credentialID primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := s.db.GetCollection("smtp_credentials")
+	result, err := collection.UpdateOne(ctx,
+		bson.M{
+			"_id":               credentialID,
+			"user_id":           userID,
+			"status":            bson.M{"$in": visibleCredentialStatuses},
+			"previous_password": bson.M{"$exists": true},
+		},
+		bson.M{
+			"$unset": bson.M{"previous_password": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// 区分凭据不存在和没有旧密码
+		if _, err := s.GetCredential(userID, credentialID); err != nil {
+			return err
+		}
+		return errors.New("没有处于宽限期的旧密码")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID.Hex(),
+		"credential_id": credentialID.Hex(),
+	}).Info("撤销SMTP凭据旧密码成功")
+
+	return nil
+}
+
+// SetExpiration 设置凭据过期时间（为空表示永不过期）；因过期被禁用的凭据延期后自动恢复
+func (s *SMTPCredentialService) SetExpiration(userID, credentialID primitive.ObjectID, expiresAt *time.Time) (*models.SMTPCredential, error) {
+	now := time.Now()
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, errors.New("过期时间必须晚于当前时间")
+	}
+
+	credential, err := s.GetCredential(userID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	update := bson.M{"updated_at": now}
+	unset := bson.M{}
+	if expiresAt != nil {
+		update["expires_at"] = *expiresAt
+	} else {
+		unset["expires_at"] = ""
+	}
+	if credential.Status == models.CredentialStatusDisabled && credential.DisabledReason == models.CredentialDisabledExpired {
+		update["status"] = models.CredentialStatusActive
+		unset["disabled_at"] = ""
+		unset["disabled_reason"] = ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	changes := bson.M{"$set": update}
+	if len(unset) > 0 {
+		changes["$unset"] = unset
+	}
+	if _, err := s.db.GetCollection("smtp_credentials").UpdateOne(ctx, bson.M{"_id": credential.ID}, changes); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID.Hex(),
+		"credential_id": credentialID.Hex(),
+		"expires_at":    expiresAt,
+	}).Info("设置SMTP凭据过期时间成功")
+
+	return s.GetCredential(userID, credentialID)
+}
+
+// EnableCredential 重新启用因疑似泄露被自动停用的凭据；停用后必须先重置密码
+func (s *SMTPCredentialService) EnableCredential(userID, credentialID primitive.ObjectID) (*models.SMTPCredential, error) {
+	credential, err := s.GetCredential(userID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if credential.Status == models.CredentialStatusActive {
+		return credential, nil
+	}
+	if credential.DisabledReason != models.CredentialDisabledCompromised {
+		return nil, errors.New("该凭据不能手动启用")
+	}
+	if credential.DisabledAt != nil && (credential.PasswordCreatedAt == nil || !credential.PasswordCreatedAt.After(*credential.DisabledAt)) {
+		return nil, errors.New("凭据疑似泄露，请先重置密码")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	result, err := s.db.GetCollection("smtp_credentials").UpdateOne(ctx,
+		bson.M{"_id": credential.ID, "status": models.CredentialStatusDisabled, "disabled_reason": models.CredentialDisabledCompromised},
+		bson.M{
+			"$set":   bson.M{"status": models.CredentialStatusActive, "updated_at": now},
+			"$unset": bson.M{"disabled_at": "", "disabled_reason": ""},
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if result.MatchedCount == 0 {
+		return nil, errors.New("SMTP凭据已被修改，请重试")
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"user_id":       userID.Hex(),
+		"credential_id": credentialID.Hex(),
+	}).Info("重新启用SMTP凭据成功")
+
+	return s.GetCredential(userID, credentialID)
 }
 
 // DeleteCredential 删除SMTP凭据
@@ -246,12 +504,12 @@ func (s *SMTPCredentialService) DeleteCredential(userID, credentialID primitive.
 	filter := bson.M{
 		"_id":     credentialID,
 		"user_id": userID,
-		"status":  "active",
+		"status":  bson.M{"$in": visibleCredentialStatuses},
 	}
 
 	update := bson.M{
 		"$set": bson.M{
-			"status":     "deleted",
+			"status":     models.CredentialStatusDeleted,
 			"updated_at": time.Now(),
 		},
 	}
@@ -273,8 +531,8 @@ func (s *SMTPCredentialService) DeleteCredential(userID, credentialID primitive.
 	return nil
 }
 
-// AuthenticateSMTP 验证SMTP凭据
-func (s *SMTPCredentialService) AuthenticateSMTP(username, password string) (*models.SMTPCredential, error) {
+// AuthenticateSMTP 验证SMTP凭据，返回凭据及本次认证使用的密码标识（当前密码或宽限期内的旧密码）
+func (s *SMTPCredentialService) AuthenticateSMTP(username, password string) (*models.SMTPCredential, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -288,37 +546,119 @@ func (s *SMTPCredentialService) AuthenticateSMTP(username, password string) (*mo
 	err := collection.FindOne(ctx, filter).Decode(&credential)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil, errors.New("SMTP凭据不存在")
+			return nil, "", errors.New("SMTP凭据不存在")
 		}
-		return nil, err
+		return nil, "", err
+	}
+
+	// 过期检查（定期任务禁用之前也拒绝认证）
+	now := time.Now()
+	if credential.IsExpired(now) {
+		return nil, "", errors.New("SMTP凭据已过期")
 	}
 
-	// 验证密码
+	// 验证密码：先当前密码，再宽限期内的旧密码
+	passwordID := credential.PasswordID
+	previous := false
 	if err := bcrypt.CompareHashAndPassword([]byte(credential.PasswordHash), []byte(password)); err != nil {
-		return nil, errors.New("密码错误")
+		old := credential.PreviousPassword
+		if old == nil || !now.Before(old.ExpiresAt) || bcrypt.CompareHashAndPassword([]byte(old.Hash), []byte(password)) != nil {
+			return nil, "", errors.New("密码错误")
+		}
+		passwordID = old.ID
+		previous = true
 	}
 
 	// 更新使用统计
-	go s.updateUsageStats(credential.ID)
+	go s.updateUsageStats(credential.ID, previous)
 
-	return &credential, nil
+	return &credential, passwordID, nil
 }
 
-// updateUsageStats 更新使用统计
-func (s *SMTPCredentialService) updateUsageStats(credentialID primitive.ObjectID) {
+// updateUsageStats 更新使用统计，previous表示使用的是宽限期内的旧密码
+func (s *SMTPCredentialService) updateUsageStats(credentialID primitive.ObjectID, previous bool) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	collection := s.db.GetCollection("smtp_credentials")
+	now := time.Now()
+	set := bson.M{"last_used": now}
 	filter := bson.M{"_id": credentialID}
+	if previous {
+		set["previous_password.last_used"] = now
+		filter["previous_password"] = bson.M{"$exists": true}
+	} else {
+		set["password_last_used"] = now
+	}
+
+	collection := s.db.GetCollection("smtp_credentials")
 	update := bson.M{
 		"$inc": bson.M{"usage_count": 1},
-		"$set": bson.M{"last_used": time.Now()},
+		"$set": set,
 	}
 
 	collection.UpdateOne(ctx, filter, update)
 }
 
+// StartExpiryChecker 启动定期任务：禁用已过期的凭据，清理宽限期已结束的旧密码
+func (s *SMTPCredentialService) StartExpiryChecker(interval time.Duration) {
+	go func() {
+		s.checkExpiry()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.checkExpiry()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithField("interval", interval.String()).Info("SMTP凭据过期检查协程已启动")
+}
+
+// Stop 停止凭据过期检查协程
+func (s *SMTPCredentialService) Stop() {
+	close(s.stopChan)
+}
+
+// checkExpiry 禁用已过期的凭据并清理过期的旧密码
+func (s *SMTPCredentialService) checkExpiry() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	collection := s.db.GetCollection("smtp_credentials")
+
+	result, err := collection.UpdateMany(ctx,
+		bson.M{"status": models.CredentialStatusActive, "expires_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{
+			"status":          models.CredentialStatusDisabled,
+			"disabled_at":     now,
+			"disabled_reason": models.CredentialDisabledExpired,
+			"updated_at":      now,
+		}},
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("禁用过期SMTP凭据失败")
+	} else if result.ModifiedCount > 0 {
+		s.logger.WithField("count", result.ModifiedCount).Info("已禁用过期的SMTP凭据")
+	}
+
+	result, err = collection.UpdateMany(ctx,
+		bson.M{"previous_password.expires_at": bson.M{"$lte": now}},
+		bson.M{"$unset": bson.M{"previous_password": ""}},
+	)
+	if err != nil {
+		s.logger.WithError(err).Error("清理过期的SMTP凭据旧密码失败")
+	} else if result.ModifiedCount > 0 {
+		s.logger.WithField("count", result.ModifiedCount).Info("已清理宽限期结束的SMTP凭据旧密码")
+	}
+}
+
 // generateSMTPUsername 生成SMTP用户名
 func (s *SMTPCredentialService) generateSMTPUsername(userID primitive.ObjectID) string {
 	// 生成格式：relay_{userID前8位}_{随机4位}
@@ -330,6 +670,13 @@ func (s *SMTPCredentialService) generateSMTPUsername(userID primitive.ObjectID)
 	return fmt.Sprintf("relay_%s_%s", userIDStr[:8], randomStr)
 }
 
+// generatePasswordID 生成密码标识（用于区分认证时使用的是哪个密码）
+func generatePasswordID() string {
+	bytes := make([]byte, 4)
+	rand.Read(bytes)
+	return hex.EncodeToString(bytes)
+}
+
 // generateRandomPassword 生成随机密码
 func (s *SMTPCredentialService) generateRandomPassword() string {
 	// 生成16位随机密码
@@ -0,0 +1,371 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+	"smtp-relay/internal/timeutil"
+)
+
+// 用量计量参数
+const (
+	usageMaxDaysPerRun = 31  // 每个用户每轮最多计量的天数（补算历史数据时分多轮完成）
+	usageMaxRangeDays  = 366 // 查询和导出的最大日期范围
+)
+
+// UsageService 用量计量服务：按用户时区的自然日汇总每个用户、每个凭据的用量，生成不可修改的每日用量记录
+//
+// 一天结束并经过结算期（等待投递结果）后才生成该天的记录，此后邮件状态的变化不再计入。
+type UsageService struct {
+	db       *database.MongoDB
+	settle   time.Duration
+	logger   *logrus.Logger
+	stopChan chan struct{}
+}
+
+// NewUsageService 创建用量计量服务，settle为一天结束后等待投递结果的结算期
+func NewUsageService(db *database.MongoDB, settle time.Duration, logger *logrus.Logger) *UsageService {
+	return &UsageService{
+		db:       db,
+		settle:   settle,
+		logger:   logger,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start 启动用量计量协程
+func (s *UsageService) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.run()
+			case <-s.stopChan:
+				return
+			}
+		}
+	}()
+
+	s.logger.WithFields(logrus.Fields{
+		"interval": interval.String(),
+		"settle":   s.settle.String(),
+	}).Info("用量计量协程已启动")
+}
+
+// Stop 停止用量计量协程
+func (s *UsageService) Stop() {
+	close(s.stopChan)
+}
+
+// run 为所有用户生成已结算日期的用量记录
+func (s *UsageService) run() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	cursor, err := s.db.GetCollection("users").Find(ctx, bson.M{"status": bson.M{"$ne": "deleted"}})
+	if err != nil {
+		cancel()
+		s.logger.WithError(err).Error("查询用户失败")
+		return
+	}
+	var users []models.User
+	err = cursor.All(ctx, &users)
+	cancel()
+	if err != nil {
+		s.logger.WithError(err).Error("解析用户失败")
+		return
+	}
+
+	now := time.Now()
+	for i := range users {
+		if err := s.meterUser(&users[i], now); err != nil {
+			s.logger.WithError(err).WithField("user_id", users[i].ID.Hex()).Error("生成用量记录失败")
+		}
+	}
+}
+
+// meterUser 从计量进度开始逐日生成用户的用量记录，直到尚未结算的日期
+//
+// 每天的起止时间按用户当前的时区计算；时区变更后的第一天从上次计量的截止时间开始，保证记录之间不重叠。
+func (s *UsageService) meterUser(user *models.User, now time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	plan, err := userPlan(ctx, s.db, user.PlanID)
+	if err != nil {
+		return err
+	}
+	location := QuotaLocation(user, plan)
+
+	stateCollection := s.db.GetCollection("usage_meter_states")
+	var state models.UsageMeterState
+	err = stateCollection.FindOne(ctx, bson.M{"_id": user.ID}).Decode(&state)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return fmt.Errorf("查询计量进度失败: %w", err)
+	}
+
+	start := state.MeteredUntil
+	if start.IsZero() {
+		// 首次计量从用户的第一封邮件所在日期开始
+		var first models.MailLog
+		err := s.db.GetCollection("mail_logs").FindOne(ctx, bson.M{"user_id": user.ID},
+			options.FindOne().SetSort(bson.D{{Key: "created_at", Value: 1}}).SetProjection(bson.M{"created_at": 1}),
+		).Decode(&first)
+		if err == mongo.ErrNoDocuments {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("查询邮件日志失败: %w", err)
+		}
+		start = timeutil.StartOfDay(first.CreatedAt, location)
+	}
+
+	for day := 0; day < usageMaxDaysPerRun; day++ {
+		_, end := timeutil.Window(timeutil.PeriodDay, start, location)
+		if end.Add(s.settle).After(now) {
+			break
+		}
+
+		records, err := s.aggregateDay(ctx, user.ID, start, end, location)
+		if err != nil {
+			return err
+		}
+		if len(records) > 0 {
+			documents := make([]interface{}, len(records))
+			for i, record := range records {
+				documents[i] = record
+			}
+			// 唯一索引保证重试时不会重复生成
+			_, err := s.db.GetCollection("usage_records").InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+			if err != nil && !mongo.IsDuplicateKeyError(err) {
+				return fmt.Errorf("保存用量记录失败: %w", err)
+			}
+		}
+
+		_, err = stateCollection.UpdateOne(ctx,
+			bson.M{"_id": user.ID},
+			bson.M{"$set": bson.M{"metered_until": end, "updated_at": time.Now()}},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return fmt.Errorf("更新计量进度失败: %w", err)
+		}
+		start = end
+	}
+	return nil
+}
+
+// aggregateDay 按凭据汇总用户在[start, end)内的邮件日志
+func (s *UsageService) aggregateDay(ctx context.Context, userID primitive.ObjectID, start, end time.Time, location *time.Location) ([]*models.UsageRecord, error) {
+	cursor, err := s.db.GetCollection("mail_logs").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{"user_id": userID, "created_at": bson.M{"$gte": start, "$lt": end}}},
+		{"$group": bson.M{
+			"_id":        "$credential_id",
+			"messages":   bson.M{"$sum": 1},
+			"recipients": bson.M{"$sum": bson.M{"$size": bson.M{"$ifNull": bson.A{"$to", bson.A{}}}}},
+			"bytes":      bson.M{"$sum": "$size"},
+			"delivered":  bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "sent"}}, 1, 0}}},
+			"bounced":    bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$status", "failed"}}, 1, 0}}},
+		}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("汇总邮件日志失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		CredentialID       *primitive.ObjectID `bson:"_id"`
+		models.UsageCounts `bson:",inline"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("解析用量汇总失败: %w", err)
+	}
+
+	names, err := s.credentialNames(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	date := start.In(location).Format("2006-01-02")
+	records := make([]*models.UsageRecord, 0, len(results))
+	for _, result := range results {
+		record := &models.UsageRecord{
+			ID:           primitive.NewObjectID(),
+			UserID:       userID,
+			CredentialID: result.CredentialID,
+			Date:         date,
+			Timezone:     location.String(),
+			WindowStart:  start,
+			WindowEnd:    end,
+			UsageCounts:  result.UsageCounts,
+			CreatedAt:    now,
+		}
+		if result.CredentialID != nil {
+			record.CredentialName = names[*result.CredentialID]
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// credentialNames 获取用户所有凭据（包括已删除的）的名称
+func (s *UsageService) credentialNames(ctx context.Context, userID primitive.ObjectID) (map[primitive.ObjectID]string, error) {
+	cursor, err := s.db.GetCollection("smtp_credentials").Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("查询SMTP凭据失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var credentials []models.SMTPCredential
+	if err := cursor.All(ctx, &credentials); err != nil {
+		return nil, fmt.Errorf("解析SMTP凭据失败: %w", err)
+	}
+	names := make(map[primitive.ObjectID]string, len(credentials))
+	for _, credential := range credentials {
+		names[credential.ID] = credential.Name
+	}
+	return names, nil
+}
+
+// ListUsage 获取日期范围内（用户时区的日期，包含两端）的每日用量记录和合计，credentialID为空时返回所有凭据
+func (s *UsageService) ListUsage(userID primitive.ObjectID, from, to string, credentialID *primitive.ObjectID) ([]*models.UsageRecord, models.UsageCounts, error) {
+	var totals models.UsageCounts
+	fromDate, err := timeutil.ParseDate(from, time.UTC)
+	if err != nil {
+		return nil, totals, fmt.Errorf("无效的开始日期")
+	}
+	toDate, err := timeutil.ParseDate(to, time.UTC)
+	if err != nil {
+		return nil, totals, fmt.Errorf("无效的结束日期")
+	}
+	if toDate.Before(fromDate) {
+		return nil, totals, fmt.Errorf("无效的日期范围")
+	}
+	if toDate.Sub(fromDate) >= usageMaxRangeDays*24*time.Hour {
+		return nil, totals, fmt.Errorf("日期范围不能超过%d天", usageMaxRangeDays)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	filter := bson.M{"user_id": userID, "date": bson.M{"$gte": from, "$lte": to}}
+	if credentialID != nil {
+		filter["credential_id"] = *credentialID
+	}
+	cursor, err := s.db.GetCollection("usage_records").Find(ctx, filter,
+		options.Find().SetSort(bson.D{{Key: "window_start", Value: 1}, {Key: "credential_name", Value: 1}}))
+	if err != nil {
+		return nil, totals, fmt.Errorf("查询用量记录失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	records := []*models.UsageRecord{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, totals, fmt.Errorf("解析用量记录失败: %w", err)
+	}
+	for _, record := range records {
+		totals.Add(record.UsageCounts)
+	}
+	return records, totals, nil
+}
+
+// GetInvoice 生成用户某月（YYYY-MM，用户时区）的用量汇总，按凭据列出用量
+func (s *UsageService) GetInvoice(userID primitive.ObjectID, month string) (*models.UsageInvoice, error) {
+	monthStart, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, fmt.Errorf("无效的月份")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var user models.User
+	err = s.db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	plan, err := userPlan(ctx, s.db, user.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("查询用户套餐失败: %w", err)
+	}
+	location := QuotaLocation(&user, plan)
+	periodStart, periodEnd := timeutil.Window(timeutil.PeriodMonth, time.Date(monthStart.Year(), monthStart.Month(), 1, 0, 0, 0, 0, location), location)
+
+	cursor, err := s.db.GetCollection("usage_records").Aggregate(ctx, []bson.M{
+		{"$match": bson.M{
+			"user_id": userID,
+			"date":    bson.M{"$gte": periodStart.Format("2006-01-02"), "$lt": periodEnd.Format("2006-01-02")},
+		}},
+		{"$sort": bson.M{"window_start": 1}},
+		{"$group": bson.M{
+			"_id":             "$credential_id",
+			"credential_name": bson.M{"$last": "$credential_name"},
+			"messages":        bson.M{"$sum": "$messages"},
+			"recipients":      bson.M{"$sum": "$recipients"},
+			"bytes":           bson.M{"$sum": "$bytes"},
+			"delivered":       bson.M{"$sum": "$delivered"},
+			"bounced":         bson.M{"$sum": "$bounced"},
+		}},
+		{"$sort": bson.M{"credential_name": 1}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("汇总用量记录失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var results []struct {
+		CredentialID       *primitive.ObjectID `bson:"_id"`
+		CredentialName     string              `bson:"credential_name"`
+		models.UsageCounts `bson:",inline"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("解析用量汇总失败: %w", err)
+	}
+
+	invoice := &models.UsageInvoice{
+		UserID:      user.ID,
+		Username:    user.Username,
+		Email:       user.Email,
+		Month:       month,
+		Timezone:    location.String(),
+		PeriodStart: periodStart,
+		PeriodEnd:   periodEnd,
+		Lines:       make([]models.UsageInvoiceLine, 0, len(results)),
+		GeneratedAt: time.Now(),
+	}
+	if plan != nil {
+		invoice.Plan = plan.Name
+	}
+	for _, result := range results {
+		invoice.Lines = append(invoice.Lines, models.UsageInvoiceLine{
+			CredentialID:   result.CredentialID,
+			CredentialName: result.CredentialName,
+			UsageCounts:    result.UsageCounts,
+		})
+		invoice.Totals.Add(result.UsageCounts)
+	}
+
+	var state models.UsageMeterState
+	err = s.db.GetCollection("usage_meter_states").FindOne(ctx, bson.M{"_id": userID}).Decode(&state)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("查询计量进度失败: %w", err)
+	}
+	invoice.Complete = !state.MeteredUntil.IsZero() && !state.MeteredUntil.Before(periodEnd)
+
+	return invoice, nil
+}
@@ -0,0 +1,233 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"smtp-relay/internal/database"
+	"smtp-relay/internal/models"
+	"smtp-relay/internal/timeutil"
+)
+
+// UserAdminService 管理员用户管理服务
+type UserAdminService struct {
+	db     *database.MongoDB
+	logger *logrus.Logger
+}
+
+// NewUserAdminService 创建管理员用户管理服务
+func NewUserAdminService(db *database.MongoDB, logger *logrus.Logger) *UserAdminService {
+	return &UserAdminService{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// UserListFilter 用户列表过滤条件
+type UserListFilter struct {
+	Search string // 按用户名或邮箱模糊搜索（不区分大小写）
+	Status string
+	Role   string
+}
+
+// ListUsers 分页获取用户列表（最新注册的在前）
+func (s *UserAdminService) ListUsers(filter UserListFilter, page, pageSize int) ([]*models.User, int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	query := bson.M{}
+	if search := strings.TrimSpace(filter.Search); search != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(search), Options: "i"}
+		query["$or"] = bson.A{bson.M{"username": pattern}, bson.M{"email": pattern}}
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	switch filter.Role {
+	case "":
+	case models.UserRoleUser:
+		// 未设置角色的用户也是普通用户
+		query["role"] = bson.M{"$in": bson.A{models.UserRoleUser, "", nil}}
+	default:
+		query["role"] = filter.Role
+	}
+
+	collection := s.db.GetCollection("users")
+	total, err := collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, fmt.Errorf("统计用户数量失败: %w", err)
+	}
+
+	cursor, err := collection.Find(ctx, query, options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(int64((page-1)*pageSize)).
+		SetLimit(int64(pageSize)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询用户失败: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	users := []*models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, fmt.Errorf("解析用户失败: %w", err)
+	}
+	return users, total, nil
+}
+
+// GetUser 获取任意状态的用户
+func (s *UserAdminService) GetUser(userID primitive.ObjectID) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var user models.User
+	err := s.db.GetCollection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询用户失败: %w", err)
+	}
+	return &user, nil
+}
+
+// SuspendUser 停用用户：用户不能再登录API，其SMTP凭据也无法认证
+func (s *UserAdminService) SuspendUser(userID primitive.ObjectID, reason string) (*models.User, error) {
+	now := time.Now()
+	return s.updateUser(userID,
+		bson.M{"status": bson.M{"$ne": models.UserStatusDeleted}},
+		bson.M{"$set": bson.M{
+			"status":           models.UserStatusSuspended,
+			"suspended_at":     now,
+			"suspended_reason": strings.TrimSpace(reason),
+			"updated_at":       now,
+		}},
+	)
+}
+
+// ReactivateUser 重新启用被停用的用户
+func (s *UserAdminService) ReactivateUser(userID primitive.ObjectID) (*models.User, error) {
+	return s.updateUser(userID,
+		bson.M{"status": bson.M{"$ne": models.UserStatusDeleted}},
+		bson.M{
+			"$set":   bson.M{"status": models.UserStatusActive, "updated_at": time.Now()},
+			"$unset": bson.M{"suspended_at": "", "suspended_reason": ""},
+		},
+	)
+}
+
+// UserSettingsUpdate 用户设置的部分更新，只更新提供的字段
+type UserSettingsUpdate struct {
+	DailyQuota           *int      `json:"daily_quota,omitempty" example:"1000"`
+	HourlyQuota          *int      `json:"hourly_quota,omitempty" example:"100"`
+	MessagesPerSecond    *int      `json:"messages_per_second,omitempty" example:"10"`    // 所有凭据合计每秒最多发送的邮件数（0表示不限制）
+	RecipientsPerMinute  *int      `json:"recipients_per_minute,omitempty" example:"600"` // 所有凭据合计每分钟最多发送的收件人数（0表示不限制）
+	Timezone             *string   `json:"timezone,omitempty" example:"Asia/Shanghai"`    // IANA时区，空字符串表示使用套餐时区
+	AllowedDomains       *[]string `json:"allowed_domains,omitempty"`                     // 新建凭据默认允许的发件域名
+	ArchiveRetentionDays *int      `json:"archive_retention_days,omitempty" example:"30"` // 原始邮件归档保留天数（0表示使用系统默认值）
+}
+
+// UpdateSettings 更新用户设置（配额、速率限制、时区等），只修改提供的字段
+func (s *UserAdminService) UpdateSettings(userID primitive.ObjectID, update *UserSettingsUpdate) (*models.User, error) {
+	fields, err := update.SetFields()
+	if err != nil {
+		return nil, err
+	}
+	fields["updated_at"] = time.Now()
+	return s.updateUser(userID, bson.M{}, bson.M{"$set": fields})
+}
+
+// SetRole 设置用户角色
+func (s *UserAdminService) SetRole(userID primitive.ObjectID, role string) (*models.User, error) {
+	if !models.IsValidUserRole(role) {
+		return nil, fmt.Errorf("无效的用户角色")
+	}
+	return s.updateUser(userID, bson.M{},
+		bson.M{"$set": bson.M{"role": role, "updated_at": time.Now()}},
+	)
+}
+
+// updateUser 更新用户并返回更新后的用户，condition为额外的匹配条件
+func (s *UserAdminService) updateUser(userID primitive.ObjectID, condition bson.M, update bson.M) (*models.User, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"_id": userID}
+	for key, value := range condition {
+		filter[key] = value
+	}
+
+	var user models.User
+	err := s.db.GetCollection("users").FindOneAndUpdate(ctx, filter, update,
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		if len(condition) > 0 {
+			if _, err := s.GetUser(userID); err == nil {
+				return nil, fmt.Errorf("用户已删除")
+			}
+		}
+		return nil, fmt.Errorf("用户不存在")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("更新用户失败: %w", err)
+	}
+	return &user, nil
+}
+
+// SetFields 校验提供的字段，返回对应的$set字段（如 settings.daily_quota）
+func (u *UserSettingsUpdate) SetFields() (bson.M, error) {
+	fields := bson.M{}
+	if u.DailyQuota != nil {
+		if *u.DailyQuota < 0 || *u.DailyQuota > 10000 {
+			return nil, fmt.Errorf("日配额必须在0-10000之间")
+		}
+		fields["settings.daily_quota"] = *u.DailyQuota
+	}
+	if u.HourlyQuota != nil {
+		if *u.HourlyQuota < 0 || *u.HourlyQuota > 1000 {
+			return nil, fmt.Errorf("小时配额必须在0-1000之间")
+		}
+		fields["settings.hourly_quota"] = *u.HourlyQuota
+	}
+	if u.MessagesPerSecond != nil {
+		if *u.MessagesPerSecond < 0 || *u.MessagesPerSecond > 1000 {
+			return nil, fmt.Errorf("每秒邮件数限制必须在0-1000之间")
+		}
+		fields["settings.messages_per_second"] = *u.MessagesPerSecond
+	}
+	if u.RecipientsPerMinute != nil {
+		if *u.RecipientsPerMinute < 0 || *u.RecipientsPerMinute > 100000 {
+			return nil, fmt.Errorf("每分钟收件人数限制必须在0-100000之间")
+		}
+		fields["settings.recipients_per_minute"] = *u.RecipientsPerMinute
+	}
+	if u.Timezone != nil {
+		timezone := strings.TrimSpace(*u.Timezone)
+		if _, err := timeutil.LoadLocation(timezone); err != nil {
+			return nil, err
+		}
+		fields["settings.timezone"] = timezone
+	}
+	if u.AllowedDomains != nil {
+		fields["settings.allowed_domains"] = *u.AllowedDomains
+	}
+	if u.ArchiveRetentionDays != nil {
+		if *u.ArchiveRetentionDays < 0 || *u.ArchiveRetentionDays > 3650 {
+			return nil, fmt.Errorf("归档保留天数必须在0-3650之间")
+		}
+		fields["settings.archive_retention_days"] = *u.ArchiveRetentionDays
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("没有需要更新的设置")
+	}
+	return fields, nil
+}
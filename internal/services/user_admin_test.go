@@ -0,0 +1,50 @@
+package services
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestUserSettingsUpdateSetFields(t *testing.T) {
+	intPtr := func(value int) *int { return &value }
+	stringPtr := func(value string) *string { return &value }
+
+	tests := []struct {
+		name    string
+		update  UserSettingsUpdate
+		want    bson.M
+		wantErr string
+	}{
+		{"只修改时区", UserSettingsUpdate{Timezone: stringPtr(" Asia/Shanghai ")}, bson.M{"settings.timezone": "Asia/Shanghai"}, ""},
+		{"清除时区", UserSettingsUpdate{Timezone: stringPtr("")}, bson.M{"settings.timezone": ""}, ""},
+		{"配额和速率限制", UserSettingsUpdate{DailyQuota: intPtr(1000), MessagesPerSecond: intPtr(0)}, bson.M{"settings.daily_quota": 1000, "settings.messages_per_second": 0}, ""},
+		{"清空允许的域名", UserSettingsUpdate{AllowedDomains: &[]string{}}, bson.M{"settings.allowed_domains": []string{}}, ""},
+		{"没有提供字段", UserSettingsUpdate{}, nil, "没有需要更新的设置"},
+		{"日配额超出范围", UserSettingsUpdate{DailyQuota: intPtr(10001)}, nil, "日配额必须在0-10000之间"},
+		{"小时配额为负数", UserSettingsUpdate{HourlyQuota: intPtr(-1)}, nil, "小时配额必须在0-1000之间"},
+		{"每秒邮件数超出范围", UserSettingsUpdate{MessagesPerSecond: intPtr(1001)}, nil, "每秒邮件数限制必须在0-1000之间"},
+		{"每分钟收件人数超出范围", UserSettingsUpdate{RecipientsPerMinute: intPtr(100001)}, nil, "每分钟收件人数限制必须在0-100000之间"},
+		{"归档保留天数超出范围", UserSettingsUpdate{ArchiveRetentionDays: intPtr(3651)}, nil, "归档保留天数必须在0-3650之间"},
+		{"无效的时区", UserSettingsUpdate{Timezone: stringPtr("Mars/Olympus")}, nil, "无效的时区"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, err := tt.update.SetFields()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("期望错误包含 %q，实际为 %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SetFields() 返回错误: %v", err)
+			}
+			if !reflect.DeepEqual(fields, tt.want) {
+				t.Errorf("SetFields() = %v，期望 %v", fields, tt.want)
+			}
+		})
+	}
+}
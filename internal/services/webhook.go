@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// cgnatNetwork 运营商级NAT地址段（RFC 6598），同样不允许作为Webhook目标
+var cgnatNetwork = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// webhookIPAllowed 检查IP是否允许作为Webhook目标：拒绝本机、内网、链路本地（包括169.254.169.254元数据服务）、组播和未指定地址
+func webhookIPAllowed(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || cgnatNetwork.Contains(ip))
+}
+
+// ValidateWebhookURL 校验用户配置的Webhook地址：只允许http(s)，主机名解析出的所有地址都必须是公网地址
+//
+// 保存时的校验只用于尽早提示，实际请求时由newWebhookHTTPClient在建立连接时再次检查，防止DNS重绑定。
+func ValidateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Hostname() == "" {
+		return fmt.Errorf("无效的Webhook地址")
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("Webhook地址不能指向内网或本机地址")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !webhookIPAllowed(ip) {
+			return fmt.Errorf("Webhook地址不能指向内网或本机地址")
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return fmt.Errorf("Webhook地址的主机名无法解析: %s", host)
+	}
+	for _, addr := range addrs {
+		if !webhookIPAllowed(addr.IP) {
+			return fmt.Errorf("Webhook地址不能指向内网或本机地址")
+		}
+	}
+	return nil
+}
+
+// newWebhookHTTPClient 创建调用用户Webhook的HTTP客户端：不使用代理、不跟随重定向，
+// 并在建立连接时检查实际连接的IP，防止通过重定向或DNS重绑定访问内网地址
+func newWebhookHTTPClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !webhookIPAllowed(ip) {
+				return fmt.Errorf("Webhook地址不能指向内网或本机地址: %s", host)
+			}
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
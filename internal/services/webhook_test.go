@@ -0,0 +1,132 @@
+package services
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookIPAllowed(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"127.8.9.10", false},
+		{"::1", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"172.31.255.255", false},
+		{"172.32.0.1", true},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"fc12:3456::1", false},
+		{"169.254.169.254", false},
+		{"169.254.0.1", false},
+		{"fe80::1", false},
+		{"100.64.0.1", false},
+		{"100.127.255.254", false},
+		{"100.128.0.1", true},
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.1.2.3", false},
+		{"::ffff:169.254.169.254", false},
+		{"::ffff:93.184.216.34", true},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"ff02::1", false},
+	}
+	for _, tt := range tests {
+		if got := webhookIPAllowed(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("webhookIPAllowed(%s) = %v，期望 %v", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	tests := []struct {
+		url     string
+		wantErr string
+	}{
+		{"https://93.184.216.34/hook", ""},
+		{"http://[2606:2800:220:1:248:1893:25c8:1946]:8080/hook", ""},
+		{"http://127.0.0.1:8080/hook", "内网或本机地址"},
+		{"http://[::1]/hook", "内网或本机地址"},
+		{"http://10.0.0.5/hook", "内网或本机地址"},
+		{"http://192.168.0.10/hook", "内网或本机地址"},
+		{"http://[fd00::1]/hook", "内网或本机地址"},
+		{"http://169.254.169.254/latest/meta-data/", "内网或本机地址"},
+		{"http://[fe80::1]/hook", "内网或本机地址"},
+		{"http://100.64.1.1/hook", "内网或本机地址"},
+		{"http://[::ffff:127.0.0.1]/hook", "内网或本机地址"},
+		{"http://localhost:8080/hook", "内网或本机地址"},
+		{"http://LOCALHOST./hook", "内网或本机地址"},
+		{"http://api.localhost/hook", "内网或本机地址"},
+		{"ftp://93.184.216.34/hook", "无效的Webhook地址"},
+		{"file:///etc/passwd", "无效的Webhook地址"},
+		{"gopher://93.184.216.34/", "无效的Webhook地址"},
+		{"93.184.216.34/hook", "无效的Webhook地址"},
+		{"https:///hook", "无效的Webhook地址"},
+		{"http://%zz/", "无效的Webhook地址"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			err := ValidateWebhookURL(tt.url)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ValidateWebhookURL() 返回错误: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("期望错误包含 %q，实际为 %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWebhookHTTPClientBlocksLoopback(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	// 保存时的校验被绕过（如DNS重绑定）时，建立连接时仍会拒绝
+	client := newWebhookHTTPClient(2 * time.Second)
+	resp, err := client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err == nil {
+		resp.Body.Close()
+		t.Fatal("请求127.0.0.1的Webhook应被拒绝")
+	}
+	if !strings.Contains(err.Error(), "内网或本机地址") {
+		t.Errorf("错误信息为 %v", err)
+	}
+	if called {
+		t.Error("被拒绝的请求不应到达服务器")
+	}
+
+	// 同一服务器使用普通客户端可以访问，说明拒绝来自连接检查
+	resp, err = server.Client().Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("普通客户端请求失败: %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWebhookHTTPClientDoesNotFollowRedirects(t *testing.T) {
+	client := newWebhookHTTPClient(time.Second)
+	req := httptest.NewRequest(http.MethodPost, "http://93.184.216.34/hook", nil)
+	if err := client.CheckRedirect(req, []*http.Request{req}); err != http.ErrUseLastResponse {
+		t.Errorf("CheckRedirect() = %v，期望 http.ErrUseLastResponse", err)
+	}
+	if transport, ok := client.Transport.(*http.Transport); !ok || transport.Proxy != nil {
+		t.Errorf("Webhook客户端不应使用代理")
+	}
+}
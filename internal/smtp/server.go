@@ -30,6 +30,15 @@ type Server struct {
 	auth              *auth.Service
 	queue             *queue.Service
 	credentialService *services.SMTPCredentialService
+	sandboxService    *services.SandboxService
+	dedupService      *services.DedupService
+	archiveService    *services.ArchiveService
+	domainService     *services.DomainService
+	clientService     *services.CredentialClientService
+	quotaService      *services.QuotaService
+	rateLimitService  *services.RateLimitService
+	planService       *services.PlanService
+	quotaAlertService *services.QuotaAlertService
 	server            *smtp.Server
 }
 
@@ -46,7 +55,7 @@ type Config struct {
 }
 
 // NewServer 创建SMTP服务器
-func NewServer(config *Config, db *database.MongoDB, logger *logrus.Logger, auth *auth.Service, queue *queue.Service, credentialService *services.SMTPCredentialService) *Server {
+func NewServer(config *Config, db *database.MongoDB, logger *logrus.Logger, auth *auth.Service, queue *queue.Service, credentialService *services.SMTPCredentialService, sandboxService *services.SandboxService, dedupService *services.DedupService, archiveService *services.ArchiveService, domainService *services.DomainService, clientService *services.CredentialClientService, quotaService *services.QuotaService, rateLimitService *services.RateLimitService, planService *services.PlanService, quotaAlertService *services.QuotaAlertService) *Server {
 	return &Server{
 		config:            config,
 		db:                db,
@@ -54,6 +63,15 @@ func NewServer(config *Config, db *database.MongoDB, logger *logrus.Logger, auth
 		auth:              auth,
 		queue:             queue,
 		credentialService: credentialService,
+		sandboxService:    sandboxService,
+		dedupService:      dedupService,
+		archiveService:    archiveService,
+		domainService:     domainService,
+		clientService:     clientService,
+		quotaService:      quotaService,
+		rateLimitService:  rateLimitService,
+		planService:       planService,
+		quotaAlertService: quotaAlertService,
 	}
 }
 
@@ -177,6 +195,8 @@ type Session struct {
 	logger     *logrus.Entry
 	user       *models.User
 	credential *models.SMTPCredential
+	plan       *models.Plan // 用户生效的套餐，没有套餐时为nil
+	passwordID string       // 本次认证使用的密码标识
 	from       string
 	to         []string
 }
@@ -203,7 +223,7 @@ func (s *Session) AuthPlain(username, password string) error {
 	s.logger.WithField("username", username).Info("SMTP认证请求")
 
 	// 使用新的多密钥对认证
-	credential, err := s.server.credentialService.AuthenticateSMTP(username, password)
+	credential, passwordID, err := s.server.credentialService.AuthenticateSMTP(username, password)
 	if err != nil {
 		s.logger.WithError(err).WithField("username", username).Warn("SMTP认证失败")
 		return err
@@ -218,12 +238,25 @@ func (s *Session) AuthPlain(username, password string) error {
 		return fmt.Errorf("用户信息获取失败")
 	}
 
+	plan, err := s.server.planService.GetUserPlan(&user)
+	if err != nil {
+		s.logger.WithError(err).WithField("user_id", credential.UserID.Hex()).Error("获取用户套餐失败")
+		return fmt.Errorf("用户信息获取失败")
+	}
+
 	s.user = &user
 	s.credential = credential
+	s.plan = plan
+	s.passwordID = passwordID
+
+	// 记录客户端历史（不阻塞认证响应）
+	s.recordClient("PLAIN")
 	s.logger.WithFields(logrus.Fields{
 		"user_id":         user.ID.Hex(),
 		"credential_id":   credential.ID.Hex(),
 		"credential_name": credential.Name,
+		"password_id":     passwordID,
+		"previous":        credential.PreviousPassword != nil && passwordID == credential.PreviousPassword.ID,
 	}).Info("SMTP认证成功")
 
 	return nil
@@ -239,12 +272,27 @@ func (s *Session) Mail(from string, opts *smtp.MailOptions) error {
 		return fmt.Errorf("认证失败：必须先通过SMTP认证才能发送邮件")
 	}
 
+	// 凭据的连接方式、发送时段和邮件大小限制
+	if err := s.checkCredentialPolicy(opts); err != nil {
+		s.logger.WithError(err).WithFields(logrus.Fields{
+			"credential_id": s.credential.ID.Hex(),
+			"from":          from,
+		}).Warn("凭据权限检查失败")
+		return err
+	}
+
 	// 验证发件人地址（使用凭据级别的域名限制）
 	if !s.isValidSender(from) {
 		s.logger.WithField("from", from).Warn("无效的发件人地址")
 		return fmt.Errorf("无效的发件人地址: %s", from)
 	}
 
+	// 只允许使用已验证的发件域名
+	if err := s.server.domainService.RequireVerifiedDomain(s.user.ID, senderDomain(from)); err != nil {
+		s.logger.WithError(err).WithField("from", from).Warn("发件域名未通过验证")
+		return fmt.Errorf("发件域名未验证: %s", senderDomain(from))
+	}
+
 	// 记录认证用户的发送行为
 	s.logger.WithFields(logrus.Fields{
 		"user_id":         s.user.ID.Hex(),
@@ -310,37 +358,127 @@ func (s *Session) Data(r io.Reader) error {
 		return err
 	}
 
-	// 检查邮件大小
-	if int64(len(data)) > s.server.config.MaxMsgSize {
-		return fmt.Errorf("邮件大小超过限制")
+	// 检查邮件大小（凭据可设置更小的限制）
+	if int64(len(data)) > s.maxMessageSize() {
+		return &smtp.SMTPError{Code: 552, EnhancedCode: smtp.EnhancedCode{5, 3, 4}, Message: "邮件大小超过限制"}
 	}
 
-	// 检查凭据级别的配额
-	if err := s.checkCredentialQuota(); err != nil {
-		s.logger.WithError(err).Warn("凭据配额检查失败")
+	// 解析并移除X-Relay-*控制头部，这些头部不会随邮件投递
+	relayHeaders, data, err := services.ParseRelayHeaders(data, time.Now())
+	if err != nil {
+		s.logger.WithError(err).Warn("X-Relay头部校验失败")
+		return &smtp.SMTPError{Code: 550, EnhancedCode: smtp.EnhancedCode{5, 6, 0}, Message: err.Error()}
+	}
+
+	// 按收件人数预占凭据和用户的配额，邮件最终未被接受时退还
+	reservation, err := s.server.quotaService.Reserve(s.user, s.plan, s.credential, len(s.to))
+	if err != nil {
+		s.logger.WithError(err).Warn("配额检查失败")
+		if services.IsQuotaExceeded(err) {
+			s.server.quotaAlertService.CheckExceeded(s.user, s.credential, err)
+			return &smtp.SMTPError{Code: 451, EnhancedCode: smtp.EnhancedCode{4, 7, 1}, Message: err.Error()}
+		}
 		return err
 	}
 
+	clientMessageID := s.extractMessageID(string(data))
+
+	// 重复提交检测：幂等窗口内的重复邮件直接返回成功但不再入队
+	dedupKey := ""
+	if s.credential.Settings.DedupEnabled {
+		key := s.server.dedupService.ComputeKey(clientMessageID, s.from, s.to, data)
+		claimed, originalID, err := s.server.dedupService.Claim(s.credential.ID, key, s.server.dedupService.Window(s.credential.Settings))
+		switch {
+		case err != nil:
+			// Redis不可用时不阻断发送
+			s.logger.WithError(err).Warn("重复提交检测失败，跳过去重")
+		case !claimed:
+			if originalID != "" {
+				if err := s.server.dedupService.MarkDuplicate(originalID); err != nil {
+					s.logger.WithError(err).WithField("original_mail_log_id", originalID).Warn("标记重复提交失败")
+				}
+			}
+			s.logger.WithFields(logrus.Fields{
+				"credential_id":        s.credential.ID.Hex(),
+				"client_message_id":    clientMessageID,
+				"original_mail_log_id": originalID,
+			}).Info("检测到重复提交，邮件不会再次投递")
+			reservation.Refund()
+			return nil
+		default:
+			dedupKey = key
+		}
+	}
+
+	// 令牌桶速率限制（多个SMTP节点共享），在配额和去重检查之后取令牌，被拒绝或重复的邮件不消耗令牌
+	if err := s.server.rateLimitService.Take(s.user, s.credential, s.plan, len(s.to)); err != nil {
+		s.logger.WithError(err).Warn("发送速率超过限制")
+		s.releaseDedupKey(dedupKey)
+		reservation.Refund()
+		if services.IsRecipientBatchTooLarge(err) {
+			return &smtp.SMTPError{Code: 552, EnhancedCode: smtp.EnhancedCode{5, 5, 3}, Message: err.Error()}
+		}
+		// 超限时返回临时错误让客户端稍后重试
+		return &smtp.SMTPError{Code: 451, EnhancedCode: smtp.EnhancedCode{4, 7, 1}, Message: err.Error()}
+	}
+
 	// 创建MailLog记录
 	mailLog := &models.MailLog{
-		UserID:       s.user.ID,
-		CredentialID: &s.credential.ID,
-		MessageID:    s.generateMessageID(),
-		From:         s.from,
-		To:           s.to,
-		Subject:      s.extractSubject(string(data)),
-		Size:         int64(len(data)),
-		Status:       "queued",
-		Attempts:     0,
-		CreatedAt:    time.Now(),
-		RelayIP:      s.getServerIP(),
+		UserID:          s.user.ID,
+		CredentialID:    &s.credential.ID,
+		PasswordID:      s.passwordID,
+		MessageID:       s.generateMessageID(),
+		ClientMessageID: clientMessageID,
+		From:            s.from,
+		To:              s.to,
+		Subject:         s.extractSubject(string(data)),
+		Size:            int64(len(data)),
+		Status:          "queued",
+		Attempts:        0,
+		CreatedAt:       time.Now(),
+		RelayIP:         s.getServerIP(),
+		ClientIP:        s.clientIP(),
+		Tags:            relayHeaders.Tags,
+		CampaignID:      relayHeaders.CampaignID,
+		Metadata:        relayHeaders.Metadata,
+		Priority:        relayHeaders.Priority,
+		ExpiresAt:       relayHeaders.ExpiresAt,
+	}
+
+	// 沙箱凭据只捕获邮件，不进入投递队列
+	if s.credential.IsSandbox() {
+		if err := s.server.sandboxService.CaptureMessage(s.credential, mailLog, data); err != nil {
+			s.logger.WithError(err).Error("沙箱邮件捕获失败")
+			s.releaseDedupKey(dedupKey)
+			reservation.Refund()
+			return err
+		}
+		s.bindDedupKey(dedupKey, mailLog)
+		s.server.quotaAlertService.Check(s.user, s.credential, reservation.Usage())
+
+		s.logger.WithFields(logrus.Fields{
+			"message_id":    mailLog.MessageID,
+			"credential_id": s.credential.ID.Hex(),
+		}).Info("沙箱凭据邮件已捕获，不会投递")
+		return nil
 	}
 
 	// 将邮件加入队列
 	if err := s.server.queue.EnqueueMail(mailLog, data); err != nil {
 		s.logger.WithError(err).Error("邮件入队失败")
+		s.releaseDedupKey(dedupKey)
+		reservation.Refund()
 		return err
 	}
+	s.bindDedupKey(dedupKey, mailLog)
+	s.server.quotaAlertService.Check(s.user, s.credential, reservation.Usage())
+
+	// 归档原始邮件（归档失败不影响投递）
+	if s.server.archiveService != nil {
+		if err := s.server.archiveService.ArchiveMessage(s.user, mailLog, data); err != nil {
+			s.logger.WithError(err).WithField("mail_log_id", mailLog.ID.Hex()).Warn("原始邮件归档失败")
+		}
+	}
 
 	s.logger.WithFields(logrus.Fields{
 		"message_id":    mailLog.MessageID,
@@ -365,11 +503,20 @@ func (s *Session) Logout() error {
 
 // isValidSender 验证发件人地址（使用凭据级别的域名限制）
 func (s *Session) isValidSender(from string) bool {
+	if !strings.Contains(from, "@") {
+		return false
+	}
+
+	// 检查是否符合凭据的发件地址规则
+	if !services.SenderAllowed(s.credential.Settings, from) {
+		return false
+	}
+
 	// 检查是否在凭据允许的域名列表中
 	if len(s.credential.Settings.AllowedDomains) > 0 {
-		domain := strings.Split(from, "@")[1]
+		domain := senderDomain(from)
 		for _, allowedDomain := range s.credential.Settings.AllowedDomains {
-			if domain == allowedDomain {
+			if strings.EqualFold(domain, allowedDomain) {
 				return true
 			}
 		}
@@ -378,6 +525,67 @@ func (s *Session) isValidSender(from string) bool {
 	return true
 }
 
+// checkCredentialPolicy 检查凭据的TLS、监听端口、发送时段和声明的邮件大小限制
+func (s *Session) checkCredentialPolicy(opts *smtp.MailOptions) error {
+	settings := s.credential.Settings
+
+	if settings.RequireTLS {
+		if _, ok := s.conn.TLSConnectionState(); !ok {
+			return &smtp.SMTPError{Code: 530, EnhancedCode: smtp.EnhancedCode{5, 7, 0}, Message: "该凭据要求使用TLS连接，请先执行STARTTLS"}
+		}
+	}
+
+	if len(settings.AllowedPorts) > 0 {
+		port := s.localPort()
+		allowed := false
+		for _, allowedPort := range settings.AllowedPorts {
+			if allowedPort == port {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return &smtp.SMTPError{Code: 550, EnhancedCode: smtp.EnhancedCode{5, 7, 1}, Message: fmt.Sprintf("该凭据不允许通过端口%d发送邮件", port)}
+		}
+	}
+
+	// 时段外返回临时错误，客户端稍后重试
+	if !services.SendingAllowedAt(settings.SendingHours, time.Now()) {
+		return &smtp.SMTPError{Code: 451, EnhancedCode: smtp.EnhancedCode{4, 7, 1}, Message: "当前时段不允许该凭据发送邮件"}
+	}
+
+	if opts != nil && opts.Size > s.maxMessageSize() {
+		return &smtp.SMTPError{Code: 552, EnhancedCode: smtp.EnhancedCode{5, 3, 4}, Message: "邮件大小超过限制"}
+	}
+
+	return nil
+}
+
+// maxMessageSize 获取本会话允许的最大邮件大小（服务器、凭据和套餐限制中最小者）
+func (s *Session) maxMessageSize() int64 {
+	limit := s.server.config.MaxMsgSize
+	if size := s.credential.Settings.MaxMessageSize; size > 0 && (limit <= 0 || size < limit) {
+		limit = size
+	}
+	if s.plan != nil && s.plan.MaxMessageSize > 0 && (limit <= 0 || s.plan.MaxMessageSize < limit) {
+		limit = s.plan.MaxMessageSize
+	}
+	return limit
+}
+
+// localPort 获取客户端连接的监听端口
+func (s *Session) localPort() int {
+	if addr, ok := s.conn.Conn().LocalAddr().(*net.TCPAddr); ok {
+		return addr.Port
+	}
+	return 0
+}
+
+// senderDomain 获取发件地址的域名
+func senderDomain(from string) string {
+	return strings.ToLower(from[strings.LastIndex(from, "@")+1:])
+}
+
 // generateMessageID 生成邮件ID
 func (s *Session) generateMessageID() string {
 	return fmt.Sprintf("%d-%s@%s", time.Now().Unix(), s.user.ID.Hex(), s.server.config.Domain)
@@ -394,78 +602,69 @@ func (s *Session) extractSubject(data string) string {
 	return ""
 }
 
-// getServerIP 获取服务器IP
-func (s *Session) getServerIP() string {
-	if conn, ok := s.conn.Conn().(*net.TCPConn); ok {
-		if addr, ok := conn.LocalAddr().(*net.TCPAddr); ok {
-			return addr.IP.String()
+// extractMessageID 提取客户端提供的Message-ID（仅在头部区域查找）
+func (s *Session) extractMessageID(data string) string {
+	lines := strings.Split(data, "\n")
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			break
+		}
+		if strings.HasPrefix(strings.ToLower(line), "message-id:") {
+			return strings.TrimSpace(line[11:])
 		}
 	}
-	return "unknown"
+	return ""
 }
 
-// checkCredentialQuota 检查凭据级别的配额
-func (s *Session) checkCredentialQuota() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// bindDedupKey 邮件被接受后将去重键关联到MailLog
+func (s *Session) bindDedupKey(key string, mailLog *models.MailLog) {
+	if key == "" {
+		return
+	}
+	s.server.dedupService.Bind(s.credential.ID, key, mailLog.ID)
+}
 
-	// 检查日配额
-	if s.credential.Settings.DailyQuota > 0 {
-		dailyCount, err := s.getDailyMailCount(ctx)
-		if err != nil {
-			return err
-		}
-		if dailyCount >= int64(s.credential.Settings.DailyQuota) {
-			return fmt.Errorf("凭据日配额已用完（%d/%d）", dailyCount, s.credential.Settings.DailyQuota)
-		}
+// releaseDedupKey 邮件未被接受时释放去重键，允许客户端重试
+func (s *Session) releaseDedupKey(key string) {
+	if key == "" {
+		return
 	}
+	s.server.dedupService.Release(s.credential.ID, key)
+}
 
-	// 检查小时配额
-	if s.credential.Settings.HourlyQuota > 0 {
-		hourlyCount, err := s.getHourlyMailCount(ctx)
-		if err != nil {
-			return err
-		}
-		if hourlyCount >= int64(s.credential.Settings.HourlyQuota) {
-			return fmt.Errorf("凭据小时配额已用完（%d/%d）", hourlyCount, s.credential.Settings.HourlyQuota)
+// getServerIP 获取服务器IP
+func (s *Session) getServerIP() string {
+	if conn, ok := s.conn.Conn().(*net.TCPConn); ok {
+		if addr, ok := conn.LocalAddr().(*net.TCPAddr); ok {
+			return addr.IP.String()
 		}
 	}
-
-	return nil
+	return "unknown"
 }
 
-// getDailyMailCount 获取凭据今日发送邮件数量
-func (s *Session) getDailyMailCount(ctx context.Context) (int64, error) {
-	collection := s.server.db.GetCollection("mail_logs")
-
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
-
-	filter := bson.M{
-		"credential_id": s.credential.ID,
-		"created_at": bson.M{
-			"$gte": today,
-			"$lt":  tomorrow,
-		},
+// recordClient 记录认证成功的客户端（来源IP、EHLO名称、TLS版本和认证机制）
+func (s *Session) recordClient(mechanism string) {
+	if s.server.clientService == nil {
+		return
 	}
+	tlsVersion := ""
+	if state, ok := s.conn.TLSConnectionState(); ok {
+		tlsVersion = tls.VersionName(state.Version)
+	}
+	credential, ip, ehlo := s.credential, s.clientIP(), s.conn.Hostname()
 
-	return collection.CountDocuments(ctx, filter)
+	go func() {
+		if err := s.server.clientService.RecordClient(credential, ip, ehlo, tlsVersion, mechanism); err != nil {
+			s.logger.WithError(err).WithField("credential_id", credential.ID.Hex()).Warn("记录凭据客户端失败")
+		}
+	}()
 }
 
-// getHourlyMailCount 获取凭据本小时发送邮件数量
-func (s *Session) getHourlyMailCount(ctx context.Context) (int64, error) {
-	collection := s.server.db.GetCollection("mail_logs")
-
-	thisHour := time.Now().Truncate(time.Hour)
-	nextHour := thisHour.Add(time.Hour)
-
-	filter := bson.M{
-		"credential_id": s.credential.ID,
-		"created_at": bson.M{
-			"$gte": thisHour,
-			"$lt":  nextHour,
-		},
+// clientIP 获取客户端IP
+func (s *Session) clientIP() string {
+	if addr, ok := s.conn.Conn().RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
 	}
-
-	return collection.CountDocuments(ctx, filter)
+	return ""
 }
@@ -0,0 +1,67 @@
+// Package timeutil 提供按时区计算配额周期和统计区间的工具
+package timeutil
+
+import (
+	"fmt"
+	"time"
+)
+
+// 周期
+const (
+	PeriodHour  = "hour"
+	PeriodDay   = "day"
+	PeriodMonth = "month"
+)
+
+// LoadLocation 解析IANA时区名称，空字符串表示UTC
+func LoadLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("无效的时区: %s", name)
+	}
+	return location, nil
+}
+
+// Location 按顺序返回第一个有效的时区，都无效或为空时返回UTC
+func Location(names ...string) *time.Location {
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
+
+// Window 获取t所在周期在指定时区中的起止时间（起始时间包含，结束时间不包含）
+func Window(period string, t time.Time, location *time.Location) (time.Time, time.Time) {
+	local := t.In(location)
+	switch period {
+	case PeriodHour:
+		// 按时间差回退到整点，夏令时结束时重复的小时不会被time.Date映射到第一个小时
+		start := local.Add(-time.Duration(local.Minute())*time.Minute - time.Duration(local.Second())*time.Second - time.Duration(local.Nanosecond()))
+		return start, start.Add(time.Hour)
+	case PeriodMonth:
+		start := time.Date(local.Year(), local.Month(), 1, 0, 0, 0, 0, location)
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start := StartOfDay(local, location)
+		return start, start.AddDate(0, 0, 1)
+	}
+}
+
+// StartOfDay 获取t在指定时区中当天的零点
+func StartOfDay(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+}
+
+// ParseDate 按指定时区解析YYYY-MM-DD格式的日期，返回当天零点
+func ParseDate(value string, location *time.Location) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02", value, location)
+}
@@ -0,0 +1,158 @@
+package timeutil
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("加载时区%s失败: %v", name, err)
+	}
+	return location
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatalf("解析时间失败: %v", err)
+	}
+	return parsed
+}
+
+func TestWindow(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name      string
+		period    string
+		time      string
+		location  *time.Location
+		wantStart string
+		wantEnd   string
+	}{
+		{"上海整点小时", PeriodHour, "2026-10-05T17:45:00Z", shanghai, "2026-10-05T17:00:00Z", "2026-10-05T18:00:00Z"},
+		{"上海自然日按本地日期计算", PeriodDay, "2026-10-05T17:00:00Z", shanghai, "2026-10-05T16:00:00Z", "2026-10-06T16:00:00Z"},
+		{"上海自然日零点包含在内", PeriodDay, "2026-10-05T16:00:00Z", shanghai, "2026-10-05T16:00:00Z", "2026-10-06T16:00:00Z"},
+		{"上海自然月", PeriodMonth, "2026-10-31T15:59:59Z", shanghai, "2026-09-30T16:00:00Z", "2026-10-31T16:00:00Z"},
+		{"上海自然月跨年", PeriodMonth, "2026-12-31T17:00:00Z", shanghai, "2026-12-31T16:00:00Z", "2027-01-31T16:00:00Z"},
+		{"纽约夏令时开始前的小时", PeriodHour, "2026-03-08T06:30:00Z", newYork, "2026-03-08T06:00:00Z", "2026-03-08T07:00:00Z"},
+		{"纽约夏令时开始后的小时", PeriodHour, "2026-03-08T07:30:00Z", newYork, "2026-03-08T07:00:00Z", "2026-03-08T08:00:00Z"},
+		{"纽约夏令时结束时重复的第一个小时", PeriodHour, "2026-11-01T05:30:00Z", newYork, "2026-11-01T05:00:00Z", "2026-11-01T06:00:00Z"},
+		{"纽约夏令时结束时重复的第二个小时", PeriodHour, "2026-11-01T06:30:00Z", newYork, "2026-11-01T06:00:00Z", "2026-11-01T07:00:00Z"},
+		{"纽约夏令时开始当天只有23小时", PeriodDay, "2026-03-08T16:00:00Z", newYork, "2026-03-08T05:00:00Z", "2026-03-09T04:00:00Z"},
+		{"纽约夏令时结束当天有25小时", PeriodDay, "2026-11-01T16:00:00Z", newYork, "2026-11-01T04:00:00Z", "2026-11-02T05:00:00Z"},
+		{"纽约跨越夏令时开始的自然月", PeriodMonth, "2026-03-20T12:00:00Z", newYork, "2026-03-01T05:00:00Z", "2026-04-01T04:00:00Z"},
+		{"纽约跨越夏令时结束的自然月", PeriodMonth, "2026-11-15T12:00:00Z", newYork, "2026-11-01T04:00:00Z", "2026-12-01T05:00:00Z"},
+		{"未知周期按自然日计算", "week", "2026-10-05T17:00:00Z", shanghai, "2026-10-05T16:00:00Z", "2026-10-06T16:00:00Z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := mustParseTime(t, tt.time)
+			start, end := Window(tt.period, at, tt.location)
+			if !start.Equal(mustParseTime(t, tt.wantStart)) || !end.Equal(mustParseTime(t, tt.wantEnd)) {
+				t.Errorf("Window(%s, %s) = [%s, %s)，期望 [%s, %s)", tt.period, tt.time,
+					start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), tt.wantStart, tt.wantEnd)
+			}
+			if at.Before(start) || !at.Before(end) {
+				t.Errorf("%s 不在周期 [%s, %s) 内", tt.time, start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339))
+			}
+			if start.Location() != tt.location {
+				t.Errorf("周期起始时间的时区为 %s，期望 %s", start.Location(), tt.location)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	shanghai := mustLoadLocation(t, "Asia/Shanghai")
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	tests := []struct {
+		name     string
+		value    string
+		location *time.Location
+		want     string
+	}{
+		{"上海零点", "2026-10-05", shanghai, "2026-10-04T16:00:00Z"},
+		{"纽约夏令时开始当天零点", "2026-03-08", newYork, "2026-03-08T05:00:00Z"},
+		{"纽约夏令时期间零点", "2026-07-01", newYork, "2026-07-01T04:00:00Z"},
+		{"UTC零点", "2026-10-05", time.UTC, "2026-10-05T00:00:00Z"},
+		{"无效的月份", "2026-13-01", time.UTC, ""},
+		{"无效的日期", "2026-02-30", time.UTC, ""},
+		{"错误的分隔符", "2026/10/05", time.UTC, ""},
+		{"包含时间", "2026-10-05T10:00:00Z", time.UTC, ""},
+		{"空字符串", "", time.UTC, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.value, tt.location)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("ParseDate(%q) = %s，期望返回错误", tt.value, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseDate(%q) 返回错误: %v", tt.value, err)
+			}
+			if !got.Equal(mustParseTime(t, tt.want)) || got.Location() != tt.location {
+				t.Errorf("ParseDate(%q) = %s，期望 %s（%s）", tt.value, got, tt.want, tt.location)
+			}
+		})
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", "UTC", false},
+		{"UTC", "UTC", false},
+		{"Asia/Shanghai", "Asia/Shanghai", false},
+		{"America/New_York", "America/New_York", false},
+		{"Mars/Olympus", "", true},
+		{"asia/shanghai ", "", true},
+	}
+	for _, tt := range tests {
+		location, err := LoadLocation(tt.name)
+		if tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), "无效的时区") {
+				t.Errorf("LoadLocation(%q) 期望返回无效的时区错误，实际为 %v", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || location.String() != tt.want {
+			t.Errorf("LoadLocation(%q) = %v（%v），期望 %s", tt.name, location, err, tt.want)
+		}
+	}
+}
+
+func TestLocation(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{"没有时区", nil, "UTC"},
+		{"都为空", []string{"", ""}, "UTC"},
+		{"用户时区优先", []string{"America/New_York", "Asia/Shanghai"}, "America/New_York"},
+		{"用户未设置时使用套餐时区", []string{"", "Asia/Shanghai"}, "Asia/Shanghai"},
+		{"用户时区无效时使用套餐时区", []string{"Mars/Olympus", "Asia/Shanghai"}, "Asia/Shanghai"},
+		{"都无效时使用UTC", []string{"Mars/Olympus", "Moon/Tranquility"}, "UTC"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Location(tt.names...); got.String() != tt.want {
+				t.Errorf("Location(%q) = %s，期望 %s", tt.names, got, tt.want)
+			}
+		})
+	}
+}
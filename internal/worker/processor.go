@@ -12,17 +12,24 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
 	"smtp-relay/internal/database"
+	"smtp-relay/internal/encryption"
 	"smtp-relay/internal/models"
 	"smtp-relay/internal/queue"
+	"smtp-relay/internal/services"
 )
 
 // Processor 邮件处理器
 type Processor struct {
-	db           *database.MongoDB
-	logger       *logrus.Logger
-	queueService *queue.Service
-	smtpConfigs  []*models.SMTPConfig
-	stopChan     chan struct{}
+	db            *database.MongoDB
+	logger        *logrus.Logger
+	queueService  *queue.Service
+	encryptor     *encryption.Encryptor
+	smimeService  *services.SMIMEService
+	dkimService   *services.DKIMService
+	domainService *services.DomainService
+	arcService    *services.ARCService
+	smtpConfigs   []*models.SMTPConfig
+	stopChan      chan struct{}
 }
 
 // Config 处理器配置
@@ -32,13 +39,18 @@ type Config struct {
 	RetryInterval  time.Duration
 }
 
-// NewProcessor 创建邮件处理器
-func NewProcessor(db *database.MongoDB, logger *logrus.Logger, queueService *queue.Service) *Processor {
+// NewProcessor 创建邮件处理器（arcService为nil时不进行ARC封装）
+func NewProcessor(db *database.MongoDB, logger *logrus.Logger, queueService *queue.Service, encryptor *encryption.Encryptor, domainService *services.DomainService, dkimService *services.DKIMService, arcService *services.ARCService) *Processor {
 	return &Processor{
-		db:           db,
-		logger:       logger,
-		queueService: queueService,
-		stopChan:     make(chan struct{}),
+		db:            db,
+		logger:        logger,
+		queueService:  queueService,
+		encryptor:     encryptor,
+		smimeService:  services.NewSMIMEService(db, encryptor, logger),
+		domainService: domainService,
+		dkimService:   dkimService,
+		arcService:    arcService,
+		stopChan:      make(chan struct{}),
 	}
 }
 
@@ -102,6 +114,11 @@ func (p *Processor) processMessage(message *queue.MailMessage, logger *logrus.En
 
 	logger.Info("开始处理邮件")
 
+	// 超过客户端指定的过期时间（X-Relay-Expires）的邮件不再投递
+	if p.markExpired(message, 0, logger) {
+		return nil
+	}
+
 	// 更新邮件状态为发送中
 	if err := p.updateMailStatus(message.MailLogID, "sending", "", 0); err != nil {
 		logger.WithError(err).Error("更新邮件状态失败")
@@ -122,11 +139,30 @@ func (p *Processor) processMessage(message *queue.MailMessage, logger *logrus.En
 		"smtp_port": smtpConfig.Port,
 	}).Info("选择SMTP服务器")
 
+	// 构建待发送的邮件内容（签名等处理）
+	data, err := p.buildMessage(message, logger)
+	if err != nil {
+		logger.WithError(err).Error("构建邮件失败")
+		p.updateMailStatus(message.MailLogID, "failed", err.Error(), 0)
+		return err
+	}
+
+	// 信封发件人（域名配置了退信域名时使用VERP地址）
+	envelopeFrom, err := p.domainService.BounceAddress(message.UserID, message.From, message.MailLogID)
+	if err != nil {
+		logger.WithError(err).Warn("查询退信域名失败，使用原发件人作为信封发件人")
+		envelopeFrom = message.From
+	}
+
 	// 发送邮件
 	attempts := 0
 	var lastError error
 
 	for attempts < 3 {
+		if attempts > 0 && p.markExpired(message, attempts, logger) {
+			return nil
+		}
+
 		attempts++
 		logger.WithField("attempt", attempts).Info("尝试发送邮件")
 
@@ -136,7 +172,7 @@ func (p *Processor) processMessage(message *queue.MailMessage, logger *logrus.En
 		}
 
 		// 发送邮件
-		if err := p.sendMail(smtpConfig, message, logger); err != nil {
+		if err := p.sendMail(smtpConfig, message, envelopeFrom, data, logger); err != nil {
 			lastError = err
 			logger.WithError(err).WithField("attempt", attempts).Warn("发送邮件失败")
 
@@ -167,8 +203,59 @@ func (p *Processor) processMessage(message *queue.MailMessage, logger *logrus.En
 	return lastError
 }
 
+// buildMessage 构建待发送的邮件内容：组装头部后依次进行S/MIME签名、DKIM签名和ARC封装
+func (p *Processor) buildMessage(message *queue.MailMessage, logger *logrus.Entry) ([]byte, error) {
+	data := append([]byte(p.buildMailHeaders(message)), message.Body...)
+
+	// S/MIME签名（需在DKIM签名之前完成）
+	certificate, err := p.smimeService.FindSigningCertificate(message.UserID, message.From)
+	if err != nil {
+		return nil, fmt.Errorf("查询S/MIME证书失败: %w", err)
+	}
+	if certificate != nil {
+		data, err = p.smimeService.SignMessage(certificate, data)
+		if err != nil {
+			return nil, fmt.Errorf("S/MIME签名失败: %w", err)
+		}
+		logger.WithField("certificate_id", certificate.ID.Hex()).Info("邮件已进行S/MIME签名")
+	}
+
+	// DKIM签名（RSA与Ed25519密钥同时存在时双重签名）
+	if p.dkimService != nil {
+		signed, keyPairs, err := p.dkimService.SignMessage(message.UserID, message.From, data)
+		switch {
+		case err != nil:
+			logger.WithError(err).Warn("DKIM签名失败，继续投递未签名的邮件")
+		case len(keyPairs) > 0:
+			data = signed
+			selectors := make([]string, 0, len(keyPairs))
+			for _, keyPair := range keyPairs {
+				selectors = append(selectors, keyPair.Selector+"/"+keyPair.Algorithm)
+			}
+			logger.WithField("dkim_selectors", selectors).Info("邮件已进行DKIM签名")
+		}
+	}
+
+	// ARC封装（需在所有修改邮件内容的步骤之后完成），失败时不影响投递
+	if p.arcService != nil {
+		sealed, chain, err := p.arcService.Seal(message.UserID, message.From, message.Body, data)
+		switch {
+		case err != nil:
+			logger.WithError(err).Warn("ARC封装失败，继续投递未封装的邮件")
+		case chain != nil:
+			data = sealed
+			logger.WithFields(logrus.Fields{
+				"arc_status":   chain.Status,
+				"arc_instance": chain.Instance() + 1,
+			}).Info("邮件已进行ARC封装")
+		}
+	}
+
+	return data, nil
+}
+
 // sendMail 发送邮件
-func (p *Processor) sendMail(config *models.SMTPConfig, message *queue.MailMessage, logger *logrus.Entry) error {
+func (p *Processor) sendMail(config *models.SMTPConfig, message *queue.MailMessage, envelopeFrom string, data []byte, logger *logrus.Entry) error {
 	// 建立SMTP连接
 	addr := fmt.Sprintf("%s:%d", config.Host, config.Port)
 
@@ -197,7 +284,7 @@ func (p *Processor) sendMail(config *models.SMTPConfig, message *queue.MailMessa
 	}
 
 	// 设置发件人
-	if err := client.Mail(message.From); err != nil {
+	if err := client.Mail(envelopeFrom); err != nil {
 		return fmt.Errorf("设置发件人失败: %w", err)
 	}
 
@@ -214,19 +301,10 @@ func (p *Processor) sendMail(config *models.SMTPConfig, message *queue.MailMessa
 		return fmt.Errorf("开始数据传输失败: %w", err)
 	}
 
-	// 构建邮件头
-	headers := p.buildMailHeaders(message)
-
-	// 写入邮件头
-	if _, err := writer.Write([]byte(headers)); err != nil {
+	// 写入邮件内容
+	if _, err := writer.Write(data); err != nil {
 		writer.Close()
-		return fmt.Errorf("写入邮件头失败: %w", err)
-	}
-
-	// 写入邮件体
-	if _, err := writer.Write(message.Body); err != nil {
-		writer.Close()
-		return fmt.Errorf("写入邮件体失败: %w", err)
+		return fmt.Errorf("写入邮件内容失败: %w", err)
 	}
 
 	// 完成数据传输
@@ -323,6 +401,20 @@ func (p *Processor) isTemporaryError(err error) bool {
 	return false
 }
 
+// markExpired 检查邮件是否已超过过期时间，已过期时将状态标记为expired
+func (p *Processor) markExpired(message *queue.MailMessage, attempts int, logger *logrus.Entry) bool {
+	if message.ExpiresAt == nil || time.Now().Before(*message.ExpiresAt) {
+		return false
+	}
+
+	logger.WithField("expires_at", message.ExpiresAt).Warn("邮件已过期，不再投递")
+	now := time.Now()
+	if err := p.updateMailStatusWithCompletion(message.MailLogID, "expired", "邮件已超过过期时间，未投递", attempts, &now); err != nil {
+		logger.WithError(err).Error("更新邮件过期状态失败")
+	}
+	return true
+}
+
 // updateMailStatus 更新邮件状态
 func (p *Processor) updateMailStatus(mailLogID primitive.ObjectID, status, errorMessage string, attempts int) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -390,6 +482,15 @@ func (p *Processor) loadSMTPConfigs() error {
 		return err
 	}
 
+	// 解密上游SMTP密码
+	for _, config := range configs {
+		password, err := p.encryptor.DecryptString(config.Password)
+		if err != nil {
+			return fmt.Errorf("解密SMTP配置%s的密码失败: %w", config.Name, err)
+		}
+		config.Password = password
+	}
+
 	p.smtpConfigs = configs
 	p.logger.WithField("config_count", len(configs)).Info("加载SMTP配置完成")
 